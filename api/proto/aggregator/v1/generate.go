@@ -0,0 +1,3 @@
+package aggregatorv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative aggregator/v1/marketdata.proto aggregator/v1/instruments.proto
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	investgo "github.com/russianinvestments/invest-api-go-sdk/investgo"
+	pb "github.com/russianinvestments/invest-api-go-sdk/proto"
+	"github.com/sirupsen/logrus"
+
+	domain "main/internal/domain/entity/instruments"
+)
+
+// Reasons an instrument is left out of the sync, as reported in the summary.
+const (
+	skipInvalidUID     = "invalid_uid"
+	skipMissingFigi    = "missing_figi"
+	skipMissingBrand   = "missing_brand"
+	skipUnknownBrand   = "unknown_brand"
+	skipBadAssetType   = "invalid_asset_type"
+	skipAssetLookupErr = "asset_lookup_failed"
+	skipFigiConflict   = "figi_conflict"
+)
+
+// apiInstrument is the part of the shares/bonds/etfs/currencies/futures
+// responses every instrument kind shares.
+type apiInstrument interface {
+	GetUid() string
+	GetFigi() string
+	GetTicker() string
+	GetClassCode() string
+	GetLot() int32
+	GetAssetUid() string
+}
+
+// instrumentSet holds the instruments of one sync, by kind.
+type instrumentSet struct {
+	Shares     []domain.Share
+	Bonds      []domain.Bond
+	Etfs       []domain.Etf
+	Currencies []domain.Currency
+	Futures    []domain.Future
+}
+
+// brandResolver links instruments to synced brands through their asset. Assets
+// are looked up once each, since most are shared by several instruments. A
+// lookup that failed or found no brand is remembered as well, so an asset the
+// API cannot return does not cost a retried call per instrument.
+type brandResolver struct {
+	client *investgo.InstrumentsServiceClient
+	retry  *apiRetry
+	brands map[uuid.UUID]struct{}
+	assets map[string]assetBrand
+}
+
+// assetBrand is the outcome of one asset lookup: the asset's brand, or the
+// reason it has none.
+type assetBrand struct {
+	brandUID uuid.UUID
+	reason   string
+}
+
+func newBrandResolver(client *investgo.InstrumentsServiceClient, retry *apiRetry, brands []*domain.Brand) *brandResolver {
+	known := make(map[uuid.UUID]struct{}, len(brands))
+	for _, brand := range brands {
+		known[brand.UID] = struct{}{}
+	}
+	return &brandResolver{client: client, retry: retry, brands: known, assets: make(map[string]assetBrand)}
+}
+
+// resolve returns the synced brand of an asset, or the reason it has none.
+func (r *brandResolver) resolve(ctx context.Context, assetUID string) (uuid.UUID, string) {
+	assetUID = strings.TrimSpace(assetUID)
+	if assetUID == "" {
+		return uuid.Nil, skipMissingBrand
+	}
+	asset, ok := r.assets[assetUID]
+	if !ok {
+		asset = r.lookup(ctx, assetUID)
+		r.assets[assetUID] = asset
+	}
+	if asset.reason != "" {
+		return uuid.Nil, asset.reason
+	}
+	if _, ok := r.brands[asset.brandUID]; !ok {
+		return uuid.Nil, skipUnknownBrand
+	}
+	return asset.brandUID, ""
+}
+
+func (r *brandResolver) lookup(ctx context.Context, assetUID string) assetBrand {
+	resp, err := callAPI(ctx, r.retry, "get asset", bindArg(r.client.GetAssetBy, assetUID))
+	if err != nil {
+		return assetBrand{reason: skipAssetLookupErr}
+	}
+	brand := resp.GetAsset().GetBrand()
+	if brand == nil || strings.TrimSpace(brand.GetName()) == "" {
+		return assetBrand{reason: skipMissingBrand}
+	}
+	return assetBrand{brandUID: parseBrandUID(brand.GetUid(), strings.TrimSpace(brand.GetName()))}
+}
+
+// fetchInstruments loads every instrument kind and maps it to domain entities
+// linked to a synced brand. skipped counts the instruments left out, by kind
+// and reason.
+func fetchInstruments(ctx context.Context, client *investgo.InstrumentsServiceClient, retry *apiRetry, resolver *brandResolver, logger *logrus.Logger) (*instrumentSet, map[string]map[string]int, error) {
+	set := &instrumentSet{}
+	skipped := make(map[string]map[string]int)
+	skip := func(kind domain.InstrumentType, item apiInstrument, reason string) {
+		if skipped[string(kind)] == nil {
+			skipped[string(kind)] = make(map[string]int)
+		}
+		skipped[string(kind)][reason]++
+		logger.WithFields(logrus.Fields{
+			"type":   kind,
+			"uid":    item.GetUid(),
+			"ticker": item.GetTicker(),
+			"reason": reason,
+		}).Debug("skip instrument")
+	}
+	base := func(kind domain.InstrumentType, item apiInstrument) (domain.Instrument, bool) {
+		uid, err := uuid.Parse(strings.TrimSpace(item.GetUid()))
+		if err != nil {
+			skip(kind, item, skipInvalidUID)
+			return domain.Instrument{}, false
+		}
+		figi := strings.TrimSpace(item.GetFigi())
+		if figi == "" {
+			skip(kind, item, skipMissingFigi)
+			return domain.Instrument{}, false
+		}
+		brandUID, reason := resolver.resolve(ctx, item.GetAssetUid())
+		if reason != "" {
+			skip(kind, item, reason)
+			return domain.Instrument{}, false
+		}
+		return domain.Instrument{
+			UID:       uid,
+			Figi:      figi,
+			Ticker:    strings.TrimSpace(item.GetTicker()),
+			Lot:       item.GetLot(),
+			ClassCode: strings.TrimSpace(item.GetClassCode()),
+			BrandUID:  brandUID,
+		}, true
+	}
+
+	shares, err := callAPI(ctx, retry, "get shares", bindArg(client.Shares, pb.InstrumentStatus_INSTRUMENT_STATUS_BASE))
+	if err != nil {
+		return nil, nil, fmt.Errorf("get shares: %w", err)
+	}
+	for _, item := range shares.GetInstruments() {
+		if item == nil {
+			continue
+		}
+		if instrument, ok := base(domain.ShareType, item); ok {
+			set.Shares = append(set.Shares, domain.Share{Instrument: instrument})
+		}
+	}
+
+	bonds, err := callAPI(ctx, retry, "get bonds", bindArg(client.Bonds, pb.InstrumentStatus_INSTRUMENT_STATUS_BASE))
+	if err != nil {
+		return nil, nil, fmt.Errorf("get bonds: %w", err)
+	}
+	for _, item := range bonds.GetInstruments() {
+		if item == nil {
+			continue
+		}
+		if instrument, ok := base(domain.BondType, item); ok {
+			set.Bonds = append(set.Bonds, domain.Bond{
+				Instrument: instrument,
+				Nominal:    item.GetNominal().ToFloat(),
+				AciValue:   item.GetAciValue().ToFloat(),
+			})
+		}
+	}
+
+	etfs, err := callAPI(ctx, retry, "get etfs", bindArg(client.Etfs, pb.InstrumentStatus_INSTRUMENT_STATUS_BASE))
+	if err != nil {
+		return nil, nil, fmt.Errorf("get etfs: %w", err)
+	}
+	for _, item := range etfs.GetInstruments() {
+		if item == nil {
+			continue
+		}
+		if instrument, ok := base(domain.EtfType, item); ok {
+			set.Etfs = append(set.Etfs, domain.Etf{
+				Instrument:        instrument,
+				MinPriceIncrement: item.GetMinPriceIncrement().ToFloat(),
+			})
+		}
+	}
+
+	currencies, err := callAPI(ctx, retry, "get currencies", bindArg(client.Currencies, pb.InstrumentStatus_INSTRUMENT_STATUS_BASE))
+	if err != nil {
+		return nil, nil, fmt.Errorf("get currencies: %w", err)
+	}
+	for _, item := range currencies.GetInstruments() {
+		if item == nil {
+			continue
+		}
+		if instrument, ok := base(domain.CurrencyType, item); ok {
+			set.Currencies = append(set.Currencies, domain.Currency{Instrument: instrument})
+		}
+	}
+
+	futures, err := callAPI(ctx, retry, "get futures", bindArg(client.Futures, pb.InstrumentStatus_INSTRUMENT_STATUS_BASE))
+	if err != nil {
+		return nil, nil, fmt.Errorf("get futures: %w", err)
+	}
+	for _, item := range futures.GetInstruments() {
+		if item == nil {
+			continue
+		}
+		assetType, err := domain.NewAssetType(strings.TrimSpace(item.GetAssetType()))
+		if err != nil {
+			skip(domain.FutureType, item, skipBadAssetType)
+			continue
+		}
+		if instrument, ok := base(domain.FutureType, item); ok {
+			set.Futures = append(set.Futures, domain.Future{
+				Instrument:              instrument,
+				MinPriceIncrement:       item.GetMinPriceIncrement().ToFloat(),
+				MinPriceIncrementAmount: item.GetMinPriceIncrementAmount().ToFloat(),
+				AssetType:               assetType,
+			})
+		}
+	}
+
+	return set, skipped, nil
+}
+
+// counts reports the number of instruments of each kind.
+func (s *instrumentSet) counts() logrus.Fields {
+	return logrus.Fields{
+		string(domain.ShareType):    len(s.Shares),
+		string(domain.BondType):     len(s.Bonds),
+		string(domain.EtfType):      len(s.Etfs),
+		string(domain.CurrencyType): len(s.Currencies),
+		string(domain.FutureType):   len(s.Futures),
+	}
+}
+
+// dropFigiConflicts leaves out the instruments whose FIGI is held by a stored,
+// not deleted instrument with another UID, or by an earlier instrument of the
+// set. Writing one would break the unique FIGI index and fail the whole batch.
+// A dropped instrument is not hashed, so a later run writes it once its FIGI
+// is free. skipped counts the dropped instruments by kind.
+func dropFigiConflicts(ctx context.Context, pool *pgxpool.Pool, set *instrumentSet, logger *logrus.Logger) (*instrumentSet, map[string]int, error) {
+	var figis []string
+	set.each(func(_ domain.InstrumentType, instrument domain.Instrument) {
+		figis = append(figis, instrument.Figi)
+	})
+	owners := make(map[string]uuid.UUID, len(figis))
+	rows, err := pool.Query(ctx, `SELECT figi, uid FROM instruments WHERE figi = ANY($1) AND deleted_at IS NULL`, figis)
+	if err != nil {
+		return nil, nil, err
+	}
+	for rows.Next() {
+		var figi string
+		var uid uuid.UUID
+		if err := rows.Scan(&figi, &uid); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		owners[figi] = uid
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	skipped := make(map[string]int)
+	keep := func(kind domain.InstrumentType, instrument domain.Instrument) bool {
+		owner, ok := owners[instrument.Figi]
+		if !ok || owner == instrument.UID {
+			owners[instrument.Figi] = instrument.UID
+			return true
+		}
+		skipped[string(kind)]++
+		logger.WithFields(logrus.Fields{
+			"type":   kind,
+			"uid":    instrument.UID,
+			"figi":   instrument.Figi,
+			"holder": owner,
+		}).Warn("skip instrument: figi held by another instrument")
+		return false
+	}
+	out := &instrumentSet{
+		Shares:     keepInstruments(set.Shares, domain.ShareType, func(s domain.Share) domain.Instrument { return s.Instrument }, keep),
+		Bonds:      keepInstruments(set.Bonds, domain.BondType, func(b domain.Bond) domain.Instrument { return b.Instrument }, keep),
+		Etfs:       keepInstruments(set.Etfs, domain.EtfType, func(e domain.Etf) domain.Instrument { return e.Instrument }, keep),
+		Currencies: keepInstruments(set.Currencies, domain.CurrencyType, func(c domain.Currency) domain.Instrument { return c.Instrument }, keep),
+		Futures:    keepInstruments(set.Futures, domain.FutureType, func(f domain.Future) domain.Instrument { return f.Instrument }, keep),
+	}
+	return out, skipped, nil
+}
+
+func keepInstruments[T any](items []T, kind domain.InstrumentType, base func(T) domain.Instrument, keep func(domain.InstrumentType, domain.Instrument) bool) []T {
+	var out []T
+	for _, item := range items {
+		if keep(kind, base(item)) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// each calls fn with the base instrument of every entry, in upsert order.
+func (s *instrumentSet) each(fn func(kind domain.InstrumentType, instrument domain.Instrument)) {
+	for _, share := range s.Shares {
+		fn(domain.ShareType, share.Instrument)
+	}
+	for _, bond := range s.Bonds {
+		fn(domain.BondType, bond.Instrument)
+	}
+	for _, etf := range s.Etfs {
+		fn(domain.EtfType, etf.Instrument)
+	}
+	for _, currency := range s.Currencies {
+		fn(domain.CurrencyType, currency.Instrument)
+	}
+	for _, future := range s.Futures {
+		fn(domain.FutureType, future.Instrument)
+	}
+}
+
+// upsertInstruments writes the base row and the type-specific row of every
+// instrument. A soft-deleted instrument keeps its deleted_at.
+func upsertInstruments(ctx context.Context, pool *pgxpool.Pool, set *instrumentSet) error {
+	batch := &pgx.Batch{}
+	for _, share := range set.Shares {
+		queueInstrument(batch, share.Instrument)
+		batch.Queue(`INSERT INTO shares (uid) VALUES ($1) ON CONFLICT (uid) DO NOTHING`, share.UID)
+	}
+	for _, bond := range set.Bonds {
+		queueInstrument(batch, bond.Instrument)
+		batch.Queue(`
+			INSERT INTO bonds (uid, nominal, aci_value)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (uid) DO UPDATE
+			SET nominal = EXCLUDED.nominal,
+			    aci_value = EXCLUDED.aci_value`,
+			bond.UID,
+			bond.Nominal,
+			bond.AciValue,
+		)
+	}
+	for _, etf := range set.Etfs {
+		queueInstrument(batch, etf.Instrument)
+		batch.Queue(`
+			INSERT INTO etfs (uid, min_price_increment)
+			VALUES ($1, $2)
+			ON CONFLICT (uid) DO UPDATE
+			SET min_price_increment = EXCLUDED.min_price_increment`,
+			etf.UID,
+			etf.MinPriceIncrement,
+		)
+	}
+	for _, currency := range set.Currencies {
+		queueInstrument(batch, currency.Instrument)
+		batch.Queue(`INSERT INTO currencies (uid) VALUES ($1) ON CONFLICT (uid) DO NOTHING`, currency.UID)
+	}
+	for _, future := range set.Futures {
+		queueInstrument(batch, future.Instrument)
+		batch.Queue(`
+			INSERT INTO futures (uid, min_price_increment, min_price_increment_amount, asset_type)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (uid) DO UPDATE
+			SET min_price_increment = EXCLUDED.min_price_increment,
+			    min_price_increment_amount = EXCLUDED.min_price_increment_amount,
+			    asset_type = EXCLUDED.asset_type`,
+			future.UID,
+			future.MinPriceIncrement,
+			future.MinPriceIncrementAmount,
+			future.AssetType.String(),
+		)
+	}
+	return execBatch(ctx, pool, batch)
+}
+
+func queueInstrument(batch *pgx.Batch, instrument domain.Instrument) {
+	batch.Queue(`
+		INSERT INTO instruments (uid, figi, ticker, lot, class_code, brand_uid)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (uid) DO UPDATE
+		SET figi = EXCLUDED.figi,
+		    ticker = EXCLUDED.ticker,
+		    lot = EXCLUDED.lot,
+		    class_code = EXCLUDED.class_code,
+		    brand_uid = EXCLUDED.brand_uid,
+		    updated_at = NOW()`,
+		instrument.UID,
+		instrument.Figi,
+		instrument.Ticker,
+		instrument.Lot,
+		instrument.ClassCode,
+		instrument.BrandUID,
+	)
+}
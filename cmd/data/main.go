@@ -3,12 +3,16 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
-	"hash/crc32"
+	"maps"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -23,6 +27,21 @@ import (
 const (
 	defaultInvestEndpoint = "https://invest-public-api.tinkoff.ru:443"
 	defaultAppName        = "marketdata-data-loader"
+	defaultMarketTimezone = "Europe/Moscow"
+	// defaultVolatilityDays is the lookback of the sector volatility.
+	defaultVolatilityDays = 90
+	// defaultVolatilityInterval is the candle interval daily closes are read
+	// from; the producer stores 1m candles by default.
+	defaultVolatilityInterval = 60
+	// dryRunSampleSize is how many entities of each kind a dry run logs.
+	dryRunSampleSize = 5
+)
+
+// Reasons a brand is left out of the sync, as reported in the summary.
+const (
+	skipMissingCountry = "missing_country"
+	skipUnknownCountry = "unknown_country"
+	skipMissingName    = "missing_name"
 )
 
 type dataConfig struct {
@@ -31,6 +50,22 @@ type dataConfig struct {
 	AppName       string
 	SkipTLSVerify bool
 	DatabaseDSN   string
+	// DryRun fetches and prepares everything but writes nothing.
+	DryRun bool
+	// ForceFullSync upserts every row, not only those whose content changed
+	// since the last run.
+	ForceFullSync bool
+	// VolatilityLookbackDays is the window of the sector volatility; zero
+	// skips computing it.
+	VolatilityLookbackDays    int
+	VolatilityIntervalSeconds int64
+	// MarketTimezone sets the calendar days daily closes are taken from.
+	MarketTimezone string
+	// CallAttempts bounds the tries of each invest API call, CallRetryBase
+	// is the first wait between them, and CallTimeout limits each try.
+	CallAttempts  int
+	CallRetryBase time.Duration
+	CallTimeout   time.Duration
 }
 
 func main() {
@@ -45,12 +80,6 @@ func main() {
 		logger.Fatalf("config error: %v", err)
 	}
 
-	pool, err := pgxpool.New(ctx, cfg.DatabaseDSN)
-	if err != nil {
-		logger.Fatalf("connect postgres: %v", err)
-	}
-	defer pool.Close()
-
 	investCfg := investgo.Config{
 		EndPoint:           cfg.Endpoint,
 		Token:              cfg.Token,
@@ -69,56 +98,187 @@ func main() {
 	}()
 
 	instrumentClient := client.NewInstrumentsServiceClient()
+	retry := newAPIRetry(cfg, logger)
 
-	countries, err := fetchCountries(instrumentClient)
+	countries, err := fetchCountries(ctx, instrumentClient, retry)
 	if err != nil {
 		logger.Fatalf("fetch countries: %v", err)
 	}
-	if err := upsertCountries(ctx, pool, countries); err != nil {
-		logger.Fatalf("save countries: %v", err)
+	brands, err := fetchBrands(ctx, instrumentClient, retry)
+	if err != nil {
+		logger.Fatalf("fetch brands: %v", err)
 	}
-	logger.WithField("countries", len(countries)).Info("countries synced")
+	brandEntities, companies, sectors, skipped := prepareBrandData(brands, countries, logger)
+	logger.WithFields(logrus.Fields{
+		"brands":  len(brands),
+		"skipped": skipped,
+	}).Info("brands prepared")
 
-	brands, err := fetchBrands(instrumentClient)
+	instruments, skippedInstruments, err := fetchInstruments(ctx, instrumentClient, retry, newBrandResolver(instrumentClient, retry, brandEntities), logger)
 	if err != nil {
-		logger.Fatalf("fetch brands: %v", err)
+		logger.Fatalf("fetch instruments: %v", err)
+	}
+	logger.WithFields(instruments.counts()).WithField("skipped", skippedInstruments).Info("instruments prepared")
+
+	if cfg.DryRun {
+		reportDryRun(logger, countries, companies, sectors, brandEntities, instruments)
+		return
+	}
+
+	pool, err := pgxpool.New(ctx, cfg.DatabaseDSN)
+	if err != nil {
+		logger.Fatalf("connect postgres: %v", err)
 	}
-	brandEntities, companies, sectors := prepareBrandData(brands, countries, logger)
+	defer pool.Close()
 
-	if err := upsertCompanies(ctx, pool, companies); err != nil {
+	state, err := loadSyncState(ctx, pool, cfg.ForceFullSync)
+	if err != nil {
+		logger.Fatalf("load sync state: %v", err)
+	}
+	logger.WithFields(logrus.Fields{
+		"force_full_sync": cfg.ForceFullSync,
+		"last_synced_at":  state.watermarks,
+	}).Info("sync state loaded")
+
+	changedCountries := state.changedCountries(countries)
+	if err := upsertCountries(ctx, pool, changedCountries); err != nil {
+		logger.Fatalf("save countries: %v", err)
+	}
+	logger.WithFields(logrus.Fields{"countries": len(changedCountries), "unchanged": len(countries) - len(changedCountries)}).Info("countries synced")
+
+	changedCompanies := state.changedCompanies(companies)
+	if err := upsertCompanies(ctx, pool, changedCompanies); err != nil {
 		logger.Fatalf("save companies: %v", err)
 	}
-	logger.WithField("companies", len(companies)).Info("companies synced")
+	logger.WithFields(logrus.Fields{"companies": len(changedCompanies), "unchanged": len(companies) - len(changedCompanies)}).Info("companies synced")
 
-	if err := upsertSectors(ctx, pool, sectors); err != nil {
+	changedSectors := state.changedSectors(sectors)
+	if err := upsertSectors(ctx, pool, changedSectors); err != nil {
 		logger.Fatalf("save sectors: %v", err)
 	}
-	logger.WithField("sectors", len(sectors)).Info("sectors synced")
+	logger.WithFields(logrus.Fields{"sectors": len(changedSectors), "unchanged": len(sectors) - len(changedSectors)}).Info("sectors synced")
 
-	if err := upsertBrands(ctx, pool, brandEntities); err != nil {
+	changedBrands := state.changedBrands(brandEntities)
+	if err := upsertBrands(ctx, pool, changedBrands); err != nil {
 		logger.Fatalf("save brands: %v", err)
 	}
-	logger.WithField("brands", len(brandEntities)).Info("brands synced")
+	logger.WithFields(logrus.Fields{"brands": len(changedBrands), "unchanged": len(brandEntities) - len(changedBrands)}).Info("brands synced")
+
+	instruments, figiConflicts, err := dropFigiConflicts(ctx, pool, instruments, logger)
+	if err != nil {
+		logger.Fatalf("check instrument figis: %v", err)
+	}
+	if len(figiConflicts) > 0 {
+		logger.WithField(skipFigiConflict, figiConflicts).Warn("instruments left out")
+	}
+	changedInstruments := state.changedInstruments(instruments)
+	if err := upsertInstruments(ctx, pool, changedInstruments); err != nil {
+		logger.Fatalf("save instruments: %v", err)
+	}
+	logger.WithFields(changedInstruments.counts()).Info("instruments synced")
+
+	if err := state.save(ctx, pool, kindCountry, kindCompany, kindSector, kindBrand, kindShare, kindBond, kindEtf, kindCurrency, kindFuture); err != nil {
+		logger.Fatalf("save sync state: %v", err)
+	}
+
+	if cfg.VolatilityLookbackDays > 0 {
+		if err := syncSectorVolatility(ctx, pool, cfg, logger); err != nil {
+			logger.Fatalf("sync sector volatility: %v", err)
+		}
+	}
 	logger.Info("reference data sync finished")
 }
 
+// reportDryRun logs what a real run would upsert: the count of each entity
+// kind and a sample of them.
+func reportDryRun(logger *logrus.Logger, countries map[string]*domain.Country, companies map[string]domain.Company, sectors map[string]*domain.Sector, brands []*domain.Brand, instruments *instrumentSet) {
+	countrySample := make([]*domain.Country, 0, dryRunSampleSize)
+	for _, code := range slices.Sorted(maps.Keys(countries)) {
+		if len(countrySample) == dryRunSampleSize {
+			break
+		}
+		countrySample = append(countrySample, countries[code])
+	}
+	companySample := make([]domain.Company, 0, dryRunSampleSize)
+	for _, key := range slices.Sorted(maps.Keys(companies)) {
+		if len(companySample) == dryRunSampleSize {
+			break
+		}
+		companySample = append(companySample, companies[key])
+	}
+	sectorSample := make([]*domain.Sector, 0, dryRunSampleSize)
+	for _, key := range slices.Sorted(maps.Keys(sectors)) {
+		if len(sectorSample) == dryRunSampleSize {
+			break
+		}
+		sectorSample = append(sectorSample, sectors[key])
+	}
+
+	logger.WithFields(logrus.Fields{"count": len(countries), "sample": countrySample}).Info("dry run: countries to upsert")
+	logger.WithFields(logrus.Fields{"count": len(companies), "sample": companySample}).Info("dry run: companies to upsert")
+	logger.WithFields(logrus.Fields{"count": len(sectors), "sample": sectorSample}).Info("dry run: sectors to upsert")
+	logger.WithFields(logrus.Fields{"count": len(brands), "sample": brands[:min(len(brands), dryRunSampleSize)]}).Info("dry run: brands to upsert")
+	logger.WithFields(logrus.Fields{"count": len(instruments.Shares), "sample": instruments.Shares[:min(len(instruments.Shares), dryRunSampleSize)]}).Info("dry run: shares to upsert")
+	logger.WithFields(logrus.Fields{"count": len(instruments.Bonds), "sample": instruments.Bonds[:min(len(instruments.Bonds), dryRunSampleSize)]}).Info("dry run: bonds to upsert")
+	logger.WithFields(logrus.Fields{"count": len(instruments.Etfs), "sample": instruments.Etfs[:min(len(instruments.Etfs), dryRunSampleSize)]}).Info("dry run: etfs to upsert")
+	logger.WithFields(logrus.Fields{"count": len(instruments.Currencies), "sample": instruments.Currencies[:min(len(instruments.Currencies), dryRunSampleSize)]}).Info("dry run: currencies to upsert")
+	logger.WithFields(logrus.Fields{"count": len(instruments.Futures), "sample": instruments.Futures[:min(len(instruments.Futures), dryRunSampleSize)]}).Info("dry run: futures to upsert")
+	logger.Info("dry run finished, nothing written")
+}
+
 func loadConfig() (*dataConfig, error) {
+	dryRunFlag := flag.Bool("dry-run", false, "fetch and prepare reference data without writing it")
+	flag.Parse()
+	dryRun := *dryRunFlag || boolEnv("DRY_RUN", false)
+
 	token := strings.TrimSpace(os.Getenv("INVEST_TOKEN"))
 	if token == "" {
 		return nil, errors.New("INVEST_TOKEN is required")
 	}
 
+	// A dry run never connects to the database.
 	dsn := strings.TrimSpace(os.Getenv("DATABASE_DSN"))
-	if dsn == "" {
+	if dsn == "" && !dryRun {
 		return nil, errors.New("DATABASE_DSN is required")
 	}
 
+	volatilityDays := intEnv("SECTOR_VOLATILITY_LOOKBACK_DAYS", defaultVolatilityDays)
+	if volatilityDays < 0 {
+		return nil, errors.New("SECTOR_VOLATILITY_LOOKBACK_DAYS must not be negative")
+	}
+	volatilityInterval := intEnv("SECTOR_VOLATILITY_INTERVAL_SECONDS", defaultVolatilityInterval)
+	if volatilityInterval <= 0 {
+		return nil, errors.New("SECTOR_VOLATILITY_INTERVAL_SECONDS must be positive")
+	}
+	callAttempts := intEnv("INVEST_CALL_ATTEMPTS", defaultCallAttempts)
+	if callAttempts <= 0 {
+		return nil, errors.New("INVEST_CALL_ATTEMPTS must be positive")
+	}
+	callRetryBase := intEnv("INVEST_CALL_RETRY_BASE_MS", defaultCallRetryBaseMS)
+	if callRetryBase <= 0 {
+		return nil, errors.New("INVEST_CALL_RETRY_BASE_MS must be positive")
+	}
+	callTimeout := intEnv("INVEST_CALL_TIMEOUT_SECONDS", defaultCallTimeoutSeconds)
+	if callTimeout <= 0 {
+		return nil, errors.New("INVEST_CALL_TIMEOUT_SECONDS must be positive")
+	}
+
 	return &dataConfig{
 		Token:         token,
 		Endpoint:      envOrDefault("INVEST_ENDPOINT", defaultInvestEndpoint),
 		AppName:       envOrDefault("INVEST_APP_NAME", defaultAppName),
 		SkipTLSVerify: boolEnv("INVEST_INSECURE_SKIP_VERIFY", true),
 		DatabaseDSN:   dsn,
+		DryRun:        dryRun,
+		ForceFullSync: boolEnv("FORCE_FULL_SYNC", false),
+
+		VolatilityLookbackDays:    volatilityDays,
+		VolatilityIntervalSeconds: int64(volatilityInterval),
+		MarketTimezone:            envOrDefault("MARKET_TIMEZONE", defaultMarketTimezone),
+
+		CallAttempts:  callAttempts,
+		CallRetryBase: time.Duration(callRetryBase) * time.Millisecond,
+		CallTimeout:   time.Duration(callTimeout) * time.Second,
 	}, nil
 }
 
@@ -130,6 +290,18 @@ func envOrDefault(key, fallback string) string {
 	return value
 }
 
+func intEnv(key string, fallback int) int {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
+
 func boolEnv(key string, fallback bool) bool {
 	value := strings.TrimSpace(os.Getenv(key))
 	if value == "" {
@@ -145,8 +317,8 @@ func boolEnv(key string, fallback bool) bool {
 	}
 }
 
-func fetchCountries(client *investgo.InstrumentsServiceClient) (map[string]*domain.Country, error) {
-	resp, err := client.GetCountries()
+func fetchCountries(ctx context.Context, client *investgo.InstrumentsServiceClient, retry *apiRetry) (map[string]*domain.Country, error) {
+	resp, err := callAPI(ctx, retry, "get countries", client.GetCountries)
 	if err != nil {
 		return nil, fmt.Errorf("get countries: %w", err)
 	}
@@ -178,18 +350,21 @@ func fetchCountries(client *investgo.InstrumentsServiceClient) (map[string]*doma
 	return result, nil
 }
 
-func fetchBrands(client *investgo.InstrumentsServiceClient) ([]*pb.Brand, error) {
-	resp, err := client.GetBrands()
+func fetchBrands(ctx context.Context, client *investgo.InstrumentsServiceClient, retry *apiRetry) ([]*pb.Brand, error) {
+	resp, err := callAPI(ctx, retry, "get brands", client.GetBrands)
 	if err != nil {
 		return nil, fmt.Errorf("get brands: %w", err)
 	}
 	return resp.GetBrands(), nil
 }
 
-func prepareBrandData(brands []*pb.Brand, countries map[string]*domain.Country, logger *logrus.Logger) ([]*domain.Brand, map[string]domain.Company, map[string]*domain.Sector) {
-	brandEntities := make([]*domain.Brand, 0, len(brands))
-	companies := make(map[string]domain.Company)
-	sectors := make(map[string]*domain.Sector)
+// prepareBrandData maps brands to entities and derives their companies and
+// sectors. skipped counts the brands left out, by reason.
+func prepareBrandData(brands []*pb.Brand, countries map[string]*domain.Country, logger *logrus.Logger) (brandEntities []*domain.Brand, companies map[string]domain.Company, sectors map[string]*domain.Sector, skipped map[string]int) {
+	brandEntities = make([]*domain.Brand, 0, len(brands))
+	companies = make(map[string]domain.Company)
+	sectors = make(map[string]*domain.Sector)
+	skipped = make(map[string]int)
 
 	for _, brand := range brands {
 		if brand == nil {
@@ -198,6 +373,7 @@ func prepareBrandData(brands []*pb.Brand, countries map[string]*domain.Country,
 		countryCode := strings.ToUpper(strings.TrimSpace(brand.GetCountryOfRisk()))
 		if len(countryCode) != 2 {
 			logger.WithField("brand_uid", brand.GetUid()).Warn("skip brand without country code")
+			skipped[skipMissingCountry]++
 			continue
 		}
 		if _, ok := countries[countryCode]; !ok {
@@ -205,12 +381,14 @@ func prepareBrandData(brands []*pb.Brand, countries map[string]*domain.Country,
 				"brand_uid": brand.GetUid(),
 				"country":   countryCode,
 			}).Warn("skip brand with unknown country")
+			skipped[skipUnknownCountry]++
 			continue
 		}
 
 		name := strings.TrimSpace(brand.GetName())
 		if name == "" {
 			logger.WithField("brand_uid", brand.GetUid()).Warn("skip brand without name")
+			skipped[skipMissingName]++
 			continue
 		}
 
@@ -233,9 +411,8 @@ func prepareBrandData(brands []*pb.Brand, countries map[string]*domain.Country,
 		sectorKey := strings.ToLower(sectorName)
 		if _, ok := sectors[sectorKey]; !ok {
 			sectors[sectorKey] = &domain.Sector{
-				UID:        stableUUID(uuid.NameSpaceOID, "sector:"+sectorKey),
-				Name:       sectorName,
-				Volatility: pseudoVolatility(sectorName),
+				UID:  stableUUID(uuid.NameSpaceOID, "sector:"+sectorKey),
+				Name: sectorName,
 			}
 		}
 
@@ -250,7 +427,7 @@ func prepareBrandData(brands []*pb.Brand, countries map[string]*domain.Country,
 		})
 	}
 
-	return brandEntities, companies, sectors
+	return brandEntities, companies, sectors, skipped
 }
 
 func upsertCountries(ctx context.Context, pool *pgxpool.Pool, countries map[string]*domain.Country) error {
@@ -292,13 +469,11 @@ func upsertSectors(ctx context.Context, pool *pgxpool.Pool, sectors map[string]*
 	for _, sector := range sectors {
 		batch.Queue(`
 			INSERT INTO sectors (uid, name, volatility)
-			VALUES ($1, $2, $3)
+			VALUES ($1, $2, 0)
 			ON CONFLICT (uid) DO UPDATE
-			SET name = EXCLUDED.name,
-			    volatility = EXCLUDED.volatility`,
+			SET name = EXCLUDED.name`,
 			sector.UID,
 			sector.Name,
-			sector.Volatility,
 		)
 	}
 	return execBatch(ctx, pool, batch)
@@ -363,8 +538,3 @@ func parseBrandUID(rawID, fallback string) uuid.UUID {
 	}
 	return stableUUID(uuid.NameSpaceURL, "brand:"+strings.ToLower(key))
 }
-
-func pseudoVolatility(name string) int32 {
-	sum := crc32.ChecksumIEEE([]byte(strings.ToLower(strings.TrimSpace(name))))
-	return int32(sum % 100)
-}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultCallAttempts       = 4
+	defaultCallRetryBaseMS    = 500
+	defaultCallTimeoutSeconds = 60
+	// maxCallRetryDelay caps the exponential backoff between attempts.
+	maxCallRetryDelay = 30 * time.Second
+	// rateLimitWait is how long a ResourceExhausted answer without retry info
+	// waits: the invest API counts its limits per minute.
+	rateLimitWait = time.Minute
+)
+
+// errCallTimeout is reported when an invest API call did not answer within
+// the call timeout.
+var errCallTimeout = errors.New("invest api call timed out")
+
+// apiRetry retries invest API calls that failed for a reason another attempt
+// may not hit: unavailability, rate limits, timeouts and internal errors.
+type apiRetry struct {
+	attempts  int
+	baseDelay time.Duration
+	timeout   time.Duration
+	logger    *logrus.Logger
+}
+
+func newAPIRetry(cfg *dataConfig, logger *logrus.Logger) *apiRetry {
+	return &apiRetry{
+		attempts:  cfg.CallAttempts,
+		baseDelay: cfg.CallRetryBase,
+		timeout:   cfg.CallTimeout,
+		logger:    logger,
+	}
+}
+
+// callAPI runs call, named name in the logs, up to r.attempts times. The SDK
+// methods take no context, so an attempt that outlives the call timeout is
+// abandoned rather than cancelled: its answer is discarded and the next
+// attempt starts. The wait between attempts doubles from r.baseDelay, and a
+// rate limited call waits as long as the API asks, or a full minute.
+func callAPI[T any](ctx context.Context, r *apiRetry, name string, call func() (T, error)) (T, error) {
+	var zero T
+	for attempt := 1; ; attempt++ {
+		value, err := callWithTimeout(ctx, r.timeout, call)
+		if err == nil {
+			return value, nil
+		}
+		if ctx.Err() != nil {
+			return zero, ctx.Err()
+		}
+		if attempt >= r.attempts || !retriableAPIError(err) {
+			return zero, err
+		}
+		delay := r.delay(attempt, err)
+		r.logger.WithError(err).WithFields(logrus.Fields{
+			"call":    name,
+			"attempt": attempt,
+			"delay":   delay.String(),
+		}).Warn("invest api call failed, retrying")
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return zero, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// bindArg turns an SDK method taking one argument into a call for callAPI.
+func bindArg[A, T any](method func(A) (T, error), arg A) func() (T, error) {
+	return func() (T, error) { return method(arg) }
+}
+
+// callWithTimeout runs call and waits for it at most timeout.
+func callWithTimeout[T any](ctx context.Context, timeout time.Duration, call func() (T, error)) (T, error) {
+	type result struct {
+		value T
+		err   error
+	}
+	// Buffered, so an abandoned call can still deliver and exit.
+	done := make(chan result, 1)
+	go func() {
+		value, err := call()
+		done <- result{value, err}
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var zero T
+	select {
+	case res := <-done:
+		return res.value, res.err
+	case <-timer.C:
+		return zero, fmt.Errorf("%w after %s", errCallTimeout, timeout)
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// delay is the wait before the attempt after attempt.
+func (r *apiRetry) delay(attempt int, err error) time.Duration {
+	backoff := min(r.baseDelay<<(attempt-1), maxCallRetryDelay)
+	if status.Code(err) != codes.ResourceExhausted {
+		return backoff
+	}
+	if wait, ok := retryInfoDelay(err); ok {
+		return max(wait, backoff)
+	}
+	return max(rateLimitWait, backoff)
+}
+
+// retryInfoDelay reads the RetryInfo detail, the gRPC form of Retry-After.
+func retryInfoDelay(err error) (time.Duration, bool) {
+	st, ok := status.FromError(err)
+	if !ok {
+		return 0, false
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok && info.GetRetryDelay() != nil {
+			return info.GetRetryDelay().AsDuration(), true
+		}
+	}
+	return 0, false
+}
+
+func retriableAPIError(err error) bool {
+	if errors.Is(err, errCallTimeout) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded, codes.Aborted, codes.Internal:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	domain "main/internal/domain/entity/instruments"
+)
+
+// Entity kinds tracked by the incremental sync.
+const (
+	kindCountry  = "country"
+	kindCompany  = "company"
+	kindSector   = "sector"
+	kindBrand    = "brand"
+	kindShare    = "share"
+	kindBond     = "bond"
+	kindEtf      = "etf"
+	kindCurrency = "currency"
+	kindFuture   = "future"
+)
+
+// syncState remembers the content hash of every row written by earlier runs,
+// so a run only upserts rows that changed. With force set every row counts as
+// changed, and the stored hashes are rewritten.
+type syncState struct {
+	force   bool
+	stored  map[string]map[string]string
+	pending map[string]map[string]string
+	// watermarks are the last_synced_at values of the previous run, by kind.
+	watermarks map[string]time.Time
+}
+
+func loadSyncState(ctx context.Context, pool *pgxpool.Pool, force bool) (*syncState, error) {
+	state := &syncState{
+		force:      force,
+		stored:     make(map[string]map[string]string),
+		pending:    make(map[string]map[string]string),
+		watermarks: make(map[string]time.Time),
+	}
+
+	rows, err := pool.Query(ctx, `SELECT kind, last_synced_at FROM reference_sync_state`)
+	if err != nil {
+		return nil, fmt.Errorf("load sync watermarks: %w", err)
+	}
+	for rows.Next() {
+		var kind string
+		var syncedAt time.Time
+		if err := rows.Scan(&kind, &syncedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan sync watermark: %w", err)
+		}
+		state.watermarks[kind] = syncedAt
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("load sync watermarks: %w", err)
+	}
+	if force {
+		return state, nil
+	}
+
+	rows, err = pool.Query(ctx, `SELECT kind, key, content_hash FROM reference_sync_hashes`)
+	if err != nil {
+		return nil, fmt.Errorf("load sync hashes: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var kind, key, hash string
+		if err := rows.Scan(&kind, &key, &hash); err != nil {
+			return nil, fmt.Errorf("scan sync hash: %w", err)
+		}
+		if state.stored[kind] == nil {
+			state.stored[kind] = make(map[string]string)
+		}
+		state.stored[kind][key] = hash
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("load sync hashes: %w", err)
+	}
+	return state, nil
+}
+
+// changed reports whether a row differs from what the last run wrote, and if so
+// queues its new hash for save.
+func (s *syncState) changed(kind, key string, fields ...any) bool {
+	hash := contentHash(fields...)
+	if !s.force && s.stored[kind][key] == hash {
+		return false
+	}
+	if s.pending[kind] == nil {
+		s.pending[kind] = make(map[string]string)
+	}
+	s.pending[kind][key] = hash
+	return true
+}
+
+// save stores the hashes of the rows written by this run and moves the
+// watermark of every kind in kinds. Call it only after the upserts succeeded.
+func (s *syncState) save(ctx context.Context, pool *pgxpool.Pool, kinds ...string) error {
+	batch := &pgx.Batch{}
+	for kind, hashes := range s.pending {
+		for key, hash := range hashes {
+			batch.Queue(`
+				INSERT INTO reference_sync_hashes (kind, key, content_hash, synced_at)
+				VALUES ($1, $2, $3, NOW())
+				ON CONFLICT (kind, key) DO UPDATE
+				SET content_hash = EXCLUDED.content_hash,
+				    synced_at = EXCLUDED.synced_at`,
+				kind, key, hash,
+			)
+		}
+	}
+	for _, kind := range kinds {
+		batch.Queue(`
+			INSERT INTO reference_sync_state (kind, last_synced_at)
+			VALUES ($1, NOW())
+			ON CONFLICT (kind) DO UPDATE
+			SET last_synced_at = EXCLUDED.last_synced_at`,
+			kind,
+		)
+	}
+	return execBatch(ctx, pool, batch)
+}
+
+// contentHash is a stable digest of an entity's stored fields.
+func contentHash(fields ...any) string {
+	h := sha256.New()
+	for _, field := range fields {
+		fmt.Fprintf(h, "%v\x1f", field)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (s *syncState) changedCountries(countries map[string]*domain.Country) map[string]*domain.Country {
+	out := make(map[string]*domain.Country)
+	for code, country := range countries {
+		if s.changed(kindCountry, code, country.AlfaThree, country.Name, country.NameBrief) {
+			out[code] = country
+		}
+	}
+	return out
+}
+
+func (s *syncState) changedCompanies(companies map[string]domain.Company) map[string]domain.Company {
+	out := make(map[string]domain.Company)
+	for key, company := range companies {
+		if s.changed(kindCompany, company.UID.String(), company.Name) {
+			out[key] = company
+		}
+	}
+	return out
+}
+
+func (s *syncState) changedSectors(sectors map[string]*domain.Sector) map[string]*domain.Sector {
+	out := make(map[string]*domain.Sector)
+	for key, sector := range sectors {
+		if s.changed(kindSector, sector.UID.String(), sector.Name) {
+			out[key] = sector
+		}
+	}
+	return out
+}
+
+func (s *syncState) changedBrands(brands []*domain.Brand) []*domain.Brand {
+	var out []*domain.Brand
+	for _, brand := range brands {
+		if s.changed(kindBrand, brand.UID.String(), brand.Name, brand.Description, brand.Info, brand.CompanyUID, brand.SectorUID, brand.CountryCode) {
+			out = append(out, brand)
+		}
+	}
+	return out
+}
+
+func (s *syncState) changedInstruments(set *instrumentSet) *instrumentSet {
+	out := &instrumentSet{}
+	base := func(kind string, i domain.Instrument, extra ...any) bool {
+		fields := append([]any{i.Figi, i.Ticker, i.Lot, i.ClassCode, i.BrandUID}, extra...)
+		return s.changed(kind, i.UID.String(), fields...)
+	}
+	for _, share := range set.Shares {
+		if base(kindShare, share.Instrument) {
+			out.Shares = append(out.Shares, share)
+		}
+	}
+	for _, bond := range set.Bonds {
+		if base(kindBond, bond.Instrument, bond.Nominal, bond.AciValue) {
+			out.Bonds = append(out.Bonds, bond)
+		}
+	}
+	for _, etf := range set.Etfs {
+		if base(kindEtf, etf.Instrument, etf.MinPriceIncrement) {
+			out.Etfs = append(out.Etfs, etf)
+		}
+	}
+	for _, currency := range set.Currencies {
+		if base(kindCurrency, currency.Instrument) {
+			out.Currencies = append(out.Currencies, currency)
+		}
+	}
+	for _, future := range set.Futures {
+		if base(kindFuture, future.Instrument, future.MinPriceIncrement, future.MinPriceIncrementAmount, future.AssetType) {
+			out.Futures = append(out.Futures, future)
+		}
+	}
+	return out
+}
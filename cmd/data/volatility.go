@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+
+	appmarketdata "main/internal/application/service/marketdata"
+	"main/internal/config"
+	inframarketdata "main/internal/infrastructure/marketdata"
+)
+
+// maxSectorVolatility is the largest value the sectors.volatility check allows.
+const maxSectorVolatility = 99
+
+// syncSectorVolatility stores the realized volatility of every sector with
+// enough candles, as a whole annualized percentage. Sectors without enough
+// candles keep their stored value.
+func syncSectorVolatility(ctx context.Context, pool *pgxpool.Pool, cfg *dataConfig, logger *logrus.Logger) error {
+	location, err := time.LoadLocation(cfg.MarketTimezone)
+	if err != nil {
+		return fmt.Errorf("load market timezone: %w", err)
+	}
+	repo, err := inframarketdata.NewRepository(ctx, config.PostgresConfig{DSN: cfg.DatabaseDSN})
+	if err != nil {
+		return fmt.Errorf("init marketdata repository: %w", err)
+	}
+	defer repo.Close()
+	service := appmarketdata.NewService(repo)
+	service.SetMarketLocation(location)
+
+	sectors, err := service.GetSectorVolatility(ctx, cfg.VolatilityIntervalSeconds, cfg.VolatilityLookbackDays)
+	if err != nil {
+		return fmt.Errorf("compute sector volatility: %w", err)
+	}
+
+	batch := &pgx.Batch{}
+	for _, sector := range sectors {
+		percent := int32(math.Round(sector.Annualized * 100))
+		if percent > maxSectorVolatility {
+			logger.WithFields(logrus.Fields{
+				"sector_uid": sector.SectorUID,
+				"volatility": sector.Annualized,
+			}).Warn("sector volatility above 99%, storing 99")
+			percent = maxSectorVolatility
+		}
+		batch.Queue(`UPDATE sectors SET volatility = $2 WHERE uid = $1`, sector.SectorUID, percent)
+	}
+	if err := execBatch(ctx, pool, batch); err != nil {
+		return fmt.Errorf("save sector volatility: %w", err)
+	}
+	logger.WithFields(logrus.Fields{
+		"sectors":       len(sectors),
+		"lookback_days": cfg.VolatilityLookbackDays,
+	}).Info("sector volatility synced")
+	return nil
+}
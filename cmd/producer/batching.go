@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	domain "main/internal/domain/entity/marketdata"
+	"main/internal/infrastructure/broker"
+)
+
+// batchKey groups the entities that may share a message: one stream, one
+// instrument, since the routing key carries the instrument, and for candles
+// one interval, since the interval_seconds header describes the whole message.
+type batchKey struct {
+	kind            streamKind
+	instrumentUID   uuid.UUID
+	intervalSeconds int64
+}
+
+// messageBatcher coalesces entities into one broker.BaseMessage per batch key.
+// A batch goes out when it reaches size entities, from the pump that filled
+// it, or at the latest on the next interval tick. Pending batches belong to
+// the publisher, so they outlive a reconnected stream.
+type messageBatcher struct {
+	size     int
+	interval time.Duration
+	publish  func(ctx context.Context, key batchKey, msg *broker.BaseMessage, items int) error
+
+	mu      sync.Mutex
+	pending map[batchKey]*broker.BaseMessage
+}
+
+func newMessageBatcher(size int, interval time.Duration, publish func(context.Context, batchKey, *broker.BaseMessage, int) error) *messageBatcher {
+	return &messageBatcher{
+		size:     size,
+		interval: interval,
+		publish:  publish,
+		pending:  make(map[batchKey]*broker.BaseMessage),
+	}
+}
+
+func (b *messageBatcher) addCandle(ctx context.Context, candle *domain.Candle) error {
+	key := batchKey{kind: kindCandles, instrumentUID: candle.InstrumentUID, intervalSeconds: candle.IntervalSeconds}
+	return b.add(ctx, key, func(msg *broker.BaseMessage) int {
+		msg.Candles = append(msg.Candles, *candle)
+		return len(msg.Candles)
+	})
+}
+
+func (b *messageBatcher) addTrade(ctx context.Context, trade *domain.Trade) error {
+	key := batchKey{kind: kindTrades, instrumentUID: trade.InstrumentUID}
+	return b.add(ctx, key, func(msg *broker.BaseMessage) int {
+		msg.Trades = append(msg.Trades, *trade)
+		return len(msg.Trades)
+	})
+}
+
+func (b *messageBatcher) addOrderBook(ctx context.Context, snapshot *domain.OrderBookSnapshot) error {
+	key := batchKey{kind: kindOrderBooks, instrumentUID: snapshot.InstrumentUID}
+	return b.add(ctx, key, func(msg *broker.BaseMessage) int {
+		msg.OrderBookSnapshots = append(msg.OrderBookSnapshots, *snapshot)
+		return len(msg.OrderBookSnapshots)
+	})
+}
+
+// add appends an entity to the batch of key and publishes the batch once it is
+// full. appendTo returns the batch length after the append.
+func (b *messageBatcher) add(ctx context.Context, key batchKey, appendTo func(*broker.BaseMessage) int) error {
+	b.mu.Lock()
+	msg := b.pending[key]
+	if msg == nil {
+		msg = &broker.BaseMessage{}
+		b.pending[key] = msg
+	}
+	items := appendTo(msg)
+	if items < b.size {
+		b.mu.Unlock()
+		return nil
+	}
+	delete(b.pending, key)
+	b.mu.Unlock()
+	return b.publish(ctx, key, msg, items)
+}
+
+// flush publishes every pending batch. Batches are taken one at a time, so
+// when ctx ends midway the ones not reached stay pending.
+func (b *messageBatcher) flush(ctx context.Context) error {
+	b.mu.Lock()
+	keys := slices.Collect(maps.Keys(b.pending))
+	b.mu.Unlock()
+	for _, key := range keys {
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		b.mu.Lock()
+		msg := b.pending[key]
+		delete(b.pending, key)
+		b.mu.Unlock()
+		if msg == nil {
+			// A pump filled and published it meanwhile.
+			continue
+		}
+		if err := b.publish(ctx, key, msg, batchLen(msg)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run flushes the pending batches every interval while a stream runs. Once the
+// pumps are done, i.e. the stream drained for shutdown, it publishes what is
+// left and returns. When ctx ends first the batches stay pending for the next
+// stream.
+func (b *messageBatcher) run(ctx context.Context, pumpsDone <-chan struct{}) error {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-pumpsDone:
+			return b.flush(ctx)
+		case <-ticker.C:
+			if err := b.flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func batchLen(msg *broker.BaseMessage) int {
+	return len(msg.Trades) + len(msg.Candles) + len(msg.OrderBookSnapshots)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+	// Embedded so MARKET_TIMEZONE resolves in images without system tzdata.
+	_ "time/tzdata"
+
+	"github.com/sirupsen/logrus"
+)
+
+// errStreamIdle is reported when the watchdog reconnects a stream that stayed
+// silent during market hours.
+var errStreamIdle = errors.New("market data stream idle")
+
+// streamKind names a stream in the watchdog's log lines.
+type streamKind string
+
+const (
+	kindCandles    streamKind = "candles"
+	kindTrades     streamKind = "trades"
+	kindOrderBooks streamKind = "orderbooks"
+)
+
+var streamKinds = []streamKind{kindCandles, kindTrades, kindOrderBooks}
+
+// streamActivity records when each pump last received a message. The pumps
+// write it and the watchdog reads it, so the timestamps are atomic.
+type streamActivity struct {
+	last map[streamKind]*atomic.Int64
+}
+
+// newStreamActivity starts every stream as if it had just received a message,
+// so a fresh subscription gets a full idle timeout.
+func newStreamActivity(now time.Time) *streamActivity {
+	a := &streamActivity{last: make(map[streamKind]*atomic.Int64, len(streamKinds))}
+	for _, kind := range streamKinds {
+		a.last[kind] = new(atomic.Int64)
+		a.last[kind].Store(now.UnixNano())
+	}
+	return a
+}
+
+// touch records a message on kind. A nil activity records nothing.
+func (a *streamActivity) touch(kind streamKind) {
+	if a == nil {
+		return
+	}
+	a.last[kind].Store(time.Now().UnixNano())
+}
+
+// latest returns the time of the last message on any stream.
+func (a *streamActivity) latest() time.Time {
+	var latest int64
+	for _, kind := range streamKinds {
+		latest = max(latest, a.last[kind].Load())
+	}
+	return time.Unix(0, latest)
+}
+
+// marketHours is the daily window the watchdog checks, in the market timezone.
+// Outside it, and on weekends, silence is expected and never reported. A zero
+// window means around the clock, every day.
+type marketHours struct {
+	open, close time.Duration // offsets from midnight; close < open wraps past midnight
+	loc         *time.Location
+}
+
+func (h marketHours) allDay() bool {
+	return h.open == h.close
+}
+
+// contains reports whether t falls inside the window.
+func (h marketHours) contains(t time.Time) bool {
+	if h.allDay() {
+		return true
+	}
+	local := t.In(h.loc)
+	if day := local.Weekday(); day == time.Saturday || day == time.Sunday {
+		return false
+	}
+	offset := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute + time.Duration(local.Second())*time.Second
+	if h.open < h.close {
+		return offset >= h.open && offset < h.close
+	}
+	return offset >= h.open || offset < h.close
+}
+
+// parseMarketHours parses "HH:MM-HH:MM"; an empty value means around the clock.
+func parseMarketHours(value string, loc *time.Location) (marketHours, error) {
+	hours := marketHours{loc: loc}
+	if value == "" {
+		return hours, nil
+	}
+	openRaw, closeRaw, ok := strings.Cut(value, "-")
+	if !ok {
+		return hours, fmt.Errorf("must be HH:MM-HH:MM, got %q", value)
+	}
+	var err error
+	if hours.open, err = parseClock(strings.TrimSpace(openRaw)); err != nil {
+		return hours, err
+	}
+	if hours.close, err = parseClock(strings.TrimSpace(closeRaw)); err != nil {
+		return hours, err
+	}
+	if hours.allDay() {
+		return hours, fmt.Errorf("open and close are both %s", strings.TrimSpace(openRaw))
+	}
+	return hours, nil
+}
+
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("time must be HH:MM, got %q", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// watchIdle checks the stream activity while the stream runs. When no message
+// of any kind has arrived for cfg.IdleTimeout inside market hours, it logs a
+// warning with the silence of each stream, once per quiet spell. With
+// cfg.IdleReconnect it returns errStreamIdle instead, so the stream is torn
+// down and reconnected; this catches half-open connections the transport
+// never reports. Time outside market hours does not count towards the
+// timeout, so the first minutes after the open are not blamed on the night.
+func watchIdle(ctx context.Context, cfg *producerConfig, activity *streamActivity, logger *logrus.Logger) error {
+	ticker := time.NewTicker(idleCheckInterval(cfg.IdleTimeout))
+	defer ticker.Stop()
+
+	quietFrom := time.Now()
+	warned := false
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			if !cfg.MarketHours.contains(now) {
+				quietFrom = now
+				continue
+			}
+			since := activity.latest()
+			if since.After(quietFrom) {
+				quietFrom = since
+				warned = false
+			}
+			idle := now.Sub(quietFrom)
+			if idle < cfg.IdleTimeout || warned {
+				continue
+			}
+			fields := logrus.Fields{"idle": idle.Round(time.Second).String()}
+			for _, kind := range streamKinds {
+				fields[string(kind)+"_idle"] = now.Sub(time.Unix(0, activity.last[kind].Load())).Round(time.Second).String()
+			}
+			if cfg.IdleReconnect {
+				logger.WithFields(fields).Warn("market data stream idle during market hours, reconnecting")
+				return &streamError{fmt.Errorf("%w for %s", errStreamIdle, idle.Round(time.Second))}
+			}
+			logger.WithFields(fields).Warn("market data stream idle during market hours")
+			warned = true
+		}
+	}
+}
+
+// idleCheckInterval checks four times per timeout, so an idle stream is noticed
+// at most a quarter of the timeout late, but no more than every second.
+func idleCheckInterval(timeout time.Duration) time.Duration {
+	return max(timeout/4, time.Second)
+}
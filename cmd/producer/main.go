@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -20,8 +21,10 @@ import (
 	pb "github.com/russianinvestments/invest-api-go-sdk/proto"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
 
 	domain "main/internal/domain/entity/marketdata"
+	"main/internal/infrastructure/broker"
 )
 
 const (
@@ -32,6 +35,7 @@ const (
 	defaultTradesExchange     = "marketdata.trades"
 	defaultCandlesExchange    = "marketdata.candles"
 	defaultOrderBooksExchange = "marketdata.orderbooks"
+	defaultMarketTimezone     = "Europe/Moscow"
 )
 
 type producerConfig struct {
@@ -40,12 +44,42 @@ type producerConfig struct {
 	AppName            string
 	SkipTLSVerify      bool
 	RabbitURL          string
+	RabbitHeartbeat    time.Duration
+	RabbitDialTimeout  time.Duration
+	PublishChannels    int
+	ConfirmTimeout     time.Duration
+	InstrumentsFile    string
 	Exchanges          exchangeSet
+	ExchangeType       string
 	Instruments        []string
-	CandleInterval     pb.SubscriptionInterval
+	CandleIntervals    []pb.SubscriptionInterval
 	CandleWaitingClose bool
 	OrderBookDepth     int32
+	OrderBookSequence  bool
 	TradeSource        pb.TradeSourceType
+	ReconnectBase      time.Duration
+	ReconnectMax       time.Duration
+	// ShutdownDrain bounds how long the pumps may keep publishing messages the
+	// stream delivered before shutdown.
+	ShutdownDrain time.Duration
+	// IdleTimeout is the silence within MarketHours after which the watchdog
+	// warns, or reconnects with IdleReconnect; zero disables the watchdog.
+	IdleTimeout   time.Duration
+	IdleReconnect bool
+	MarketHours   marketHours
+	// PublishTimeout bounds a whole publish, including nack retries and the
+	// confirm wait, unless PublishPolicy is policyBlock.
+	PublishTimeout time.Duration
+	PublishPolicy  publishPolicy
+	// MetricsAddr is the listen address of /metrics; empty serves none.
+	MetricsAddr string
+	// BatchSize above 1 coalesces up to that many entities per message,
+	// sent at the latest every BatchInterval.
+	BatchSize     int
+	BatchInterval time.Duration
+	// IncludeRawProto attaches each entity's invest API message to its
+	// metadata; see attachRawProto.
+	IncludeRawProto bool
 }
 
 type exchangeSet struct {
@@ -66,13 +100,18 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	rabbitConn, err := amqp.Dial(cfg.RabbitURL)
+	rabbitConn, err := amqp.DialConfig(cfg.RabbitURL, rabbitDialConfig(cfg))
 	if err != nil {
 		logger.Fatalf("connect rabbitmq: %v", err)
 	}
 	defer rabbitConn.Close()
 
-	pub, err := newPublisher(rabbitConn, cfg.Exchanges, logger)
+	metrics := newProducerMetrics()
+	if cfg.MetricsAddr != "" {
+		go serveMetrics(ctx, cfg.MetricsAddr, metrics, logger)
+	}
+
+	pub, err := newPublisher(rabbitConn, cfg, metrics, logger)
 	if err != nil {
 		logger.Fatalf("init publisher: %v", err)
 	}
@@ -95,54 +134,194 @@ func main() {
 		}
 	}()
 
+	var sequencer *orderBookSequencer
+	if cfg.OrderBookSequence {
+		sequencer = newOrderBookSequencer(time.Now())
+	}
+
+	logger.WithFields(logrus.Fields{
+		"instruments":    len(cfg.Instruments),
+		"trades_ex":      cfg.Exchanges.Trades,
+		"candles_ex":     cfg.Exchanges.Candles,
+		"orderbook_ex":   cfg.Exchanges.OrderBooks,
+		"exchange":       cfg.ExchangeType,
+		"ob_depth":       cfg.OrderBookDepth,
+		"trade_source":   cfg.TradeSource.String(),
+		"publish_policy": cfg.PublishPolicy,
+	}).Info("producer started")
+
+	instruments := newInstrumentSet(cfg.Instruments)
+	go watchInstruments(ctx, cfg.InstrumentsFile, instruments, logger)
+
 	mdClient := client.NewMarketDataStreamClient()
-	stream, err := mdClient.MarketDataStream()
-	if err != nil {
-		logger.Fatalf("create market data stream: %v", err)
+	if err := runStream(ctx, mdClient, cfg, instruments, pub, sequencer, logger); err != nil {
+		logger.Fatalf("producer stopped with error: %v", err)
 	}
-	defer stream.Stop()
 
-	candleChan, err := stream.SubscribeCandle(cfg.Instruments, cfg.CandleInterval, cfg.CandleWaitingClose, nil)
-	if err != nil {
-		logger.Fatalf("subscribe candles: %v", err)
+	logger.Info("producer stopped")
+}
+
+// errStreamClosed is reported when Listen returns without an error while the
+// producer is still running.
+var errStreamClosed = errors.New("market data stream closed")
+
+// errNoInstrumentsAccepted stops the producer when the stream rejected every
+// instrument, since there is nothing left to publish.
+var errNoInstrumentsAccepted = errors.New("no instrument subscription was accepted")
+
+// errDrainTimeout cancels the pumps when they have not drained the stream
+// within the shutdown drain timeout.
+var errDrainTimeout = errors.New("shutdown drain timed out")
+
+// streamError marks failures of the upstream stream itself, which are retried;
+// every other error (e.g. a failed publish) stops the producer.
+type streamError struct {
+	err error
+}
+
+func (e *streamError) Error() string { return e.err.Error() }
+func (e *streamError) Unwrap() error { return e.err }
+
+// runStream keeps the market data stream alive, reconnecting with exponential
+// backoff and re-subscribing to the current instruments each time. The
+// publisher and order book sequencer are shared across reconnects. It returns
+// nil once ctx is cancelled and the last stream has drained.
+func runStream(ctx context.Context, mdClient *investgo.MarketDataStreamClient, cfg *producerConfig, instruments *instrumentSet, pub *publisher, sequencer *orderBookSequencer, logger *logrus.Logger) error {
+	backoff := cfg.ReconnectBase
+	for {
+		started := time.Now()
+		err := streamOnce(ctx, mdClient, cfg, instruments, pub, sequencer, logger)
+		if ctx.Err() != nil {
+			if err != nil {
+				logger.WithError(err).Warn("market data stream did not drain cleanly")
+			}
+			return nil
+		}
+		var streamErr *streamError
+		if !errors.As(err, &streamErr) {
+			return err
+		}
+
+		// A session that stayed up longer than the maximum delay counts as
+		// recovered, so the next failure starts from the base delay again.
+		if time.Since(started) > cfg.ReconnectMax {
+			backoff = cfg.ReconnectBase
+		}
+		logger.WithError(err).WithField("retry_in", backoff.String()).Warn("market data stream lost, reconnecting")
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, cfg.ReconnectMax)
 	}
+}
 
-	tradeChan, err := stream.SubscribeTrade(cfg.Instruments, cfg.TradeSource, false)
+// streamOnce opens a stream, subscribes and pumps messages until the stream or
+// a pump fails, or ctx is cancelled. Instruments whose subscription fails are
+// left out of the stream; it fails only when none was accepted. Instrument reloads are applied to the
+// stream while it runs.
+//
+// Shutdown runs in a fixed order: the stream is stopped first, which makes the
+// SDK close the subscription channels; the pumps then publish whatever the
+// channels still buffer and return when they are closed. Only if that takes
+// longer than the drain timeout are in-flight publishes abandoned. The caller
+// closes the publisher after streamOnce returns, so it never closes under a
+// pump.
+func streamOnce(ctx context.Context, mdClient *investgo.MarketDataStreamClient, cfg *producerConfig, instruments *instrumentSet, pub *publisher, sequencer *orderBookSequencer, logger *logrus.Logger) error {
+	stream, err := mdClient.MarketDataStream()
 	if err != nil {
-		logger.Fatalf("subscribe trades: %v", err)
+		return &streamError{fmt.Errorf("create market data stream: %w", err)}
 	}
+	defer stream.Stop()
 
-	orderBookChan, err := stream.SubscribeOrderBook(cfg.Instruments, cfg.OrderBookDepth)
-	if err != nil {
-		logger.Fatalf("subscribe order books: %v", err)
+	subscribed, rejected, chans := subscribeEach(stream, cfg, instruments.Get(), logger)
+	if len(subscribed) == 0 {
+		return fmt.Errorf("%w: %d rejected", errNoInstrumentsAccepted, len(rejected))
 	}
+	logger.WithFields(logrus.Fields{
+		"accepted": len(subscribed),
+		"rejected": len(rejected),
+	}).Info("stream subscribed")
+
+	// The pumps must outlive ctx to drain the stream, so their context is only
+	// cancelled by a failure in the group or by the drain timeout.
+	pumpCtx, cancelPumps := context.WithCancelCause(context.WithoutCancel(ctx))
+	defer cancelPumps(nil)
+	stopOnShutdown := context.AfterFunc(ctx, func() {
+		stream.Stop()
+		time.AfterFunc(cfg.ShutdownDrain, func() { cancelPumps(errDrainTimeout) })
+	})
+	defer stopOnShutdown()
 
-	g, gctx := errgroup.WithContext(ctx)
+	g, gctx := errgroup.WithContext(pumpCtx)
+	// Listen does not watch our context; stopping the stream unblocks it when
+	// a pump fails.
+	stopOnFailure := context.AfterFunc(gctx, stream.Stop)
+	defer stopOnFailure()
 	g.Go(func() error {
-		return stream.Listen()
+		err := stream.Listen()
+		if ctx.Err() != nil {
+			// Stopped for shutdown; the pumps finish on the closed channels.
+			return nil
+		}
+		if err != nil {
+			return &streamError{fmt.Errorf("listen: %w", err)}
+		}
+		return &streamError{errStreamClosed}
 	})
-	g.Go(func() error {
-		return pumpCandles(gctx, candleChan, pub, logger)
+	activity := newStreamActivity(time.Now())
+	var pumps sync.WaitGroup
+	goPump := func(pump func() error) {
+		pumps.Add(1)
+		g.Go(func() error {
+			defer pumps.Done()
+			return pump()
+		})
+	}
+	// The SDK may hand every candle subscription the same channel; a pump per
+	// subscription still consumes each candle exactly once.
+	for _, candleChan := range chans.candles {
+		goPump(func() error {
+			return pumpCandles(gctx, candleChan, pub, cfg.IncludeRawProto, activity, logger)
+		})
+	}
+	goPump(func() error {
+		return pumpTrades(gctx, chans.trades, pub, cfg.IncludeRawProto, activity, logger)
 	})
-	g.Go(func() error {
-		return pumpTrades(gctx, tradeChan, pub, logger)
+	goPump(func() error {
+		return pumpOrderBooks(gctx, chans.orderBooks, pub, sequencer, cfg.IncludeRawProto, activity, logger)
 	})
+	if pub.batches != nil {
+		// Batches still pending once the pumps have drained the stream are
+		// published before streamOnce returns and the publisher closes.
+		pumpsDone := make(chan struct{})
+		go func() {
+			pumps.Wait()
+			close(pumpsDone)
+		}()
+		g.Go(func() error {
+			return pub.batches.run(gctx, pumpsDone)
+		})
+	}
+	// Reloads and the idle watchdog stop with the stream, on shutdown as well
+	// as on failure.
+	reloadCtx, cancelReload := context.WithCancel(gctx)
+	defer cancelReload()
+	stopReloadOnShutdown := context.AfterFunc(ctx, cancelReload)
+	defer stopReloadOnShutdown()
 	g.Go(func() error {
-		return pumpOrderBooks(gctx, orderBookChan, pub, logger)
+		return applyInstrumentChanges(reloadCtx, stream, cfg, instruments, subscribed, logger)
 	})
-
-	logger.WithFields(logrus.Fields{
-		"instruments":  len(cfg.Instruments),
-		"trades_ex":    cfg.Exchanges.Trades,
-		"candles_ex":   cfg.Exchanges.Candles,
-		"orderbook_ex": cfg.Exchanges.OrderBooks,
-	}).Info("producer started")
-
-	if err := g.Wait(); err != nil && !errors.Is(err, context.Canceled) {
-		logger.Fatalf("producer stopped with error: %v", err)
+	if cfg.IdleTimeout > 0 {
+		g.Go(func() error {
+			return watchIdle(reloadCtx, cfg, activity, logger)
+		})
 	}
-
-	logger.Info("producer stopped")
+	return g.Wait()
 }
 
 func loadConfig() (*producerConfig, error) {
@@ -169,13 +348,84 @@ func loadConfig() (*producerConfig, error) {
 		Candles:    envOrDefault("RABBITMQ_CANDLES_EXCHANGE", defaultCandlesExchange),
 		OrderBooks: envOrDefault("RABBITMQ_ORDERBOOKS_EXCHANGE", defaultOrderBooksExchange),
 	}
+	exchangeType := envOrDefault("RABBITMQ_EXCHANGE_TYPE", broker.ExchangeFanout)
+	if err := broker.ValidateExchangeType(exchangeType); err != nil {
+		return nil, fmt.Errorf("RABBITMQ_EXCHANGE_TYPE: %w", err)
+	}
+
+	orderBookDepth, err := parseOrderBookDepth(envOrDefault("ORDERBOOK_DEPTH", "10"))
+	if err != nil {
+		return nil, fmt.Errorf("ORDERBOOK_DEPTH: %w", err)
+	}
+	tradeSource, err := parseTradeSource(envOrDefault("TRADE_SOURCE", "exchange"))
+	if err != nil {
+		return nil, fmt.Errorf("TRADE_SOURCE: %w", err)
+	}
+
+	heartbeat := intEnv("RABBITMQ_HEARTBEAT_SECONDS", 10)
+	if heartbeat < 0 {
+		return nil, errors.New("RABBITMQ_HEARTBEAT_SECONDS must not be negative")
+	}
+	dialTimeout := intEnv("RABBITMQ_DIAL_TIMEOUT_SECONDS", 30)
+	if dialTimeout < 0 {
+		return nil, errors.New("RABBITMQ_DIAL_TIMEOUT_SECONDS must not be negative")
+	}
 
-	orderBookDepth := intEnv("ORDERBOOK_DEPTH", 10)
-	if orderBookDepth <= 0 {
-		orderBookDepth = 10
+	reconnectBase := intEnv("STREAM_RECONNECT_BASE_SECONDS", 1)
+	if reconnectBase <= 0 {
+		return nil, errors.New("STREAM_RECONNECT_BASE_SECONDS must be positive")
+	}
+	reconnectMax := intEnv("STREAM_RECONNECT_MAX_SECONDS", 60)
+	if reconnectMax < reconnectBase {
+		return nil, errors.New("STREAM_RECONNECT_MAX_SECONDS must not be less than STREAM_RECONNECT_BASE_SECONDS")
 	}
 
+	candleIntervals, err := parseCandleIntervals(envOrDefault("CANDLE_INTERVAL", "1m"))
+	if err != nil {
+		return nil, fmt.Errorf("CANDLE_INTERVAL: %w", err)
+	}
+	publishChannels := intEnv("RABBITMQ_PUBLISH_CHANNELS", 4)
+	if publishChannels <= 0 {
+		return nil, errors.New("RABBITMQ_PUBLISH_CHANNELS must be positive")
+	}
+	confirmTimeout := intEnv("RABBITMQ_CONFIRM_TIMEOUT_SECONDS", 5)
+	if confirmTimeout <= 0 {
+		return nil, errors.New("RABBITMQ_CONFIRM_TIMEOUT_SECONDS must be positive")
+	}
+	publishTimeout := intEnv("PUBLISH_TIMEOUT_SECONDS", 10)
+	if publishTimeout <= 0 {
+		return nil, errors.New("PUBLISH_TIMEOUT_SECONDS must be positive")
+	}
+	policy, err := parsePublishPolicy(envOrDefault("PUBLISH_TIMEOUT_POLICY", string(policyBlock)))
+	if err != nil {
+		return nil, fmt.Errorf("PUBLISH_TIMEOUT_POLICY: %w", err)
+	}
+	batchSize := intEnv("PUBLISH_BATCH_SIZE", 1)
+	if batchSize <= 0 {
+		return nil, errors.New("PUBLISH_BATCH_SIZE must be positive")
+	}
+	batchInterval := intEnv("PUBLISH_BATCH_INTERVAL_MS", 100)
+	if batchInterval <= 0 {
+		return nil, errors.New("PUBLISH_BATCH_INTERVAL_MS must be positive")
+	}
+	shutdownDrain := intEnv("SHUTDOWN_DRAIN_SECONDS", 10)
+	if shutdownDrain < 0 {
+		return nil, errors.New("SHUTDOWN_DRAIN_SECONDS must not be negative")
+	}
+	idleTimeout := intEnv("STREAM_IDLE_TIMEOUT_SECONDS", 0)
+	if idleTimeout < 0 {
+		return nil, errors.New("STREAM_IDLE_TIMEOUT_SECONDS must not be negative")
+	}
+	marketLoc, err := time.LoadLocation(envOrDefault("MARKET_TIMEZONE", defaultMarketTimezone))
+	if err != nil {
+		return nil, fmt.Errorf("MARKET_TIMEZONE: %w", err)
+	}
+	idleHours, err := parseMarketHours(envOrDefault("STREAM_IDLE_HOURS", ""), marketLoc)
+	if err != nil {
+		return nil, fmt.Errorf("STREAM_IDLE_HOURS: %w", err)
+	}
 	waitingClose := boolEnv("CANDLE_WAITING_CLOSE", true)
+	orderBookSequence := boolEnv("ORDERBOOK_SEQUENCE", true)
 	skipVerify := boolEnv("INVEST_INSECURE_SKIP_VERIFY", true)
 
 	return &producerConfig{
@@ -184,15 +434,47 @@ func loadConfig() (*producerConfig, error) {
 		AppName:            appName,
 		SkipTLSVerify:      skipVerify,
 		RabbitURL:          rabbitURL,
+		RabbitHeartbeat:    time.Duration(heartbeat) * time.Second,
+		RabbitDialTimeout:  time.Duration(dialTimeout) * time.Second,
+		PublishChannels:    publishChannels,
+		ConfirmTimeout:     time.Duration(confirmTimeout) * time.Second,
+		PublishTimeout:     time.Duration(publishTimeout) * time.Second,
+		PublishPolicy:      policy,
+		MetricsAddr:        envOrDefault("METRICS_ADDR", ""),
+		BatchSize:          batchSize,
+		BatchInterval:      time.Duration(batchInterval) * time.Millisecond,
+		InstrumentsFile:    instrumentsFile,
 		Exchanges:          exchanges,
+		ExchangeType:       exchangeType,
 		Instruments:        instruments,
-		CandleInterval:     pb.SubscriptionInterval_SUBSCRIPTION_INTERVAL_ONE_MINUTE,
+		CandleIntervals:    candleIntervals,
 		CandleWaitingClose: waitingClose,
-		OrderBookDepth:     int32(orderBookDepth),
-		TradeSource:        pb.TradeSourceType_TRADE_SOURCE_EXCHANGE,
+		OrderBookDepth:     orderBookDepth,
+		OrderBookSequence:  orderBookSequence,
+		TradeSource:        tradeSource,
+		ReconnectBase:      time.Duration(reconnectBase) * time.Second,
+		ReconnectMax:       time.Duration(reconnectMax) * time.Second,
+		ShutdownDrain:      time.Duration(shutdownDrain) * time.Second,
+		IdleTimeout:        time.Duration(idleTimeout) * time.Second,
+		IdleReconnect:      boolEnv("STREAM_IDLE_RECONNECT", false),
+		MarketHours:        idleHours,
+		IncludeRawProto:    boolEnv("INCLUDE_RAW_PROTO", false),
 	}, nil
 }
 
+// rabbitDialConfig applies the configured heartbeat and dial timeout; zero values
+// keep the amqp091 defaults.
+func rabbitDialConfig(cfg *producerConfig) amqp.Config {
+	amqpCfg := amqp.Config{
+		Heartbeat: cfg.RabbitHeartbeat,
+		Locale:    "en_US",
+	}
+	if cfg.RabbitDialTimeout > 0 {
+		amqpCfg.Dial = amqp.DefaultDial(cfg.RabbitDialTimeout)
+	}
+	return amqpCfg
+}
+
 func envOrDefault(key, fallback string) string {
 	value := strings.TrimSpace(os.Getenv(key))
 	if value == "" {
@@ -246,15 +528,43 @@ func readInstruments(path string) ([]string, error) {
 	return instruments, nil
 }
 
+// publisher publishes over a pool of AMQP channels so candle, trade and order
+// book pumps do not serialize on one channel. Each publish leases a channel
+// for its duration; a channel found closed is replaced by a fresh one.
 type publisher struct {
-	channel   *amqp.Channel
+	conn      *amqp.Connection
 	exchanges exchangeSet
 	logger    *logrus.Logger
-	mu        sync.Mutex
+	// slots holds the idle channels. A nil slot stands for a channel that
+	// died and could not be reopened yet; it is reopened on the next lease.
+	slots chan *amqp.Channel
+	size  int
+	// confirmTimeout bounds the wait for the broker to ack a publish.
+	confirmTimeout time.Duration
+	// timeout and policy govern publishes that take too long; see send.
+	timeout time.Duration
+	policy  publishPolicy
+	metrics *producerMetrics
+	// batches is nil unless entities are published in batches.
+	batches *messageBatcher
 }
 
-func newPublisher(conn *amqp.Connection, exchanges exchangeSet, logger *logrus.Logger) (*publisher, error) {
-	ch, err := conn.Channel()
+var (
+	// errPublishNacked means the broker refused the message; it was not
+	// enqueued, so publishing it again cannot create a duplicate.
+	errPublishNacked = errors.New("publish nacked by broker")
+	// errConfirmTimeout means the broker did not answer in time; the message
+	// may or may not have been enqueued.
+	errConfirmTimeout = errors.New("publish confirm timed out")
+)
+
+func newPublisher(conn *amqp.Connection, cfg *producerConfig, metrics *producerMetrics, logger *logrus.Logger) (*publisher, error) {
+	exchanges := cfg.Exchanges
+	poolSize := cfg.PublishChannels
+	if poolSize <= 0 {
+		poolSize = 1
+	}
+	ch, err := openConfirmChannel(conn)
 	if err != nil {
 		return nil, fmt.Errorf("create channel: %w", err)
 	}
@@ -268,124 +578,343 @@ func newPublisher(conn *amqp.Connection, exchanges exchangeSet, logger *logrus.L
 		if _, ok := declared[name]; ok {
 			continue
 		}
-		if err := ch.ExchangeDeclare(name, "fanout", true, false, false, false, nil); err != nil {
+		if err := ch.ExchangeDeclare(name, cfg.ExchangeType, true, false, false, false, nil); err != nil {
 			ch.Close()
 			return nil, fmt.Errorf("declare exchange %s: %w", name, err)
 		}
 		declared[name] = struct{}{}
 	}
 
-	return &publisher{
-		channel:   ch,
-		exchanges: exchanges,
-		logger:    logger,
-	}, nil
+	// Open every channel before building the pool: Close waits for size
+	// channels, so a partly filled pool could not be closed.
+	channels := []*amqp.Channel{ch}
+	for len(channels) < poolSize {
+		ch, err := openConfirmChannel(conn)
+		if err != nil {
+			for _, opened := range channels {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("create channel: %w", err)
+		}
+		channels = append(channels, ch)
+	}
+
+	p := &publisher{
+		conn:           conn,
+		exchanges:      exchanges,
+		logger:         logger,
+		slots:          make(chan *amqp.Channel, poolSize),
+		size:           poolSize,
+		confirmTimeout: cfg.ConfirmTimeout,
+		timeout:        cfg.PublishTimeout,
+		policy:         cfg.PublishPolicy,
+		metrics:        metrics,
+	}
+	if cfg.BatchSize > 1 {
+		p.batches = newMessageBatcher(cfg.BatchSize, cfg.BatchInterval, p.sendBatch)
+	}
+	for _, ch := range channels {
+		p.slots <- ch
+	}
+	return p, nil
 }
 
+// Close waits for in-flight publishes to return their channels and closes all
+// of them.
 func (p *publisher) Close() {
 	if p == nil {
 		return
 	}
-	if err := p.channel.Close(); err != nil {
-		p.logger.Errorf("close rabbitmq channel: %v", err)
+	for i := 0; i < p.size; i++ {
+		ch := <-p.slots
+		if ch == nil || ch.IsClosed() {
+			continue
+		}
+		if err := ch.Close(); err != nil {
+			p.logger.Errorf("close rabbitmq channel: %v", err)
+		}
 	}
 }
 
+// lease takes an idle channel from the pool, reopening it if it has died.
+// On success the caller must hand the channel back with release.
+func (p *publisher) lease(ctx context.Context) (*amqp.Channel, error) {
+	var ch *amqp.Channel
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case ch = <-p.slots:
+	}
+	if ch != nil && !ch.IsClosed() {
+		return ch, nil
+	}
+	ch, err := p.reopen()
+	if err != nil {
+		p.release(nil)
+		return nil, err
+	}
+	return ch, nil
+}
+
+func (p *publisher) release(ch *amqp.Channel) {
+	p.slots <- ch
+}
+
+func (p *publisher) reopen() (*amqp.Channel, error) {
+	ch, err := openConfirmChannel(p.conn)
+	if err != nil {
+		return nil, fmt.Errorf("reopen channel: %w", err)
+	}
+	p.logger.Warn("replaced closed rabbitmq channel")
+	return ch, nil
+}
+
+// openConfirmChannel opens a channel in confirm mode, so every publish on it is
+// acked or nacked by the broker.
+func openConfirmChannel(conn *amqp.Connection) (*amqp.Channel, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("enable publisher confirms: %w", err)
+	}
+	return ch, nil
+}
+
+// marketDataPublisher is what the pumps publish through; *publisher
+// implements it.
+type marketDataPublisher interface {
+	sendCandle(ctx context.Context, candle *domain.Candle) error
+	sendTrade(ctx context.Context, trade *domain.Trade) error
+	sendOrderBook(ctx context.Context, snapshot *domain.OrderBookSnapshot) error
+}
+
+// PublishCandle sends a candle with its interval in the interval_seconds header
+// as well as in the body, so consumers can filter intervals without decoding.
 func (p *publisher) PublishCandle(ctx context.Context, candle *domain.Candle) error {
-	return p.publish(ctx, p.exchanges.Candles, candle)
+	key := broker.RoutingKey(broker.CandleRoutingPrefix, candle.InstrumentUID)
+	return p.publish(ctx, p.exchanges.Candles, key, candle, amqp.Table{"interval_seconds": candle.IntervalSeconds})
 }
 
 func (p *publisher) PublishTrade(ctx context.Context, trade *domain.Trade) error {
-	return p.publish(ctx, p.exchanges.Trades, trade)
+	key := broker.RoutingKey(broker.TradeRoutingPrefix, trade.InstrumentUID)
+	return p.publish(ctx, p.exchanges.Trades, key, trade, nil)
 }
 
 func (p *publisher) PublishOrderBook(ctx context.Context, snapshot *domain.OrderBookSnapshot) error {
-	return p.publish(ctx, p.exchanges.OrderBooks, snapshot)
+	key := broker.RoutingKey(broker.OrderBookRoutingPrefix, snapshot.InstrumentUID)
+	return p.publish(ctx, p.exchanges.OrderBooks, key, snapshot, nil)
 }
 
-func (p *publisher) publish(ctx context.Context, exchange string, payload any) error {
+// PublishBatch sends the entities of one batch as a single broker.BaseMessage.
+// A candle batch holds one interval, so it carries the interval_seconds header
+// like a single candle.
+func (p *publisher) PublishBatch(ctx context.Context, key batchKey, msg *broker.BaseMessage) error {
+	switch key.kind {
+	case kindCandles:
+		routingKey := broker.RoutingKey(broker.CandleRoutingPrefix, key.instrumentUID)
+		return p.publish(ctx, p.exchanges.Candles, routingKey, msg, amqp.Table{"interval_seconds": key.intervalSeconds})
+	case kindTrades:
+		return p.publish(ctx, p.exchanges.Trades, broker.RoutingKey(broker.TradeRoutingPrefix, key.instrumentUID), msg, nil)
+	case kindOrderBooks:
+		return p.publish(ctx, p.exchanges.OrderBooks, broker.RoutingKey(broker.OrderBookRoutingPrefix, key.instrumentUID), msg, nil)
+	default:
+		return fmt.Errorf("unsupported stream %s", key.kind)
+	}
+}
+
+// publish sends payload with routingKey, which topic exchanges route on and
+// fanout exchanges ignore.
+func (p *publisher) publish(ctx context.Context, exchange, routingKey string, payload any, headers amqp.Table) error {
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("marshal payload: %w", err)
 	}
 
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
-	return p.channel.PublishWithContext(ctx, exchange, "", false, false, amqp.Publishing{
+	msg := amqp.Publishing{
+		Headers:      headers,
 		ContentType:  "application/json",
 		DeliveryMode: amqp.Persistent,
 		Timestamp:    time.Now().UTC(),
 		Body:         body,
-	})
+	}
+
+	ch, err := p.lease(ctx)
+	if err != nil {
+		return err
+	}
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, exchange, routingKey, false, false, msg)
+	if err != nil && ch.IsClosed() {
+		// The channel died under us; the message never left, so retry once
+		// on a fresh channel.
+		ch, err = p.reopen()
+		if err == nil {
+			confirm, err = ch.PublishWithDeferredConfirmWithContext(ctx, exchange, routingKey, false, false, msg)
+		}
+	}
+	// The channel can carry more publishes while this one awaits its confirm.
+	p.release(ch)
+	if err != nil {
+		return err
+	}
+	return p.waitConfirm(ctx, confirm)
 }
 
-func pumpCandles(ctx context.Context, stream <-chan *pb.Candle, pub *publisher, logger *logrus.Logger) error {
-	for {
+// confirmation is the broker's answer to one publish; *amqp.DeferredConfirmation
+// implements it.
+type confirmation interface {
+	WaitContext(ctx context.Context) (bool, error)
+}
+
+func (p *publisher) waitConfirm(ctx context.Context, confirm confirmation) error {
+	waitCtx, cancel := context.WithTimeout(ctx, p.confirmTimeout)
+	defer cancel()
+	acked, err := confirm.WaitContext(waitCtx)
+	if err != nil {
+		if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+			return errConfirmTimeout
+		}
+		return err
+	}
+	if !acked {
+		return errPublishNacked
+	}
+	return nil
+}
+
+// maxNackedAttempts bounds how often the pumps send a message the broker keeps nacking.
+const maxNackedAttempts = 3
+
+// publishRetryingNacks runs publish again when the broker nacked the message.
+// Other failures, including confirm timeouts, are returned at once because a
+// retry could duplicate a message the broker already enqueued.
+func publishRetryingNacks(ctx context.Context, logger *logrus.Logger, publish func() error) error {
+	var err error
+	for attempt := 1; attempt <= maxNackedAttempts; attempt++ {
+		err = publish()
+		if !errors.Is(err, errPublishNacked) {
+			return err
+		}
+		logger.WithField("attempt", attempt).Warn("publish nacked by broker")
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * 100 * time.Millisecond):
+		}
+	}
+	return err
+}
+
+func pumpCandles(ctx context.Context, stream <-chan *pb.Candle, pub marketDataPublisher, rawProto bool, activity *streamActivity, logger *logrus.Logger) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
 		case candle, ok := <-stream:
 			if !ok {
 				return nil
 			}
+			activity.touch(kindCandles)
 			entity, err := convertCandle(candle)
+			if err == nil && rawProto {
+				entity.Metadata, err = attachRawProto(entity.Metadata, candle)
+			}
 			if err != nil {
 				logger.WithError(err).Warn("skip candle")
 				continue
 			}
-			if candle == nil {
-				continue
-			}
-			if err := pub.PublishCandle(ctx, entity); err != nil {
+			if err := pub.sendCandle(ctx, entity); err != nil {
 				return fmt.Errorf("publish candle: %w", err)
 			}
 		}
 	}
 }
 
-func pumpTrades(ctx context.Context, stream <-chan *pb.Trade, pub *publisher, logger *logrus.Logger) error {
+func pumpTrades(ctx context.Context, stream <-chan *pb.Trade, pub marketDataPublisher, rawProto bool, activity *streamActivity, logger *logrus.Logger) error {
 	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return context.Cause(ctx)
 		case trade, ok := <-stream:
 			if !ok {
 				return nil
 			}
+			activity.touch(kindTrades)
 			entity, err := convertTrade(trade)
+			if err == nil && rawProto {
+				entity.Metadata, err = attachRawProto(entity.Metadata, trade)
+			}
 			if err != nil {
 				logger.WithError(err).Warn("skip trade")
 				continue
 			}
-			if err := pub.PublishTrade(ctx, entity); err != nil {
+			if err := pub.sendTrade(ctx, entity); err != nil {
 				return fmt.Errorf("publish trade: %w", err)
 			}
 		}
 	}
 }
 
-func pumpOrderBooks(ctx context.Context, stream <-chan *pb.OrderBook, pub *publisher, logger *logrus.Logger) error {
+func pumpOrderBooks(ctx context.Context, stream <-chan *pb.OrderBook, pub marketDataPublisher, sequencer *orderBookSequencer, rawProto bool, activity *streamActivity, logger *logrus.Logger) error {
 	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return context.Cause(ctx)
 		case snapshot, ok := <-stream:
 			if !ok {
 				return nil
 			}
+			activity.touch(kindOrderBooks)
 			entity, err := convertOrderBook(snapshot)
+			if err == nil && rawProto {
+				entity.Metadata, err = attachRawProto(entity.Metadata, snapshot)
+			}
 			if err != nil {
 				logger.WithError(err).Warn("skip order book")
 				continue
 			}
-			if err := pub.PublishOrderBook(ctx, entity); err != nil {
+			if sequencer != nil {
+				seq := sequencer.Next(entity.InstrumentUID)
+				entity.Sequence = &seq
+			}
+			if err := pub.sendOrderBook(ctx, entity); err != nil {
 				return fmt.Errorf("publish order book: %w", err)
 			}
 		}
 	}
 }
 
+// orderBookSequencer assigns a monotonic per-instrument ingestion sequence to order
+// book snapshots. The invest API stream carries no update number, so the sequence
+// counts snapshots as the producer receives them. Counters start from the producer
+// start time in microseconds, which keeps them increasing across restarts; a restart
+// therefore shows up as a gap.
+type orderBookSequencer struct {
+	base int64
+	last map[uuid.UUID]int64
+}
+
+func newOrderBookSequencer(start time.Time) *orderBookSequencer {
+	return &orderBookSequencer{
+		base: start.UnixMicro(),
+		last: make(map[uuid.UUID]int64),
+	}
+}
+
+// Next returns the next sequence number for the instrument. It is not safe for
+// concurrent use; order books are pumped from a single goroutine.
+func (s *orderBookSequencer) Next(instrumentUID uuid.UUID) int64 {
+	seq, ok := s.last[instrumentUID]
+	if !ok {
+		seq = s.base
+	}
+	seq++
+	s.last[instrumentUID] = seq
+	return seq
+}
+
 func convertCandle(msg *pb.Candle) (*domain.Candle, error) {
 	if msg == nil {
 		return nil, errors.New("candle payload is nil")
@@ -400,9 +929,6 @@ func convertCandle(msg *pb.Candle) (*domain.Candle, error) {
 	if err != nil {
 		return nil, err
 	}
-	if intervalSeconds == 0 {
-		return nil, nil
-	}
 
 	periodStart := time.Time{}
 	if ts := msg.GetTime(); ts != nil {
@@ -477,6 +1003,7 @@ func convertTrade(msg *pb.Trade) (*domain.Trade, error) {
 		Price:         quotationToFloat(msg.GetPrice()),
 		QuantityLots:  msg.GetQuantity(),
 		TradedAt:      tradedAt,
+		Venue:         strings.TrimSpace(msg.GetClassCode()),
 		Metadata:      metadata,
 	}, nil
 }
@@ -533,6 +1060,24 @@ func convertOrderBook(msg *pb.OrderBook) (*domain.OrderBookSnapshot, error) {
 	}, nil
 }
 
+// rawProtoKey is the metadata key holding the invest API message an entity
+// was converted from.
+const rawProtoKey = "raw_proto"
+
+// attachRawProto stores msg in metadata as base64 of its protobuf wire form,
+// so the entity can be derived again should the conversion change.
+func attachRawProto(metadata map[string]any, msg proto.Message) (map[string]any, error) {
+	raw, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("marshal raw proto: %w", err)
+	}
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	metadata[rawProtoKey] = base64.StdEncoding.EncodeToString(raw)
+	return metadata, nil
+}
+
 func quotationToFloat(q *pb.Quotation) float64 {
 	if q == nil {
 		return 0
@@ -552,22 +1097,122 @@ func parseInstrumentUID(raw string) (uuid.UUID, error) {
 	return id, nil
 }
 
+// mapTradeSide maps the stream direction to a side. Venues report auction prints
+// without a direction; those trades are kept with an UNKNOWN side.
 func mapTradeSide(direction pb.TradeDirection) (domain.TradeSide, error) {
 	switch direction {
 	case pb.TradeDirection_TRADE_DIRECTION_BUY:
 		return domain.TradeSideBuy, nil
 	case pb.TradeDirection_TRADE_DIRECTION_SELL:
 		return domain.TradeSideSell, nil
+	case pb.TradeDirection_TRADE_DIRECTION_UNSPECIFIED:
+		return domain.TradeSideUnknown, nil
 	default:
 		return "", fmt.Errorf("unsupported trade direction: %s", direction.String())
 	}
 }
 
+// candleIntervals lists the supported subscription intervals with their length and
+// the short name accepted by CANDLE_INTERVAL. A month is stored as 30 days.
+var candleIntervals = []struct {
+	interval pb.SubscriptionInterval
+	seconds  int64
+	name     string
+}{
+	{pb.SubscriptionInterval_SUBSCRIPTION_INTERVAL_ONE_MINUTE, 60, "1m"},
+	{pb.SubscriptionInterval_SUBSCRIPTION_INTERVAL_2_MIN, 2 * 60, "2m"},
+	{pb.SubscriptionInterval_SUBSCRIPTION_INTERVAL_3_MIN, 3 * 60, "3m"},
+	{pb.SubscriptionInterval_SUBSCRIPTION_INTERVAL_FIVE_MINUTES, 5 * 60, "5m"},
+	{pb.SubscriptionInterval_SUBSCRIPTION_INTERVAL_10_MIN, 10 * 60, "10m"},
+	{pb.SubscriptionInterval_SUBSCRIPTION_INTERVAL_FIFTEEN_MINUTES, 15 * 60, "15m"},
+	{pb.SubscriptionInterval_SUBSCRIPTION_INTERVAL_30_MIN, 30 * 60, "30m"},
+	{pb.SubscriptionInterval_SUBSCRIPTION_INTERVAL_ONE_HOUR, 60 * 60, "1h"},
+	{pb.SubscriptionInterval_SUBSCRIPTION_INTERVAL_2_HOUR, 2 * 60 * 60, "2h"},
+	{pb.SubscriptionInterval_SUBSCRIPTION_INTERVAL_4_HOUR, 4 * 60 * 60, "4h"},
+	{pb.SubscriptionInterval_SUBSCRIPTION_INTERVAL_ONE_DAY, 24 * 60 * 60, "1d"},
+	{pb.SubscriptionInterval_SUBSCRIPTION_INTERVAL_WEEK, 7 * 24 * 60 * 60, "1w"},
+	{pb.SubscriptionInterval_SUBSCRIPTION_INTERVAL_MONTH, 30 * 24 * 60 * 60, "1mo"},
+}
+
 func candleIntervalToSeconds(interval pb.SubscriptionInterval) (int64, error) {
-	switch interval {
-	case pb.SubscriptionInterval_SUBSCRIPTION_INTERVAL_ONE_MINUTE:
-		return 60, nil
-	default:
-		return 0, nil
+	for _, entry := range candleIntervals {
+		if entry.interval == interval {
+			return entry.seconds, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported candle interval %s", interval.String())
+}
+
+// parseCandleIntervals parses a comma-separated list of intervals, dropping duplicates.
+func parseCandleIntervals(value string) ([]pb.SubscriptionInterval, error) {
+	var intervals []pb.SubscriptionInterval
+	seen := map[pb.SubscriptionInterval]struct{}{}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		interval, err := parseCandleInterval(part)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[interval]; ok {
+			continue
+		}
+		seen[interval] = struct{}{}
+		intervals = append(intervals, interval)
+	}
+	if len(intervals) == 0 {
+		return nil, errors.New("at least one candle interval is required")
+	}
+	return intervals, nil
+}
+
+// parseCandleInterval accepts a short name (5m, 1h, ...) or the full enum name
+// (SUBSCRIPTION_INTERVAL_FIVE_MINUTES).
+func parseCandleInterval(value string) (pb.SubscriptionInterval, error) {
+	for _, entry := range candleIntervals {
+		if strings.EqualFold(value, entry.name) || strings.EqualFold(value, entry.interval.String()) {
+			return entry.interval, nil
+		}
+	}
+	return pb.SubscriptionInterval_SUBSCRIPTION_INTERVAL_UNSPECIFIED, fmt.Errorf("unsupported candle interval %q", value)
+}
+
+// orderBookDepths are the depths the order book subscription accepts.
+var orderBookDepths = []int32{1, 10, 20, 30, 40, 50}
+
+func parseOrderBookDepth(value string) (int32, error) {
+	depth, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("depth must be an integer, got %q", value)
+	}
+	for _, allowed := range orderBookDepths {
+		if depth == int(allowed) {
+			return allowed, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported depth %d, must be one of %v", depth, orderBookDepths)
+}
+
+// tradeSources lists the trade sources of the trade subscription with the short
+// name accepted by TRADE_SOURCE.
+var tradeSources = []struct {
+	name   string
+	source pb.TradeSourceType
+}{
+	{"exchange", pb.TradeSourceType_TRADE_SOURCE_EXCHANGE},
+	{"dealer", pb.TradeSourceType_TRADE_SOURCE_DEALER},
+	{"all", pb.TradeSourceType_TRADE_SOURCE_ALL},
+}
+
+// parseTradeSource accepts a short name (exchange, dealer, all) or the full enum
+// name (TRADE_SOURCE_DEALER).
+func parseTradeSource(value string) (pb.TradeSourceType, error) {
+	for _, entry := range tradeSources {
+		if strings.EqualFold(value, entry.name) || strings.EqualFold(value, entry.source.String()) {
+			return entry.source, nil
+		}
 	}
+	return pb.TradeSourceType_TRADE_SOURCE_UNSPECIFIED, fmt.Errorf("unsupported trade source %q, must be exchange, dealer or all", value)
 }
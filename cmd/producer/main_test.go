@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	pb "github.com/russianinvestments/invest-api-go-sdk/proto"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	domain "main/internal/domain/entity/marketdata"
+	"main/internal/infrastructure/broker"
+)
+
+// fakeConfirmation answers a publish with a fixed ack, or never when block is set.
+type fakeConfirmation struct {
+	acked bool
+	err   error
+	block bool
+}
+
+func (c fakeConfirmation) WaitContext(ctx context.Context) (bool, error) {
+	if c.block {
+		<-ctx.Done()
+		return false, ctx.Err()
+	}
+	return c.acked, c.err
+}
+
+func quietLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+func TestWaitConfirm(t *testing.T) {
+	brokerErr := errors.New("channel closed")
+	tests := []struct {
+		name    string
+		confirm fakeConfirmation
+		want    error
+	}{
+		{"acked", fakeConfirmation{acked: true}, nil},
+		{"nacked", fakeConfirmation{acked: false}, errPublishNacked},
+		{"no answer in time", fakeConfirmation{block: true}, errConfirmTimeout},
+		{"wait failed", fakeConfirmation{err: brokerErr}, brokerErr},
+	}
+	p := &publisher{confirmTimeout: 10 * time.Millisecond}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := p.waitConfirm(context.Background(), tt.confirm); !errors.Is(err, tt.want) {
+				t.Fatalf("waitConfirm() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitConfirmCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p := &publisher{confirmTimeout: time.Second}
+	if err := p.waitConfirm(ctx, fakeConfirmation{block: true}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("waitConfirm() = %v, want context.Canceled", err)
+	}
+}
+
+func TestPublishRetryingNacks(t *testing.T) {
+	p := &publisher{confirmTimeout: 10 * time.Millisecond}
+	tests := []struct {
+		name      string
+		answers   []fakeConfirmation
+		want      error
+		wantCalls int
+	}{
+		{"acked at once", []fakeConfirmation{{acked: true}}, nil, 1},
+		{"acked after a nack", []fakeConfirmation{{}, {acked: true}}, nil, 2},
+		{"nacked every time", []fakeConfirmation{{}, {}, {}}, errPublishNacked, maxNackedAttempts},
+		{"timeout is not retried", []fakeConfirmation{{block: true}}, errConfirmTimeout, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := publishRetryingNacks(context.Background(), quietLogger(), func() error {
+				answer := tt.answers[calls]
+				calls++
+				return p.waitConfirm(context.Background(), answer)
+			})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("publishRetryingNacks() = %v, want %v", err, tt.want)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("publish called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// recordingPublisher records the trades it is asked to publish instead of
+// sending them.
+type recordingPublisher struct {
+	mu     sync.Mutex
+	trades []*domain.Trade
+}
+
+func (p *recordingPublisher) sendCandle(context.Context, *domain.Candle) error { return nil }
+
+func (p *recordingPublisher) sendTrade(_ context.Context, trade *domain.Trade) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.trades = append(p.trades, trade)
+	return nil
+}
+
+func (p *recordingPublisher) sendOrderBook(context.Context, *domain.OrderBookSnapshot) error {
+	return nil
+}
+
+func TestShutdownDrainsBufferedTrades(t *testing.T) {
+	const buffered = 5
+	trades := make(chan *pb.Trade, buffered)
+	for i := range buffered {
+		trades <- &pb.Trade{
+			InstrumentUid: uuid.NewString(),
+			Direction:     pb.TradeDirection_TRADE_DIRECTION_BUY,
+			Price:         &pb.Quotation{Units: 100},
+			Quantity:      int64(i + 1),
+			Time:          timestamppb.Now(),
+		}
+	}
+	// Stopping the stream closes its channels with the messages still buffered.
+	close(trades)
+
+	pub := &recordingPublisher{}
+	// The pumps run on a context the shutdown signal does not cancel.
+	shutdown, cancel := context.WithCancel(context.Background())
+	cancel()
+	pumpCtx := context.WithoutCancel(shutdown)
+
+	if err := pumpTrades(pumpCtx, trades, pub, false, newStreamActivity(time.Now()), quietLogger()); err != nil {
+		t.Fatalf("pumpTrades() = %v, want nil on a clean shutdown", err)
+	}
+	if len(pub.trades) != buffered {
+		t.Fatalf("published %d trades, want %d", len(pub.trades), buffered)
+	}
+}
+
+// batchingPublisher returns a publisher that batches every message and records
+// the trades of the batches it publishes instead of sending them.
+func batchingPublisher(published *[]broker.BaseMessage, mu *sync.Mutex) *publisher {
+	p := &publisher{logger: quietLogger()}
+	p.batches = newMessageBatcher(100, time.Hour, func(_ context.Context, _ batchKey, msg *broker.BaseMessage, _ int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		*published = append(*published, *msg)
+		return nil
+	})
+	return p
+}
+
+func TestShutdownFlushesBufferedBatches(t *testing.T) {
+	const buffered = 5
+	trades := make(chan *pb.Trade, buffered)
+	for i := range buffered {
+		trades <- &pb.Trade{
+			InstrumentUid: uuid.NewString(),
+			Direction:     pb.TradeDirection_TRADE_DIRECTION_BUY,
+			Price:         &pb.Quotation{Units: 100},
+			Quantity:      int64(i + 1),
+			Time:          timestamppb.Now(),
+		}
+	}
+	close(trades)
+
+	var (
+		mu        sync.Mutex
+		published []broker.BaseMessage
+	)
+	pub := batchingPublisher(&published, &mu)
+	pumpCtx := context.WithoutCancel(context.Background())
+
+	pumpsDone := make(chan struct{})
+	pumpErr := make(chan error, 1)
+	go func() {
+		defer close(pumpsDone)
+		pumpErr <- pumpTrades(pumpCtx, trades, pub, false, newStreamActivity(time.Now()), quietLogger())
+	}()
+	// The batcher flushes what it still holds once the pumps are done.
+	if err := pub.batches.run(pumpCtx, pumpsDone); err != nil {
+		t.Fatalf("batcher run() = %v, want nil", err)
+	}
+	if err := <-pumpErr; err != nil {
+		t.Fatalf("pumpTrades() = %v, want nil on a clean shutdown", err)
+	}
+
+	total := 0
+	for _, msg := range published {
+		total += len(msg.Trades)
+	}
+	if total != buffered {
+		t.Fatalf("published %d trades, want %d", total, buffered)
+	}
+}
+
+func TestShutdownDrainTimeoutStopsPumps(t *testing.T) {
+	pumpCtx, cancelPumps := context.WithCancelCause(context.Background())
+	cancelPumps(errDrainTimeout)
+
+	// The stream never closes this channel, so only the drain timeout ends the pump.
+	trades := make(chan *pb.Trade)
+	err := pumpTrades(pumpCtx, trades, &recordingPublisher{}, false, newStreamActivity(time.Now()), quietLogger())
+	if !errors.Is(err, errDrainTimeout) {
+		t.Fatalf("pumpTrades() = %v, want errDrainTimeout", err)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+
+	domain "main/internal/domain/entity/marketdata"
+	"main/internal/infrastructure/broker"
+)
+
+// publishPolicy decides what a pump does with a message whose publish did not
+// finish within the publish timeout.
+type publishPolicy string
+
+const (
+	// policyBlock waits for the broker however long it takes; the timeout is
+	// not applied.
+	policyBlock publishPolicy = "block"
+	// policyDropOldest gives up on the message and moves on to the next. The
+	// message in hand is the oldest one the stream delivered and the pump has
+	// not published yet.
+	policyDropOldest publishPolicy = "drop_oldest"
+	// policyError stops the producer, like any other failed publish.
+	policyError publishPolicy = "error"
+)
+
+// errPublishTimeout stops the producer under policyError.
+var errPublishTimeout = errors.New("publish timed out")
+
+func parsePublishPolicy(value string) (publishPolicy, error) {
+	switch policy := publishPolicy(value); policy {
+	case policyBlock, policyDropOldest, policyError:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unsupported policy %q, must be block, drop_oldest or error", value)
+	}
+}
+
+// producerMetrics counts per stream (candles, trades, orderbooks) the
+// publishes that timed out and the messages dropped because of it.
+type producerMetrics struct {
+	registry *prometheus.Registry
+	timeouts *prometheus.CounterVec
+	dropped  *prometheus.CounterVec
+}
+
+func newProducerMetrics() *producerMetrics {
+	m := &producerMetrics{
+		registry: prometheus.NewRegistry(),
+		timeouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "producer_publish_timeouts_total",
+			Help: "Publishes that did not finish within PUBLISH_TIMEOUT_SECONDS by stream.",
+		}, []string{"stream"}),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "producer_messages_dropped_total",
+			Help: "Stream messages given up after a publish timeout by stream. The broker may still have enqueued some of them.",
+		}, []string{"stream"}),
+	}
+	m.registry.MustRegister(
+		m.timeouts,
+		m.dropped,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// serveMetrics serves the registry on addr at /metrics until ctx is done. A
+// listener that fails is logged and not retried; publishing carries on.
+func serveMetrics(ctx context.Context, addr string, metrics *producerMetrics, logger *logrus.Logger) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(metrics.registry, promhttp.HandlerOpts{}))
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	stopOnShutdown := context.AfterFunc(ctx, func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	})
+	defer stopOnShutdown()
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.WithError(err).Error("metrics listener stopped")
+	}
+}
+
+// send publishes a message of kind holding items entities, retrying nacks,
+// within the publish timeout under policyDropOldest and policyError. A confirm
+// that timed out counts as a publish timeout as well; a publish cut short by
+// shutdown does not.
+func (p *publisher) send(ctx context.Context, kind streamKind, items int, publish func(context.Context) error) error {
+	if p.policy == policyBlock || p.timeout <= 0 {
+		return publishRetryingNacks(ctx, p.logger, func() error { return publish(ctx) })
+	}
+	publishCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	err := publishRetryingNacks(publishCtx, p.logger, func() error { return publish(publishCtx) })
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	if !errors.Is(err, errConfirmTimeout) && !errors.Is(publishCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	p.metrics.timeouts.WithLabelValues(string(kind)).Inc()
+	if p.policy == policyError {
+		return fmt.Errorf("%w after %s", errPublishTimeout, p.timeout)
+	}
+	p.metrics.dropped.WithLabelValues(string(kind)).Add(float64(items))
+	p.logger.WithError(err).WithFields(logrus.Fields{
+		"stream":  kind,
+		"items":   items,
+		"timeout": p.timeout.String(),
+	}).Warn("publish timed out, dropping message")
+	return nil
+}
+
+// sendCandle publishes a candle on its own, or adds it to its batch when
+// batching is on.
+func (p *publisher) sendCandle(ctx context.Context, candle *domain.Candle) error {
+	if p.batches != nil {
+		return p.batches.addCandle(ctx, candle)
+	}
+	return p.send(ctx, kindCandles, 1, func(ctx context.Context) error { return p.PublishCandle(ctx, candle) })
+}
+
+func (p *publisher) sendTrade(ctx context.Context, trade *domain.Trade) error {
+	if p.batches != nil {
+		return p.batches.addTrade(ctx, trade)
+	}
+	return p.send(ctx, kindTrades, 1, func(ctx context.Context) error { return p.PublishTrade(ctx, trade) })
+}
+
+func (p *publisher) sendOrderBook(ctx context.Context, snapshot *domain.OrderBookSnapshot) error {
+	if p.batches != nil {
+		return p.batches.addOrderBook(ctx, snapshot)
+	}
+	return p.send(ctx, kindOrderBooks, 1, func(ctx context.Context) error { return p.PublishOrderBook(ctx, snapshot) })
+}
+
+// sendBatch publishes a full or due batch under the same timeout policy as
+// single messages.
+func (p *publisher) sendBatch(ctx context.Context, key batchKey, msg *broker.BaseMessage, items int) error {
+	return p.send(ctx, key.kind, items, func(ctx context.Context) error { return p.PublishBatch(ctx, key, msg) })
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+
+	investgo "github.com/russianinvestments/invest-api-go-sdk/investgo"
+	pb "github.com/russianinvestments/invest-api-go-sdk/proto"
+	"github.com/sirupsen/logrus"
+)
+
+// instrumentSet holds the instruments the producer tracks. A reload replaces
+// them; the running stream applies the difference and a reconnected stream
+// subscribes to the current list as a whole.
+type instrumentSet struct {
+	mu  sync.Mutex
+	ids []string
+	// changed is signalled, without blocking, after every replace.
+	changed chan struct{}
+}
+
+func newInstrumentSet(ids []string) *instrumentSet {
+	return &instrumentSet{ids: slices.Clone(ids), changed: make(chan struct{}, 1)}
+}
+
+func (s *instrumentSet) Get() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.ids)
+}
+
+// Replace swaps in ids and returns the instruments added and removed.
+func (s *instrumentSet) Replace(ids []string) (added, removed []string) {
+	s.mu.Lock()
+	added, removed = diffInstruments(s.ids, ids)
+	s.ids = slices.Clone(ids)
+	s.mu.Unlock()
+
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+	return added, removed
+}
+
+// watchInstruments re-reads the instruments file on SIGHUP. A file that cannot
+// be read or lists no instruments is ignored, so a truncated file never drops
+// every subscription.
+func watchInstruments(ctx context.Context, path string, instruments *instrumentSet, logger *logrus.Logger) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			next, err := readInstruments(path)
+			if err != nil {
+				logger.Errorf("instruments reload failed, keeping current list: %v", err)
+				continue
+			}
+			if len(next) == 0 {
+				logger.Warn("instruments reload found an empty list, keeping current list")
+				continue
+			}
+			added, removed := instruments.Replace(next)
+			logger.WithFields(logrus.Fields{
+				"instruments": len(next),
+				"added":       len(added),
+				"removed":     len(removed),
+			}).Info("instruments reloaded")
+		}
+	}
+}
+
+// applyInstrumentChanges keeps a running stream's subscriptions in line with
+// instruments, starting from subscribed, until ctx is done. The SDK delivers
+// every subscription of a kind on the channel the first one returned, so the
+// pumps pick up added instruments without being restarted. Added instruments
+// whose subscription fails are dropped, and tried again on the next reload. A
+// failed unsubscribe is a stream error: the reconnected stream subscribes to
+// the whole current list.
+func applyInstrumentChanges(ctx context.Context, stream *investgo.MarketDataStream, cfg *producerConfig, instruments *instrumentSet, subscribed []string, logger *logrus.Logger) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-instruments.changed:
+		}
+		next := instruments.Get()
+		added, removed := diffInstruments(subscribed, next)
+		if len(removed) > 0 {
+			if err := unsubscribeInstruments(stream, cfg, removed); err != nil {
+				return &streamError{err}
+			}
+		}
+		var rejected []string
+		if len(added) > 0 {
+			added, rejected, _ = subscribeEach(stream, cfg, added, logger)
+		}
+		subscribed = slices.DeleteFunc(next, func(id string) bool {
+			return slices.Contains(rejected, id)
+		})
+		if len(added) > 0 || len(removed) > 0 || len(rejected) > 0 {
+			logger.WithFields(logrus.Fields{
+				"added":    len(added),
+				"removed":  len(removed),
+				"rejected": len(rejected),
+			}).Info("stream subscriptions updated")
+		}
+	}
+}
+
+// subscriptionChans are the channels the SDK delivers subscribed data on, one
+// per candle interval and one each for trades and order books.
+type subscriptionChans struct {
+	candles    []<-chan *pb.Candle
+	trades     <-chan *pb.Trade
+	orderBooks <-chan *pb.OrderBook
+}
+
+// subscribeEach subscribes ids one instrument at a time, so a single rejected
+// instrument cannot fail the whole list. A rejected instrument is logged,
+// unsubscribed from whatever kinds it did get, and left out of accepted. The
+// channels are those of the first accepted instrument; they are unset when
+// none was accepted.
+func subscribeEach(stream *investgo.MarketDataStream, cfg *producerConfig, ids []string, logger *logrus.Logger) (accepted, rejected []string, chans subscriptionChans) {
+	for _, id := range ids {
+		got, err := subscribeInstruments(stream, cfg, []string{id})
+		if err != nil {
+			logger.WithError(err).WithField("instrument", id).Warn("instrument subscription rejected, skipping it")
+			if undoErr := unsubscribeInstruments(stream, cfg, []string{id}); undoErr != nil {
+				logger.WithError(undoErr).WithField("instrument", id).Warn("undo partial subscription failed")
+			}
+			rejected = append(rejected, id)
+			continue
+		}
+		if len(accepted) == 0 {
+			chans = got
+		}
+		accepted = append(accepted, id)
+	}
+	return accepted, rejected, chans
+}
+
+// subscribeInstruments adds ids to the candle, trade and order book
+// subscriptions and returns the channels the SDK handed back.
+func subscribeInstruments(stream *investgo.MarketDataStream, cfg *producerConfig, ids []string) (subscriptionChans, error) {
+	var chans subscriptionChans
+	for _, interval := range cfg.CandleIntervals {
+		candleChan, err := stream.SubscribeCandle(ids, interval, cfg.CandleWaitingClose, nil)
+		if err != nil {
+			return subscriptionChans{}, fmt.Errorf("subscribe candles %s: %w", interval.String(), err)
+		}
+		chans.candles = append(chans.candles, candleChan)
+	}
+	tradeChan, err := stream.SubscribeTrade(ids, cfg.TradeSource, false)
+	if err != nil {
+		return subscriptionChans{}, fmt.Errorf("subscribe trades: %w", err)
+	}
+	chans.trades = tradeChan
+	orderBookChan, err := stream.SubscribeOrderBook(ids, cfg.OrderBookDepth)
+	if err != nil {
+		return subscriptionChans{}, fmt.Errorf("subscribe order books: %w", err)
+	}
+	chans.orderBooks = orderBookChan
+	return chans, nil
+}
+
+func unsubscribeInstruments(stream *investgo.MarketDataStream, cfg *producerConfig, ids []string) error {
+	for _, interval := range cfg.CandleIntervals {
+		if err := stream.UnSubscribeCandle(ids, interval, cfg.CandleWaitingClose, nil); err != nil {
+			return fmt.Errorf("unsubscribe candles %s: %w", interval.String(), err)
+		}
+	}
+	if err := stream.UnSubscribeTrade(ids); err != nil {
+		return fmt.Errorf("unsubscribe trades: %w", err)
+	}
+	if err := stream.UnSubscribeOrderBook(ids, cfg.OrderBookDepth); err != nil {
+		return fmt.Errorf("unsubscribe order books: %w", err)
+	}
+	return nil
+}
+
+// diffInstruments lists the ids of next missing from prev and those of prev
+// missing from next, in list order.
+func diffInstruments(prev, next []string) (added, removed []string) {
+	for _, id := range next {
+		if !slices.Contains(prev, id) && !slices.Contains(added, id) {
+			added = append(added, id)
+		}
+	}
+	for _, id := range prev {
+		if !slices.Contains(next, id) && !slices.Contains(removed, id) {
+			removed = append(removed, id)
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	investgo "github.com/russianinvestments/invest-api-go-sdk/investgo"
+	pb "github.com/russianinvestments/invest-api-go-sdk/proto"
+	"github.com/sirupsen/logrus"
+
+	appmarketdata "main/internal/application/service/marketdata"
+	"main/internal/config"
+	domain "main/internal/domain/entity/marketdata"
+	inframarketdata "main/internal/infrastructure/marketdata"
+)
+
+const (
+	defaultInvestEndpoint  = "https://invest-public-api.tinkoff.ru:443"
+	defaultAppName         = "marketdata-candle-repair"
+	defaultIntervalSeconds = 60
+	defaultLookbackHours   = 24
+	defaultScheduleMinutes = 60
+	defaultMaxRPS          = 5
+)
+
+// candleIntervals maps stored interval lengths to the historical API interval and
+// the widest range the API accepts in a single request for it.
+var candleIntervals = map[int64]struct {
+	interval pb.CandleInterval
+	maxSpan  time.Duration
+}{
+	60:    {pb.CandleInterval_CANDLE_INTERVAL_1_MIN, 24 * time.Hour},
+	300:   {pb.CandleInterval_CANDLE_INTERVAL_5_MIN, 7 * 24 * time.Hour},
+	900:   {pb.CandleInterval_CANDLE_INTERVAL_15_MIN, 21 * 24 * time.Hour},
+	3600:  {pb.CandleInterval_CANDLE_INTERVAL_HOUR, 90 * 24 * time.Hour},
+	86400: {pb.CandleInterval_CANDLE_INTERVAL_DAY, 6 * 365 * 24 * time.Hour},
+}
+
+type repairConfig struct {
+	Token           string
+	Endpoint        string
+	AppName         string
+	SkipTLSVerify   bool
+	DatabaseDSN     string
+	IntervalSeconds int64
+	Lookback        time.Duration
+	Schedule        time.Duration
+	MaxRPS          int
+}
+
+// repairSummary is the per-instrument outcome of a single repair pass.
+type repairSummary struct {
+	Gaps     int
+	Missing  int64
+	Repaired int
+	Failed   int
+}
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	cfg, err := loadConfig()
+	if err != nil {
+		logger.Fatalf("config error: %v", err)
+	}
+
+	repo, err := inframarketdata.NewRepository(ctx, config.PostgresConfig{DSN: cfg.DatabaseDSN})
+	if err != nil {
+		logger.Fatalf("connect postgres: %v", err)
+	}
+	defer repo.Close()
+	service := appmarketdata.NewService(repo)
+	// Live ingestion may fill a gap while it is being repaired.
+	service.SetConflictPolicy(domain.ConflictSkip)
+
+	investCfg := investgo.Config{
+		EndPoint:           cfg.Endpoint,
+		Token:              cfg.Token,
+		AppName:            cfg.AppName,
+		InsecureSkipVerify: cfg.SkipTLSVerify,
+	}
+
+	client, err := investgo.NewClient(ctx, investCfg, logger)
+	if err != nil {
+		logger.Fatalf("create invest api client: %v", err)
+	}
+	defer func() {
+		if stopErr := client.Stop(); stopErr != nil {
+			logger.Errorf("stop invest api client: %v", stopErr)
+		}
+	}()
+
+	repairer := &repairer{
+		cfg:        cfg,
+		service:    service,
+		marketdata: client.NewMarketDataServiceClient(),
+		throttle:   time.NewTicker(time.Second / time.Duration(cfg.MaxRPS)),
+		logger:     logger,
+	}
+	defer repairer.throttle.Stop()
+
+	repairer.run(ctx)
+	if cfg.Schedule <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(cfg.Schedule)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("candle repair stopped")
+			return
+		case <-ticker.C:
+			repairer.run(ctx)
+		}
+	}
+}
+
+type repairer struct {
+	cfg        *repairConfig
+	service    *appmarketdata.Service
+	marketdata *investgo.MarketDataServiceClient
+	throttle   *time.Ticker
+	logger     *logrus.Logger
+}
+
+// run performs one detection and repair pass over the lookback window.
+func (r *repairer) run(ctx context.Context) {
+	to := time.Now().UTC()
+	from := to.Add(-r.cfg.Lookback)
+
+	gaps, err := r.service.GetCandleGaps(ctx, r.cfg.IntervalSeconds, from, to)
+	if err != nil {
+		r.logger.Errorf("detect candle gaps: %v", err)
+		return
+	}
+	if len(gaps) == 0 {
+		r.logger.Info("no candle gaps found")
+		return
+	}
+
+	summaries := make(map[uuid.UUID]*repairSummary)
+	for _, gap := range gaps {
+		if ctx.Err() != nil {
+			return
+		}
+		summary, ok := summaries[gap.InstrumentUID]
+		if !ok {
+			summary = &repairSummary{}
+			summaries[gap.InstrumentUID] = summary
+		}
+		summary.Gaps++
+		summary.Missing += gap.Missing
+
+		repaired, err := r.repairGap(ctx, gap)
+		summary.Repaired += repaired
+		if err != nil {
+			summary.Failed++
+			r.logger.WithFields(logrus.Fields{
+				"instrument_uid": gap.InstrumentUID,
+				"from":           gap.From,
+				"to":             gap.To,
+			}).Errorf("repair candle gap: %v", err)
+		}
+	}
+
+	for uid, summary := range summaries {
+		r.logger.WithFields(logrus.Fields{
+			"instrument_uid": uid,
+			"gaps":           summary.Gaps,
+			"missing":        summary.Missing,
+			"repaired":       summary.Repaired,
+			"failed":         summary.Failed,
+		}).Info("candle gaps repaired")
+	}
+}
+
+// repairGap fetches the candles of a gap from the historical API, splitting the
+// range into chunks the API accepts, and stores the completed ones.
+func (r *repairer) repairGap(ctx context.Context, gap domain.CandleGap) (int, error) {
+	spec := candleIntervals[gap.IntervalSeconds]
+	interval := time.Duration(gap.IntervalSeconds) * time.Second
+	end := gap.To.Add(interval)
+
+	repaired := 0
+	for start := gap.From; start.Before(end); start = start.Add(spec.maxSpan) {
+		chunkEnd := start.Add(spec.maxSpan)
+		if chunkEnd.After(end) {
+			chunkEnd = end
+		}
+
+		select {
+		case <-ctx.Done():
+			return repaired, ctx.Err()
+		case <-r.throttle.C:
+		}
+
+		resp, err := r.marketdata.GetCandles(gap.InstrumentUID.String(), spec.interval, start, chunkEnd, pb.GetCandlesRequest_CANDLE_SOURCE_UNSPECIFIED, 0)
+		if err != nil {
+			return repaired, fmt.Errorf("get candles: %w", err)
+		}
+
+		candles := make([]domain.Candle, 0, len(resp.GetCandles()))
+		for _, hc := range resp.GetCandles() {
+			if !hc.GetIsComplete() {
+				continue
+			}
+			candles = append(candles, mapHistoricCandle(gap.InstrumentUID, gap.IntervalSeconds, hc))
+		}
+		if _, err := r.service.AddCandles(ctx, candles); err != nil {
+			return repaired, fmt.Errorf("save candles: %w", err)
+		}
+		repaired += len(candles)
+	}
+	return repaired, nil
+}
+
+func mapHistoricCandle(instrumentUID uuid.UUID, intervalSeconds int64, hc *pb.HistoricCandle) domain.Candle {
+	volumeBuy := hc.GetVolumeBuy()
+	volumeSell := hc.GetVolumeSell()
+	return domain.Candle{
+		InstrumentUID:   instrumentUID,
+		IntervalSeconds: intervalSeconds,
+		PeriodStart:     hc.GetTime().AsTime().UTC(),
+		Open:            hc.GetOpen().ToFloat(),
+		High:            hc.GetHigh().ToFloat(),
+		Low:             hc.GetLow().ToFloat(),
+		Close:           hc.GetClose().ToFloat(),
+		VolumeLots:      hc.GetVolume(),
+		VolumeBuyLots:   &volumeBuy,
+		VolumeSellLots:  &volumeSell,
+		Metadata:        map[string]any{"source": "repair"},
+	}
+}
+
+func loadConfig() (*repairConfig, error) {
+	token := strings.TrimSpace(os.Getenv("INVEST_TOKEN"))
+	if token == "" {
+		return nil, errors.New("INVEST_TOKEN is required")
+	}
+
+	dsn := strings.TrimSpace(os.Getenv("DATABASE_DSN"))
+	if dsn == "" {
+		return nil, errors.New("DATABASE_DSN is required")
+	}
+
+	intervalSeconds := intEnv("REPAIR_CANDLE_INTERVAL_SECONDS", defaultIntervalSeconds)
+	if _, ok := candleIntervals[int64(intervalSeconds)]; !ok {
+		return nil, fmt.Errorf("REPAIR_CANDLE_INTERVAL_SECONDS: unsupported interval %d", intervalSeconds)
+	}
+
+	lookbackHours := intEnv("REPAIR_LOOKBACK_HOURS", defaultLookbackHours)
+	if lookbackHours <= 0 {
+		return nil, errors.New("REPAIR_LOOKBACK_HOURS must be positive")
+	}
+
+	// A schedule of zero runs a single pass and exits, for use from cron.
+	scheduleMinutes := intEnv("REPAIR_SCHEDULE_MINUTES", defaultScheduleMinutes)
+
+	maxRPS := intEnv("REPAIR_MAX_RPS", defaultMaxRPS)
+	if maxRPS <= 0 {
+		return nil, errors.New("REPAIR_MAX_RPS must be positive")
+	}
+
+	return &repairConfig{
+		Token:           token,
+		Endpoint:        envOrDefault("INVEST_ENDPOINT", defaultInvestEndpoint),
+		AppName:         envOrDefault("INVEST_APP_NAME", defaultAppName),
+		SkipTLSVerify:   boolEnv("INVEST_INSECURE_SKIP_VERIFY", true),
+		DatabaseDSN:     dsn,
+		IntervalSeconds: int64(intervalSeconds),
+		Lookback:        time.Duration(lookbackHours) * time.Hour,
+		Schedule:        time.Duration(scheduleMinutes) * time.Minute,
+		MaxRPS:          maxRPS,
+	}, nil
+}
+
+func envOrDefault(key, fallback string) string {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func intEnv(key string, fallback int) int {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
+
+func boolEnv(key string, fallback bool) bool {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback
+	}
+	switch strings.ToLower(value) {
+	case "1", "t", "true", "yes", "y":
+		return true
+	case "0", "f", "false", "no", "n":
+		return false
+	default:
+		return fallback
+	}
+}
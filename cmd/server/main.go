@@ -3,8 +3,12 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -12,9 +16,14 @@ import (
 	appinstruments "main/internal/application/service/instruments"
 	appmarketdata "main/internal/application/service/marketdata"
 	"main/internal/config"
+	domainmarketdata "main/internal/domain/entity/marketdata"
 	"main/internal/infrastructure/broker"
+	"main/internal/infrastructure/httpclient"
 	infrainstruments "main/internal/infrastructure/instruments"
+	"main/internal/infrastructure/lastprice"
 	inframarketdata "main/internal/infrastructure/marketdata"
+	"main/internal/infrastructure/tracing"
+	infragrpc "main/internal/interfaces/grpc"
 	infrahttp "main/internal/interfaces/http"
 
 	"github.com/redis/go-redis/v9"
@@ -32,17 +41,33 @@ func main() {
 	if err != nil {
 		logger.Fatalf("failed to load config: %v", err)
 	}
+	if err := applyLogLevel(logger, cfg.Log.Level); err != nil {
+		logger.Fatalf("failed to apply log level: %v", err)
+	}
+
+	outbound := httpclient.New(cfg.Outbound)
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing, outbound)
+	if err != nil {
+		logger.Fatalf("failed to init tracing: %v", err)
+	}
+	defer func() {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			logger.Errorf("tracing shutdown error: %v", err)
+		}
+	}()
 
 	docs.SwaggerInfo.BasePath = "/api/v1"
 	docs.SwaggerInfo.Host = cfg.HTTP.Addr()
 
-	instrumentRepo, err := infrainstruments.NewRepository(ctx, cfg.Postgres.DSN)
+	instrumentRepo, err := infrainstruments.NewRepository(ctx, cfg.Postgres)
 	if err != nil {
 		logger.Fatalf("failed to init instruments repo: %v", err)
 	}
 	defer instrumentRepo.Close()
 
-	marketdataRepo, err := inframarketdata.NewRepository(ctx, cfg.Postgres.DSN)
+	marketdataRepo, err := inframarketdata.NewRepository(ctx, cfg.Postgres)
 	if err != nil {
 		logger.Fatalf("failed to init marketdata repo: %v", err)
 	}
@@ -62,12 +87,52 @@ func main() {
 	}
 
 	instrumentService := appinstruments.NewService(instrumentRepo)
+	instrumentService.SetBatchGetMax(cfg.InstrumentQuery.BatchGetMax)
 	marketdataService := appmarketdata.NewService(marketdataRepo)
+	marketdataService.SetMetadataLimits(appmarketdata.MetadataLimits{
+		MaxKeys:  cfg.Metadata.MaxKeys,
+		MaxDepth: cfg.Metadata.MaxDepth,
+		MaxBytes: cfg.Metadata.MaxBytes,
+	})
+	marketdataService.SetDepthFallback(appmarketdata.DepthFallback(cfg.OrderBookQuery.DepthFallback))
+	marketLocation, err := time.LoadLocation(cfg.Market.Timezone)
+	if err != nil {
+		logger.Fatalf("failed to load market timezone: %v", err)
+	}
+	marketdataService.SetMarketLocation(marketLocation)
+	marketdataService.SetConflictPolicy(domainmarketdata.ConflictPolicy(cfg.Ingest.OnConflict))
+	marketdataService.SetCandleDedup(cfg.Ingest.CandleDedup)
+	if cfg.Ingest.CheckInstruments {
+		marketdataService.SetInstrumentCheck(instrumentRepo, cfg.Ingest.InstrumentTTL)
+	}
+	marketdataService.SetDeleteChunkSize(cfg.Retention.DeleteChunkSize)
+	marketdataService.SetMaxRange(cfg.RangeQuery.MaxRange)
+	if redisClient != nil {
+		marketdataService.SetLastPriceStore(lastprice.NewRedisStore(redisClient))
+	}
+	marketdataService.SetOrderBookChecks(appmarketdata.OrderBookChecks{
+		Crossed:       appmarketdata.CrossedBookPolicy(cfg.Ingest.CrossedBook),
+		RequireSorted: cfg.Ingest.RequireSortedBook,
+		OnCrossed: func(snapshot *domainmarketdata.OrderBookSnapshot, bestBid, bestAsk float64) {
+			logger.WithFields(logrus.Fields{
+				"instrument_uid": snapshot.InstrumentUID,
+				"snapshot_at":    snapshot.SnapshotAt,
+				"best_bid":       bestBid,
+				"best_ask":       bestAsk,
+			}).Warn("storing crossed order book")
+		},
+	})
 
 	rabbitConsumer, err := broker.NewConsumer(cfg.RabbitMQ, marketdataService, logger)
 	if err != nil {
 		logger.Fatalf("failed to init rabbitmq consumer: %v", err)
 	}
+	rabbitConsumer.SetOrderBookThrottle(cfg.OrderBookThrottle)
+	tradeHub := appmarketdata.NewTradeHub()
+	rabbitConsumer.SetTradeHub(tradeHub)
+	candleHub := appmarketdata.NewCandleHub()
+	rabbitConsumer.SetCandleHub(candleHub)
+	rabbitConsumer.SetHealthProbe(marketdataRepo.Ping)
 	if err := rabbitConsumer.Start(ctx); err != nil {
 		logger.Fatalf("failed to start rabbitmq consumer: %v", err)
 	}
@@ -81,12 +146,46 @@ func main() {
 
 	cacheTTL := time.Duration(cfg.Cache.TTLSeconds) * time.Second
 	handler := infrahttp.NewHandler(instrumentService, marketdataService, redisClient, cacheTTL)
+	handler.SetLogger(logger)
+	handler.SetCacheRouteTTLs(cfg.Cache.RouteTTLs)
+	handler.SetConfig(*cfg)
+	handler.SetCompression(cfg.HTTP.GzipEnabled, cfg.HTTP.GzipMinBytes)
+	handler.SetMaxBodyBytes(cfg.HTTP.MaxBodyBytes)
+	handler.SetDebugErrors(cfg.HTTP.DebugErrors)
+	handler.SetRateLimit(cfg.RateLimit.RequestsPerSecond, cfg.RateLimit.Burst)
+	handler.SetTradeStream(tradeHub, cfg.HTTP.StreamBuffer)
+	handler.SetCandleStream(candleHub, cfg.HTTP.StreamBuffer)
+	if cfg.Tracing.Endpoint != "" {
+		handler.SetTracing(cfg.Tracing.ServiceName)
+	}
+	if err := handler.RegisterMetrics(rabbitConsumer.Collectors()...); err != nil {
+		logger.Fatalf("failed to register ingestion metrics: %v", err)
+	}
+	handler.AddReadinessCheck("postgres", func(ctx context.Context) error {
+		return errors.Join(instrumentRepo.Ping(ctx), marketdataRepo.Ping(ctx))
+	})
+	handler.AddPoolStats("instruments", instrumentRepo.Stat)
+	handler.AddPoolStats("marketdata", marketdataRepo.Stat)
 
+	// Requests do not derive from the signal context, so shutdown lets them
+	// finish; cancelling requestCtx aborts them and their database queries.
+	requestCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
 	server := &http.Server{
-		Addr:    cfg.HTTP.Addr(),
-		Handler: handler,
+		Addr:        cfg.HTTP.Addr(),
+		Handler:     handler,
+		BaseContext: func(net.Listener) context.Context { return requestCtx },
+	}
+
+	var grpcServer *infragrpc.Server
+	if cfg.GRPC.Port != 0 {
+		grpcServer = infragrpc.NewServer(instrumentService, marketdataService)
+		grpcServer.SetLogger(logger)
+		grpcServer.SetConfig(*cfg)
 	}
 
+	go watchReload(ctx, cfg, handler, grpcServer, logger)
+
 	go func() {
 		logger.Infof("HTTP server listening on %s", cfg.HTTP.Addr())
 		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -94,14 +193,104 @@ func main() {
 		}
 	}()
 
+	if grpcServer != nil {
+		listener, err := net.Listen("tcp", cfg.GRPC.Addr())
+		if err != nil {
+			logger.Fatalf("failed to listen for grpc: %v", err)
+		}
+		go func() {
+			logger.Infof("gRPC server listening on %s", cfg.GRPC.Addr())
+			if err := grpcServer.Serve(listener); err != nil {
+				logger.Fatalf("grpc server error: %v", err)
+			}
+		}()
+	}
+
 	<-ctx.Done()
-	logger.Infof("shutting down server")
+	logger.WithFields(logrus.Fields{
+		"in_flight":       handler.InFlight(),
+		"timeout_seconds": int64(cfg.HTTP.ShutdownTimeout.Seconds()),
+	}).Info("shutting down server")
+	// Shutdown does not wait for hijacked WebSocket connections and waits for
+	// event streams forever; closing the hubs ends both kinds of stream.
+	tradeHub.Close()
+	candleHub.Close()
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
 	defer shutdownCancel()
 
+	// Both servers drain at once, so each gets the whole timeout.
+	var grpcStopped sync.WaitGroup
+	if grpcServer != nil {
+		grpcStopped.Go(func() {
+			if err := grpcServer.Shutdown(shutdownCtx); err != nil {
+				logger.Errorf("grpc server shutdown error: %v", err)
+			}
+		})
+	}
 	if err := server.Shutdown(shutdownCtx); err != nil {
-		logger.Errorf("server shutdown error: %v", err)
+		logger.WithError(err).WithField("in_flight", handler.InFlight()).Warn("shutdown timed out, cancelling the requests still running")
+		cancelRequests()
+		if err := server.Close(); err != nil {
+			logger.Errorf("server close error: %v", err)
+		}
 	}
+	grpcStopped.Wait()
 	logger.Info("server stopped")
 }
+
+// watchReload re-reads the configuration on SIGHUP and applies the hot-reloadable
+// subset (cache TTL, log level, API keys) to the running servers. grpcServer is
+// nil when the gRPC server is disabled.
+func watchReload(ctx context.Context, current *config.Config, handler *infrahttp.Handler, grpcServer *infragrpc.Server, logger *logrus.Logger) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			next, err := config.Load()
+			if err != nil {
+				logger.Errorf("config reload failed, keeping current settings: %v", err)
+				continue
+			}
+			if changed := current.ImmutableChanges(next); len(changed) > 0 {
+				logger.Warnf("config reload ignores settings that require a restart: %v", changed)
+			}
+			if err := applyLogLevel(logger, next.Log.Level); err != nil {
+				logger.Errorf("config reload: %v", err)
+			} else {
+				current.Log = next.Log
+			}
+			current.Cache = next.Cache
+			current.Auth = next.Auth
+			current.RateLimit = next.RateLimit
+			handler.SetRateLimit(next.RateLimit.RequestsPerSecond, next.RateLimit.Burst)
+			handler.SetCacheTTL(time.Duration(next.Cache.TTLSeconds) * time.Second)
+			handler.SetCacheRouteTTLs(next.Cache.RouteTTLs)
+			handler.SetConfig(*current)
+			if grpcServer != nil {
+				grpcServer.SetConfig(*current)
+			}
+			logger.WithFields(logrus.Fields{
+				"cache_ttl_seconds": current.Cache.TTLSeconds,
+				"cache_route_ttls":  len(current.Cache.RouteTTLs),
+				"log_level":         current.Log.Level,
+				"api_keys":          len(current.Auth.APIKeys),
+				"rate_limit_rps":    current.RateLimit.RequestsPerSecond,
+			}).Info("config reloaded")
+		}
+	}
+}
+
+func applyLogLevel(logger *logrus.Logger, raw string) error {
+	level, err := logrus.ParseLevel(raw)
+	if err != nil {
+		return fmt.Errorf("parse LOG_LEVEL: %w", err)
+	}
+	logger.SetLevel(level)
+	return nil
+}
@@ -9,15 +9,109 @@ const docTemplate = `{
     "info": {
         "description": "{{escape .Description}}",
         "title": "{{.Title}}",
-        "contact": {},
+        "termsOfService": "http://swagger.io/terms/",
+        "contact": {
+            "name": "API Support",
+            "url": "http://www.swagger.io/support",
+            "email": "support@swagger.io"
+        },
+        "license": {
+            "name": "Apache 2.0",
+            "url": "http://www.apache.org/licenses/LICENSE-2.0.html"
+        },
         "version": "{{.Version}}"
     },
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/config": {
+            "get": {
+                "description": "Get the configuration the server is running with, including values reloaded on SIGHUP. Passwords in the database and RabbitMQ URLs are replaced with xxxxx; the Redis password, admin token and API keys are never returned. Requires ADMIN_TOKEN as a bearer token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get effective configuration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer admin token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.adminConfigView"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/pool-stats": {
+            "get": {
+                "description": "Get the utilization of each Postgres connection pool, keyed by pool name (instruments, marketdata): connections by state, how often a connection was acquired and how long that took, and how often a caller had to wait for one. Counts and durations are totals since startup. Requires ADMIN_TOKEN as a bearer token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get Postgres pool statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer admin token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/internal_interfaces_http.poolStatsView"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/instruments": {
             "get": {
-                "description": "Get a financial instrument by UID",
+                "description": "Get a financial instrument by UID. Without uid but with sector or country, it answers like GET /instruments/list with the same query.",
                 "consumes": [
                     "application/json"
                 ],
@@ -35,6 +129,12 @@ const docTemplate = `{
                         "name": "uid",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also return a soft-deleted instrument",
+                        "name": "include_deleted",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -47,19 +147,19 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
@@ -97,19 +197,19 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
@@ -147,25 +247,19 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Delete a financial instrument by UID",
+                "description": "Soft-delete a financial instrument by UID by setting deleted_at; with hard=true the row is removed",
                 "consumes": [
                     "application/json"
                 ],
@@ -183,6 +277,12 @@ const docTemplate = `{
                         "name": "uid",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Remove the row instead of setting deleted_at",
+                        "name": "hard",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -192,20 +292,75 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/instruments/batch-get": {
+            "post": {
+                "description": "Get the instruments of up to INSTRUMENTS_BATCH_GET_MAX UIDs in one request. Found instruments come in the order of the request; UIDs without an instrument are listed in missing. A repeated UID is answered once. Like other reads it needs no API key unless API_KEY_PROTECT_READS is set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "instruments"
+                ],
+                "summary": "Batch get instruments",
+                "parameters": [
+                    {
+                        "description": "Instrument UIDs",
+                        "name": "uids",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
                                 "type": "string"
                             }
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also return soft-deleted instruments",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.batchGetView"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
                     }
                 }
             }
@@ -244,19 +399,19 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
@@ -294,19 +449,13 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
@@ -332,6 +481,12 @@ const docTemplate = `{
                         "name": "uid",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also return a soft-deleted instrument",
+                        "name": "include_deleted",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -345,25 +500,25 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Delete a bond instrument by UID",
+                "description": "Delete a bond instrument by UID. Soft delete by default; hard=true removes the row",
                 "consumes": [
                     "application/json"
                 ],
@@ -381,6 +536,12 @@ const docTemplate = `{
                         "name": "uid",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Remove the row instead of setting deleted_at",
+                        "name": "hard",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -390,19 +551,87 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/instruments/by-ticker": {
+            "get": {
+                "description": "Resolve a ticker, e.g. SBER, to the instrument and its type. A ticker traded on several boards is ambiguous without class_code and answers 409 listing the class codes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "instruments"
+                ],
+                "summary": "Get instrument by ticker",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Exact ticker",
+                        "name": "ticker",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Class code of the board, e.g. TQBR",
+                        "name": "class_code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also match soft-deleted instruments",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main_internal_domain_entity_instruments.ListedInstrument"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
@@ -442,19 +671,19 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
@@ -492,19 +721,13 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
@@ -530,6 +753,12 @@ const docTemplate = `{
                         "name": "uid",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also return a soft-deleted instrument",
+                        "name": "include_deleted",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -543,25 +772,25 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Delete a currency instrument by UID",
+                "description": "Delete a currency instrument by UID. Soft delete by default; hard=true removes the row",
                 "consumes": [
                     "application/json"
                 ],
@@ -579,6 +808,12 @@ const docTemplate = `{
                         "name": "uid",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Remove the row instead of setting deleted_at",
+                        "name": "hard",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -588,19 +823,19 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
@@ -640,19 +875,19 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
@@ -690,19 +925,13 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
@@ -728,6 +957,12 @@ const docTemplate = `{
                         "name": "uid",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also return a soft-deleted instrument",
+                        "name": "include_deleted",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -741,25 +976,25 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Delete an ETF instrument by UID",
+                "description": "Delete an ETF instrument by UID. Soft delete by default; hard=true removes the row",
                 "consumes": [
                     "application/json"
                 ],
@@ -777,6 +1012,12 @@ const docTemplate = `{
                         "name": "uid",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Remove the row instead of setting deleted_at",
+                        "name": "hard",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -786,19 +1027,19 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
@@ -838,19 +1079,19 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
@@ -888,19 +1129,13 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
@@ -926,6 +1161,12 @@ const docTemplate = `{
                         "name": "uid",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also return a soft-deleted instrument",
+                        "name": "include_deleted",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -939,25 +1180,25 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Delete a future instrument by UID",
+                "description": "Delete a future instrument by UID. Soft delete by default; hard=true removes the row",
                 "consumes": [
                     "application/json"
                 ],
@@ -975,6 +1216,12 @@ const docTemplate = `{
                         "name": "uid",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Remove the row instead of setting deleted_at",
+                        "name": "hard",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -984,27 +1231,27 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
             }
         },
-        "/instruments/shares": {
-            "put": {
-                "description": "Update a share instrument and its base data",
+        "/instruments/list": {
+            "get": {
+                "description": "List instruments ordered by ticker, optionally filtered. Soft-deleted instruments are left out unless include_deleted is set.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1012,49 +1259,109 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "shares"
+                    "instruments"
                 ],
-                "summary": "Update share",
+                "summary": "List instruments",
                 "parameters": [
                     {
-                        "description": "Share data with UID",
-                        "name": "share",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/internal_interfaces_http.sharePayload"
-                        }
+                        "type": "string",
+                        "description": "Exact ticker",
+                        "name": "ticker",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exact class code",
+                        "name": "class_code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "FIGI prefix",
+                        "name": "figi",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "share",
+                            "bond",
+                            "future",
+                            "currency",
+                            "etf"
+                        ],
+                        "type": "string",
+                        "description": "Instrument type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sector UID of the instrument's brand",
+                        "name": "sector",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Country of risk of the instrument's brand, ISO 3166 alpha-2 or alpha-3",
+                        "name": "country",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include soft-deleted instruments",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of instruments to skip",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main_internal_domain_entity_instruments.Share"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main_internal_domain_entity_instruments.ListedInstrument"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Offset": {
+                                "type": "integer",
+                                "description": "Offset of the next page; absent on the last page"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Create a share instrument along with its base instrument record",
+            }
+        },
+        "/instruments/price": {
+            "get": {
+                "description": "Convert a price in points to the instrument's currency with the rule of its type: bonds are quoted in percent of the nominal, futures in min price increments worth min_price_increment_amount each, shares, currencies and ETFs per unit and multiplied by the lot.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1062,51 +1369,62 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "shares"
+                    "instruments"
                 ],
-                "summary": "Create share",
+                "summary": "Get instrument price",
                 "parameters": [
                     {
-                        "description": "Share data",
-                        "name": "share",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/internal_interfaces_http.sharePayload"
-                        }
+                        "type": "string",
+                        "description": "Instrument UID",
+                        "name": "uid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Price in points",
+                        "name": "points",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also price a soft-deleted instrument",
+                        "name": "include_deleted",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main_internal_domain_entity_instruments.Share"
+                            "$ref": "#/definitions/main_internal_application_service_instruments.InstrumentPrice"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
             }
         },
-        "/instruments/shares/{uid}": {
+        "/instruments/search": {
             "get": {
-                "description": "Get a share instrument by UID",
+                "description": "Find instruments whose ticker, brand name or company name contains q, case-insensitively. Matches are ranked: an exact ticker first, then tickers starting with q, tickers containing q, brand or company names starting with q and the other name matches; within a rank shorter tickers come first. MatchedOn names the field that matched. Soft-deleted instruments are left out unless include_deleted is set.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1114,48 +1432,73 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "shares"
+                    "instruments"
                 ],
-                "summary": "Get share",
+                "summary": "Search instruments",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Share UID",
-                        "name": "uid",
-                        "in": "path",
+                        "description": "Text to look for, 1 to 100 characters",
+                        "name": "q",
+                        "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include soft-deleted instruments",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of matches to skip",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main_internal_domain_entity_instruments.InstrumentMatch"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Offset": {
+                                "type": "integer",
+                                "description": "Offset of the next page; absent on the last page"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a share instrument by UID",
+            }
+        },
+        "/instruments/shares": {
+            "put": {
+                "description": "Update a share instrument and its base data",
                 "consumes": [
                     "application/json"
                 ],
@@ -1165,44 +1508,47 @@ const docTemplate = `{
                 "tags": [
                     "shares"
                 ],
-                "summary": "Delete share",
+                "summary": "Update share",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Share UID",
-                        "name": "uid",
-                        "in": "path",
-                        "required": true
-                    }
+                        "description": "Share data with UID",
+                        "name": "share",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.sharePayload"
+                        }
+                    }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main_internal_domain_entity_instruments.Share"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/marketdata/candles": {
-            "get": {
-                "description": "Get candles for an instrument within a time range",
+            },
+            "post": {
+                "description": "Create a share instrument along with its base instrument record",
                 "consumes": [
                     "application/json"
                 ],
@@ -1210,71 +1556,100 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "candles"
+                    "shares"
                 ],
-                "summary": "Get candles range",
+                "summary": "Create share",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Instrument UID",
-                        "name": "instrument_uid",
-                        "in": "query",
-                        "required": true
+                        "description": "Share data",
+                        "name": "share",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.sharePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main_internal_domain_entity_instruments.Share"
+                        }
                     },
-                    {
-                        "type": "integer",
-                        "description": "Candle interval in seconds",
-                        "name": "interval_seconds",
-                        "in": "query",
-                        "required": true
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
                     },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/instruments/shares/{uid}": {
+            "get": {
+                "description": "Get a share instrument by UID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "Get share",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Start time (RFC3339)",
-                        "name": "from",
-                        "in": "query",
+                        "description": "Share UID",
+                        "name": "uid",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "End time (RFC3339)",
-                        "name": "to",
-                        "in": "query",
-                        "required": true
+                        "type": "boolean",
+                        "description": "Also return a soft-deleted instrument",
+                        "name": "include_deleted",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.Candle"
-                            }
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
             },
-            "post": {
-                "description": "Add a single candle record",
+            "delete": {
+                "description": "Delete a share instrument by UID. Soft delete by default; hard=true removes the row",
                 "consumes": [
                     "application/json"
                 ],
@@ -1282,48 +1657,52 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "candles"
+                    "shares"
                 ],
-                "summary": "Add candle",
+                "summary": "Delete share",
                 "parameters": [
                     {
-                        "description": "Candle data",
-                        "name": "candle",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/main_internal_domain_entity_marketdata.Candle"
-                        }
+                        "type": "string",
+                        "description": "Share UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Remove the row instead of setting deleted_at",
+                        "name": "hard",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created"
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
             }
         },
-        "/marketdata/candles/batch": {
-            "post": {
-                "description": "Add multiple candle records in a single request",
+        "/instruments/upsert": {
+            "put": {
+                "description": "Create the instrument, or update the one with the same figi that is not deleted. The update keeps the stored uid, created_at and deleted_at. A uid in the payload is optional; one that differs from the stored instrument's, or belongs to another instrument, answers 409 with UID_CONFLICT and changes nothing. With restore=true and no live instrument of the figi, a soft-deleted one is restored and updated instead of creating a new one.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1331,128 +1710,121 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "candles"
+                    "instruments"
                 ],
-                "summary": "Add candles batch",
+                "summary": "Upsert instrument",
                 "parameters": [
                     {
-                        "description": "Array of candle data",
-                        "name": "candles",
+                        "description": "Instrument data with figi",
+                        "name": "instrument",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.Candle"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.instrumentPayload"
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Restore a soft-deleted instrument of the figi",
+                        "name": "restore",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main_internal_domain_entity_instruments.Instrument"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
             }
         },
-        "/marketdata/candles/last": {
+        "/instruments/{uid}/details": {
             "get": {
-                "description": "Get the last N candles for an instrument",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get an instrument of any type with its typed fields and its brand, company, sector and country. type is empty and instrument holds only the base fields when the instrument has no typed row; brand is null when it has no brand.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "candles"
+                    "instruments"
                 ],
-                "summary": "Get last candles",
+                "summary": "Get instrument details",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "Instrument UID",
-                        "name": "instrument_uid",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Candle interval in seconds",
-                        "name": "interval_seconds",
-                        "in": "query",
+                        "name": "uid",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Number of candles to retrieve",
-                        "name": "limit",
-                        "in": "query",
-                        "required": true
+                        "type": "boolean",
+                        "description": "Also return a soft-deleted instrument",
+                        "name": "include_deleted",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.Candle"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.instrumentDetailsView"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
             }
         },
-        "/marketdata/orderbooks": {
+        "/marketdata/candles": {
             "get": {
-                "description": "Get order book snapshots for an instrument within a time range",
+                "description": "Get candles for an instrument within a time range. With layout=columnar the response is a columnarCandles object of parallel arrays instead of an array of candles. The window may span at most RANGE_QUERY_MAX_DAYS unless the admin token is sent as a bearer token.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv"
                 ],
                 "tags": [
-                    "orderbooks"
+                    "candles"
                 ],
-                "summary": "Get order books range",
+                "summary": "Get candles range",
                 "parameters": [
                     {
                         "type": "string",
@@ -1463,58 +1835,116 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "Order book depth",
-                        "name": "depth",
+                        "format": "int64",
+                        "description": "Candle interval in seconds",
+                        "name": "interval_seconds",
                         "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Start time (RFC3339)",
+                        "description": "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given",
                         "name": "from",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Start time in Unix milliseconds, instead of from",
+                        "name": "from_unix_ms",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "End time (RFC3339)",
+                        "description": "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given",
                         "name": "to",
-                        "in": "query",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.OrderBookSnapshot"
-                            }
-                        }
+                        "in": "query"
                     },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                    {
+                        "type": "integer",
+                        "description": "End time in Unix milliseconds, instead of to",
+                        "name": "to_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "objects",
+                            "columnar"
+                        ],
+                        "type": "string",
+                        "default": "objects",
+                        "description": "Response layout",
+                        "name": "layout",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Response format; csv streams the range as CSV, also selected by Accept: text/csv",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 10000,
+                        "type": "integer",
+                        "default": 1000,
+                        "description": "Page size; a CSV export without limit returns the whole range",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of candles to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Metadata key=value the rows must contain; repeat to require several",
+                        "name": "meta",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.Candle"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Offset": {
+                                "type": "integer",
+                                "description": "Offset of the next page; absent on the last page and on CSV exports"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Add a single order book snapshot",
+                "description": "Add a single candle record",
                 "consumes": [
                     "application/json"
                 ],
@@ -1522,48 +1952,126 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "orderbooks"
+                    "candles"
                 ],
-                "summary": "Add order book",
+                "summary": "Add candle",
                 "parameters": [
                     {
-                        "description": "Order book snapshot data",
-                        "name": "orderbook",
+                        "description": "Candle data",
+                        "name": "candle",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main_internal_domain_entity_marketdata.OrderBookSnapshot"
+                            "$ref": "#/definitions/main_internal_domain_entity_marketdata.Candle"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Created"
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main_internal_domain_entity_marketdata.Candle"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the stored candle"
+                            }
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete an instrument's candles of every interval starting before before, or within from/to (bounds included). Rows are removed in chunks of RETENTION_DELETE_CHUNK_SIZE.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "candles"
+                ],
+                "summary": "Delete candles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Instrument UID",
+                        "name": "instrument_uid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delete candles whose period started before this time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds)",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix milliseconds form of before",
+                        "name": "before_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds), with to instead of before",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix milliseconds form of from",
+                        "name": "from_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds), with from instead of before",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix milliseconds form of to",
+                        "name": "to_unix_ms",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.deleteResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
             }
         },
-        "/marketdata/orderbooks/batch": {
+        "/marketdata/candles/batch": {
             "post": {
-                "description": "Add multiple order book snapshots in a single request",
+                "description": "Add multiple candle records in a single request. With validate_only=true the candles are only validated and a report of the failing ones is returned; nothing is stored.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1571,51 +2079,66 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "orderbooks"
+                    "candles"
                 ],
-                "summary": "Add order books batch",
+                "summary": "Add candles batch",
                 "parameters": [
                     {
-                        "description": "Array of order book snapshot data",
-                        "name": "orderbooks",
+                        "description": "Array of candle data",
+                        "name": "candles",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.OrderBookSnapshot"
+                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.Candle"
                             }
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Validate without storing",
+                        "name": "validate_only",
+                        "in": "query"
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.validationReport"
+                        }
+                    },
                     "201": {
-                        "description": "Created"
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.batchResult"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
             }
         },
-        "/marketdata/orderbooks/last": {
+        "/marketdata/candles/derived": {
             "get": {
-                "description": "Get the last N order book snapshots for an instrument",
+                "description": "Aggregate stored trades into candles of any interval. Periods start at multiples of interval_seconds since the Unix epoch, like stored candles, unless tz aligns them to a calendar, and only periods starting within [from, to] are returned. Periods without trades are skipped. Derived candles are not stored: their id is the nil UUID and metadata carries source=trades and the trade_count.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1623,9 +2146,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "orderbooks"
+                    "candles"
                 ],
-                "summary": "Get last order books",
+                "summary": "Get candles derived from trades",
                 "parameters": [
                     {
                         "type": "string",
@@ -1636,17 +2159,41 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "Order book depth",
-                        "name": "depth",
+                        "format": "int64",
+                        "description": "Candle interval in seconds",
+                        "name": "interval_seconds",
                         "in": "query",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given",
+                        "name": "from",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "Number of snapshots to retrieve",
-                        "name": "limit",
-                        "in": "query",
-                        "required": true
+                        "description": "Start time in Unix milliseconds, instead of from",
+                        "name": "from_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "End time in Unix milliseconds, instead of to",
+                        "name": "to_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA timezone (e.g. Europe/Moscow) whose midnight starts daily periods and whose Monday midnight starts weekly ones; only for interval_seconds 86400 or 604800",
+                        "name": "tz",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1655,34 +2202,28 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.OrderBookSnapshot"
+                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.Candle"
                             }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
             }
         },
-        "/marketdata/trades": {
+        "/marketdata/candles/gaps": {
             "get": {
-                "description": "Get trades for an instrument within a time range",
+                "description": "Get runs of missing candles between stored candles of an instrument. Gaps before the first or after the last stored candle in the range are not reported.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1690,9 +2231,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "trades"
+                    "candles"
                 ],
-                "summary": "Get trades range",
+                "summary": "Get candle gaps",
                 "parameters": [
                     {
                         "type": "string",
@@ -1702,18 +2243,36 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Start time (RFC3339)",
-                        "name": "from",
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Candle interval in seconds",
+                        "name": "interval_seconds",
                         "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "End time (RFC3339)",
+                        "description": "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Start time in Unix milliseconds, instead of from",
+                        "name": "from_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given",
                         "name": "to",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "End time in Unix milliseconds, instead of to",
+                        "name": "to_unix_ms",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1722,32 +2281,28 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.Trade"
+                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.CandleGap"
                             }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Add a single trade record",
+            }
+        },
+        "/marketdata/candles/last": {
+            "get": {
+                "description": "Get the last N candles for an instrument",
                 "consumes": [
                     "application/json"
                 ],
@@ -1755,48 +2310,1739 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "trades"
+                    "candles"
                 ],
-                "summary": "Add trade",
+                "summary": "Get last candles",
                 "parameters": [
                     {
-                        "description": "Trade data",
-                        "name": "trade",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/main_internal_domain_entity_marketdata.Trade"
-                        }
-                    }
-                ],
-                "responses": {
-                    "201": {
-                        "description": "Created"
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
+                        "type": "string",
+                        "description": "Instrument UID",
+                        "name": "instrument_uid",
+                        "in": "query",
+                        "required": true
                     },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Candle interval in seconds",
+                        "name": "interval_seconds",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of candles to retrieve",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Newest first, or the same candles oldest first",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.Candle"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketdata/candles/resampled": {
+            "get": {
+                "description": "Merge stored candles of base_interval_seconds into candles of target_interval_seconds, which must be a multiple of it: open of the first, highest high, lowest low, close of the last and summed volume. Periods start at multiples of the target interval since the Unix epoch, unless tz aligns them to a calendar, and only periods starting within [from, to] are returned. Resampled candles are not stored: their id is the nil UUID and metadata carries source=candles, the base_interval_seconds and the candle_count merged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "candles"
+                ],
+                "summary": "Get resampled candles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Instrument UID",
+                        "name": "instrument_uid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Interval of the stored candles in seconds",
+                        "name": "base_interval_seconds",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Interval of the returned candles in seconds",
+                        "name": "target_interval_seconds",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Start time in Unix milliseconds, instead of from",
+                        "name": "from_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "End time in Unix milliseconds, instead of to",
+                        "name": "to_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA timezone (e.g. Europe/Moscow) whose midnight starts daily periods and whose Monday midnight starts weekly ones; only for target_interval_seconds 86400 or 604800",
+                        "name": "tz",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.Candle"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketdata/candles/sse": {
+            "get": {
+                "description": "Emits every newly stored candle of the instrument and interval as an SSE data event whose id is the candle's period_start in unix milliseconds. A client resuming with Last-Event-ID is first sent the candles stored after that id; when more were missed than one page, a \"gap\" event names the range to fetch from /marketdata/candles/. A \"close\" event is sent before the server ends the stream because the client fell behind or the server is shutting down.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "candles"
+                ],
+                "summary": "Stream candle updates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Instrument UID",
+                        "name": "instrument_uid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Candle interval in seconds",
+                        "name": "interval_seconds",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Id of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main_internal_domain_entity_marketdata.Candle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketdata/candles/summary": {
+            "get": {
+                "description": "Get the number of stored candles of an instrument and interval, the first and last period start, the lowest low, the highest high and the total volume. Without candles the count is zero and the periods and prices are null.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "candles"
+                ],
+                "summary": "Get candle summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Instrument UID",
+                        "name": "instrument_uid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Candle interval in seconds",
+                        "name": "interval_seconds",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main_internal_domain_entity_marketdata.CandleSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketdata/candles/{id}": {
+            "get": {
+                "description": "Get a candle by its id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "candles"
+                ],
+                "summary": "Get candle",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Candle ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main_internal_domain_entity_marketdata.Candle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketdata/orderbooks": {
+            "get": {
+                "description": "Get order book snapshots for an instrument within a time range. The window may span at most RANGE_QUERY_MAX_DAYS unless the admin token is sent as a bearer token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "orderbooks"
+                ],
+                "summary": "Get order books range",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Instrument UID",
+                        "name": "instrument_uid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Order book depth",
+                        "name": "depth",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "at_least",
+                            "exact"
+                        ],
+                        "type": "string",
+                        "default": "at_least",
+                        "description": "Match snapshots stored at this depth or deeper (truncated), or only at this depth",
+                        "name": "depth_match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Start time in Unix milliseconds, instead of from",
+                        "name": "from_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "End time in Unix milliseconds, instead of to",
+                        "name": "to_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Set sequence_gap on snapshots that do not follow the previous sequence",
+                        "name": "flag_gaps",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Response format; csv streams the range as CSV with bids and asks as JSON columns, also selected by Accept: text/csv",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 10000,
+                        "type": "integer",
+                        "default": 1000,
+                        "description": "Page size; a CSV export without limit returns the whole range",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of snapshots to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Metadata key=value the rows must contain; repeat to require several",
+                        "name": "meta",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.OrderBookSnapshot"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Offset": {
+                                "type": "integer",
+                                "description": "Offset of the next page; absent on the last page and on CSV exports"
+                            },
+                            "X-Orderbook-Depth": {
+                                "type": "integer",
+                                "description": "Depth actually served when it differs from the requested one"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a single order book snapshot",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orderbooks"
+                ],
+                "summary": "Add order book",
+                "parameters": [
+                    {
+                        "description": "Order book snapshot data",
+                        "name": "orderbook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main_internal_domain_entity_marketdata.OrderBookSnapshot"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main_internal_domain_entity_marketdata.OrderBookSnapshot"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the stored snapshot"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete an instrument's order book snapshots of every depth taken before before, or within from/to (bounds included). Rows are removed in chunks of RETENTION_DELETE_CHUNK_SIZE.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orderbooks"
+                ],
+                "summary": "Delete order books",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Instrument UID",
+                        "name": "instrument_uid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delete snapshots taken before this time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds)",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix milliseconds form of before",
+                        "name": "before_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds), with to instead of before",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix milliseconds form of from",
+                        "name": "from_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds), with from instead of before",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix milliseconds form of to",
+                        "name": "to_unix_ms",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.deleteResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketdata/orderbooks/batch": {
+            "post": {
+                "description": "Add multiple order book snapshots in a single request. With validate_only=true the snapshots are only validated and a report of the failing ones is returned; nothing is stored.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orderbooks"
+                ],
+                "summary": "Add order books batch",
+                "parameters": [
+                    {
+                        "description": "Array of order book snapshot data",
+                        "name": "orderbooks",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.OrderBookSnapshot"
+                            }
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Validate without storing",
+                        "name": "validate_only",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.validationReport"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.batchResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketdata/orderbooks/imbalance": {
+            "get": {
+                "description": "Get (bid quantity - ask quantity) / (bid quantity + ask quantity) of each order book snapshot for an instrument within a time range, in ascending time order. Quantities are summed over the first levels levels of each side, or all stored levels without levels. Of snapshots stored at several depths at the same instant the deepest is used. A snapshot with bids only has imbalance 1, one with asks only -1, and one with neither null.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orderbooks"
+                ],
+                "summary": "Get order book imbalance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Instrument UID",
+                        "name": "instrument_uid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Levels per side to count, from the best price; all stored levels if omitted",
+                        "name": "levels",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Start time in Unix milliseconds, instead of from",
+                        "name": "from_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "End time in Unix milliseconds, instead of to",
+                        "name": "to_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 10000,
+                        "type": "integer",
+                        "default": 1000,
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of snapshots to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.OrderBookImbalance"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Offset": {
+                                "type": "integer",
+                                "description": "Offset of the next page; absent on the last page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketdata/orderbooks/last": {
+            "get": {
+                "description": "Get the last N order book snapshots for an instrument",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orderbooks"
+                ],
+                "summary": "Get last order books",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Instrument UID",
+                        "name": "instrument_uid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Order book depth",
+                        "name": "depth",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "at_least",
+                            "exact"
+                        ],
+                        "type": "string",
+                        "default": "at_least",
+                        "description": "Match snapshots stored at this depth or deeper (truncated), or only at this depth",
+                        "name": "depth_match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of snapshots to retrieve",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Set sequence_gap on snapshots that do not follow the previous sequence",
+                        "name": "flag_gaps",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Newest first, or the same snapshots oldest first",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.OrderBookSnapshot"
+                            }
+                        },
+                        "headers": {
+                            "X-Orderbook-Depth": {
+                                "type": "integer",
+                                "description": "Depth actually served when it differs from the requested one"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketdata/orderbooks/spread": {
+            "get": {
+                "description": "Get the best bid, best ask and spread of each order book snapshot for an instrument within a time range, in ascending time order, without the full books. A side without levels is null, and so is the spread.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orderbooks"
+                ],
+                "summary": "Get order book spread",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Instrument UID",
+                        "name": "instrument_uid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Start time in Unix milliseconds, instead of from",
+                        "name": "from_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "End time in Unix milliseconds, instead of to",
+                        "name": "to_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 10000,
+                        "type": "integer",
+                        "default": 1000,
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of snapshots to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.TopOfBook"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Offset": {
+                                "type": "integer",
+                                "description": "Offset of the next page; absent on the last page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketdata/orderbooks/spread-series": {
+            "get": {
+                "description": "Get the average best-ask minus best-bid spread and mid price per time bucket for an instrument. Buckets are aligned to the Unix epoch, returned in ascending order, and empty buckets are included with null averages. One-sided snapshots are skipped.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orderbooks"
+                ],
+                "summary": "Get order book spread series",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Instrument UID",
+                        "name": "instrument_uid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Order book depth",
+                        "name": "depth",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Start time in Unix milliseconds, instead of from",
+                        "name": "from_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "End time in Unix milliseconds, instead of to",
+                        "name": "to_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Bucket width in seconds",
+                        "name": "bucket_seconds",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.SpreadBucket"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketdata/orderbooks/summary": {
+            "get": {
+                "description": "Get the number of stored order book snapshots of an instrument across all depths, the first and last snapshot time and the depths stored. Without snapshots the count is zero, the times are null and depths is empty.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orderbooks"
+                ],
+                "summary": "Get order book summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Instrument UID",
+                        "name": "instrument_uid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main_internal_domain_entity_marketdata.OrderBookSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketdata/orderbooks/{id}": {
+            "get": {
+                "description": "Get an order book snapshot by its id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orderbooks"
+                ],
+                "summary": "Get order book",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Snapshot ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main_internal_domain_entity_marketdata.OrderBookSnapshot"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketdata/price/last": {
+            "get": {
+                "description": "Get the price of the newest trade of an instrument with the time it traded at, so a client can judge how stale it is. With REDIS_ADDR set the price is read from the Redis hash the broker consumer keeps up to date (source cache), falling back to the newest stored trade (source database). The response is never served from the response cache.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trades"
+                ],
+                "summary": "Get last price",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Instrument UID",
+                        "name": "instrument_uid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main_internal_domain_entity_marketdata.LastPrice"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketdata/trades": {
+            "get": {
+                "description": "Get trades for an instrument within a time range. The window may span at most RANGE_QUERY_MAX_DAYS unless the admin token is sent as a bearer token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "trades"
+                ],
+                "summary": "Get trades range",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Instrument UID",
+                        "name": "instrument_uid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Start time in Unix milliseconds, instead of from",
+                        "name": "from_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "End time in Unix milliseconds, instead of to",
+                        "name": "to_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only trades executed on this board (e.g. TQBR)",
+                        "name": "venue",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Response format; csv streams the range as CSV, also selected by Accept: text/csv",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 10000,
+                        "type": "integer",
+                        "default": 1000,
+                        "description": "Page size; a CSV export without limit returns the whole range",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of trades to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Metadata key=value the rows must contain; repeat to require several",
+                        "name": "meta",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.Trade"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Offset": {
+                                "type": "integer",
+                                "description": "Offset of the next page; absent on the last page and on CSV exports"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a single trade record",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trades"
+                ],
+                "summary": "Add trade",
+                "parameters": [
+                    {
+                        "description": "Trade data",
+                        "name": "trade",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main_internal_domain_entity_marketdata.Trade"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main_internal_domain_entity_marketdata.Trade"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the stored trade"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete an instrument's trades older than before, or within from/to (bounds included). Rows are removed in chunks of RETENTION_DELETE_CHUNK_SIZE.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trades"
+                ],
+                "summary": "Delete trades",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Instrument UID",
+                        "name": "instrument_uid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delete trades executed before this time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds)",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix milliseconds form of before",
+                        "name": "before_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds), with to instead of before",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix milliseconds form of from",
+                        "name": "from_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds), with from instead of before",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix milliseconds form of to",
+                        "name": "to_unix_ms",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.deleteResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketdata/trades/activity": {
+            "get": {
+                "description": "Get trade count and volume per time bucket for an instrument. Buckets are aligned to the Unix epoch, returned in ascending order, and empty buckets are included with zeros.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trades"
+                ],
+                "summary": "Get trade activity",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Instrument UID",
+                        "name": "instrument_uid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Start time in Unix milliseconds, instead of from",
+                        "name": "from_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "End time in Unix milliseconds, instead of to",
+                        "name": "to_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Bucket width in seconds",
+                        "name": "bucket_seconds",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.TradeActivityBucket"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketdata/trades/adv": {
+            "get": {
+                "description": "Get the average daily traded volume of an instrument over the last days complete calendar days in the market timezone (MARKET_TIMEZONE). The current day is excluded. The average is taken over the days with at least one trade, and the per-day volumes are returned alongside. A window without trades is answered with 404 NO_TRADES.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trades"
+                ],
+                "summary": "Get average daily volume",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Instrument UID",
+                        "name": "instrument_uid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maximum": 365,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Number of trailing calendar days",
+                        "name": "days",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main_internal_domain_entity_marketdata.ADV"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketdata/trades/batch": {
+            "post": {
+                "description": "Add multiple trade records in a single request. With validate_only=true the trades are only validated and a report of the failing ones is returned; nothing is stored.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trades"
+                ],
+                "summary": "Add trades batch",
+                "parameters": [
+                    {
+                        "description": "Array of trade data",
+                        "name": "trades",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.Trade"
+                            }
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Validate without storing",
+                        "name": "validate_only",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.validationReport"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.batchResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketdata/trades/last": {
+            "get": {
+                "description": "Get the last N trades for an instrument",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trades"
+                ],
+                "summary": "Get last trades",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Instrument UID",
+                        "name": "instrument_uid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of trades to retrieve",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only trades executed on this board (e.g. TQBR)",
+                        "name": "venue",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Newest first, or the same trades oldest first",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.Trade"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
                     }
                 }
             }
         },
-        "/marketdata/trades/batch": {
-            "post": {
-                "description": "Add multiple trade records in a single request",
+        "/marketdata/trades/stream": {
+            "get": {
+                "description": "Upgrades to a WebSocket and pushes every new trade of the subscribed instruments as {\"type\":\"trade\",\"trade\":{...}}. Send {\"action\":\"subscribe\"|\"unsubscribe\",\"instrument_uids\":[...]} to change the subscription; the server answers with the current instrument_uids. A client that falls too far behind is closed with status 1013, and server shutdown closes sockets with 1001.",
+                "tags": [
+                    "trades"
+                ],
+                "summary": "Stream live trades",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Instrument UIDs, repeated or comma-separated",
+                        "name": "instrument_uid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.streamMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketdata/trades/summary": {
+            "get": {
+                "description": "Get the number of stored trades of an instrument, the first and last trade time, the lowest and highest price and the total volume. An instrument without trades gets a zero count and null times and prices.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1806,49 +4052,41 @@ const docTemplate = `{
                 "tags": [
                     "trades"
                 ],
-                "summary": "Add trades batch",
+                "summary": "Get trade summary",
                 "parameters": [
                     {
-                        "description": "Array of trade data",
-                        "name": "trades",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.Trade"
-                            }
-                        }
+                        "type": "string",
+                        "description": "Instrument UID",
+                        "name": "instrument_uid",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main_internal_domain_entity_marketdata.TradeSummary"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     }
                 }
             }
         },
-        "/marketdata/trades/last": {
+        "/marketdata/trades/vwap": {
             "get": {
-                "description": "Get the last N trades for an instrument",
+                "description": "Get the volume-weighted average price, SUM(price*quantity_lots)/SUM(quantity_lots), of an instrument's trades within a time range, with the total volume and trade count. A range without traded volume is answered with 404 NO_TRADES.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1858,7 +4096,7 @@ const docTemplate = `{
                 "tags": [
                     "trades"
                 ],
-                "summary": "Get last trades",
+                "summary": "Get trade VWAP",
                 "parameters": [
                     {
                         "type": "string",
@@ -1867,11 +4105,75 @@ const docTemplate = `{
                         "in": "query",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given",
+                        "name": "from",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "Number of trades to retrieve",
-                        "name": "limit",
-                        "in": "query",
+                        "description": "Start time in Unix milliseconds, instead of from",
+                        "name": "from_unix_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "End time in Unix milliseconds, instead of to",
+                        "name": "to_unix_ms",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main_internal_domain_entity_marketdata.VWAP"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/marketdata/trades/{id}": {
+            "get": {
+                "description": "Get a trade by its id, e.g. the one in the Location header of a created trade",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trades"
+                ],
+                "summary": "Get trade",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Trade ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -1879,41 +4181,457 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/main_internal_domain_entity_marketdata.Trade"
-                            }
+                            "$ref": "#/definitions/main_internal_domain_entity_marketdata.Trade"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
+                            "$ref": "#/definitions/internal_interfaces_http.errorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "internal_interfaces_http.adminCacheRouteTTL": {
+            "type": "object",
+            "properties": {
+                "pattern": {
+                    "type": "string"
+                },
+                "ttl_seconds": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_interfaces_http.adminConfigView": {
+            "type": "object",
+            "properties": {
+                "auth": {
+                    "description": "Auth reports how many API keys are configured, never the keys.",
+                    "type": "object",
+                    "properties": {
+                        "api_keys": {
+                            "type": "integer"
+                        },
+                        "protect_reads": {
+                            "type": "boolean"
+                        }
+                    }
+                },
+                "cache": {
+                    "type": "object",
+                    "properties": {
+                        "enabled": {
+                            "type": "boolean"
+                        },
+                        "route_ttls": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/internal_interfaces_http.adminCacheRouteTTL"
+                            }
+                        },
+                        "ttl_seconds": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "debug_errors": {
+                    "type": "boolean"
+                },
+                "env": {
+                    "type": "string"
+                },
+                "grpc_addr": {
+                    "description": "GRPCAddr is empty when the gRPC server is disabled.",
+                    "type": "string"
+                },
+                "gzip": {
+                    "type": "object",
+                    "properties": {
+                        "enabled": {
+                            "type": "boolean"
+                        },
+                        "min_bytes": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "http_addr": {
+                    "type": "string"
+                },
+                "ingest": {
+                    "type": "object",
+                    "properties": {
+                        "candle_dedup": {
+                            "type": "boolean"
+                        },
+                        "check_instruments": {
+                            "type": "boolean"
+                        },
+                        "crossed_book": {
+                            "type": "string"
+                        },
+                        "instrument_ttl_seconds": {
+                            "type": "integer"
+                        },
+                        "on_conflict": {
+                            "type": "string"
+                        },
+                        "require_sorted_book": {
+                            "type": "boolean"
+                        }
+                    }
+                },
+                "instrument_query": {
+                    "type": "object",
+                    "properties": {
+                        "batch_get_max": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "log_level": {
+                    "type": "string"
+                },
+                "market": {
+                    "type": "object",
+                    "properties": {
+                        "timezone": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "max_body_bytes": {
+                    "type": "integer"
+                },
+                "metadata": {
+                    "type": "object",
+                    "properties": {
+                        "max_bytes": {
+                            "type": "integer"
+                        },
+                        "max_depth": {
+                            "type": "integer"
+                        },
+                        "max_keys": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "orderbook_query": {
+                    "type": "object",
+                    "properties": {
+                        "depth_fallback": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "orderbook_throttle": {
+                    "type": "object",
+                    "properties": {
+                        "min_interval_ms": {
+                            "type": "integer"
+                        },
+                        "overrides_ms": {
                             "type": "object",
                             "additionalProperties": {
+                                "type": "integer",
+                                "format": "int64"
+                            }
+                        }
+                    }
+                },
+                "outbound_http": {
+                    "type": "object",
+                    "properties": {
+                        "dial_timeout_seconds": {
+                            "type": "integer"
+                        },
+                        "max_idle_conns": {
+                            "type": "integer"
+                        },
+                        "max_idle_conns_per_host": {
+                            "type": "integer"
+                        },
+                        "response_header_timeout_seconds": {
+                            "type": "integer"
+                        },
+                        "timeout_seconds": {
+                            "type": "integer"
+                        },
+                        "tls_handshake_timeout_seconds": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "postgres": {
+                    "type": "object",
+                    "properties": {
+                        "dsn": {
+                            "type": "string"
+                        },
+                        "max_conn_idle_time_seconds": {
+                            "type": "integer"
+                        },
+                        "max_conn_lifetime_seconds": {
+                            "type": "integer"
+                        },
+                        "max_conns": {
+                            "type": "integer"
+                        },
+                        "min_conns": {
+                            "type": "integer"
+                        },
+                        "ssl_cert": {
+                            "type": "string"
+                        },
+                        "ssl_key": {
+                            "type": "string"
+                        },
+                        "ssl_mode": {
+                            "description": "The TLS settings are omitted when the DSN's TLS setup is used.",
+                            "type": "string"
+                        },
+                        "ssl_root_cert": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "rabbitmq": {
+                    "type": "object",
+                    "properties": {
+                        "batch_size": {
+                            "type": "integer"
+                        },
+                        "batch_timeout_ms": {
+                            "type": "integer"
+                        },
+                        "bind_instruments": {
+                            "description": "BindInstruments is omitted when a topic consumer binds every instrument.",
+                            "type": "array",
+                            "items": {
                                 "type": "string"
                             }
+                        },
+                        "candles_batch_size": {
+                            "type": "integer"
+                        },
+                        "candles_batch_timeout_ms": {
+                            "type": "integer"
+                        },
+                        "candles_exchange": {
+                            "type": "string"
+                        },
+                        "dead_letter_exchange": {
+                            "type": "string"
+                        },
+                        "dial_timeout_seconds": {
+                            "type": "integer"
+                        },
+                        "exchange_type": {
+                            "type": "string"
+                        },
+                        "flush_attempts": {
+                            "type": "integer"
+                        },
+                        "flush_retry_base_ms": {
+                            "type": "integer"
+                        },
+                        "flush_workers": {
+                            "type": "integer"
+                        },
+                        "heartbeat_seconds": {
+                            "type": "integer"
+                        },
+                        "max_retries": {
+                            "type": "integer"
+                        },
+                        "orderbooks_batch_size": {
+                            "type": "integer"
+                        },
+                        "orderbooks_batch_timeout_ms": {
+                            "type": "integer"
+                        },
+                        "orderbooks_exchange": {
+                            "type": "string"
+                        },
+                        "pause_after_flush_errors": {
+                            "type": "integer"
+                        },
+                        "pause_probe_seconds": {
+                            "type": "integer"
+                        },
+                        "prefetch": {
+                            "type": "integer"
+                        },
+                        "queue_depth_interval_seconds": {
+                            "type": "integer"
+                        },
+                        "queue_depth_warn": {
+                            "type": "integer"
+                        },
+                        "reconnect_base_seconds": {
+                            "type": "integer"
+                        },
+                        "reconnect_max_seconds": {
+                            "type": "integer"
+                        },
+                        "trades_batch_size": {
+                            "description": "Batch overrides are omitted when they keep the global value.",
+                            "type": "integer"
+                        },
+                        "trades_batch_timeout_ms": {
+                            "type": "integer"
+                        },
+                        "trades_exchange": {
+                            "type": "string"
+                        },
+                        "url": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "range_query": {
+                    "type": "object",
+                    "properties": {
+                        "max_range_days": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "rate_limit": {
+                    "type": "object",
+                    "properties": {
+                        "burst": {
+                            "type": "integer"
+                        },
+                        "requests_per_second": {
+                            "type": "number"
+                        }
+                    }
+                },
+                "redis": {
+                    "type": "object",
+                    "properties": {
+                        "addr": {
+                            "type": "string"
+                        },
+                        "db": {
+                            "type": "integer"
+                        },
+                        "password_set": {
+                            "type": "boolean"
+                        }
+                    }
+                },
+                "retention": {
+                    "type": "object",
+                    "properties": {
+                        "delete_chunk_size": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "shutdown": {
+                    "type": "object",
+                    "properties": {
+                        "timeout_seconds": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "stream": {
+                    "type": "object",
+                    "properties": {
+                        "buffer": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "tracing": {
+                    "type": "object",
+                    "properties": {
+                        "endpoint": {
+                            "type": "string"
+                        },
+                        "sample_ratio": {
+                            "type": "number"
+                        },
+                        "service_name": {
+                            "type": "string"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
+        },
+        "internal_interfaces_http.backpressureReason": {
+            "type": "string",
+            "enum": [
+                "RATE_LIMITED",
+                "OVERLOADED",
+                "LOCK_TIMEOUT",
+                "DATABASE_BUSY"
+            ],
+            "x-enum-varnames": [
+                "reasonRateLimited",
+                "reasonOverloaded",
+                "reasonLockTimeout",
+                "reasonDatabaseBusy"
+            ]
+        },
+        "internal_interfaces_http.batchGetView": {
+            "type": "object",
+            "properties": {
+                "instruments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main_internal_domain_entity_instruments.Instrument"
+                    }
+                },
+                "missing": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "internal_interfaces_http.batchResult": {
+            "type": "object",
+            "properties": {
+                "inserted": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "integer"
+                }
+            }
+        },
         "internal_interfaces_http.bondPayload": {
             "type": "object",
             "properties": {
                 "aci_value": {
                     "type": "number"
                 },
+                "brand_uid": {
+                    "description": "BrandUID links the instrument to a brand; empty leaves it unlinked.",
+                    "type": "string"
+                },
                 "class_code": {
                     "type": "string"
                 },
@@ -1937,9 +4655,67 @@ const docTemplate = `{
                 }
             }
         },
+        "internal_interfaces_http.brandView": {
+            "type": "object",
+            "properties": {
+                "company": {
+                    "$ref": "#/definitions/internal_interfaces_http.companyView"
+                },
+                "country": {
+                    "$ref": "#/definitions/internal_interfaces_http.countryView"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "info": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "sector": {
+                    "$ref": "#/definitions/internal_interfaces_http.sectorView"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_interfaces_http.companyView": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_interfaces_http.countryView": {
+            "type": "object",
+            "properties": {
+                "alfa_three": {
+                    "type": "string"
+                },
+                "alfa_two": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "name_brief": {
+                    "type": "string"
+                }
+            }
+        },
         "internal_interfaces_http.currencyPayload": {
             "type": "object",
             "properties": {
+                "brand_uid": {
+                    "description": "BrandUID links the instrument to a brand; empty leaves it unlinked.",
+                    "type": "string"
+                },
                 "class_code": {
                     "type": "string"
                 },
@@ -1960,9 +4736,155 @@ const docTemplate = `{
                 }
             }
         },
+        "internal_interfaces_http.deleteResult": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_interfaces_http.errorCode": {
+            "type": "string",
+            "enum": [
+                "INVALID_REQUEST",
+                "INVALID_ID",
+                "INVALID_META_FILTER",
+                "MISSING_UID",
+                "MISSING_INSTRUMENT",
+                "INVALID_RANGE",
+                "INVALID_LIMIT",
+                "INVALID_OFFSET",
+                "INVALID_QUERY",
+                "INVALID_INSTRUMENT_TYPE",
+                "INVALID_SECTOR",
+                "INVALID_COUNTRY",
+                "INVALID_POINTS",
+                "MISSING_TICKER",
+                "MISSING_FIGI",
+                "AMBIGUOUS_TICKER",
+                "UID_CONFLICT",
+                "TOO_MANY_UIDS",
+                "NOT_PRICEABLE",
+                "INVALID_INTERVAL",
+                "INVALID_DEPTH",
+                "INVALID_LEVELS",
+                "DEPTH_UNAVAILABLE",
+                "INVALID_DEPTH_MATCH",
+                "INVALID_BUCKET",
+                "INVALID_DAYS",
+                "INVALID_TIMEZONE",
+                "INVALID_LAYOUT",
+                "INVALID_ORDER",
+                "INVALID_FORMAT",
+                "TOO_MANY_BUCKETS",
+                "RANGE_TOO_WIDE",
+                "EMPTY_PAYLOAD",
+                "METADATA_LIMIT_EXCEEDED",
+                "INVALID_TRADE",
+                "INVALID_ORDER_BOOK",
+                "CROSSED_BOOK",
+                "INSTRUMENT_NOT_FOUND",
+                "UNKNOWN_INSTRUMENT",
+                "NO_TRADES",
+                "NOT_FOUND",
+                "UNAUTHORIZED",
+                "RATE_LIMITED",
+                "PAYLOAD_TOO_LARGE",
+                "SERVICE_UNAVAILABLE",
+                "INTERNAL_ERROR"
+            ],
+            "x-enum-varnames": [
+                "codeInvalidRequest",
+                "codeInvalidID",
+                "codeInvalidMetaFilter",
+                "codeMissingUID",
+                "codeMissingInstrument",
+                "codeInvalidRange",
+                "codeInvalidLimit",
+                "codeInvalidOffset",
+                "codeInvalidQuery",
+                "codeInvalidType",
+                "codeInvalidSector",
+                "codeInvalidCountry",
+                "codeInvalidPoints",
+                "codeMissingTicker",
+                "codeMissingFigi",
+                "codeAmbiguousTicker",
+                "codeUIDConflict",
+                "codeTooManyUIDs",
+                "codeNotPriceable",
+                "codeInvalidInterval",
+                "codeInvalidDepth",
+                "codeInvalidLevels",
+                "codeDepthUnavailable",
+                "codeInvalidDepthMatch",
+                "codeInvalidBucket",
+                "codeInvalidDays",
+                "codeInvalidTimezone",
+                "codeInvalidLayout",
+                "codeInvalidOrder",
+                "codeInvalidFormat",
+                "codeTooManyBuckets",
+                "codeRangeTooWide",
+                "codeEmptyPayload",
+                "codeMetadataLimit",
+                "codeInvalidTrade",
+                "codeInvalidOrderBook",
+                "codeCrossedBook",
+                "codeInstrumentNotFound",
+                "codeUnknownInstrument",
+                "codeNoTrades",
+                "codeNotFound",
+                "codeUnauthorized",
+                "codeRateLimited",
+                "codePayloadTooLarge",
+                "codeUnavailable",
+                "codeInternal"
+            ]
+        },
+        "internal_interfaces_http.errorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "$ref": "#/definitions/internal_interfaces_http.errorCode"
+                },
+                "details": {
+                    "description": "Details carries machine-readable facts about some errors, e.g. the\nfailing index of a batch; see errorDetails.",
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "error": {
+                    "description": "Error repeats Message for clients written before it existed.",
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "Reason and RetryAfterSeconds are set on 429 and 503 refusals only.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/internal_interfaces_http.backpressureReason"
+                        }
+                    ]
+                },
+                "request_id": {
+                    "description": "RequestID matches the X-Request-ID header and the server's access log.",
+                    "type": "string"
+                },
+                "retry_after_seconds": {
+                    "type": "integer"
+                }
+            }
+        },
         "internal_interfaces_http.etfPayload": {
             "type": "object",
             "properties": {
+                "brand_uid": {
+                    "description": "BrandUID links the instrument to a brand; empty leaves it unlinked.",
+                    "type": "string"
+                },
                 "class_code": {
                     "type": "string"
                 },
@@ -1992,6 +4914,10 @@ const docTemplate = `{
                 "asset_type": {
                     "type": "string"
                 },
+                "brand_uid": {
+                    "description": "BrandUID links the instrument to a brand; empty leaves it unlinked.",
+                    "type": "string"
+                },
                 "class_code": {
                     "type": "string"
                 },
@@ -2018,9 +4944,25 @@ const docTemplate = `{
                 }
             }
         },
+        "internal_interfaces_http.instrumentDetailsView": {
+            "type": "object",
+            "properties": {
+                "brand": {
+                    "$ref": "#/definitions/internal_interfaces_http.brandView"
+                },
+                "instrument": {},
+                "type": {
+                    "$ref": "#/definitions/main_internal_domain_entity_instruments.InstrumentType"
+                }
+            }
+        },
         "internal_interfaces_http.instrumentPayload": {
             "type": "object",
             "properties": {
+                "brand_uid": {
+                    "description": "BrandUID links the instrument to a brand; empty leaves it unlinked.",
+                    "type": "string"
+                },
                 "class_code": {
                     "type": "string"
                 },
@@ -2041,9 +4983,88 @@ const docTemplate = `{
                 }
             }
         },
+        "internal_interfaces_http.poolStatsView": {
+            "type": "object",
+            "properties": {
+                "acquire_count": {
+                    "type": "integer"
+                },
+                "acquire_duration_ms": {
+                    "type": "number"
+                },
+                "acquired_conns": {
+                    "type": "integer"
+                },
+                "avg_acquire_duration_ms": {
+                    "type": "number"
+                },
+                "canceled_acquire_count": {
+                    "type": "integer"
+                },
+                "constructing_conns": {
+                    "type": "integer"
+                },
+                "empty_acquire_count": {
+                    "type": "integer"
+                },
+                "empty_acquire_wait_ms": {
+                    "type": "number"
+                },
+                "idle_conns": {
+                    "type": "integer"
+                },
+                "max_conns": {
+                    "type": "integer"
+                },
+                "max_idle_destroy_count": {
+                    "type": "integer"
+                },
+                "max_lifetime_destroy_count": {
+                    "type": "integer"
+                },
+                "new_conns_count": {
+                    "type": "integer"
+                },
+                "total_conns": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_interfaces_http.recordViolation": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "$ref": "#/definitions/internal_interfaces_http.errorCode"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_interfaces_http.sectorView": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                },
+                "volatility": {
+                    "type": "integer"
+                }
+            }
+        },
         "internal_interfaces_http.sharePayload": {
             "type": "object",
             "properties": {
+                "brand_uid": {
+                    "description": "BrandUID links the instrument to a brand; empty leaves it unlinked.",
+                    "type": "string"
+                },
                 "class_code": {
                     "type": "string"
                 },
@@ -2064,6 +5085,60 @@ const docTemplate = `{
                 }
             }
         },
+        "internal_interfaces_http.streamMessage": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "instrument_uids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "trade": {
+                    "$ref": "#/definitions/main_internal_domain_entity_marketdata.Trade"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_interfaces_http.validationReport": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_interfaces_http.recordViolation"
+                    }
+                },
+                "invalid": {
+                    "type": "integer"
+                },
+                "valid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main_internal_application_service_instruments.InstrumentPrice": {
+            "type": "object",
+            "properties": {
+                "points": {
+                    "type": "number"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "type": {
+                    "$ref": "#/definitions/main_internal_domain_entity_instruments.InstrumentType"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
         "main_internal_domain_entity_instruments.AssetType": {
             "type": "string",
             "enum": [
@@ -2079,11 +5154,91 @@ const docTemplate = `{
                 "AssetTypeCurrency"
             ]
         },
-        "main_internal_domain_entity_instruments.Bond": {
+        "main_internal_domain_entity_instruments.Bond": {
+            "type": "object",
+            "properties": {
+                "aciValue": {
+                    "type": "number",
+                    "format": "float64"
+                },
+                "brandUID": {
+                    "type": "string"
+                },
+                "classCode": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "figi": {
+                    "type": "string"
+                },
+                "logoURL": {
+                    "type": "string"
+                },
+                "lot": {
+                    "type": "integer",
+                    "format": "int32"
+                },
+                "nominal": {
+                    "type": "number",
+                    "format": "float64"
+                },
+                "ticker": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "main_internal_domain_entity_instruments.Currency": {
+            "type": "object",
+            "properties": {
+                "brandUID": {
+                    "type": "string"
+                },
+                "classCode": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "figi": {
+                    "type": "string"
+                },
+                "logoURL": {
+                    "type": "string"
+                },
+                "lot": {
+                    "type": "integer",
+                    "format": "int32"
+                },
+                "ticker": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "main_internal_domain_entity_instruments.Etf": {
             "type": "object",
             "properties": {
-                "aciValue": {
-                    "type": "number"
+                "brandUID": {
+                    "type": "string"
                 },
                 "classCode": {
                     "type": "string"
@@ -2101,10 +5256,12 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "lot": {
-                    "type": "integer"
+                    "type": "integer",
+                    "format": "int32"
                 },
-                "nominal": {
-                    "type": "number"
+                "minPriceIncrement": {
+                    "type": "number",
+                    "format": "float64"
                 },
                 "ticker": {
                     "type": "string"
@@ -2117,9 +5274,15 @@ const docTemplate = `{
                 }
             }
         },
-        "main_internal_domain_entity_instruments.Currency": {
+        "main_internal_domain_entity_instruments.Future": {
             "type": "object",
             "properties": {
+                "assetType": {
+                    "$ref": "#/definitions/main_internal_domain_entity_instruments.AssetType"
+                },
+                "brandUID": {
+                    "type": "string"
+                },
                 "classCode": {
                     "type": "string"
                 },
@@ -2136,7 +5299,16 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "lot": {
-                    "type": "integer"
+                    "type": "integer",
+                    "format": "int32"
+                },
+                "minPriceIncrement": {
+                    "type": "number",
+                    "format": "float64"
+                },
+                "minPriceIncrementAmount": {
+                    "type": "number",
+                    "format": "float64"
                 },
                 "ticker": {
                     "type": "string"
@@ -2149,9 +5321,12 @@ const docTemplate = `{
                 }
             }
         },
-        "main_internal_domain_entity_instruments.Etf": {
+        "main_internal_domain_entity_instruments.Instrument": {
             "type": "object",
             "properties": {
+                "brandUID": {
+                    "type": "string"
+                },
                 "classCode": {
                     "type": "string"
                 },
@@ -2168,10 +5343,8 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "lot": {
-                    "type": "integer"
-                },
-                "minPriceIncrement": {
-                    "type": "number"
+                    "type": "integer",
+                    "format": "int32"
                 },
                 "ticker": {
                     "type": "string"
@@ -2184,15 +5357,21 @@ const docTemplate = `{
                 }
             }
         },
-        "main_internal_domain_entity_instruments.Future": {
+        "main_internal_domain_entity_instruments.InstrumentMatch": {
             "type": "object",
             "properties": {
-                "assetType": {
-                    "$ref": "#/definitions/main_internal_domain_entity_instruments.AssetType"
+                "brandName": {
+                    "type": "string"
+                },
+                "brandUID": {
+                    "type": "string"
                 },
                 "classCode": {
                     "type": "string"
                 },
+                "companyName": {
+                    "type": "string"
+                },
                 "createdAt": {
                     "type": "string"
                 },
@@ -2206,17 +5385,18 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "lot": {
-                    "type": "integer"
+                    "type": "integer",
+                    "format": "int32"
                 },
-                "minPriceIncrement": {
-                    "type": "number"
-                },
-                "minPriceIncrementAmount": {
-                    "type": "number"
+                "matchedOn": {
+                    "type": "string"
                 },
                 "ticker": {
                     "type": "string"
                 },
+                "type": {
+                    "$ref": "#/definitions/main_internal_domain_entity_instruments.InstrumentType"
+                },
                 "uid": {
                     "type": "string"
                 },
@@ -2225,9 +5405,31 @@ const docTemplate = `{
                 }
             }
         },
-        "main_internal_domain_entity_instruments.Instrument": {
+        "main_internal_domain_entity_instruments.InstrumentType": {
+            "type": "string",
+            "enum": [
+                "share",
+                "future",
+                "currency",
+                "bond",
+                "etf",
+                "*"
+            ],
+            "x-enum-varnames": [
+                "ShareType",
+                "FutureType",
+                "CurrencyType",
+                "BondType",
+                "EtfType",
+                "AllType"
+            ]
+        },
+        "main_internal_domain_entity_instruments.ListedInstrument": {
             "type": "object",
             "properties": {
+                "brandUID": {
+                    "type": "string"
+                },
                 "classCode": {
                     "type": "string"
                 },
@@ -2244,11 +5446,15 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "lot": {
-                    "type": "integer"
+                    "type": "integer",
+                    "format": "int32"
                 },
                 "ticker": {
                     "type": "string"
                 },
+                "type": {
+                    "$ref": "#/definitions/main_internal_domain_entity_instruments.InstrumentType"
+                },
                 "uid": {
                     "type": "string"
                 },
@@ -2260,6 +5466,9 @@ const docTemplate = `{
         "main_internal_domain_entity_instruments.Share": {
             "type": "object",
             "properties": {
+                "brandUID": {
+                    "type": "string"
+                },
                 "classCode": {
                     "type": "string"
                 },
@@ -2276,7 +5485,8 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "lot": {
-                    "type": "integer"
+                    "type": "integer",
+                    "format": "int32"
                 },
                 "ticker": {
                     "type": "string"
@@ -2289,6 +5499,38 @@ const docTemplate = `{
                 }
             }
         },
+        "main_internal_domain_entity_marketdata.ADV": {
+            "type": "object",
+            "properties": {
+                "adv_lots": {
+                    "type": "number"
+                },
+                "daily": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main_internal_domain_entity_marketdata.DailyVolume"
+                    }
+                },
+                "days": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "instrument_uid": {
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "trading_days": {
+                    "type": "integer"
+                }
+            }
+        },
         "main_internal_domain_entity_marketdata.Candle": {
             "type": "object",
             "properties": {
@@ -2301,13 +5543,13 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "instrumentUID": {
+                "instrument_uid": {
                     "type": "string"
                 },
-                "intervalSeconds": {
+                "interval_seconds": {
                     "type": "integer"
                 },
-                "lastTradeAt": {
+                "last_trade_at": {
                     "type": "string"
                 },
                 "low": {
@@ -2320,17 +5562,120 @@ const docTemplate = `{
                 "open": {
                     "type": "number"
                 },
-                "periodStart": {
+                "period_start": {
+                    "type": "string"
+                },
+                "volume_buy_lots": {
+                    "type": "integer"
+                },
+                "volume_lots": {
+                    "type": "integer"
+                },
+                "volume_sell_lots": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main_internal_domain_entity_marketdata.CandleGap": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "instrument_uid": {
+                    "type": "string"
+                },
+                "interval_seconds": {
+                    "type": "integer"
+                },
+                "missing": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "main_internal_domain_entity_marketdata.CandleSummary": {
+            "type": "object",
+            "properties": {
+                "candle_count": {
+                    "type": "integer"
+                },
+                "first_period_start": {
+                    "type": "string"
+                },
+                "high": {
+                    "type": "number"
+                },
+                "instrument_uid": {
+                    "type": "string"
+                },
+                "interval_seconds": {
+                    "type": "integer"
+                },
+                "last_period_start": {
+                    "type": "string"
+                },
+                "low": {
+                    "type": "number"
+                },
+                "volume_lots": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main_internal_domain_entity_marketdata.DailyVolume": {
+            "type": "object",
+            "properties": {
+                "day": {
+                    "type": "string"
+                },
+                "trade_count": {
+                    "type": "integer"
+                },
+                "volume_lots": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main_internal_domain_entity_marketdata.LastPrice": {
+            "type": "object",
+            "properties": {
+                "instrument_uid": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "traded_at": {
                     "type": "string"
                 },
-                "volumeBuyLots": {
+                "venue": {
+                    "type": "string"
+                }
+            }
+        },
+        "main_internal_domain_entity_marketdata.OrderBookImbalance": {
+            "type": "object",
+            "properties": {
+                "ask_quantity": {
                     "type": "integer"
                 },
-                "volumeLots": {
+                "bid_quantity": {
                     "type": "integer"
                 },
-                "volumeSellLots": {
+                "depth": {
                     "type": "integer"
+                },
+                "imbalance": {
+                    "type": "number"
+                },
+                "snapshot_at": {
+                    "type": "string"
                 }
             }
         },
@@ -2366,15 +5711,84 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "instrumentUID": {
+                "instrument_uid": {
                     "type": "string"
                 },
                 "metadata": {
                     "type": "object",
                     "additionalProperties": {}
                 },
-                "snapshotAt": {
+                "sequence": {
+                    "description": "Sequence is the per-instrument ingestion sequence assigned by the producer;\nnil for snapshots stored without one.",
+                    "type": "integer"
+                },
+                "sequence_from": {
+                    "description": "SequenceFrom is the sequence of the first snapshot the consumer's\nthrottle dropped right before this one; the snapshot stands for\nSequenceFrom through Sequence. nil when nothing was dropped.",
+                    "type": "integer"
+                },
+                "sequence_gap": {
+                    "description": "SequenceGap is set on read, when gap flagging is requested, for snapshots\nwhose sequence does not directly follow the previous snapshot's.",
+                    "type": "boolean"
+                },
+                "snapshot_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "main_internal_domain_entity_marketdata.OrderBookSummary": {
+            "type": "object",
+            "properties": {
+                "depths": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "first_snapshot_at": {
+                    "type": "string"
+                },
+                "instrument_uid": {
+                    "type": "string"
+                },
+                "last_snapshot_at": {
+                    "type": "string"
+                },
+                "snapshot_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main_internal_domain_entity_marketdata.SpreadBucket": {
+            "type": "object",
+            "properties": {
+                "avg_mid": {
+                    "type": "number"
+                },
+                "avg_spread": {
+                    "type": "number"
+                },
+                "bucket_start": {
+                    "type": "string"
+                },
+                "snapshot_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main_internal_domain_entity_marketdata.TopOfBook": {
+            "type": "object",
+            "properties": {
+                "best_ask": {
+                    "type": "number"
+                },
+                "best_bid": {
+                    "type": "number"
+                },
+                "snapshot_at": {
                     "type": "string"
+                },
+                "spread": {
+                    "type": "number"
                 }
             }
         },
@@ -2384,7 +5798,7 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "instrumentUID": {
+                "instrument_uid": {
                     "type": "string"
                 },
                 "metadata": {
@@ -2394,14 +5808,32 @@ const docTemplate = `{
                 "price": {
                     "type": "number"
                 },
-                "quantityLots": {
+                "quantity_lots": {
                     "type": "integer"
                 },
                 "side": {
                     "$ref": "#/definitions/main_internal_domain_entity_marketdata.TradeSide"
                 },
-                "tradedAt": {
+                "traded_at": {
                     "type": "string"
+                },
+                "venue": {
+                    "description": "Venue is the exchange board the trade executed on (e.g. TQBR), taken from\nthe stream or derived from the instrument's class_code at ingestion.",
+                    "type": "string"
+                }
+            }
+        },
+        "main_internal_domain_entity_marketdata.TradeActivityBucket": {
+            "type": "object",
+            "properties": {
+                "bucket_start": {
+                    "type": "string"
+                },
+                "trade_count": {
+                    "type": "integer"
+                },
+                "volume_lots": {
+                    "type": "integer"
                 }
             }
         },
@@ -2409,24 +5841,83 @@ const docTemplate = `{
             "type": "string",
             "enum": [
                 "BUY",
-                "SELL"
+                "SELL",
+                "UNKNOWN"
             ],
             "x-enum-varnames": [
                 "TradeSideBuy",
-                "TradeSideSell"
+                "TradeSideSell",
+                "TradeSideUnknown"
             ]
+        },
+        "main_internal_domain_entity_marketdata.TradeSummary": {
+            "type": "object",
+            "properties": {
+                "first_traded_at": {
+                    "type": "string"
+                },
+                "instrument_uid": {
+                    "type": "string"
+                },
+                "last_traded_at": {
+                    "type": "string"
+                },
+                "max_price": {
+                    "type": "number"
+                },
+                "min_price": {
+                    "type": "number"
+                },
+                "trade_count": {
+                    "type": "integer"
+                },
+                "volume_lots": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main_internal_domain_entity_marketdata.VWAP": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "instrument_uid": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "trade_count": {
+                    "type": "integer"
+                },
+                "volume_lots": {
+                    "type": "integer"
+                },
+                "vwap": {
+                    "type": "number"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "description": "Required on writes, and on reads with API_KEY_PROTECT_READS, once API_KEYS is set.",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
         }
     }
 }`
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
-	Version:          "",
-	Host:             "",
-	BasePath:         "",
+	Version:          "1.0",
+	Host:             "localhost:8080",
+	BasePath:         "/api/v1",
 	Schemes:          []string{},
-	Title:            "",
-	Description:      "",
+	Title:            "Market Data Aggregator API",
+	Description:      "API for managing financial instruments and market data",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
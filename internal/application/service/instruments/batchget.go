@@ -0,0 +1,71 @@
+package instruments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	domain "main/internal/domain/entity/instruments"
+
+	"github.com/google/uuid"
+)
+
+// DefaultBatchGetMax caps the UIDs of one GetInstrumentsByUIDs call unless
+// SetBatchGetMax sets another cap.
+const DefaultBatchGetMax = 100
+
+// ErrTooManyUIDs means a batch get asked for more UIDs than the cap.
+var ErrTooManyUIDs = errors.New("too many uids")
+
+// BatchGetResult is the answer to GetInstrumentsByUIDs.
+type BatchGetResult struct {
+	// Instruments holds the found instruments in the order they were asked for.
+	Instruments []domain.Instrument
+	// Missing lists the UIDs without an instrument, in the order they were
+	// asked for.
+	Missing []uuid.UUID
+}
+
+// SetBatchGetMax caps the UIDs of one GetInstrumentsByUIDs call. It is meant
+// to be called once at startup, before the service is shared.
+func (s *Service) SetBatchGetMax(limit int) {
+	s.batchGetMax = limit
+}
+
+// GetInstrumentsByUIDs loads the instruments of uids in one query. A UID
+// asked for twice is answered once.
+func (s *Service) GetInstrumentsByUIDs(ctx context.Context, uids []uuid.UUID, includeDeleted bool) (*BatchGetResult, error) {
+	unique := make([]uuid.UUID, 0, len(uids))
+	seen := make(map[uuid.UUID]struct{}, len(uids))
+	for _, uid := range uids {
+		if _, ok := seen[uid]; ok {
+			continue
+		}
+		seen[uid] = struct{}{}
+		unique = append(unique, uid)
+	}
+	if len(unique) > s.batchGetMax {
+		return nil, fmt.Errorf("%w: got %d, the maximum is %d", ErrTooManyUIDs, len(unique), s.batchGetMax)
+	}
+
+	result := &BatchGetResult{Instruments: []domain.Instrument{}, Missing: []uuid.UUID{}}
+	if len(unique) == 0 {
+		return result, nil
+	}
+	found, err := s.repo.GetInstrumentsByUIDs(ctx, unique, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	byUID := make(map[uuid.UUID]domain.Instrument, len(found))
+	for _, instrument := range found {
+		byUID[instrument.UID] = instrument
+	}
+	for _, uid := range unique {
+		if instrument, ok := byUID[uid]; ok {
+			result.Instruments = append(result.Instruments, instrument)
+		} else {
+			result.Missing = append(result.Missing, uid)
+		}
+	}
+	return result, nil
+}
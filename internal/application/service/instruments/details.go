@@ -0,0 +1,42 @@
+package instruments
+
+import (
+	"context"
+
+	domain "main/internal/domain/entity/instruments"
+
+	"github.com/google/uuid"
+)
+
+// InstrumentDetails is an instrument of any type with the brand it belongs to.
+type InstrumentDetails struct {
+	Type domain.InstrumentType
+	// Instrument is the typed entity, e.g. a *domain.Bond, or the base
+	// *domain.Instrument when the instrument has no typed row.
+	Instrument any
+	// Brand is nil when the instrument has no brand.
+	Brand *domain.BrandDetails
+}
+
+// GetInstrumentDetails loads an instrument with its typed fields and its
+// brand, company, sector and country.
+func (s *Service) GetInstrumentDetails(ctx context.Context, uid uuid.UUID, includeDeleted bool) (*InstrumentDetails, error) {
+	instrumentType, err := s.repo.GetInstrumentType(ctx, uid, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	var instrument any
+	if instrumentType == "" {
+		instrument, err = s.repo.GetInstrument(ctx, uid, includeDeleted)
+	} else {
+		instrument, err = s.instrumentModel(ctx, uid, instrumentType, includeDeleted)
+	}
+	if err != nil {
+		return nil, err
+	}
+	brand, err := s.repo.GetInstrumentBrand(ctx, uid, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	return &InstrumentDetails{Type: instrumentType, Instrument: instrument, Brand: brand}, nil
+}
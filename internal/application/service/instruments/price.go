@@ -0,0 +1,71 @@
+package instruments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	domain "main/internal/domain/entity/instruments"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidPoints = errors.New("points must be a finite number")
+	// ErrNotPriceable means the instrument lacks the data GetPrice needs: it has
+	// no typed row, or it is a future without a min price increment.
+	ErrNotPriceable = errors.New("instrument cannot be priced")
+)
+
+// InstrumentPrice is a price in points converted to the instrument's currency.
+type InstrumentPrice struct {
+	UID    uuid.UUID             `json:"uid"`
+	Type   domain.InstrumentType `json:"type"`
+	Points float64               `json:"points"`
+	Price  float64               `json:"price"`
+}
+
+// GetPrice converts points to a price in currency with the GetPrice of the
+// instrument's type: bonds are priced in percent of the nominal, futures by
+// the min price increment and its amount, the rest per lot.
+func (s *Service) GetPrice(ctx context.Context, uid uuid.UUID, points float64, includeDeleted bool) (*InstrumentPrice, error) {
+	if math.IsNaN(points) || math.IsInf(points, 0) {
+		return nil, ErrInvalidPoints
+	}
+	instrumentType, err := s.repo.GetInstrumentType(ctx, uid, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	model, err := s.instrumentModel(ctx, uid, instrumentType, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	if instrumentType == domain.FutureType && model.GetMinPriceIncrement() == 0 {
+		return nil, fmt.Errorf("%w: future has no min price increment", ErrNotPriceable)
+	}
+	return &InstrumentPrice{
+		UID:    uid,
+		Type:   instrumentType,
+		Points: points,
+		Price:  model.GetPrice(points),
+	}, nil
+}
+
+// instrumentModel loads the typed instrument that carries the pricing fields.
+func (s *Service) instrumentModel(ctx context.Context, uid uuid.UUID, instrumentType domain.InstrumentType, includeDeleted bool) (domain.InstrumentModel, error) {
+	switch instrumentType {
+	case domain.ShareType:
+		return s.repo.GetShare(ctx, uid, includeDeleted)
+	case domain.BondType:
+		return s.repo.GetBond(ctx, uid, includeDeleted)
+	case domain.FutureType:
+		return s.repo.GetFuture(ctx, uid, includeDeleted)
+	case domain.CurrencyType:
+		return s.repo.GetCurrency(ctx, uid, includeDeleted)
+	case domain.EtfType:
+		return s.repo.GetEtf(ctx, uid, includeDeleted)
+	default:
+		return nil, fmt.Errorf("%w: instrument has no share, bond, future, currency or etf details", ErrNotPriceable)
+	}
+}
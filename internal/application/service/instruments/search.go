@@ -0,0 +1,46 @@
+package instruments
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	domain "main/internal/domain/entity/instruments"
+)
+
+const (
+	// DefaultSearchLimit is the page size of searches that do not set one.
+	DefaultSearchLimit = 20
+	// MaxSearchLimit caps the page size of searches.
+	MaxSearchLimit = 100
+	// MaxSearchQueryLength caps the characters of a search query.
+	MaxSearchQueryLength = 100
+)
+
+var (
+	// ErrInvalidSearchQuery means a search query was blank or too long.
+	ErrInvalidSearchQuery = fmt.Errorf("q must be 1 to %d characters", MaxSearchQueryLength)
+	// ErrInvalidSearchLimit means a search page size was outside 1..MaxSearchLimit.
+	ErrInvalidSearchLimit = fmt.Errorf("limit must be between 1 and %d", MaxSearchLimit)
+)
+
+// SearchInstruments returns a page of instruments whose ticker, brand name or
+// company name contains the query, best matches first. The query is trimmed;
+// a zero limit means DefaultSearchLimit.
+func (s *Service) SearchInstruments(ctx context.Context, search domain.InstrumentSearch) ([]domain.InstrumentMatch, error) {
+	search.Query = strings.TrimSpace(search.Query)
+	if search.Query == "" || utf8.RuneCountInString(search.Query) > MaxSearchQueryLength {
+		return nil, ErrInvalidSearchQuery
+	}
+	if search.Limit == 0 {
+		search.Limit = DefaultSearchLimit
+	}
+	if search.Limit < 0 || search.Limit > MaxSearchLimit {
+		return nil, ErrInvalidSearchLimit
+	}
+	if search.Offset < 0 {
+		return nil, ErrInvalidListOffset
+	}
+	return s.repo.SearchInstruments(ctx, search)
+}
@@ -3,6 +3,8 @@ package instruments
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 
 	domain "main/internal/domain/entity/instruments"
 	interfaces "main/internal/domain/interfaces"
@@ -10,14 +12,33 @@ import (
 	"github.com/google/uuid"
 )
 
-var ErrNilInstrument = errors.New("instrument is nil")
+var (
+	ErrNilInstrument         = domain.ErrNilInstrument
+	ErrInvalidListLimit      = fmt.Errorf("limit must be between 1 and %d", MaxListLimit)
+	ErrInvalidListOffset     = errors.New("offset must not be negative")
+	ErrInvalidInstrumentType = errors.New("instrument type must be one of share, bond, future, currency, etf")
+	ErrInvalidCountryCode    = errors.New("country must be an ISO 3166 alpha-2 or alpha-3 code")
+	ErrMissingTicker         = errors.New("ticker is required")
+	ErrMissingFigi           = errors.New("figi is required")
+	// ErrAmbiguousTicker means the ticker is listed under several class codes
+	// and none was given to pick one.
+	ErrAmbiguousTicker = errors.New("ticker matches several instruments")
+)
+
+const (
+	// DefaultListLimit is the page size of instrument listings that do not set one.
+	DefaultListLimit = 100
+	// MaxListLimit caps the page size of instrument listings.
+	MaxListLimit = 1000
+)
 
 type Service struct {
-	repo interfaces.InstrumentsRepository
+	repo        interfaces.InstrumentsRepository
+	batchGetMax int
 }
 
 func NewService(repo interfaces.InstrumentsRepository) *Service {
-	return &Service{repo: repo}
+	return &Service{repo: repo, batchGetMax: DefaultBatchGetMax}
 }
 
 func (s *Service) CreateInstrument(ctx context.Context, instrument *domain.Instrument) error {
@@ -27,8 +48,8 @@ func (s *Service) CreateInstrument(ctx context.Context, instrument *domain.Instr
 	return s.repo.CreateInstrument(ctx, instrument)
 }
 
-func (s *Service) GetInstrument(ctx context.Context, uid uuid.UUID) (*domain.Instrument, error) {
-	return s.repo.GetInstrument(ctx, uid)
+func (s *Service) GetInstrument(ctx context.Context, uid uuid.UUID, includeDeleted bool) (*domain.Instrument, error) {
+	return s.repo.GetInstrument(ctx, uid, includeDeleted)
 }
 
 func (s *Service) UpdateInstrument(ctx context.Context, instrument *domain.Instrument) error {
@@ -38,8 +59,22 @@ func (s *Service) UpdateInstrument(ctx context.Context, instrument *domain.Instr
 	return s.repo.UpdateInstrument(ctx, instrument)
 }
 
-func (s *Service) DeleteInstrument(ctx context.Context, uid uuid.UUID) error {
-	return s.repo.DeleteInstrument(ctx, uid)
+// UpsertInstrument creates the instrument or updates the one with the same
+// figi, see the repository for how a UID in the payload is treated. With
+// restore, a soft-deleted instrument of that figi is restored and updated when
+// no other one is live.
+func (s *Service) UpsertInstrument(ctx context.Context, instrument *domain.Instrument, restore bool) error {
+	if instrument == nil {
+		return ErrNilInstrument
+	}
+	if strings.TrimSpace(instrument.Figi) == "" {
+		return ErrMissingFigi
+	}
+	return s.repo.UpsertInstrument(ctx, instrument, restore)
+}
+
+func (s *Service) DeleteInstrument(ctx context.Context, uid uuid.UUID, hard bool) error {
+	return s.repo.DeleteInstrument(ctx, uid, hard)
 }
 
 func (s *Service) CreateShare(ctx context.Context, share *domain.Share) error {
@@ -56,12 +91,12 @@ func (s *Service) UpdateShare(ctx context.Context, share *domain.Share) error {
 	return s.repo.UpdateShare(ctx, share)
 }
 
-func (s *Service) DeleteShare(ctx context.Context, uid uuid.UUID) error {
-	return s.repo.DeleteShare(ctx, uid)
+func (s *Service) DeleteShare(ctx context.Context, uid uuid.UUID, hard bool) error {
+	return s.repo.DeleteShare(ctx, uid, hard)
 }
 
-func (s *Service) GetShare(ctx context.Context, uid uuid.UUID) (*domain.Share, error) {
-	return s.repo.GetShare(ctx, uid)
+func (s *Service) GetShare(ctx context.Context, uid uuid.UUID, includeDeleted bool) (*domain.Share, error) {
+	return s.repo.GetShare(ctx, uid, includeDeleted)
 }
 
 func (s *Service) CreateBond(ctx context.Context, bond *domain.Bond) error {
@@ -78,12 +113,12 @@ func (s *Service) UpdateBond(ctx context.Context, bond *domain.Bond) error {
 	return s.repo.UpdateBond(ctx, bond)
 }
 
-func (s *Service) DeleteBond(ctx context.Context, uid uuid.UUID) error {
-	return s.repo.DeleteBond(ctx, uid)
+func (s *Service) DeleteBond(ctx context.Context, uid uuid.UUID, hard bool) error {
+	return s.repo.DeleteBond(ctx, uid, hard)
 }
 
-func (s *Service) GetBond(ctx context.Context, uid uuid.UUID) (*domain.Bond, error) {
-	return s.repo.GetBond(ctx, uid)
+func (s *Service) GetBond(ctx context.Context, uid uuid.UUID, includeDeleted bool) (*domain.Bond, error) {
+	return s.repo.GetBond(ctx, uid, includeDeleted)
 }
 
 func (s *Service) CreateFuture(ctx context.Context, future *domain.Future) error {
@@ -100,12 +135,12 @@ func (s *Service) UpdateFuture(ctx context.Context, future *domain.Future) error
 	return s.repo.UpdateFuture(ctx, future)
 }
 
-func (s *Service) DeleteFuture(ctx context.Context, uid uuid.UUID) error {
-	return s.repo.DeleteFuture(ctx, uid)
+func (s *Service) DeleteFuture(ctx context.Context, uid uuid.UUID, hard bool) error {
+	return s.repo.DeleteFuture(ctx, uid, hard)
 }
 
-func (s *Service) GetFuture(ctx context.Context, uid uuid.UUID) (*domain.Future, error) {
-	return s.repo.GetFuture(ctx, uid)
+func (s *Service) GetFuture(ctx context.Context, uid uuid.UUID, includeDeleted bool) (*domain.Future, error) {
+	return s.repo.GetFuture(ctx, uid, includeDeleted)
 }
 
 func (s *Service) CreateCurrency(ctx context.Context, currency *domain.Currency) error {
@@ -122,12 +157,12 @@ func (s *Service) UpdateCurrency(ctx context.Context, currency *domain.Currency)
 	return s.repo.UpdateCurrency(ctx, currency)
 }
 
-func (s *Service) DeleteCurrency(ctx context.Context, uid uuid.UUID) error {
-	return s.repo.DeleteCurrency(ctx, uid)
+func (s *Service) DeleteCurrency(ctx context.Context, uid uuid.UUID, hard bool) error {
+	return s.repo.DeleteCurrency(ctx, uid, hard)
 }
 
-func (s *Service) GetCurrency(ctx context.Context, uid uuid.UUID) (*domain.Currency, error) {
-	return s.repo.GetCurrency(ctx, uid)
+func (s *Service) GetCurrency(ctx context.Context, uid uuid.UUID, includeDeleted bool) (*domain.Currency, error) {
+	return s.repo.GetCurrency(ctx, uid, includeDeleted)
 }
 
 func (s *Service) CreateEtf(ctx context.Context, etf *domain.Etf) error {
@@ -144,12 +179,83 @@ func (s *Service) UpdateEtf(ctx context.Context, etf *domain.Etf) error {
 	return s.repo.UpdateEtf(ctx, etf)
 }
 
-func (s *Service) DeleteEtf(ctx context.Context, uid uuid.UUID) error {
-	return s.repo.DeleteEtf(ctx, uid)
+func (s *Service) DeleteEtf(ctx context.Context, uid uuid.UUID, hard bool) error {
+	return s.repo.DeleteEtf(ctx, uid, hard)
+}
+
+func (s *Service) GetEtf(ctx context.Context, uid uuid.UUID, includeDeleted bool) (*domain.Etf, error) {
+	return s.repo.GetEtf(ctx, uid, includeDeleted)
 }
 
-func (s *Service) GetEtf(ctx context.Context, uid uuid.UUID) (*domain.Etf, error) {
-	return s.repo.GetEtf(ctx, uid)
+// ListInstruments returns a page of instruments matching filter. A zero limit
+// means DefaultListLimit.
+func (s *Service) ListInstruments(ctx context.Context, filter domain.InstrumentFilter) ([]domain.ListedInstrument, error) {
+	if filter.Limit == 0 {
+		filter.Limit = DefaultListLimit
+	}
+	if filter.Limit < 0 || filter.Limit > MaxListLimit {
+		return nil, ErrInvalidListLimit
+	}
+	if filter.Offset < 0 {
+		return nil, ErrInvalidListOffset
+	}
+	switch filter.Type {
+	case "", domain.ShareType, domain.BondType, domain.FutureType, domain.CurrencyType, domain.EtfType:
+	default:
+		return nil, ErrInvalidInstrumentType
+	}
+	if filter.CountryCode != "" {
+		code, ok := normalizeCountryCode(filter.CountryCode)
+		if !ok {
+			return nil, ErrInvalidCountryCode
+		}
+		filter.CountryCode = code
+	}
+	return s.repo.ListInstruments(ctx, filter)
+}
+
+// GetInstrumentByTicker resolves a ticker, and optionally a class code, to the
+// single instrument it names. A ticker traded on several boards needs the
+// class code; without it the error lists the boards to choose from.
+func (s *Service) GetInstrumentByTicker(ctx context.Context, ticker, classCode string, includeDeleted bool) (*domain.ListedInstrument, error) {
+	ticker = strings.TrimSpace(ticker)
+	if ticker == "" {
+		return nil, ErrMissingTicker
+	}
+	classCode = strings.TrimSpace(classCode)
+	matches, err := s.repo.GetInstrumentByTicker(ctx, ticker, classCode, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	switch len(matches) {
+	case 0:
+		return nil, domain.ErrInstrumentNotFound
+	case 1:
+		return &matches[0], nil
+	}
+	classCodes := make([]string, len(matches))
+	for i, match := range matches {
+		classCodes[i] = match.ClassCode
+	}
+	if classCode != "" {
+		return nil, fmt.Errorf("%w: %d instruments have ticker %s and class code %s", ErrAmbiguousTicker, len(matches), ticker, classCode)
+	}
+	return nil, fmt.Errorf("%w: %s is listed under class codes %s; pass class_code", ErrAmbiguousTicker, ticker, strings.Join(classCodes, ", "))
+}
+
+// normalizeCountryCode upper-cases a two or three letter country code. Codes
+// that are well-formed but unknown are left to match nothing.
+func normalizeCountryCode(code string) (string, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 2 && len(code) != 3 {
+		return "", false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", false
+		}
+	}
+	return code, true
 }
 
 func (s *Service) Close() {
@@ -0,0 +1,123 @@
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	marketdata "main/internal/domain/entity/marketdata"
+
+	"github.com/google/uuid"
+)
+
+// DepthFallback decides what happens when an order book query asks for a depth
+// that was never stored for the instrument.
+type DepthFallback string
+
+const (
+	// DepthFallbackStrict rejects the query with a DepthUnavailableError.
+	DepthFallbackStrict DepthFallback = "strict"
+	// DepthFallbackLenient serves the nearest stored depth instead.
+	DepthFallbackLenient DepthFallback = "lenient"
+)
+
+// ErrDepthUnavailable is matched by every DepthUnavailableError.
+var ErrDepthUnavailable = errors.New("order book depth not available")
+
+// ErrInvalidDepthMatch rejects a depth match mode other than at_least or exact.
+var ErrInvalidDepthMatch = errors.New("depth_match must be at_least or exact")
+
+// DepthUnavailableError lists the depths that are stored for the instrument.
+type DepthUnavailableError struct {
+	Requested int32
+	Available []int32
+}
+
+func (e *DepthUnavailableError) Error() string {
+	available := make([]string, len(e.Available))
+	for i, depth := range e.Available {
+		available[i] = strconv.Itoa(int(depth))
+	}
+	return fmt.Sprintf("order book depth %d not available; available depths: %s", e.Requested, strings.Join(available, ", "))
+}
+
+func (e *DepthUnavailableError) Is(target error) bool {
+	return target == ErrDepthUnavailable
+}
+
+// SetDepthFallback selects the behavior of ResolveOrderBookDepth. It is meant to
+// be called once at startup, before the service is shared.
+func (s *Service) SetDepthFallback(fallback DepthFallback) {
+	s.depthFallback = fallback
+}
+
+// ResolveOrderBookDepth checks a requested depth against the stored ones. It
+// returns depth itself when it is stored (or, with DepthMatchAtLeast, a deeper
+// one is) or when nothing is stored at all. For a depth that cannot be served it
+// returns a DepthUnavailableError in strict mode, or the nearest stored depth
+// (the deeper one on a tie) in lenient mode.
+func (s *Service) ResolveOrderBookDepth(ctx context.Context, instrumentUID uuid.UUID, depth int32, match marketdata.DepthMatch) (int32, error) {
+	if depth <= 0 {
+		return 0, ErrInvalidDepth
+	}
+	if err := validateDepthMatch(match); err != nil {
+		return 0, err
+	}
+	available, err := s.repo.GetOrderBookDepths(ctx, instrumentUID)
+	if err != nil {
+		return 0, err
+	}
+	if len(available) == 0 || slices.Contains(available, depth) {
+		return depth, nil
+	}
+	if match == marketdata.DepthMatchAtLeast && available[len(available)-1] > depth {
+		return depth, nil
+	}
+	if s.depthFallback != DepthFallbackLenient {
+		return 0, &DepthUnavailableError{Requested: depth, Available: available}
+	}
+
+	nearest := available[0]
+	for _, candidate := range available[1:] {
+		if abs32(candidate-depth) <= abs32(nearest-depth) {
+			nearest = candidate
+		}
+	}
+	return nearest, nil
+}
+
+// validateDepthMatch rejects an unknown depth match mode.
+func validateDepthMatch(match marketdata.DepthMatch) error {
+	if match != marketdata.DepthMatchAtLeast && match != marketdata.DepthMatchExact {
+		return ErrInvalidDepthMatch
+	}
+	return nil
+}
+
+// truncateDepth cuts snapshots stored deeper than depth down to depth levels per
+// side, so DepthMatchAtLeast callers get exactly the depth they asked for.
+func truncateDepth(snapshots []marketdata.OrderBookSnapshot, depth int32) {
+	for i := range snapshots {
+		snapshot := &snapshots[i]
+		if snapshot.Depth <= depth {
+			continue
+		}
+		snapshot.Depth = depth
+		if len(snapshot.Bids) > int(depth) {
+			snapshot.Bids = snapshot.Bids[:depth]
+		}
+		if len(snapshot.Asks) > int(depth) {
+			snapshot.Asks = snapshot.Asks[:depth]
+		}
+	}
+}
+
+func abs32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,85 @@
+package marketdata
+
+import (
+	"context"
+	"time"
+
+	marketdata "main/internal/domain/entity/marketdata"
+
+	"github.com/google/uuid"
+)
+
+// The Each*Between methods back exports that stream a range straight to the
+// client. They validate like their Get*Between counterparts and call fn per row
+// as it is read, so a large range is never held in memory. Unlike the Get
+// methods, a zero page limit means the whole range and a positive limit is not
+// capped at MaxPageLimit.
+
+// EachTradeBetween calls fn for every trade in the range, in GetTradesBetween
+// order, stopping at the first error.
+func (s *Service) EachTradeBetween(ctx context.Context, instrumentUID uuid.UUID, venue string, from, to time.Time, meta marketdata.MetadataFilter, page marketdata.Page, fn func(marketdata.Trade) error) error {
+	if err := validateExportPage(page); err != nil {
+		return err
+	}
+	if from.After(to) {
+		from, to = to, from
+	}
+	if err := s.checkRange(ctx, from, to); err != nil {
+		return err
+	}
+	return s.repo.EachTradeBetween(ctx, instrumentUID, normalizeVenue(venue), from, to, meta, page, fn)
+}
+
+// EachCandleBetween calls fn for every candle in the range, in GetCandlesBetween
+// order, stopping at the first error.
+func (s *Service) EachCandleBetween(ctx context.Context, instrumentUID uuid.UUID, intervalSeconds int64, from, to time.Time, meta marketdata.MetadataFilter, page marketdata.Page, fn func(marketdata.Candle) error) error {
+	if intervalSeconds <= 0 {
+		return ErrInvalidInterval
+	}
+	if err := validateExportPage(page); err != nil {
+		return err
+	}
+	if from.After(to) {
+		from, to = to, from
+	}
+	if err := s.checkRange(ctx, from, to); err != nil {
+		return err
+	}
+	return s.repo.EachCandleBetween(ctx, instrumentUID, from, to, intervalSeconds, meta, page, fn)
+}
+
+// EachOrderBookSnapshotBetween calls fn for every snapshot in the range, matched
+// and truncated to depth like GetOrderBookSnapshotsBetween, stopping at the
+// first error.
+func (s *Service) EachOrderBookSnapshotBetween(ctx context.Context, instrumentUID uuid.UUID, depth int32, match marketdata.DepthMatch, from, to time.Time, meta marketdata.MetadataFilter, page marketdata.Page, fn func(marketdata.OrderBookSnapshot) error) error {
+	if depth <= 0 {
+		return ErrInvalidDepth
+	}
+	if err := validateDepthMatch(match); err != nil {
+		return err
+	}
+	if err := validateExportPage(page); err != nil {
+		return err
+	}
+	if from.After(to) {
+		from, to = to, from
+	}
+	if err := s.checkRange(ctx, from, to); err != nil {
+		return err
+	}
+	return s.repo.EachOrderBookSnapshotBetween(ctx, instrumentUID, from, to, depth, match, meta, page, func(snapshot marketdata.OrderBookSnapshot) error {
+		snapshots := []marketdata.OrderBookSnapshot{snapshot}
+		truncateDepth(snapshots, depth)
+		return fn(snapshots[0])
+	})
+}
+
+func validateExportPage(page marketdata.Page) error {
+	if page.Limit < 0 {
+		return ErrInvalidLimit
+	}
+	if page.Offset < 0 {
+		return ErrInvalidOffset
+	}
+	return nil
+}
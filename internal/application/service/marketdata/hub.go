@@ -0,0 +1,205 @@
+package marketdata
+
+import (
+	"errors"
+	"sync"
+
+	marketdata "main/internal/domain/entity/marketdata"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrHubClosed ends the subscriptions of a hub that is shutting down.
+	ErrHubClosed = errors.New("stream hub closed")
+	// ErrSlowConsumer ends a subscription whose buffer filled up.
+	ErrSlowConsumer = errors.New("subscriber too slow")
+
+	// errClosed marks a subscription ended by its owner.
+	errClosed = errors.New("subscription closed")
+)
+
+// Hub fans live market data out to subscribers by instrument. Publishing never
+// blocks: a subscriber whose buffer is full is dropped with ErrSlowConsumer, so
+// one slow client cannot hold up ingestion or the other clients.
+type Hub[T any] struct {
+	key  func(*T) uuid.UUID
+	mu   sync.RWMutex
+	subs map[uuid.UUID]map[*Subscription[T]]struct{}
+	// all holds every open subscription, including those with no instrument.
+	all    map[*Subscription[T]]struct{}
+	closed bool
+}
+
+// NewHub creates a hub routing items by the instrument key returns.
+func NewHub[T any](key func(*T) uuid.UUID) *Hub[T] {
+	return &Hub[T]{
+		key:  key,
+		subs: make(map[uuid.UUID]map[*Subscription[T]]struct{}),
+		all:  make(map[*Subscription[T]]struct{}),
+	}
+}
+
+// NewTradeHub creates a hub for live trades.
+func NewTradeHub() *Hub[marketdata.Trade] {
+	return NewHub(func(trade *marketdata.Trade) uuid.UUID { return trade.InstrumentUID })
+}
+
+// NewCandleHub creates a hub for stored candles of every interval.
+func NewCandleHub() *Hub[marketdata.Candle] {
+	return NewHub(func(candle *marketdata.Candle) uuid.UUID { return candle.InstrumentUID })
+}
+
+// Subscription receives the items of the instruments it is subscribed to. C is
+// closed when the subscription ends; Err then reports why.
+type Subscription[T any] struct {
+	C <-chan *T
+
+	hub         *Hub[T]
+	ch          chan *T
+	instruments map[uuid.UUID]struct{}
+	err         error
+}
+
+// Subscribe registers a subscriber for instrumentUIDs with room for buffer
+// undelivered items. It fails with ErrHubClosed once the hub is closed.
+func (h *Hub[T]) Subscribe(instrumentUIDs []uuid.UUID, buffer int) (*Subscription[T], error) {
+	ch := make(chan *T, max(buffer, 1))
+	sub := &Subscription[T]{C: ch, hub: h, ch: ch, instruments: make(map[uuid.UUID]struct{})}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, ErrHubClosed
+	}
+	h.all[sub] = struct{}{}
+	h.addLocked(sub, instrumentUIDs)
+	return sub, nil
+}
+
+// Publish delivers an item to every subscriber of its instrument. The item is
+// shared between subscribers and must not be modified afterwards.
+func (h *Hub[T]) Publish(item *T) {
+	if item == nil {
+		return
+	}
+	var slow []*Subscription[T]
+	h.mu.RLock()
+	for sub := range h.subs[h.key(item)] {
+		select {
+		case sub.ch <- item:
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, sub := range slow {
+		sub.end(ErrSlowConsumer)
+	}
+}
+
+// Close ends every subscription with ErrHubClosed and rejects new ones.
+func (h *Hub[T]) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for sub := range h.all {
+		h.endLocked(sub, ErrHubClosed)
+	}
+}
+
+// Add subscribes to more instruments. It does nothing once the subscription
+// has ended.
+func (s *Subscription[T]) Add(instrumentUIDs ...uuid.UUID) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	if s.err != nil {
+		return
+	}
+	s.hub.addLocked(s, instrumentUIDs)
+}
+
+// Remove unsubscribes from instruments. The subscription stays open even when
+// no instrument is left.
+func (s *Subscription[T]) Remove(instrumentUIDs ...uuid.UUID) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	for _, uid := range instrumentUIDs {
+		if _, ok := s.instruments[uid]; !ok {
+			continue
+		}
+		delete(s.instruments, uid)
+		s.hub.removeLocked(s, uid)
+	}
+}
+
+// Instruments returns the instruments currently subscribed to.
+func (s *Subscription[T]) Instruments() []uuid.UUID {
+	s.hub.mu.RLock()
+	defer s.hub.mu.RUnlock()
+	out := make([]uuid.UUID, 0, len(s.instruments))
+	for uid := range s.instruments {
+		out = append(out, uid)
+	}
+	return out
+}
+
+// Close ends the subscription. It is safe to call more than once.
+func (s *Subscription[T]) Close() {
+	s.end(nil)
+}
+
+// Err reports why the subscription ended: ErrSlowConsumer, ErrHubClosed, or nil
+// after Close. It is only meaningful once C is closed.
+func (s *Subscription[T]) Err() error {
+	s.hub.mu.RLock()
+	defer s.hub.mu.RUnlock()
+	if errors.Is(s.err, errClosed) {
+		return nil
+	}
+	return s.err
+}
+
+func (s *Subscription[T]) end(reason error) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.endLocked(s, reason)
+}
+
+func (h *Hub[T]) addLocked(sub *Subscription[T], instrumentUIDs []uuid.UUID) {
+	for _, uid := range instrumentUIDs {
+		sub.instruments[uid] = struct{}{}
+		if h.subs[uid] == nil {
+			h.subs[uid] = make(map[*Subscription[T]]struct{})
+		}
+		h.subs[uid][sub] = struct{}{}
+	}
+}
+
+func (h *Hub[T]) removeLocked(sub *Subscription[T], uid uuid.UUID) {
+	delete(h.subs[uid], sub)
+	if len(h.subs[uid]) == 0 {
+		delete(h.subs, uid)
+	}
+}
+
+// endLocked detaches the subscription and closes its channel. Sends happen
+// under the read lock, so closing under the write lock cannot race with them.
+func (h *Hub[T]) endLocked(sub *Subscription[T], reason error) {
+	if sub.err != nil {
+		return
+	}
+	if reason == nil {
+		reason = errClosed
+	}
+	sub.err = reason
+	for uid := range sub.instruments {
+		h.removeLocked(sub, uid)
+	}
+	delete(h.all, sub)
+	close(sub.ch)
+}
@@ -0,0 +1,130 @@
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	marketdata "main/internal/domain/entity/marketdata"
+
+	"github.com/google/uuid"
+)
+
+// ErrUnknownInstrument is wrapped when the instrument check is on and market
+// data names an instrument_uid with no instrument row.
+var ErrUnknownInstrument = errors.New("unknown instrument")
+
+// InstrumentLookup reports which of the given UIDs have an instrument row.
+type InstrumentLookup interface {
+	GetExistingInstrumentUIDs(ctx context.Context, uids []uuid.UUID) ([]uuid.UUID, error)
+}
+
+// instrumentCheck caches the UIDs known to exist. Only positive answers are
+// cached, so an instrument created after a rejection is accepted on the next
+// write. The whole cache is dropped every ttl, so instruments removed with a
+// hard delete stop being accepted within a ttl.
+type instrumentCheck struct {
+	lookup InstrumentLookup
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	known   map[uuid.UUID]struct{}
+	expires time.Time
+}
+
+// SetInstrumentCheck makes every add reject market data whose instrument does
+// not exist, looked up through lookup and cached for ttl; a nil lookup turns
+// the check off. It is meant to be called once at startup, before the service
+// is shared.
+func (s *Service) SetInstrumentCheck(lookup InstrumentLookup, ttl time.Duration) {
+	if lookup == nil {
+		s.instruments = nil
+		return
+	}
+	s.instruments = &instrumentCheck{lookup: lookup, ttl: ttl}
+}
+
+// CheckInstruments returns an error wrapping ErrUnknownInstrument for the
+// first of uids with no instrument row. Uncached UIDs are loaded in one query.
+// It always succeeds while the check is off.
+func (s *Service) CheckInstruments(ctx context.Context, uids ...uuid.UUID) error {
+	check := s.instruments
+	if check == nil || len(uids) == 0 {
+		return nil
+	}
+	missing := check.uncached(uids)
+	if len(missing) == 0 {
+		return nil
+	}
+	existing, err := check.lookup.GetExistingInstrumentUIDs(ctx, missing)
+	if err != nil {
+		return fmt.Errorf("look up instruments: %w", err)
+	}
+	check.store(existing)
+	found := make(map[uuid.UUID]struct{}, len(existing))
+	for _, uid := range existing {
+		found[uid] = struct{}{}
+	}
+	for _, uid := range missing {
+		if _, ok := found[uid]; !ok {
+			return fmt.Errorf("%w %s", ErrUnknownInstrument, uid)
+		}
+	}
+	return nil
+}
+
+// uncached returns the distinct uids not known to exist, in their first order.
+func (c *instrumentCheck) uncached(uids []uuid.UUID) []uuid.UUID {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now := time.Now(); !now.Before(c.expires) {
+		c.known = make(map[uuid.UUID]struct{})
+		c.expires = now.Add(c.ttl)
+	}
+	var missing []uuid.UUID
+	seen := make(map[uuid.UUID]struct{})
+	for _, uid := range uids {
+		if _, ok := c.known[uid]; ok {
+			continue
+		}
+		if _, ok := seen[uid]; !ok {
+			seen[uid] = struct{}{}
+			missing = append(missing, uid)
+		}
+	}
+	return missing
+}
+
+func (c *instrumentCheck) store(uids []uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, uid := range uids {
+		c.known[uid] = struct{}{}
+	}
+}
+
+func tradeInstruments(trades []marketdata.Trade) []uuid.UUID {
+	uids := make([]uuid.UUID, len(trades))
+	for i := range trades {
+		uids[i] = trades[i].InstrumentUID
+	}
+	return uids
+}
+
+func candleInstruments(candles []marketdata.Candle) []uuid.UUID {
+	uids := make([]uuid.UUID, len(candles))
+	for i := range candles {
+		uids[i] = candles[i].InstrumentUID
+	}
+	return uids
+}
+
+func orderBookInstruments(snapshots []marketdata.OrderBookSnapshot) []uuid.UUID {
+	uids := make([]uuid.UUID, len(snapshots))
+	for i := range snapshots {
+		uids[i] = snapshots[i].InstrumentUID
+	}
+	return uids
+}
@@ -0,0 +1,64 @@
+package marketdata
+
+import (
+	"context"
+
+	marketdata "main/internal/domain/entity/marketdata"
+
+	"github.com/google/uuid"
+)
+
+// LastPriceStore keeps the price of the newest trade per instrument outside
+// Postgres, for cheap reads of the latest price.
+type LastPriceStore interface {
+	// SetLastPrices records the newest of trades per instrument, unless the
+	// store already holds a newer trade of that instrument.
+	SetLastPrices(ctx context.Context, trades []marketdata.Trade) error
+	// GetLastPrice returns nil without an error when the store has no price
+	// for the instrument.
+	GetLastPrice(ctx context.Context, instrumentUID uuid.UUID) (*marketdata.LastPrice, error)
+}
+
+// SetLastPriceStore makes RecordLastPrices write to store and GetLastPrice
+// read from it first; nil leaves GetLastPrice reading Postgres only. It is
+// meant to be called once at startup, before the service is shared.
+func (s *Service) SetLastPriceStore(store LastPriceStore) {
+	s.lastPrices = store
+}
+
+// RecordLastPrices passes stored trades to the last price store. It does
+// nothing without a store.
+func (s *Service) RecordLastPrices(ctx context.Context, trades []marketdata.Trade) error {
+	if s.lastPrices == nil || len(trades) == 0 {
+		return nil
+	}
+	return s.lastPrices.SetLastPrices(ctx, trades)
+}
+
+// GetLastPrice returns the price of the newest trade of the instrument. It
+// asks the last price store first and falls back to the newest stored trade
+// when the store has no price or fails. It returns ErrNoTrades when the
+// instrument has no trade at all.
+func (s *Service) GetLastPrice(ctx context.Context, instrumentUID uuid.UUID) (*marketdata.LastPrice, error) {
+	if s.lastPrices != nil {
+		// A store error is not the caller's problem: Postgres still answers.
+		if price, err := s.lastPrices.GetLastPrice(ctx, instrumentUID); err == nil && price != nil {
+			price.Source = marketdata.LastPriceSourceCache
+			return price, nil
+		}
+	}
+	trades, err := s.repo.GetLastTrades(ctx, instrumentUID, "", 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(trades) == 0 {
+		return nil, ErrNoTrades
+	}
+	return &marketdata.LastPrice{
+		InstrumentUID: instrumentUID,
+		Price:         trades[0].Price,
+		TradedAt:      trades[0].TradedAt,
+		Venue:         trades[0].Venue,
+		Source:        marketdata.LastPriceSourceDatabase,
+	}, nil
+}
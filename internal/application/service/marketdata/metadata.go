@@ -0,0 +1,79 @@
+package marketdata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrMetadataLimit is wrapped by every metadata limit violation; the wrapping
+// message names the limit that was exceeded.
+var ErrMetadataLimit = errors.New("metadata exceeds limit")
+
+// MetadataLimits bounds the free-form metadata accepted on trades, candles and
+// order book snapshots. A zero field disables that limit.
+type MetadataLimits struct {
+	// MaxKeys caps the number of keys across all nesting levels.
+	MaxKeys int
+	// MaxDepth caps nesting; a flat object has depth 1, and every nested
+	// object or array adds a level.
+	MaxDepth int
+	// MaxBytes caps the JSON-encoded size.
+	MaxBytes int
+}
+
+// DefaultMetadataLimits are applied until SetMetadataLimits is called.
+var DefaultMetadataLimits = MetadataLimits{
+	MaxKeys:  64,
+	MaxDepth: 4,
+	MaxBytes: 8 << 10,
+}
+
+// Validate reports the first limit metadata exceeds. Nil metadata is always valid.
+func (l MetadataLimits) Validate(metadata map[string]any) error {
+	if len(metadata) == 0 {
+		return nil
+	}
+	keys, depth := measureMetadata(metadata)
+	if l.MaxKeys > 0 && keys > l.MaxKeys {
+		return fmt.Errorf("%w: max_keys is %d, got %d", ErrMetadataLimit, l.MaxKeys, keys)
+	}
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return fmt.Errorf("%w: max_depth is %d, got %d", ErrMetadataLimit, l.MaxDepth, depth)
+	}
+	if l.MaxBytes > 0 {
+		encoded, err := json.Marshal(metadata)
+		if err != nil {
+			return fmt.Errorf("encode metadata: %w", err)
+		}
+		if len(encoded) > l.MaxBytes {
+			return fmt.Errorf("%w: max_bytes is %d, got %d", ErrMetadataLimit, l.MaxBytes, len(encoded))
+		}
+	}
+	return nil
+}
+
+// measureMetadata returns the total key count and nesting depth of a decoded
+// JSON value.
+func measureMetadata(value any) (keys, depth int) {
+	switch v := value.(type) {
+	case map[string]any:
+		maxChild := 0
+		for _, child := range v {
+			childKeys, childDepth := measureMetadata(child)
+			keys += 1 + childKeys
+			maxChild = max(maxChild, childDepth)
+		}
+		return keys, 1 + maxChild
+	case []any:
+		maxChild := 0
+		for _, child := range v {
+			childKeys, childDepth := measureMetadata(child)
+			keys += childKeys
+			maxChild = max(maxChild, childDepth)
+		}
+		return keys, 1 + maxChild
+	default:
+		return 0, 0
+	}
+}
@@ -0,0 +1,59 @@
+package marketdata
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// nestedMetadata returns metadata nested depth objects deep.
+func nestedMetadata(depth int) map[string]any {
+	metadata := map[string]any{"leaf": "value"}
+	for i := 1; i < depth; i++ {
+		metadata = map[string]any{"child": metadata}
+	}
+	return metadata
+}
+
+func TestMetadataLimitsValidate(t *testing.T) {
+	limits := MetadataLimits{MaxKeys: 4, MaxDepth: 3, MaxBytes: 64}
+	tests := []struct {
+		name     string
+		metadata map[string]any
+		limit    string
+	}{
+		{name: "nil", metadata: nil},
+		{name: "flat", metadata: map[string]any{"venue": "MOEX", "source": "stream"}},
+		{name: "at max depth", metadata: nestedMetadata(3)},
+		{name: "too deep", metadata: nestedMetadata(4), limit: "max_depth"},
+		{name: "array adds a level", metadata: map[string]any{"a": map[string]any{"b": []any{map[string]any{"c": 1}}}}, limit: "max_depth"},
+		{name: "too many keys", metadata: map[string]any{"a": 1, "b": 2, "c": 3, "d": 4, "e": 5}, limit: "max_keys"},
+		{name: "nested keys count", metadata: map[string]any{"a": map[string]any{"b": 1, "c": 2}, "d": map[string]any{"e": 3}}, limit: "max_keys"},
+		{name: "oversized", metadata: map[string]any{"note": strings.Repeat("x", 64)}, limit: "max_bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := limits.Validate(tt.metadata)
+			if tt.limit == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrMetadataLimit) {
+				t.Fatalf("Validate() = %v, want ErrMetadataLimit", err)
+			}
+			if !strings.Contains(err.Error(), tt.limit) {
+				t.Fatalf("Validate() = %q, want it to name %s", err, tt.limit)
+			}
+		})
+	}
+}
+
+func TestMetadataLimitsZeroDisables(t *testing.T) {
+	metadata := nestedMetadata(50)
+	metadata["note"] = strings.Repeat("x", 1<<20)
+	if err := (MetadataLimits{}).Validate(metadata); err != nil {
+		t.Fatalf("Validate() with no limits = %v, want nil", err)
+	}
+}
@@ -0,0 +1,48 @@
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultMaxRange caps the window of a range query unless SetMaxRange says
+// otherwise.
+const DefaultMaxRange = 31 * 24 * time.Hour
+
+// ErrRangeTooWide is wrapped when a range query spans more than the maximum
+// window.
+var ErrRangeTooWide = errors.New("time range too wide")
+
+type unlimitedRangeKey struct{}
+
+// WithoutRangeLimit returns a context under which range queries skip the
+// maximum window check, for callers trusted to scan any span.
+func WithoutRangeLimit(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unlimitedRangeKey{}, true)
+}
+
+// RangeUnlimited reports whether ctx came from WithoutRangeLimit.
+func RangeUnlimited(ctx context.Context) bool {
+	unlimited, _ := ctx.Value(unlimitedRangeKey{}).(bool)
+	return unlimited
+}
+
+// SetMaxRange sets the widest window the Get*Between and Each*Between methods
+// and the range aggregates accept; zero lifts the cap. It is meant to be
+// called once at startup, before the service is shared.
+func (s *Service) SetMaxRange(window time.Duration) {
+	s.maxRange = window
+}
+
+// checkRange rejects an ordered range wider than the maximum window.
+func (s *Service) checkRange(ctx context.Context, from, to time.Time) error {
+	if s.maxRange <= 0 || RangeUnlimited(ctx) {
+		return nil
+	}
+	if span := to.Sub(from); span > s.maxRange {
+		return fmt.Errorf("%w: %s exceeds the maximum of %s", ErrRangeTooWide, span, s.maxRange)
+	}
+	return nil
+}
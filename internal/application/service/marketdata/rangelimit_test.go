@@ -0,0 +1,175 @@
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"main/internal/domain/entity/marketdata"
+	interfaces "main/internal/domain/interfaces"
+
+	"github.com/google/uuid"
+)
+
+// rangeRepo records the range queries that reach it. Methods it does not
+// override panic through the nil embedded interface.
+type rangeRepo struct {
+	interfaces.MarketDataRepository
+	calls []string
+}
+
+func (r *rangeRepo) GetTradesBetween(context.Context, uuid.UUID, string, time.Time, time.Time, marketdata.MetadataFilter, marketdata.Page) ([]marketdata.Trade, error) {
+	r.calls = append(r.calls, "GetTradesBetween")
+	return nil, nil
+}
+
+func (r *rangeRepo) EachTradeBetween(context.Context, uuid.UUID, string, time.Time, time.Time, marketdata.MetadataFilter, marketdata.Page, func(marketdata.Trade) error) error {
+	r.calls = append(r.calls, "EachTradeBetween")
+	return nil
+}
+
+func (r *rangeRepo) GetCandlesBetween(context.Context, uuid.UUID, time.Time, time.Time, int64, marketdata.MetadataFilter, marketdata.Page) ([]marketdata.Candle, error) {
+	r.calls = append(r.calls, "GetCandlesBetween")
+	return nil, nil
+}
+
+func (r *rangeRepo) GetOrderBookSnapshotsBetween(context.Context, uuid.UUID, time.Time, time.Time, int32, marketdata.DepthMatch, marketdata.MetadataFilter, marketdata.Page) ([]marketdata.OrderBookSnapshot, error) {
+	r.calls = append(r.calls, "GetOrderBookSnapshotsBetween")
+	return nil, nil
+}
+
+func (r *rangeRepo) GetVWAP(context.Context, uuid.UUID, time.Time, time.Time) (*marketdata.VWAP, error) {
+	r.calls = append(r.calls, "GetVWAP")
+	return nil, nil
+}
+
+func (r *rangeRepo) GetTradeActivity(context.Context, uuid.UUID, time.Time, time.Time, int64) ([]marketdata.TradeActivityBucket, error) {
+	r.calls = append(r.calls, "GetTradeActivity")
+	return nil, nil
+}
+
+func (r *rangeRepo) GetCandlesFromTrades(context.Context, uuid.UUID, int64, time.Time, time.Time, string) ([]marketdata.Candle, error) {
+	r.calls = append(r.calls, "GetCandlesFromTrades")
+	return nil, nil
+}
+
+func (r *rangeRepo) GetCandlesResampled(context.Context, uuid.UUID, int64, int64, time.Time, time.Time, string) ([]marketdata.Candle, error) {
+	r.calls = append(r.calls, "GetCandlesResampled")
+	return nil, nil
+}
+
+func (r *rangeRepo) GetTopOfBook(context.Context, uuid.UUID, time.Time, time.Time, marketdata.Page) ([]marketdata.TopOfBook, error) {
+	r.calls = append(r.calls, "GetTopOfBook")
+	return nil, nil
+}
+
+func (r *rangeRepo) GetSpreadSeries(context.Context, uuid.UUID, int32, time.Time, time.Time, int64) ([]marketdata.SpreadBucket, error) {
+	r.calls = append(r.calls, "GetSpreadSeries")
+	return nil, nil
+}
+
+func (r *rangeRepo) GetOrderBookImbalance(context.Context, uuid.UUID, int32, time.Time, time.Time, marketdata.Page) ([]marketdata.OrderBookImbalance, error) {
+	r.calls = append(r.calls, "GetOrderBookImbalance")
+	return nil, nil
+}
+
+// rangeQueries runs every capped range query of s over from and to.
+var rangeQueries = []struct {
+	name string
+	run  func(s *Service, ctx context.Context, from, to time.Time) error
+}{
+	{"GetTradesBetween", func(s *Service, ctx context.Context, from, to time.Time) error {
+		_, err := s.GetTradesBetween(ctx, uuid.New(), "", from, to, nil, marketdata.Page{})
+		return err
+	}},
+	{"EachTradeBetween", func(s *Service, ctx context.Context, from, to time.Time) error {
+		return s.EachTradeBetween(ctx, uuid.New(), "", from, to, nil, marketdata.Page{}, func(marketdata.Trade) error { return nil })
+	}},
+	{"GetCandlesBetween", func(s *Service, ctx context.Context, from, to time.Time) error {
+		_, err := s.GetCandlesBetween(ctx, uuid.New(), 60, from, to, nil, marketdata.Page{})
+		return err
+	}},
+	{"GetOrderBookSnapshotsBetween", func(s *Service, ctx context.Context, from, to time.Time) error {
+		_, err := s.GetOrderBookSnapshotsBetween(ctx, uuid.New(), 10, marketdata.DepthMatchExact, from, to, nil, marketdata.Page{})
+		return err
+	}},
+	{"GetVWAP", func(s *Service, ctx context.Context, from, to time.Time) error {
+		_, err := s.GetVWAP(ctx, uuid.New(), from, to)
+		return err
+	}},
+	{"GetTradeActivity", func(s *Service, ctx context.Context, from, to time.Time) error {
+		_, err := s.GetTradeActivity(ctx, uuid.New(), from, to, WeekIntervalSeconds)
+		return err
+	}},
+	{"GetCandlesFromTrades", func(s *Service, ctx context.Context, from, to time.Time) error {
+		_, err := s.GetCandlesFromTrades(ctx, uuid.New(), WeekIntervalSeconds, from, to, nil)
+		return err
+	}},
+	{"GetCandlesResampled", func(s *Service, ctx context.Context, from, to time.Time) error {
+		_, err := s.GetCandlesResampled(ctx, uuid.New(), DayIntervalSeconds, WeekIntervalSeconds, from, to, nil)
+		return err
+	}},
+	{"GetTopOfBook", func(s *Service, ctx context.Context, from, to time.Time) error {
+		_, err := s.GetTopOfBook(ctx, uuid.New(), from, to, marketdata.Page{})
+		return err
+	}},
+	{"GetSpreadSeries", func(s *Service, ctx context.Context, from, to time.Time) error {
+		_, err := s.GetSpreadSeries(ctx, uuid.New(), 10, from, to, WeekIntervalSeconds)
+		return err
+	}},
+	{"GetOrderBookImbalance", func(s *Service, ctx context.Context, from, to time.Time) error {
+		_, err := s.GetOrderBookImbalance(ctx, uuid.New(), 0, from, to, marketdata.Page{})
+		return err
+	}},
+}
+
+func TestRangeTooWideRejectedBeforeRepository(t *testing.T) {
+	from := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, q := range rangeQueries {
+		t.Run(q.name, func(t *testing.T) {
+			repo := &rangeRepo{}
+			err := q.run(NewService(repo), context.Background(), from, to)
+			if !errors.Is(err, ErrRangeTooWide) {
+				t.Fatalf("err = %v, want ErrRangeTooWide", err)
+			}
+			if len(repo.calls) != 0 {
+				t.Fatalf("repository called: %v", repo.calls)
+			}
+			// Reversed bounds are swapped, not let through.
+			if err := q.run(NewService(repo), context.Background(), to, from); !errors.Is(err, ErrRangeTooWide) {
+				t.Fatalf("reversed bounds: err = %v, want ErrRangeTooWide", err)
+			}
+		})
+	}
+}
+
+func TestRangeLimitAllows(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		maxRange time.Duration
+		to       time.Time
+	}{
+		{"window at the cap", context.Background(), DefaultMaxRange, from.Add(DefaultMaxRange)},
+		{"unlimited context", WithoutRangeLimit(context.Background()), DefaultMaxRange, from.AddDate(5, 0, 0)},
+		{"cap lifted", context.Background(), 0, from.AddDate(5, 0, 0)},
+	}
+	for _, tt := range tests {
+		for _, q := range rangeQueries {
+			t.Run(tt.name+"/"+q.name, func(t *testing.T) {
+				repo := &rangeRepo{}
+				s := NewService(repo)
+				s.SetMaxRange(tt.maxRange)
+				if err := q.run(s, tt.ctx, from, tt.to); errors.Is(err, ErrRangeTooWide) {
+					t.Fatalf("err = %v, want the range accepted", err)
+				}
+				if len(repo.calls) != 1 || repo.calls[0] != q.name {
+					t.Fatalf("repository calls = %v, want [%s]", repo.calls, q.name)
+				}
+			})
+		}
+	}
+}
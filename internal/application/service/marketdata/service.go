@@ -3,6 +3,9 @@ package marketdata
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
+	"sort"
 	"time"
 
 	marketdata "main/internal/domain/entity/marketdata"
@@ -16,15 +19,106 @@ var (
 	ErrNilCandle       = errors.New("candle is nil")
 	ErrNilOrderBook    = errors.New("order book snapshot is nil")
 	ErrInvalidLimit    = errors.New("limit must be positive")
+	ErrLimitTooLarge   = fmt.Errorf("limit must not exceed %d", MaxPageLimit)
+	ErrInvalidOffset   = errors.New("offset must not be negative")
 	ErrInvalidInterval = errors.New("interval seconds must be positive")
+	ErrInvalidDepth    = errors.New("depth must be positive")
+	ErrInvalidLevels   = errors.New("levels must be positive")
+	ErrInvalidBucket   = errors.New("bucket seconds must be positive")
+	ErrTooManyBuckets  = fmt.Errorf("time range spans more than %d buckets", MaxBuckets)
+	ErrNoTrades        = errors.New("no trades in the time range")
+	ErrInvalidDays     = fmt.Errorf("days must be between 1 and %d", MaxADVDays)
+	ErrInvalidResample = errors.New("target interval must be a positive multiple of the base interval")
+	ErrCalendarAlign   = errors.New("only daily and weekly intervals can be aligned to a timezone")
+)
+
+// Intervals that GetCandlesFromTrades and GetCandlesResampled can align to the
+// calendar of a timezone.
+const (
+	DayIntervalSeconds  = 86400
+	WeekIntervalSeconds = 7 * DayIntervalSeconds
+)
+
+// MaxADVDays caps the lookback of GetADV and GetSectorVolatility.
+const MaxADVDays = 365
+
+const (
+	// TradingDaysPerYear annualizes daily volatility.
+	TradingDaysPerYear = 252
+	// minVolatilityReturns is the fewest daily returns an instrument needs to
+	// count towards its sector's volatility.
+	minVolatilityReturns = 5
+)
+
+// MaxBuckets caps the number of time buckets a single aggregate query may return.
+const MaxBuckets = 10000
+
+const (
+	// DefaultPageLimit is the page size of range queries that do not set one.
+	DefaultPageLimit = 1000
+	// MaxPageLimit caps the page size of range queries.
+	MaxPageLimit = 10000
+	// DefaultDeleteChunkSize is how many rows a delete removes per statement
+	// unless SetDeleteChunkSize says otherwise.
+	DefaultDeleteChunkSize = 10000
 )
 
 type Service struct {
-	repo interfaces.MarketDataRepository
+	repo            interfaces.MarketDataRepository
+	metadataLimits  MetadataLimits
+	depthFallback   DepthFallback
+	venues          venueCache
+	marketLocation  *time.Location
+	onConflict      marketdata.ConflictPolicy
+	candleDedup     bool
+	orderBookChecks OrderBookChecks
+	deleteChunk     int
+	maxRange        time.Duration
+	instruments     *instrumentCheck
+	lastPrices      LastPriceStore
 }
 
 func NewService(repo interfaces.MarketDataRepository) *Service {
-	return &Service{repo: repo}
+	return &Service{repo: repo, metadataLimits: DefaultMetadataLimits, depthFallback: DepthFallbackStrict, marketLocation: time.UTC, onConflict: marketdata.ConflictFail, orderBookChecks: DefaultOrderBookChecks, deleteChunk: DefaultDeleteChunkSize, maxRange: DefaultMaxRange}
+}
+
+// SetConflictPolicy selects how batch adds treat rows that are already stored.
+// It is meant to be called once at startup, before the service is shared.
+func (s *Service) SetConflictPolicy(policy marketdata.ConflictPolicy) {
+	s.onConflict = policy
+}
+
+// SetCandleDedup makes candle writes upsert on instrument, interval and period
+// start, so a candle published again as it updates replaces the stored one
+// instead of colliding with it. Batches then bypass the conflict policy. It is
+// meant to be called once at startup, before the service is shared.
+func (s *Service) SetCandleDedup(enabled bool) {
+	s.candleDedup = enabled
+}
+
+// SetDeleteChunkSize sets how many rows the delete methods remove per
+// statement. It is meant to be called once at startup, before the service is
+// shared.
+func (s *Service) SetDeleteChunkSize(size int) {
+	s.deleteChunk = size
+}
+
+// SetMarketLocation sets the timezone whose calendar days GetADV groups trades
+// by. It is meant to be called once at startup, before the service is shared.
+func (s *Service) SetMarketLocation(loc *time.Location) {
+	s.marketLocation = loc
+}
+
+// SetMetadataLimits replaces the limits applied to metadata on every add call.
+// It is meant to be called once at startup, before the service is shared.
+func (s *Service) SetMetadataLimits(limits MetadataLimits) {
+	s.metadataLimits = limits
+}
+
+// ValidateMetadata checks metadata against the configured limits, letting
+// callers that queue entities reject them one by one before a batched add.
+func (s *Service) ValidateMetadata(metadata map[string]any) error {
+	return s.metadataLimits.Validate(metadata)
 }
 
 // Trades
@@ -33,28 +127,182 @@ func (s *Service) AddTrade(ctx context.Context, trade *marketdata.Trade) error {
 	if trade == nil {
 		return ErrNilTrade
 	}
+	if err := s.ValidateTrade(trade); err != nil {
+		return err
+	}
+	if err := s.CheckInstruments(ctx, trade.InstrumentUID); err != nil {
+		return err
+	}
+	if err := s.fillVenues(ctx, []*marketdata.Trade{trade}); err != nil {
+		return err
+	}
 	return s.repo.AddTrade(ctx, trade)
 }
 
-func (s *Service) AddTrades(ctx context.Context, trades []marketdata.Trade) error {
+// AddTrades stores trades as one batch in a single transaction and returns how
+// many were stored, which is fewer than given when the conflict policy skips
+// duplicates. On error none of them are stored.
+func (s *Service) AddTrades(ctx context.Context, trades []marketdata.Trade) (int64, error) {
 	if len(trades) == 0 {
-		return nil
+		return 0, nil
+	}
+	for i := range trades {
+		if err := s.ValidateTrade(&trades[i]); err != nil {
+			return 0, &BatchItemError{Item: "trade", Index: i, Err: err}
+		}
+	}
+	if err := s.CheckInstruments(ctx, tradeInstruments(trades)...); err != nil {
+		return 0, err
 	}
-	return s.repo.AddTrades(ctx, trades)
+	pending := make([]*marketdata.Trade, len(trades))
+	for i := range trades {
+		pending[i] = &trades[i]
+	}
+	if err := s.fillVenues(ctx, pending); err != nil {
+		return 0, err
+	}
+	return s.repo.AddTrades(ctx, trades, s.onConflict)
+}
+
+// DeleteTradesBefore removes an instrument's trades older than cutoff and
+// returns how many were removed.
+func (s *Service) DeleteTradesBefore(ctx context.Context, instrumentUID uuid.UUID, cutoff time.Time) (int64, error) {
+	return s.repo.DeleteTradesBefore(ctx, instrumentUID, cutoff, s.deleteChunk)
+}
+
+// DeleteTradesBetween removes an instrument's trades in the range, the same
+// trades GetTradesBetween would return across all venues.
+func (s *Service) DeleteTradesBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time) (int64, error) {
+	if from.After(to) {
+		from, to = to, from
+	}
+	return s.repo.DeleteTradesBetween(ctx, instrumentUID, from, to, s.deleteChunk)
 }
 
-func (s *Service) GetTradesBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time) ([]marketdata.Trade, error) {
+// GetTradesBetween returns a page of trades in the range. A non-empty venue keeps
+// only trades executed on that board, and meta only trades whose metadata
+// contains every pair.
+func (s *Service) GetTradesBetween(ctx context.Context, instrumentUID uuid.UUID, venue string, from, to time.Time, meta marketdata.MetadataFilter, page marketdata.Page) ([]marketdata.Trade, error) {
+	page, err := normalizePage(page)
+	if err != nil {
+		return nil, err
+	}
 	if from.After(to) {
 		from, to = to, from
 	}
-	return s.repo.GetTradesBetween(ctx, instrumentUID, from, to)
+	if err := s.checkRange(ctx, from, to); err != nil {
+		return nil, err
+	}
+	return s.repo.GetTradesBetween(ctx, instrumentUID, normalizeVenue(venue), from, to, meta, page)
+}
+
+// GetTradeByID returns a single trade, or marketdata.ErrTradeNotFound.
+func (s *Service) GetTradeByID(ctx context.Context, id uuid.UUID) (*marketdata.Trade, error) {
+	return s.repo.GetTradeByID(ctx, id)
 }
 
-func (s *Service) GetLastTrades(ctx context.Context, instrumentUID uuid.UUID, limit int) ([]marketdata.Trade, error) {
+func (s *Service) GetLastTrades(ctx context.Context, instrumentUID uuid.UUID, venue string, limit int) ([]marketdata.Trade, error) {
 	if limit <= 0 {
 		return nil, ErrInvalidLimit
 	}
-	return s.repo.GetLastTrades(ctx, instrumentUID, limit)
+	return s.repo.GetLastTrades(ctx, instrumentUID, normalizeVenue(venue), limit)
+}
+
+// GetVWAP returns the volume-weighted average price of the trades in the range,
+// or ErrNoTrades when the range holds no traded volume.
+func (s *Service) GetVWAP(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time) (*marketdata.VWAP, error) {
+	if from.After(to) {
+		from, to = to, from
+	}
+	if err := s.checkRange(ctx, from, to); err != nil {
+		return nil, err
+	}
+	vwap, err := s.repo.GetVWAP(ctx, instrumentUID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if vwap == nil {
+		return nil, ErrNoTrades
+	}
+	return vwap, nil
+}
+
+// GetTradeSummary reports how many trades of the instrument are stored and
+// what they span. An instrument without trades gets a zero count.
+func (s *Service) GetTradeSummary(ctx context.Context, instrumentUID uuid.UUID) (*marketdata.TradeSummary, error) {
+	return s.repo.GetTradeSummary(ctx, instrumentUID)
+}
+
+// GetADV returns the average daily volume over the last days complete calendar
+// days in the market timezone; the current day is left out because it is not
+// over yet. It returns ErrNoTrades when none of those days had a trade.
+func (s *Service) GetADV(ctx context.Context, instrumentUID uuid.UUID, days int) (*marketdata.ADV, error) {
+	if days <= 0 || days > MaxADVDays {
+		return nil, ErrInvalidDays
+	}
+	now := time.Now().In(s.marketLocation)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.marketLocation)
+	from := to.AddDate(0, 0, -days)
+	daily, err := s.repo.GetDailyVolumes(ctx, instrumentUID, from, to, s.marketLocation.String())
+	if err != nil {
+		return nil, err
+	}
+	if len(daily) == 0 {
+		return nil, ErrNoTrades
+	}
+	var total int64
+	for i := range daily {
+		daily[i].Day = daily[i].Day.In(s.marketLocation)
+		total += daily[i].VolumeLots
+	}
+	return &marketdata.ADV{
+		InstrumentUID: instrumentUID,
+		Days:          days,
+		Timezone:      s.marketLocation.String(),
+		From:          from,
+		To:            to,
+		TradingDays:   len(daily),
+		ADVLots:       float64(total) / float64(len(daily)),
+		Daily:         daily,
+	}, nil
+}
+
+// GetSectorVolatility computes the realized volatility of every sector from the
+// daily closes of its instruments' stored candles of intervalSeconds, over the
+// complete calendar days in the market timezone before today. Sectors without
+// enough candles are left out.
+func (s *Service) GetSectorVolatility(ctx context.Context, intervalSeconds int64, days int) ([]marketdata.SectorVolatility, error) {
+	if intervalSeconds <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	if days <= 0 || days > MaxADVDays {
+		return nil, ErrInvalidDays
+	}
+	now := time.Now().In(s.marketLocation)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.marketLocation)
+	from := to.AddDate(0, 0, -days)
+	sectors, err := s.repo.GetSectorVolatility(ctx, intervalSeconds, from, to, s.marketLocation.String(), minVolatilityReturns)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sectors {
+		sectors[i].Annualized = sectors[i].DailyStddev * math.Sqrt(TradingDaysPerYear)
+	}
+	return sectors, nil
+}
+
+// GetTradeActivity returns trade counts and volume per time bucket, including empty buckets.
+func (s *Service) GetTradeActivity(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, bucketSeconds int64) ([]marketdata.TradeActivityBucket, error) {
+	if from.After(to) {
+		from, to = to, from
+	}
+	if err := s.checkRange(ctx, from, to); err != nil {
+		return nil, err
+	}
+	if err := validateBuckets(from, to, bucketSeconds); err != nil {
+		return nil, err
+	}
+	return s.repo.GetTradeActivity(ctx, instrumentUID, from, to, bucketSeconds)
 }
 
 // Candles
@@ -63,24 +311,71 @@ func (s *Service) AddCandle(ctx context.Context, candle *marketdata.Candle) erro
 	if candle == nil {
 		return ErrNilCandle
 	}
+	if err := s.ValidateMetadata(candle.Metadata); err != nil {
+		return err
+	}
+	if err := s.CheckInstruments(ctx, candle.InstrumentUID); err != nil {
+		return err
+	}
+	if s.candleDedup {
+		return s.repo.UpsertCandle(ctx, candle)
+	}
 	return s.repo.AddCandle(ctx, candle)
 }
 
-func (s *Service) AddCandles(ctx context.Context, candles []marketdata.Candle) error {
+// AddCandles stores candles as one batch in a single transaction and returns
+// how many were stored. On error none of them are stored.
+func (s *Service) AddCandles(ctx context.Context, candles []marketdata.Candle) (int64, error) {
 	if len(candles) == 0 {
-		return nil
+		return 0, nil
+	}
+	for i := range candles {
+		if err := s.ValidateMetadata(candles[i].Metadata); err != nil {
+			return 0, &BatchItemError{Item: "candle", Index: i, Err: err}
+		}
 	}
-	return s.repo.AddCandles(ctx, candles)
+	if err := s.CheckInstruments(ctx, candleInstruments(candles)...); err != nil {
+		return 0, err
+	}
+	if s.candleDedup {
+		return s.repo.UpsertCandles(ctx, candles)
+	}
+	return s.repo.AddCandles(ctx, candles, s.onConflict)
+}
+
+// DeleteCandlesBefore removes an instrument's candles of every interval whose
+// period started before cutoff.
+func (s *Service) DeleteCandlesBefore(ctx context.Context, instrumentUID uuid.UUID, cutoff time.Time) (int64, error) {
+	return s.repo.DeleteCandlesBefore(ctx, instrumentUID, cutoff, s.deleteChunk)
+}
+
+func (s *Service) DeleteCandlesBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time) (int64, error) {
+	if from.After(to) {
+		from, to = to, from
+	}
+	return s.repo.DeleteCandlesBetween(ctx, instrumentUID, from, to, s.deleteChunk)
 }
 
-func (s *Service) GetCandlesBetween(ctx context.Context, instrumentUID uuid.UUID, intervalSeconds int64, from, to time.Time) ([]marketdata.Candle, error) {
+func (s *Service) GetCandlesBetween(ctx context.Context, instrumentUID uuid.UUID, intervalSeconds int64, from, to time.Time, meta marketdata.MetadataFilter, page marketdata.Page) ([]marketdata.Candle, error) {
 	if intervalSeconds <= 0 {
 		return nil, ErrInvalidInterval
 	}
+	page, err := normalizePage(page)
+	if err != nil {
+		return nil, err
+	}
 	if from.After(to) {
 		from, to = to, from
 	}
-	return s.repo.GetCandlesBetween(ctx, instrumentUID, from, to, intervalSeconds)
+	if err := s.checkRange(ctx, from, to); err != nil {
+		return nil, err
+	}
+	return s.repo.GetCandlesBetween(ctx, instrumentUID, from, to, intervalSeconds, meta, page)
+}
+
+// GetCandleByID returns a single candle, or marketdata.ErrCandleNotFound.
+func (s *Service) GetCandleByID(ctx context.Context, id uuid.UUID) (*marketdata.Candle, error) {
+	return s.repo.GetCandleByID(ctx, id)
 }
 
 func (s *Service) GetLastCandles(ctx context.Context, instrumentUID uuid.UUID, intervalSeconds int64, limit int) ([]marketdata.Candle, error) {
@@ -93,40 +388,334 @@ func (s *Service) GetLastCandles(ctx context.Context, instrumentUID uuid.UUID, i
 	return s.repo.GetLastCandles(ctx, instrumentUID, intervalSeconds, limit)
 }
 
+func (s *Service) GetCandleSummary(ctx context.Context, instrumentUID uuid.UUID, intervalSeconds int64) (*marketdata.CandleSummary, error) {
+	if intervalSeconds <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	return s.repo.GetCandleSummary(ctx, instrumentUID, intervalSeconds)
+}
+
+// GetCandleGaps reports runs of missing candles; with no instrumentUIDs it scans all instruments.
+func (s *Service) GetCandleGaps(ctx context.Context, intervalSeconds int64, from, to time.Time, instrumentUIDs ...uuid.UUID) ([]marketdata.CandleGap, error) {
+	if intervalSeconds <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	if from.After(to) {
+		from, to = to, from
+	}
+	return s.repo.GetCandleGaps(ctx, intervalSeconds, from, to, instrumentUIDs...)
+}
+
+// GetCandlesFromTrades builds candles of any interval from stored trades. Buckets
+// are aligned to the Unix epoch like stored candles, and buckets without trades
+// are skipped. A non-nil loc aligns daily and weekly buckets to midnight, or
+// Monday midnight, in loc instead, and period starts are returned in loc.
+func (s *Service) GetCandlesFromTrades(ctx context.Context, instrumentUID uuid.UUID, intervalSeconds int64, from, to time.Time, loc *time.Location) ([]marketdata.Candle, error) {
+	if intervalSeconds <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	timezone, err := calendarTimezone(intervalSeconds, loc)
+	if err != nil {
+		return nil, err
+	}
+	if from.After(to) {
+		from, to = to, from
+	}
+	if err := s.checkRange(ctx, from, to); err != nil {
+		return nil, err
+	}
+	if err := validateBuckets(from, to, intervalSeconds); err != nil {
+		return nil, err
+	}
+	candles, err := s.repo.GetCandlesFromTrades(ctx, instrumentUID, intervalSeconds, from, to, timezone)
+	return alignCandles(candles, loc), err
+}
+
+// GetCandlesResampled merges stored candles of baseInterval into coarser ones
+// of targetInterval, which must be a multiple of it. Buckets are aligned to the
+// Unix epoch, or like GetCandlesFromTrades to the calendar of a non-nil loc,
+// and may hold fewer candles than the ratio at the edges of the stored data or
+// across trading breaks.
+func (s *Service) GetCandlesResampled(ctx context.Context, instrumentUID uuid.UUID, baseInterval, targetInterval int64, from, to time.Time, loc *time.Location) ([]marketdata.Candle, error) {
+	if baseInterval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	if targetInterval <= 0 || targetInterval%baseInterval != 0 {
+		return nil, ErrInvalidResample
+	}
+	timezone, err := calendarTimezone(targetInterval, loc)
+	if err != nil {
+		return nil, err
+	}
+	if from.After(to) {
+		from, to = to, from
+	}
+	if err := s.checkRange(ctx, from, to); err != nil {
+		return nil, err
+	}
+	if err := validateBuckets(from, to, targetInterval); err != nil {
+		return nil, err
+	}
+	candles, err := s.repo.GetCandlesResampled(ctx, instrumentUID, baseInterval, targetInterval, from, to, timezone)
+	return alignCandles(candles, loc), err
+}
+
+// calendarTimezone returns the name of loc for the repository, or "" for nil,
+// and ErrCalendarAlign when loc is set for an interval other than a day or a
+// week.
+func calendarTimezone(intervalSeconds int64, loc *time.Location) (string, error) {
+	if loc == nil {
+		return "", nil
+	}
+	if intervalSeconds != DayIntervalSeconds && intervalSeconds != WeekIntervalSeconds {
+		return "", ErrCalendarAlign
+	}
+	return loc.String(), nil
+}
+
+// alignCandles shows the period starts of candles aligned to loc in loc, so
+// a daily candle reads as local midnight.
+func alignCandles(candles []marketdata.Candle, loc *time.Location) []marketdata.Candle {
+	if loc == nil {
+		return candles
+	}
+	for i := range candles {
+		candles[i].PeriodStart = candles[i].PeriodStart.In(loc)
+	}
+	return candles
+}
+
 // Order book snapshots
 
 func (s *Service) AddOrderBookSnapshot(ctx context.Context, snapshot *marketdata.OrderBookSnapshot) error {
 	if snapshot == nil {
 		return ErrNilOrderBook
 	}
+	if err := s.validateOrderBookForAdd(snapshot); err != nil {
+		return err
+	}
+	if err := s.CheckInstruments(ctx, snapshot.InstrumentUID); err != nil {
+		return err
+	}
 	return s.repo.AddOrderBookSnapshot(ctx, snapshot)
 }
 
-func (s *Service) AddOrderBookSnapshots(ctx context.Context, snapshots []marketdata.OrderBookSnapshot) error {
+// AddOrderBookSnapshots stores snapshots as one batch in a single transaction
+// and returns how many were stored. On error none of them are stored.
+func (s *Service) AddOrderBookSnapshots(ctx context.Context, snapshots []marketdata.OrderBookSnapshot) (int64, error) {
 	if len(snapshots) == 0 {
-		return nil
+		return 0, nil
+	}
+	for i := range snapshots {
+		if err := s.validateOrderBookForAdd(&snapshots[i]); err != nil {
+			return 0, &BatchItemError{Item: "order book snapshot", Index: i, Err: err}
+		}
+	}
+	if err := s.CheckInstruments(ctx, orderBookInstruments(snapshots)...); err != nil {
+		return 0, err
+	}
+	return s.repo.AddOrderBookSnapshots(ctx, snapshots, s.onConflict)
+}
+
+// DeleteOrderBookSnapshotsBefore removes an instrument's snapshots of every
+// depth taken before cutoff.
+func (s *Service) DeleteOrderBookSnapshotsBefore(ctx context.Context, instrumentUID uuid.UUID, cutoff time.Time) (int64, error) {
+	return s.repo.DeleteOrderBookSnapshotsBefore(ctx, instrumentUID, cutoff, s.deleteChunk)
+}
+
+func (s *Service) DeleteOrderBookSnapshotsBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time) (int64, error) {
+	if from.After(to) {
+		from, to = to, from
 	}
-	return s.repo.AddOrderBookSnapshots(ctx, snapshots)
+	return s.repo.DeleteOrderBookSnapshotsBetween(ctx, instrumentUID, from, to, s.deleteChunk)
 }
 
-func (s *Service) GetOrderBookSnapshotsBetween(ctx context.Context, instrumentUID uuid.UUID, depth int32, from, to time.Time) ([]marketdata.OrderBookSnapshot, error) {
+// GetOrderBookSnapshotsBetween returns a page of snapshots in the range. With
+// DepthMatchAtLeast, deeper snapshots are included and truncated to depth.
+func (s *Service) GetOrderBookSnapshotsBetween(ctx context.Context, instrumentUID uuid.UUID, depth int32, match marketdata.DepthMatch, from, to time.Time, meta marketdata.MetadataFilter, page marketdata.Page) ([]marketdata.OrderBookSnapshot, error) {
 	if depth <= 0 {
-		return nil, errors.New("depth must be positive")
+		return nil, ErrInvalidDepth
+	}
+	if err := validateDepthMatch(match); err != nil {
+		return nil, err
+	}
+	page, err := normalizePage(page)
+	if err != nil {
+		return nil, err
 	}
 	if from.After(to) {
 		from, to = to, from
 	}
-	return s.repo.GetOrderBookSnapshotsBetween(ctx, instrumentUID, from, to, depth)
+	if err := s.checkRange(ctx, from, to); err != nil {
+		return nil, err
+	}
+	snapshots, err := s.repo.GetOrderBookSnapshotsBetween(ctx, instrumentUID, from, to, depth, match, meta, page)
+	if err != nil {
+		return nil, err
+	}
+	truncateDepth(snapshots, depth)
+	return snapshots, nil
+}
+
+// GetOrderBookByID returns a single snapshot, or
+// marketdata.ErrOrderBookNotFound.
+func (s *Service) GetOrderBookByID(ctx context.Context, id uuid.UUID) (*marketdata.OrderBookSnapshot, error) {
+	return s.repo.GetOrderBookByID(ctx, id)
 }
 
-func (s *Service) GetLastOrderBookSnapshots(ctx context.Context, instrumentUID uuid.UUID, depth int32, limit int) ([]marketdata.OrderBookSnapshot, error) {
+// GetLastOrderBookSnapshots returns the latest snapshots, matching depth like
+// GetOrderBookSnapshotsBetween.
+func (s *Service) GetLastOrderBookSnapshots(ctx context.Context, instrumentUID uuid.UUID, depth int32, match marketdata.DepthMatch, limit int) ([]marketdata.OrderBookSnapshot, error) {
 	if depth <= 0 {
-		return nil, errors.New("depth must be positive")
+		return nil, ErrInvalidDepth
+	}
+	if err := validateDepthMatch(match); err != nil {
+		return nil, err
 	}
 	if limit <= 0 {
 		return nil, ErrInvalidLimit
 	}
-	return s.repo.GetLastOrderBookSnapshots(ctx, instrumentUID, depth, limit)
+	snapshots, err := s.repo.GetLastOrderBookSnapshots(ctx, instrumentUID, depth, match, limit)
+	if err != nil {
+		return nil, err
+	}
+	truncateDepth(snapshots, depth)
+	return snapshots, nil
+}
+
+// GetOrderBookSummary reports how many snapshots of the instrument are stored,
+// what they span and at which depths.
+func (s *Service) GetOrderBookSummary(ctx context.Context, instrumentUID uuid.UUID) (*marketdata.OrderBookSummary, error) {
+	return s.repo.GetOrderBookSummary(ctx, instrumentUID)
+}
+
+// GetTopOfBook returns a page of best bid, best ask and spread per snapshot in
+// the range. One-sided snapshots are included with the missing side nil.
+func (s *Service) GetTopOfBook(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, page marketdata.Page) ([]marketdata.TopOfBook, error) {
+	page, err := normalizePage(page)
+	if err != nil {
+		return nil, err
+	}
+	if from.After(to) {
+		from, to = to, from
+	}
+	if err := s.checkRange(ctx, from, to); err != nil {
+		return nil, err
+	}
+	return s.repo.GetTopOfBook(ctx, instrumentUID, from, to, page)
+}
+
+// GetSpreadSeries returns the average top-of-book spread and mid price per time
+// bucket, including empty buckets.
+func (s *Service) GetSpreadSeries(ctx context.Context, instrumentUID uuid.UUID, depth int32, from, to time.Time, bucketSeconds int64) ([]marketdata.SpreadBucket, error) {
+	if depth <= 0 {
+		return nil, ErrInvalidDepth
+	}
+	if from.After(to) {
+		from, to = to, from
+	}
+	if err := s.checkRange(ctx, from, to); err != nil {
+		return nil, err
+	}
+	if err := validateBuckets(from, to, bucketSeconds); err != nil {
+		return nil, err
+	}
+	return s.repo.GetSpreadSeries(ctx, instrumentUID, depth, from, to, bucketSeconds)
+}
+
+// GetOrderBookImbalance returns a page of order book imbalance per snapshot in
+// the range, over the first levels levels of each side, or all of them for
+// zero. A one-sided snapshot has an imbalance of 1 or -1, and an empty one has
+// none.
+func (s *Service) GetOrderBookImbalance(ctx context.Context, instrumentUID uuid.UUID, levels int32, from, to time.Time, page marketdata.Page) ([]marketdata.OrderBookImbalance, error) {
+	if levels < 0 {
+		return nil, ErrInvalidLevels
+	}
+	page, err := normalizePage(page)
+	if err != nil {
+		return nil, err
+	}
+	if from.After(to) {
+		from, to = to, from
+	}
+	if err := s.checkRange(ctx, from, to); err != nil {
+		return nil, err
+	}
+	return s.repo.GetOrderBookImbalance(ctx, instrumentUID, levels, from, to, page)
+}
+
+// FlagSequenceGaps marks snapshots whose sequence does not directly follow the
+// previous snapshot's in time order, which means updates were dropped or arrived
+// out of order. Snapshots without a sequence are never flagged and break the chain.
+// Snapshots the consumer's throttle dropped on purpose are not gaps.
+func FlagSequenceGaps(snapshots []marketdata.OrderBookSnapshot) {
+	order := make([]int, len(snapshots))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return snapshots[order[a]].SnapshotAt.Before(snapshots[order[b]].SnapshotAt)
+	})
+
+	var prev *int64
+	for _, i := range order {
+		snapshots[i].SequenceGap = IsSequenceGap(prev, snapshots[i])
+		prev = snapshots[i].Sequence
+	}
+}
+
+// IsSequenceGap reports whether snapshot does not directly follow a snapshot
+// with sequence prev. A snapshot with SequenceFrom follows prev when
+// SequenceFrom does.
+func IsSequenceGap(prev *int64, snapshot marketdata.OrderBookSnapshot) bool {
+	if prev == nil || snapshot.Sequence == nil {
+		return false
+	}
+	first := snapshot.Sequence
+	if snapshot.SequenceFrom != nil {
+		first = snapshot.SequenceFrom
+	}
+	return *first != *prev+1
+}
+
+// normalizePage applies DefaultPageLimit to an unset limit and rejects pages
+// outside the allowed bounds.
+func normalizePage(page marketdata.Page) (marketdata.Page, error) {
+	switch {
+	case page.Limit == 0:
+		page.Limit = DefaultPageLimit
+	case page.Limit < 0:
+		return page, ErrInvalidLimit
+	case page.Limit > MaxPageLimit:
+		return page, ErrLimitTooLarge
+	}
+	if page.Offset < 0 {
+		return page, ErrInvalidOffset
+	}
+	return page, nil
+}
+
+// validateBuckets counts buckets the way the aggregate queries align them,
+// floor(epoch / bucketSeconds), so bounds that sit inside their buckets count
+// the partial buckets at both ends.
+func validateBuckets(from, to time.Time, bucketSeconds int64) error {
+	if bucketSeconds <= 0 {
+		return ErrInvalidBucket
+	}
+	if floorDiv(to.Unix(), bucketSeconds)-floorDiv(from.Unix(), bucketSeconds)+1 > MaxBuckets {
+		return ErrTooManyBuckets
+	}
+	return nil
+}
+
+// floorDiv divides rounding toward negative infinity, so pre-epoch times land
+// in the same bucket as in SQL's floor().
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
 }
 
 func (s *Service) Close() {
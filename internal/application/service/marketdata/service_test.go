@@ -0,0 +1,92 @@
+package marketdata
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"main/internal/domain/entity/marketdata"
+)
+
+func seq(n int64) *int64 { return &n }
+
+func TestFlagSequenceGaps(t *testing.T) {
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		sequences [][2]*int64 // sequence, sequence_from
+		want      []bool
+	}{
+		{
+			name:      "consecutive",
+			sequences: [][2]*int64{{seq(1), nil}, {seq(2), nil}, {seq(3), nil}},
+			want:      []bool{false, false, false},
+		},
+		{
+			name:      "missing sequence",
+			sequences: [][2]*int64{{seq(1), nil}, {seq(3), nil}},
+			want:      []bool{false, true},
+		},
+		{
+			name:      "throttled sequences are not a gap",
+			sequences: [][2]*int64{{seq(1), nil}, {seq(5), seq(2)}, {seq(6), nil}},
+			want:      []bool{false, false, false},
+		},
+		{
+			name:      "gap before throttled sequences",
+			sequences: [][2]*int64{{seq(1), nil}, {seq(5), seq(3)}},
+			want:      []bool{false, true},
+		},
+		{
+			name:      "no sequence breaks the chain",
+			sequences: [][2]*int64{{seq(1), nil}, {nil, nil}, {seq(7), nil}},
+			want:      []bool{false, false, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshots := make([]marketdata.OrderBookSnapshot, len(tt.sequences))
+			// Stored newest first, as the latest-rows endpoint returns them.
+			for i, s := range tt.sequences {
+				snapshots[len(snapshots)-1-i] = marketdata.OrderBookSnapshot{
+					SnapshotAt:   base.Add(time.Duration(i) * time.Second),
+					Sequence:     s[0],
+					SequenceFrom: s[1],
+				}
+			}
+			FlagSequenceGaps(snapshots)
+			for i, want := range tt.want {
+				if got := snapshots[len(snapshots)-1-i].SequenceGap; got != want {
+					t.Errorf("snapshot %d: SequenceGap = %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateBuckets(t *testing.T) {
+	epoch := time.Unix(0, 0).UTC()
+	tests := []struct {
+		name    string
+		from    time.Time
+		to      time.Time
+		bucket  int64
+		wantErr error
+	}{
+		{"aligned at the cap", epoch, epoch.Add((MaxBuckets - 1) * time.Second), 1, nil},
+		{"aligned over the cap", epoch, epoch.Add(MaxBuckets * time.Second), 1, ErrTooManyBuckets},
+		{"unaligned bounds span one more bucket", epoch.Add(900 * time.Millisecond), epoch.Add(10000*time.Second + 100*time.Millisecond), 1, ErrTooManyBuckets},
+		{"unaligned bounds inside the cap", epoch.Add(900 * time.Millisecond), epoch.Add(9999*time.Second + 100*time.Millisecond), 1, nil},
+		{"wide buckets", epoch.Add(30 * time.Second), epoch.Add(MaxBuckets*time.Minute + 10*time.Second), 60, ErrTooManyBuckets},
+		{"pre-epoch bounds", epoch.Add(-10000*time.Second + 100*time.Millisecond), epoch.Add(100 * time.Millisecond), 1, ErrTooManyBuckets},
+		{"pre-epoch inside the cap", epoch.Add(-9999*time.Second - 900*time.Millisecond), epoch.Add(-100 * time.Millisecond), 1, nil},
+		{"zero bucket", epoch, epoch, 0, ErrInvalidBucket},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateBuckets(tt.from, tt.to, tt.bucket); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("validateBuckets = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
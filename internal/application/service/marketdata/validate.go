@@ -0,0 +1,239 @@
+package marketdata
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	marketdata "main/internal/domain/entity/marketdata"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidTrade is wrapped by every trade field violation.
+	ErrInvalidTrade = errors.New("invalid trade")
+	// ErrInvalidOrderBook is wrapped by every order book snapshot violation.
+	ErrInvalidOrderBook = errors.New("invalid order book snapshot")
+	// ErrCrossedBook marks a snapshot whose best bid is not below its best ask.
+	ErrCrossedBook = fmt.Errorf("%w: crossed book", ErrInvalidOrderBook)
+)
+
+// BatchItemError names the record of a batch that failed validation.
+type BatchItemError struct {
+	// Item is the kind of record, e.g. "trade".
+	Item  string
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("%s %d: %v", e.Item, e.Index, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error { return e.Err }
+
+// CrossedBookPolicy decides what happens to a snapshot whose best bid is at or
+// above its best ask. Some venues cross briefly, so the default only warns.
+type CrossedBookPolicy string
+
+const (
+	// CrossedBookWarn stores the snapshot and reports it to OnCrossed.
+	CrossedBookWarn CrossedBookPolicy = "warn"
+	// CrossedBookReject fails the snapshot with ErrCrossedBook.
+	CrossedBookReject CrossedBookPolicy = "reject"
+)
+
+// OrderBookChecks configures the optional order book validation.
+type OrderBookChecks struct {
+	Crossed CrossedBookPolicy
+	// RequireSorted rejects snapshots whose bids are not in descending or asks
+	// not in ascending price order.
+	RequireSorted bool
+	// OnCrossed, if set, is called for every crossed snapshot stored under
+	// CrossedBookWarn.
+	OnCrossed func(snapshot *marketdata.OrderBookSnapshot, bestBid, bestAsk float64)
+}
+
+// DefaultOrderBookChecks are applied until SetOrderBookChecks is called.
+var DefaultOrderBookChecks = OrderBookChecks{Crossed: CrossedBookWarn}
+
+// SetOrderBookChecks replaces the order book checks applied on every add call.
+// It is meant to be called once at startup, before the service is shared.
+func (s *Service) SetOrderBookChecks(checks OrderBookChecks) {
+	s.orderBookChecks = checks
+}
+
+// ValidateTrade checks a trade's fields and metadata, letting callers that queue
+// trades reject them one by one before a batched add.
+func (s *Service) ValidateTrade(trade *marketdata.Trade) error {
+	if trade == nil {
+		return ErrNilTrade
+	}
+	if err := validateTradeFields(trade); err != nil {
+		return err
+	}
+	return s.ValidateMetadata(trade.Metadata)
+}
+
+// ValidateOrderBookSnapshot checks a snapshot's levels and metadata, letting
+// callers that queue snapshots reject them one by one before a batched add. A
+// crossed book is only an error under CrossedBookReject, and is not reported to
+// OnCrossed here; the add call that stores it does that.
+func (s *Service) ValidateOrderBookSnapshot(snapshot *marketdata.OrderBookSnapshot) error {
+	if snapshot == nil {
+		return ErrNilOrderBook
+	}
+	if _, _, err := s.checkOrderBook(snapshot); err != nil {
+		return err
+	}
+	return s.ValidateMetadata(snapshot.Metadata)
+}
+
+// Violation is a batch record that fails validation, by its index in the batch.
+type Violation struct {
+	Index int
+	Err   error
+}
+
+// ValidateTrades runs the checks AddTrades applies to every trade and reports
+// all failing trades instead of stopping at the first. Nothing is stored and
+// the repository is not queried, so venues and duplicates are not checked.
+func (s *Service) ValidateTrades(trades []marketdata.Trade) []Violation {
+	return collectViolations(trades, s.ValidateTrade)
+}
+
+// ValidateCandles is ValidateTrades for the checks AddCandles applies.
+func (s *Service) ValidateCandles(candles []marketdata.Candle) []Violation {
+	return collectViolations(candles, func(candle *marketdata.Candle) error {
+		return s.ValidateMetadata(candle.Metadata)
+	})
+}
+
+// ValidateOrderBookSnapshots is ValidateTrades for the checks
+// AddOrderBookSnapshots applies. Crossed books are not reported to OnCrossed.
+func (s *Service) ValidateOrderBookSnapshots(snapshots []marketdata.OrderBookSnapshot) []Violation {
+	return collectViolations(snapshots, s.ValidateOrderBookSnapshot)
+}
+
+func collectViolations[T any](records []T, validate func(*T) error) []Violation {
+	var violations []Violation
+	for i := range records {
+		if err := validate(&records[i]); err != nil {
+			violations = append(violations, Violation{Index: i, Err: err})
+		}
+	}
+	return violations
+}
+
+// validateOrderBookForAdd is ValidateOrderBookSnapshot plus the OnCrossed report.
+func (s *Service) validateOrderBookForAdd(snapshot *marketdata.OrderBookSnapshot) error {
+	crossed, bestBid, err := s.checkOrderBook(snapshot)
+	if err != nil {
+		return err
+	}
+	if err := s.ValidateMetadata(snapshot.Metadata); err != nil {
+		return err
+	}
+	if crossed && s.orderBookChecks.OnCrossed != nil {
+		s.orderBookChecks.OnCrossed(snapshot, bestBid, bestAskOf(snapshot.Asks))
+	}
+	return nil
+}
+
+func validateTradeFields(trade *marketdata.Trade) error {
+	switch {
+	case trade.InstrumentUID == uuid.Nil:
+		return fmt.Errorf("%w: instrument_uid is required", ErrInvalidTrade)
+	case trade.Side != marketdata.TradeSideBuy && trade.Side != marketdata.TradeSideSell && trade.Side != marketdata.TradeSideUnknown:
+		return fmt.Errorf("%w: side must be %s, %s or %s, got %q", ErrInvalidTrade, marketdata.TradeSideBuy, marketdata.TradeSideSell, marketdata.TradeSideUnknown, trade.Side)
+	case !validPrice(trade.Price):
+		return fmt.Errorf("%w: price must be positive, got %v", ErrInvalidTrade, trade.Price)
+	case trade.QuantityLots <= 0:
+		return fmt.Errorf("%w: quantity_lots must be positive, got %d", ErrInvalidTrade, trade.QuantityLots)
+	case trade.TradedAt.IsZero():
+		return fmt.Errorf("%w: traded_at is required", ErrInvalidTrade)
+	}
+	return nil
+}
+
+// checkOrderBook validates the snapshot's fields and levels. It reports a crossed
+// book that the policy lets through, with the best bid for the report.
+func (s *Service) checkOrderBook(snapshot *marketdata.OrderBookSnapshot) (crossed bool, bestBid float64, err error) {
+	switch {
+	case snapshot.InstrumentUID == uuid.Nil:
+		return false, 0, fmt.Errorf("%w: instrument_uid is required", ErrInvalidOrderBook)
+	case snapshot.SnapshotAt.IsZero():
+		return false, 0, fmt.Errorf("%w: snapshot_at is required", ErrInvalidOrderBook)
+	}
+	if err := validateLevels("bid", snapshot.Bids); err != nil {
+		return false, 0, err
+	}
+	if err := validateLevels("ask", snapshot.Asks); err != nil {
+		return false, 0, err
+	}
+	if s.orderBookChecks.RequireSorted {
+		if i := unsortedLevel(snapshot.Bids, func(prev, next float64) bool { return next < prev }); i > 0 {
+			return false, 0, fmt.Errorf("%w: bids must be in descending price order, bid %d is not", ErrInvalidOrderBook, i)
+		}
+		if i := unsortedLevel(snapshot.Asks, func(prev, next float64) bool { return next > prev }); i > 0 {
+			return false, 0, fmt.Errorf("%w: asks must be in ascending price order, ask %d is not", ErrInvalidOrderBook, i)
+		}
+	}
+
+	if len(snapshot.Bids) == 0 || len(snapshot.Asks) == 0 {
+		return false, 0, nil
+	}
+	bestBid = bestBidOf(snapshot.Bids)
+	bestAsk := bestAskOf(snapshot.Asks)
+	if bestBid < bestAsk {
+		return false, bestBid, nil
+	}
+	if s.orderBookChecks.Crossed == CrossedBookReject {
+		return false, 0, fmt.Errorf("%w: best bid %v is not below best ask %v", ErrCrossedBook, bestBid, bestAsk)
+	}
+	return true, bestBid, nil
+}
+
+func validateLevels(side string, levels []marketdata.OrderBookLevel) error {
+	for i, level := range levels {
+		if !validPrice(level.Price) {
+			return fmt.Errorf("%w: %s %d price must be positive, got %v", ErrInvalidOrderBook, side, i, level.Price)
+		}
+		if level.Quantity < 0 {
+			return fmt.Errorf("%w: %s %d quantity must not be negative, got %d", ErrInvalidOrderBook, side, i, level.Quantity)
+		}
+	}
+	return nil
+}
+
+// unsortedLevel returns the index of the first level that is not strictly
+// ordered after its predecessor, or 0 when the levels are in order.
+func unsortedLevel(levels []marketdata.OrderBookLevel, ordered func(prev, next float64) bool) int {
+	for i := 1; i < len(levels); i++ {
+		if !ordered(levels[i-1].Price, levels[i].Price) {
+			return i
+		}
+	}
+	return 0
+}
+
+func bestBidOf(bids []marketdata.OrderBookLevel) float64 {
+	best := bids[0].Price
+	for _, level := range bids[1:] {
+		best = max(best, level.Price)
+	}
+	return best
+}
+
+func bestAskOf(asks []marketdata.OrderBookLevel) float64 {
+	best := asks[0].Price
+	for _, level := range asks[1:] {
+		best = min(best, level.Price)
+	}
+	return best
+}
+
+func validPrice(price float64) bool {
+	return price > 0 && !math.IsInf(price, 0)
+}
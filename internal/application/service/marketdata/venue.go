@@ -0,0 +1,80 @@
+package marketdata
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	marketdata "main/internal/domain/entity/marketdata"
+
+	"github.com/google/uuid"
+)
+
+// venueCache maps instrument UIDs to their class_code, which serves as the venue
+// of trades that arrive without one. Class codes practically never change, so
+// entries are kept for the life of the process; instruments without a class
+// code are not cached and are looked up again on the next trade.
+type venueCache struct {
+	mu    sync.RWMutex
+	byUID map[uuid.UUID]string
+}
+
+func (c *venueCache) get(instrumentUID uuid.UUID) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	venue, ok := c.byUID[instrumentUID]
+	return venue, ok
+}
+
+func (c *venueCache) store(classCodes map[uuid.UUID]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.byUID == nil {
+		c.byUID = make(map[uuid.UUID]string, len(classCodes))
+	}
+	for uid, classCode := range classCodes {
+		if venue := normalizeVenue(classCode); venue != "" {
+			c.byUID[uid] = venue
+		}
+	}
+}
+
+// fillVenues normalizes the venue of every trade and derives the missing ones
+// from the instrument class_code, loading uncached instruments in one query.
+// Trades of instruments without a class code keep an empty venue.
+func (s *Service) fillVenues(ctx context.Context, trades []*marketdata.Trade) error {
+	var missing []uuid.UUID
+	seen := make(map[uuid.UUID]struct{})
+	for _, trade := range trades {
+		trade.Venue = normalizeVenue(trade.Venue)
+		if trade.Venue != "" {
+			continue
+		}
+		if _, ok := s.venues.get(trade.InstrumentUID); ok {
+			continue
+		}
+		if _, ok := seen[trade.InstrumentUID]; !ok {
+			seen[trade.InstrumentUID] = struct{}{}
+			missing = append(missing, trade.InstrumentUID)
+		}
+	}
+	if len(missing) > 0 {
+		classCodes, err := s.repo.GetInstrumentClassCodes(ctx, missing)
+		if err != nil {
+			return fmt.Errorf("load instrument class codes: %w", err)
+		}
+		s.venues.store(classCodes)
+	}
+	for _, trade := range trades {
+		if trade.Venue == "" {
+			trade.Venue, _ = s.venues.get(trade.InstrumentUID)
+		}
+	}
+	return nil
+}
+
+// normalizeVenue trims and upper-cases a board code so TQBR and tqbr match.
+func normalizeVenue(venue string) string {
+	return strings.ToUpper(strings.TrimSpace(venue))
+}
@@ -3,17 +3,32 @@ package config
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"path"
+	"reflect"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
+	// Embedded so MARKET_TIMEZONE resolves in images without system tzdata.
+	_ "time/tzdata"
 
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 )
 
 const (
 	defaultEnv                = "development"
+	defaultLogLevel           = "info"
 	defaultHTTPHost           = "0.0.0.0"
 	defaultHTTPPort           = 8080
+	defaultGRPCPort           = 50051
+	defaultGzipEnabled        = true
+	defaultGzipMinBytes       = 1024
+	defaultStreamBuffer       = 256
+	defaultMaxBodyBytes       = 16 << 20
+	defaultShutdownTimeoutS   = 10
 	defaultRedisAddr          = "localhost:6379"
 	defaultRedisDB            = 0
 	defaultCacheTTLSeconds    = 30
@@ -22,24 +37,92 @@ const (
 	defaultCandlesExchange    = "candles"
 	defaultOrderBooksExchange = "orderbooks"
 	defaultRabbitPrefetch     = 500
+	defaultRabbitHeartbeatSec = 10
+	defaultRabbitDialTimeoutS = 30
+	defaultRabbitReconnBaseS  = 1
+	defaultRabbitReconnMaxS   = 30
+	defaultRabbitDepthPollS   = 15
+	defaultRabbitDepthWarn    = 10000
+	defaultRabbitPauseAfter   = 3
+	defaultRabbitPauseProbeS  = 5
+	defaultRabbitDLX          = "marketdata.dlx"
+	defaultRabbitMaxRetries   = 5
+	defaultRabbitExchangeType = "fanout"
+	defaultFlushAttempts      = 3
+	defaultFlushRetryBaseMS   = 100
+	defaultFlushWorkers       = 1
 	defaultBatchSize          = 2000
 	defaultBatchTimeoutMS     = 200
+	defaultMetadataMaxKeys    = 64
+	defaultMetadataMaxDepth   = 4
+	defaultMetadataMaxBytes   = 8192
+	defaultOutboundTimeoutS   = 30
+	defaultOutboundDialS      = 5
+	defaultOutboundTLSS       = 5
+	defaultOutboundHeaderS    = 10
+	defaultOutboundIdleConns  = 100
+	defaultOutboundIdlePerHst = 10
+	defaultDepthFallback      = "strict"
+	defaultMarketTimezone     = "Europe/Moscow"
+	defaultIngestOnConflict   = "fail"
+	defaultIngestCrossedBook  = "warn"
+	defaultInstrumentCacheS   = 300
+	defaultRateLimitBurst     = 20
+	defaultDeleteChunkSize    = 10000
+	defaultRangeMaxDays       = 31
+	defaultBatchGetMax        = 100
+	defaultTracingService     = "marketdata-aggregator"
+	defaultTracingSampleRatio = 1.0
 )
 
 // Config keeps the runtime configuration for the service.
+//
+// Only Cache, Log and Auth are hot-reloadable on SIGHUP; every other section is
+// read once at startup and requires a restart to change (see ImmutableChanges).
 type Config struct {
 	Env      string
+	Log      LogConfig
 	HTTP     HTTPConfig
+	GRPC     GRPCConfig
 	Postgres PostgresConfig
 	Redis    RedisConfig
 	Cache    CacheConfig
 	RabbitMQ RabbitMQConfig
+	// OrderBookThrottle holds a map, so Config is not comparable with ==.
+	OrderBookThrottle OrderBookThrottleConfig
+	OrderBookQuery    OrderBookQueryConfig
+	RangeQuery        RangeQueryConfig
+	InstrumentQuery   InstrumentQueryConfig
+	Market            MarketConfig
+	Ingest            IngestConfig
+	Metadata          MetadataConfig
+	Outbound          OutboundHTTPConfig
+	Admin             AdminConfig
+	RateLimit         RateLimitConfig
+	Auth              AuthConfig
+	Retention         RetentionConfig
+	Tracing           TracingConfig
 }
 
 // HTTPConfig holds HTTP server related settings.
 type HTTPConfig struct {
 	Host string
 	Port int
+	// GzipEnabled compresses responses for clients that accept gzip.
+	GzipEnabled bool
+	// GzipMinBytes is the smallest response body worth compressing.
+	GzipMinBytes int
+	// StreamBuffer is how many live updates a streaming client may fall behind
+	// before it is disconnected.
+	StreamBuffer int
+	// MaxBodyBytes caps the request body of writes; zero disables the cap.
+	MaxBodyBytes int64
+	// DebugErrors sends the message of internal errors to clients instead of
+	// a generic one. The message is logged either way.
+	DebugErrors bool
+	// ShutdownTimeout is how long the HTTP and gRPC servers wait for running
+	// requests on shutdown before cancelling them.
+	ShutdownTimeout time.Duration
 }
 
 // Addr renders the listen address in host:port form.
@@ -47,9 +130,59 @@ func (h HTTPConfig) Addr() string {
 	return fmt.Sprintf("%s:%d", h.Host, h.Port)
 }
 
+// GRPCConfig holds the gRPC server settings.
+type GRPCConfig struct {
+	Host string
+	// Port is the gRPC listen port; zero disables the gRPC server.
+	Port int
+}
+
+// Addr renders the listen address in host:port form.
+func (g GRPCConfig) Addr() string {
+	return fmt.Sprintf("%s:%d", g.Host, g.Port)
+}
+
+// LogConfig stores logging behavior.
+type LogConfig struct {
+	Level string
+}
+
 // PostgresConfig stores database connection parameters.
 type PostgresConfig struct {
 	DSN string
+	// Pool limits; zero keeps the pgx default.
+	MaxConns        int32
+	MinConns        int32
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+	// SSLMode and the certificate files replace the DSN's TLS setup when any
+	// of them is set; all empty keeps the DSN's sslmode and sslrootcert.
+	SSLMode     string
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+}
+
+// Postgres TLS modes accepted in PG_SSL_MODE, named as in libpq.
+const (
+	PGSSLModeDisable    = "disable"
+	PGSSLModeRequire    = "require"
+	PGSSLModeVerifyCA   = "verify-ca"
+	PGSSLModeVerifyFull = "verify-full"
+)
+
+// TLSConfigured reports whether any PG_SSL_* setting is set.
+func (p PostgresConfig) TLSConfigured() bool {
+	return p.SSLMode != "" || p.SSLRootCert != "" || p.SSLCert != "" || p.SSLKey != ""
+}
+
+// EffectiveSSLMode is SSLMode, or verify-full when only certificate files are
+// set.
+func (p PostgresConfig) EffectiveSSLMode() string {
+	if p.SSLMode == "" {
+		return PGSSLModeVerifyFull
+	}
+	return p.SSLMode
 }
 
 // RedisConfig stores Redis connection parameters.
@@ -62,6 +195,28 @@ type RedisConfig struct {
 // CacheConfig stores cache behavior.
 type CacheConfig struct {
 	TTLSeconds int
+	// RouteTTLs override TTLSeconds for the routes they match; the first
+	// match wins.
+	RouteTTLs []CacheRouteTTL
+}
+
+// CacheRouteTTL sets the cache TTL of the routes matching Pattern. A zero
+// TTLSeconds keeps those routes out of the cache.
+type CacheRouteTTL struct {
+	Pattern    string
+	TTLSeconds int
+}
+
+// Matches reports whether the route template, e.g.
+// /api/v1/marketdata/trades/last, matches Pattern. Patterns are path.Match
+// globs, where * stays within one segment; a trailing /** matches the prefix
+// and every route below it.
+func (r CacheRouteTTL) Matches(route string) bool {
+	if prefix, ok := strings.CutSuffix(r.Pattern, "/**"); ok {
+		return route == prefix || strings.HasPrefix(route, prefix+"/")
+	}
+	ok, _ := path.Match(r.Pattern, route)
+	return ok
 }
 
 // RabbitMQConfig stores broker connection and batching settings.
@@ -73,6 +228,174 @@ type RabbitMQConfig struct {
 	Prefetch           int
 	BatchSize          int
 	BatchTimeout       time.Duration
+	// Per-entity batch overrides; zero falls back to BatchSize/BatchTimeout.
+	TradesBatch     BatchOverride
+	CandlesBatch    BatchOverride
+	OrderBooksBatch BatchOverride
+	// FlushAttempts is how often a batch write is tried when Postgres fails
+	// with a transient error; FlushRetryBase is the first delay, doubled after
+	// each attempt.
+	FlushAttempts  int
+	FlushRetryBase time.Duration
+	// FlushWorkers is how many batches of one entity type may be written to
+	// Postgres at once.
+	FlushWorkers int
+	// Heartbeat is the AMQP heartbeat interval negotiated with the broker.
+	// A heartbeat in the URL query (?heartbeat=N) takes precedence.
+	Heartbeat time.Duration
+	// DialTimeout bounds the TCP connect and AMQP handshake.
+	DialTimeout time.Duration
+	// ReconnectBase and ReconnectMax bound the exponential backoff between
+	// reconnect attempts after the connection or a channel closes.
+	ReconnectBase time.Duration
+	ReconnectMax  time.Duration
+	// DeadLetterExchange receives messages that cannot be processed; its
+	// durable queue is named DeadLetterExchange + ".queue".
+	DeadLetterExchange string
+	// MaxRetries is how often a message that failed with a transient error is
+	// redelivered before it is dead-lettered.
+	MaxRetries int
+	// ExchangeType is "fanout" (every queue gets every message) or "topic"
+	// (messages are routed by <stream>.<instrument_uid>).
+	ExchangeType string
+	// BindInstruments limits a topic consumer to these instruments; empty
+	// binds all of them. Only valid with the topic exchange type.
+	BindInstruments []uuid.UUID
+	// QueueDepthInterval is how often the consumer polls the depth of its
+	// queues; zero disables polling. QueueDepthWarn is the depth at which it
+	// logs a warning; zero disables the warning.
+	QueueDepthInterval time.Duration
+	QueueDepthWarn     int
+	// PauseAfterFlushErrors is how many batch flushes in a row may fail with a
+	// transient error before the consumer stops taking deliveries; zero never
+	// pauses. PauseProbeInterval is how often Postgres is pinged while paused.
+	PauseAfterFlushErrors int
+	PauseProbeInterval    time.Duration
+}
+
+// BatchOverride replaces the global batch size or timeout for one entity type.
+// Zero fields keep the global value.
+type BatchOverride struct {
+	Size    int
+	Timeout time.Duration
+}
+
+// OrderBookThrottleConfig sets the minimum time between persisted order book
+// snapshots of one instrument, measured on snapshot time. Zero persists every
+// snapshot.
+type OrderBookThrottleConfig struct {
+	MinInterval time.Duration
+	// Overrides replaces MinInterval for individual instruments.
+	Overrides map[uuid.UUID]time.Duration
+}
+
+// OrderBookQueryConfig controls order book reads.
+type OrderBookQueryConfig struct {
+	// DepthFallback is "strict" (404 listing the stored depths) or "lenient"
+	// (serve the nearest stored depth) for a depth that was never stored.
+	DepthFallback string
+}
+
+// RangeQueryConfig bounds the market data range reads.
+type RangeQueryConfig struct {
+	// MaxRange is the widest from/to window a range query or export may span.
+	// Zero lifts the cap; admin callers are never capped.
+	MaxRange time.Duration
+}
+
+// InstrumentQueryConfig bounds the instrument reads.
+type InstrumentQueryConfig struct {
+	// BatchGetMax caps the UIDs of one batch get request.
+	BatchGetMax int
+}
+
+// MarketConfig describes the trading calendar.
+type MarketConfig struct {
+	// Timezone is the IANA name of the zone whose calendar days daily
+	// aggregates are grouped by.
+	Timezone string
+}
+
+// IngestConfig controls how market data batches are written.
+type IngestConfig struct {
+	// OnConflict is "fail" (a batch with an already stored row is rejected) or
+	// "skip" (already stored rows are skipped, making redelivery harmless).
+	OnConflict string
+	// CandleDedup upserts candles on (instrument_uid, interval_seconds,
+	// period_start): a candle published again for the same period replaces
+	// the stored one, and OnConflict no longer applies to candle batches.
+	CandleDedup bool
+	// CrossedBook is "warn" (crossed order books are stored and logged) or
+	// "reject" (they are dropped like any other invalid snapshot).
+	CrossedBook string
+	// RequireSortedBook rejects order books whose bids are not in descending or
+	// asks not in ascending price order.
+	RequireSortedBook bool
+	// CheckInstruments rejects market data whose instrument_uid has no
+	// instrument row. Known UIDs are cached for InstrumentTTL.
+	CheckInstruments bool
+	InstrumentTTL    time.Duration
+}
+
+// MetadataConfig limits the metadata accepted on market data entities.
+// Zero disables a limit.
+type MetadataConfig struct {
+	MaxKeys  int
+	MaxDepth int
+	MaxBytes int
+}
+
+// AdminConfig protects the admin endpoints.
+type AdminConfig struct {
+	// Token is the bearer token the admin endpoints require; empty disables them.
+	Token string
+}
+
+// AuthConfig sets the API keys clients send in the X-API-Key header.
+type AuthConfig struct {
+	// APIKeys are all accepted, so a key can be rotated by adding the new one
+	// before removing the old. Empty disables API key checks.
+	APIKeys []string
+	// ProtectReads requires a key for reads as well; otherwise only writes and
+	// deletes need one.
+	ProtectReads bool
+}
+
+// RateLimitConfig throttles the market data write endpoints per client with a
+// token bucket. A zero RequestsPerSecond disables the limit.
+type RateLimitConfig struct {
+	RequestsPerSecond float64
+	Burst             int
+}
+
+// RetentionConfig controls the market data delete endpoints.
+type RetentionConfig struct {
+	// DeleteChunkSize is how many rows one DELETE statement removes, so a large
+	// purge never holds its locks for long.
+	DeleteChunkSize int
+}
+
+// TracingConfig controls OpenTelemetry tracing. An empty Endpoint turns
+// tracing off.
+type TracingConfig struct {
+	// Endpoint is the OTLP/HTTP collector URL spans are exported to, e.g.
+	// http://localhost:4318.
+	Endpoint    string
+	ServiceName string
+	// SampleRatio is the share of new traces recorded, from 0 to 1. Requests
+	// that arrive with a sampled parent are always recorded.
+	SampleRatio float64
+}
+
+// OutboundHTTPConfig tunes the shared client used for outbound HTTP requests.
+type OutboundHTTPConfig struct {
+	// Timeout bounds a whole request, including reading the body.
+	Timeout               time.Duration
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
 }
 
 // Load builds Config from environment variables.
@@ -85,12 +408,58 @@ func Load() (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("parse HTTP_PORT: %w", err)
 	}
+	gzipEnabled, err := getBool("HTTP_GZIP_ENABLED", defaultGzipEnabled)
+	if err != nil {
+		return nil, fmt.Errorf("parse HTTP_GZIP_ENABLED: %w", err)
+	}
+	gzipMinBytes, err := getInt("HTTP_GZIP_MIN_BYTES", defaultGzipMinBytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse HTTP_GZIP_MIN_BYTES: %w", err)
+	}
+	streamBuffer, err := getInt("HTTP_STREAM_BUFFER", defaultStreamBuffer)
+	if err != nil {
+		return nil, fmt.Errorf("parse HTTP_STREAM_BUFFER: %w", err)
+	}
+	maxBodyBytes, err := getInt("HTTP_MAX_BODY_BYTES", defaultMaxBodyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse HTTP_MAX_BODY_BYTES: %w", err)
+	}
+	shutdownTimeout, err := getInt("HTTP_SHUTDOWN_TIMEOUT_SECONDS", defaultShutdownTimeoutS)
+	if err != nil {
+		return nil, fmt.Errorf("parse HTTP_SHUTDOWN_TIMEOUT_SECONDS: %w", err)
+	}
+	debugErrors, err := getBool("HTTP_DEBUG_ERRORS", false)
+	if err != nil {
+		return nil, fmt.Errorf("parse HTTP_DEBUG_ERRORS: %w", err)
+	}
+	grpcPort, err := getInt("GRPC_PORT", defaultGRPCPort)
+	if err != nil {
+		return nil, fmt.Errorf("parse GRPC_PORT: %w", err)
+	}
 
 	dsn := os.Getenv("DATABASE_DSN")
 	if dsn == "" {
 		return nil, errors.New("DATABASE_DSN is required")
 	}
 
+	// Pool durations take Go syntax such as "30m" or "1h".
+	maxConns, err := getInt("PG_MAX_CONNS", 0)
+	if err != nil {
+		return nil, fmt.Errorf("parse PG_MAX_CONNS: %w", err)
+	}
+	minConns, err := getInt("PG_MIN_CONNS", 0)
+	if err != nil {
+		return nil, fmt.Errorf("parse PG_MIN_CONNS: %w", err)
+	}
+	lifetime, err := getDuration("PG_MAX_CONN_LIFETIME", 0)
+	if err != nil {
+		return nil, fmt.Errorf("parse PG_MAX_CONN_LIFETIME: %w", err)
+	}
+	idleTime, err := getDuration("PG_MAX_CONN_IDLE_TIME", 0)
+	if err != nil {
+		return nil, fmt.Errorf("parse PG_MAX_CONN_IDLE_TIME: %w", err)
+	}
+
 	redisDB, err := getInt("REDIS_DB", defaultRedisDB)
 	if err != nil {
 		return nil, fmt.Errorf("parse REDIS_DB: %w", err)
@@ -100,6 +469,10 @@ func Load() (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("parse CACHE_TTL_SECONDS: %w", err)
 	}
+	cacheRouteTTLs, err := loadCacheRouteTTLs()
+	if err != nil {
+		return nil, err
+	}
 
 	prefetch, err := getInt("RABBITMQ_PREFETCH", defaultRabbitPrefetch)
 	if err != nil {
@@ -113,12 +486,185 @@ func Load() (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("parse RABBITMQ_BATCH_TIMEOUT_MS: %w", err)
 	}
+	heartbeatSec, err := getInt("RABBITMQ_HEARTBEAT_SECONDS", defaultRabbitHeartbeatSec)
+	if err != nil {
+		return nil, fmt.Errorf("parse RABBITMQ_HEARTBEAT_SECONDS: %w", err)
+	}
+	dialTimeoutSec, err := getInt("RABBITMQ_DIAL_TIMEOUT_SECONDS", defaultRabbitDialTimeoutS)
+	if err != nil {
+		return nil, fmt.Errorf("parse RABBITMQ_DIAL_TIMEOUT_SECONDS: %w", err)
+	}
+	reconnectBaseSec, err := getInt("RABBITMQ_RECONNECT_BASE_SECONDS", defaultRabbitReconnBaseS)
+	if err != nil {
+		return nil, fmt.Errorf("parse RABBITMQ_RECONNECT_BASE_SECONDS: %w", err)
+	}
+	reconnectMaxSec, err := getInt("RABBITMQ_RECONNECT_MAX_SECONDS", defaultRabbitReconnMaxS)
+	if err != nil {
+		return nil, fmt.Errorf("parse RABBITMQ_RECONNECT_MAX_SECONDS: %w", err)
+	}
+	tradesBatch, err := loadBatchOverride("TRADES")
+	if err != nil {
+		return nil, err
+	}
+	candlesBatch, err := loadBatchOverride("CANDLES")
+	if err != nil {
+		return nil, err
+	}
+	orderBooksBatch, err := loadBatchOverride("ORDERBOOKS")
+	if err != nil {
+		return nil, err
+	}
+	flushAttempts, err := getInt("RABBITMQ_FLUSH_ATTEMPTS", defaultFlushAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("parse RABBITMQ_FLUSH_ATTEMPTS: %w", err)
+	}
+	flushRetryBaseMS, err := getInt("RABBITMQ_FLUSH_RETRY_BASE_MS", defaultFlushRetryBaseMS)
+	if err != nil {
+		return nil, fmt.Errorf("parse RABBITMQ_FLUSH_RETRY_BASE_MS: %w", err)
+	}
+	flushWorkers, err := getInt("RABBITMQ_FLUSH_WORKERS", defaultFlushWorkers)
+	if err != nil {
+		return nil, fmt.Errorf("parse RABBITMQ_FLUSH_WORKERS: %w", err)
+	}
+	maxRetries, err := getInt("RABBITMQ_MAX_RETRIES", defaultRabbitMaxRetries)
+	if err != nil {
+		return nil, fmt.Errorf("parse RABBITMQ_MAX_RETRIES: %w", err)
+	}
+	queueDepthIntervalSec, err := getInt("RABBITMQ_QUEUE_DEPTH_INTERVAL_SECONDS", defaultRabbitDepthPollS)
+	if err != nil {
+		return nil, fmt.Errorf("parse RABBITMQ_QUEUE_DEPTH_INTERVAL_SECONDS: %w", err)
+	}
+	queueDepthWarn, err := getInt("RABBITMQ_QUEUE_DEPTH_WARN", defaultRabbitDepthWarn)
+	if err != nil {
+		return nil, fmt.Errorf("parse RABBITMQ_QUEUE_DEPTH_WARN: %w", err)
+	}
+	pauseAfterFlushErrors, err := getInt("RABBITMQ_PAUSE_AFTER_FLUSH_ERRORS", defaultRabbitPauseAfter)
+	if err != nil {
+		return nil, fmt.Errorf("parse RABBITMQ_PAUSE_AFTER_FLUSH_ERRORS: %w", err)
+	}
+	pauseProbeSec, err := getInt("RABBITMQ_PAUSE_PROBE_SECONDS", defaultRabbitPauseProbeS)
+	if err != nil {
+		return nil, fmt.Errorf("parse RABBITMQ_PAUSE_PROBE_SECONDS: %w", err)
+	}
+	exchangeType := getString("RABBITMQ_EXCHANGE_TYPE", defaultRabbitExchangeType)
+	bindInstruments, err := parseUUIDList(os.Getenv("RABBITMQ_BIND_INSTRUMENTS"))
+	if err != nil {
+		return nil, fmt.Errorf("parse RABBITMQ_BIND_INSTRUMENTS: %w", err)
+	}
+
+	metadataMaxKeys, err := getInt("METADATA_MAX_KEYS", defaultMetadataMaxKeys)
+	if err != nil {
+		return nil, fmt.Errorf("parse METADATA_MAX_KEYS: %w", err)
+	}
+	metadataMaxDepth, err := getInt("METADATA_MAX_DEPTH", defaultMetadataMaxDepth)
+	if err != nil {
+		return nil, fmt.Errorf("parse METADATA_MAX_DEPTH: %w", err)
+	}
+	metadataMaxBytes, err := getInt("METADATA_MAX_BYTES", defaultMetadataMaxBytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse METADATA_MAX_BYTES: %w", err)
+	}
+
+	throttle, err := loadOrderBookThrottle()
+	if err != nil {
+		return nil, err
+	}
+
+	outbound, err := loadOutboundHTTP()
+	if err != nil {
+		return nil, err
+	}
+
+	depthFallback := strings.ToLower(getString("ORDERBOOK_DEPTH_FALLBACK", defaultDepthFallback))
+	if depthFallback != "strict" && depthFallback != "lenient" {
+		return nil, fmt.Errorf("parse ORDERBOOK_DEPTH_FALLBACK: %q is neither strict nor lenient", depthFallback)
+	}
+
+	onConflict := strings.ToLower(getString("INGEST_ON_CONFLICT", defaultIngestOnConflict))
+	if onConflict != "fail" && onConflict != "skip" {
+		return nil, fmt.Errorf("parse INGEST_ON_CONFLICT: %q is neither fail nor skip", onConflict)
+	}
+	candleDedup, err := getBool("INGEST_CANDLE_DEDUP", false)
+	if err != nil {
+		return nil, fmt.Errorf("parse INGEST_CANDLE_DEDUP: %w", err)
+	}
+	crossedBook := strings.ToLower(getString("INGEST_CROSSED_BOOK", defaultIngestCrossedBook))
+	if crossedBook != "warn" && crossedBook != "reject" {
+		return nil, fmt.Errorf("parse INGEST_CROSSED_BOOK: %q is neither warn nor reject", crossedBook)
+	}
+	requireSortedBook, err := getBool("INGEST_REQUIRE_SORTED_BOOK", false)
+	if err != nil {
+		return nil, fmt.Errorf("parse INGEST_REQUIRE_SORTED_BOOK: %w", err)
+	}
+	checkInstruments, err := getBool("INGEST_CHECK_INSTRUMENTS", false)
+	if err != nil {
+		return nil, fmt.Errorf("parse INGEST_CHECK_INSTRUMENTS: %w", err)
+	}
+	instrumentCacheSec, err := getInt("INGEST_INSTRUMENT_CACHE_SECONDS", defaultInstrumentCacheS)
+	if err != nil {
+		return nil, fmt.Errorf("parse INGEST_INSTRUMENT_CACHE_SECONDS: %w", err)
+	}
+
+	rateLimitRPS, err := getFloat("RATE_LIMIT_RPS", 0)
+	if err != nil {
+		return nil, fmt.Errorf("parse RATE_LIMIT_RPS: %w", err)
+	}
+	rateLimitBurst, err := getInt("RATE_LIMIT_BURST", defaultRateLimitBurst)
+	if err != nil {
+		return nil, fmt.Errorf("parse RATE_LIMIT_BURST: %w", err)
+	}
+
+	var apiKeys []string
+	for _, key := range strings.Split(os.Getenv("API_KEYS"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			apiKeys = append(apiKeys, key)
+		}
+	}
+	protectReads, err := getBool("API_KEY_PROTECT_READS", false)
+	if err != nil {
+		return nil, fmt.Errorf("parse API_KEY_PROTECT_READS: %w", err)
+	}
+
+	deleteChunkSize, err := getInt("RETENTION_DELETE_CHUNK_SIZE", defaultDeleteChunkSize)
+	if err != nil {
+		return nil, fmt.Errorf("parse RETENTION_DELETE_CHUNK_SIZE: %w", err)
+	}
+
+	rangeMaxDays, err := getInt("RANGE_QUERY_MAX_DAYS", defaultRangeMaxDays)
+	if err != nil {
+		return nil, fmt.Errorf("parse RANGE_QUERY_MAX_DAYS: %w", err)
+	}
+
+	batchGetMax, err := getInt("INSTRUMENTS_BATCH_GET_MAX", defaultBatchGetMax)
+	if err != nil {
+		return nil, fmt.Errorf("parse INSTRUMENTS_BATCH_GET_MAX: %w", err)
+	}
+
+	tracingSampleRatio, err := getFloat("OTEL_TRACES_SAMPLE_RATIO", defaultTracingSampleRatio)
+	if err != nil {
+		return nil, fmt.Errorf("parse OTEL_TRACES_SAMPLE_RATIO: %w", err)
+	}
 
-	return &Config{
+	marketTimezone := getString("MARKET_TIMEZONE", defaultMarketTimezone)
+	if _, err := time.LoadLocation(marketTimezone); err != nil {
+		return nil, fmt.Errorf("parse MARKET_TIMEZONE: %w", err)
+	}
+
+	cfg := &Config{
 		Env:  getString("APP_ENV", defaultEnv),
-		HTTP: HTTPConfig{Host: host, Port: port},
+		Log:  LogConfig{Level: getString("LOG_LEVEL", defaultLogLevel)},
+		HTTP: HTTPConfig{Host: host, Port: port, GzipEnabled: gzipEnabled, GzipMinBytes: gzipMinBytes, StreamBuffer: streamBuffer, MaxBodyBytes: int64(maxBodyBytes), DebugErrors: debugErrors, ShutdownTimeout: time.Duration(shutdownTimeout) * time.Second},
+		GRPC: GRPCConfig{Host: getString("GRPC_HOST", defaultHTTPHost), Port: grpcPort},
 		Postgres: PostgresConfig{
-			DSN: dsn,
+			DSN:             dsn,
+			MaxConns:        int32(maxConns),
+			MinConns:        int32(minConns),
+			MaxConnLifetime: lifetime,
+			MaxConnIdleTime: idleTime,
+			SSLMode:         strings.ToLower(os.Getenv("PG_SSL_MODE")),
+			SSLRootCert:     os.Getenv("PG_SSL_ROOT_CERT"),
+			SSLCert:         os.Getenv("PG_SSL_CERT"),
+			SSLKey:          os.Getenv("PG_SSL_KEY"),
 		},
 		Redis: RedisConfig{
 			Addr:     getString("REDIS_ADDR", defaultRedisAddr),
@@ -127,19 +673,265 @@ func Load() (*Config, error) {
 		},
 		Cache: CacheConfig{
 			TTLSeconds: cacheTTL,
+			RouteTTLs:  cacheRouteTTLs,
 		},
 		RabbitMQ: RabbitMQConfig{
-			URL:                getString("RABBITMQ_URL", defaultRabbitURL),
-			TradesExchange:     getString("RABBITMQ_TRADES_EXCHANGE", defaultTradesExchange),
-			CandlesExchange:    getString("RABBITMQ_CANDLES_EXCHANGE", defaultCandlesExchange),
-			OrderBooksExchange: getString("RABBITMQ_ORDERBOOKS_EXCHANGE", defaultOrderBooksExchange),
-			Prefetch:           prefetch,
-			BatchSize:          batchSize,
-			BatchTimeout:       time.Duration(timeoutMS) * time.Millisecond,
+			URL:                   getString("RABBITMQ_URL", defaultRabbitURL),
+			TradesExchange:        getString("RABBITMQ_TRADES_EXCHANGE", defaultTradesExchange),
+			CandlesExchange:       getString("RABBITMQ_CANDLES_EXCHANGE", defaultCandlesExchange),
+			OrderBooksExchange:    getString("RABBITMQ_ORDERBOOKS_EXCHANGE", defaultOrderBooksExchange),
+			ExchangeType:          exchangeType,
+			BindInstruments:       bindInstruments,
+			Prefetch:              prefetch,
+			BatchSize:             batchSize,
+			BatchTimeout:          time.Duration(timeoutMS) * time.Millisecond,
+			TradesBatch:           tradesBatch,
+			CandlesBatch:          candlesBatch,
+			OrderBooksBatch:       orderBooksBatch,
+			FlushAttempts:         flushAttempts,
+			FlushRetryBase:        time.Duration(flushRetryBaseMS) * time.Millisecond,
+			FlushWorkers:          flushWorkers,
+			Heartbeat:             time.Duration(heartbeatSec) * time.Second,
+			DialTimeout:           time.Duration(dialTimeoutSec) * time.Second,
+			ReconnectBase:         time.Duration(reconnectBaseSec) * time.Second,
+			ReconnectMax:          time.Duration(reconnectMaxSec) * time.Second,
+			DeadLetterExchange:    getString("RABBITMQ_DEAD_LETTER_EXCHANGE", defaultRabbitDLX),
+			MaxRetries:            maxRetries,
+			QueueDepthInterval:    time.Duration(queueDepthIntervalSec) * time.Second,
+			QueueDepthWarn:        queueDepthWarn,
+			PauseAfterFlushErrors: pauseAfterFlushErrors,
+			PauseProbeInterval:    time.Duration(pauseProbeSec) * time.Second,
+		},
+		OrderBookThrottle: throttle,
+		OrderBookQuery:    OrderBookQueryConfig{DepthFallback: depthFallback},
+		RangeQuery:        RangeQueryConfig{MaxRange: time.Duration(rangeMaxDays) * 24 * time.Hour},
+		InstrumentQuery:   InstrumentQueryConfig{BatchGetMax: batchGetMax},
+		Market:            MarketConfig{Timezone: marketTimezone},
+		Ingest: IngestConfig{
+			OnConflict:        onConflict,
+			CandleDedup:       candleDedup,
+			CrossedBook:       crossedBook,
+			RequireSortedBook: requireSortedBook,
+			CheckInstruments:  checkInstruments,
+			InstrumentTTL:     time.Duration(instrumentCacheSec) * time.Second,
+		},
+		Metadata: MetadataConfig{
+			MaxKeys:  metadataMaxKeys,
+			MaxDepth: metadataMaxDepth,
+			MaxBytes: metadataMaxBytes,
 		},
+		Outbound: outbound,
+		Admin:    AdminConfig{Token: os.Getenv("ADMIN_TOKEN")},
+		RateLimit: RateLimitConfig{
+			RequestsPerSecond: rateLimitRPS,
+			Burst:             rateLimitBurst,
+		},
+		Auth:      AuthConfig{APIKeys: apiKeys, ProtectReads: protectReads},
+		Retention: RetentionConfig{DeleteChunkSize: deleteChunkSize},
+		Tracing: TracingConfig{
+			Endpoint:    strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")),
+			ServiceName: getString("OTEL_SERVICE_NAME", defaultTracingService),
+			SampleRatio: tracingSampleRatio,
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadBatchOverride reads RABBITMQ_<ENTITY>_BATCH_SIZE and
+// RABBITMQ_<ENTITY>_BATCH_TIMEOUT_MS; both default to 0, keeping the global value.
+func loadBatchOverride(entity string) (BatchOverride, error) {
+	sizeKey := "RABBITMQ_" + entity + "_BATCH_SIZE"
+	size, err := getInt(sizeKey, 0)
+	if err != nil {
+		return BatchOverride{}, fmt.Errorf("parse %s: %w", sizeKey, err)
+	}
+	timeoutKey := "RABBITMQ_" + entity + "_BATCH_TIMEOUT_MS"
+	timeoutMS, err := getInt(timeoutKey, 0)
+	if err != nil {
+		return BatchOverride{}, fmt.Errorf("parse %s: %w", timeoutKey, err)
+	}
+	return BatchOverride{Size: size, Timeout: time.Duration(timeoutMS) * time.Millisecond}, nil
+}
+
+// equal compares two broker configs, including the instrument bindings.
+func (r RabbitMQConfig) equal(other RabbitMQConfig) bool {
+	if !slices.Equal(r.BindInstruments, other.BindInstruments) {
+		return false
+	}
+	r.BindInstruments, other.BindInstruments = nil, nil
+	return reflect.DeepEqual(r, other)
+}
+
+// parseUUIDList reads a comma-separated list of UUIDs, skipping empty entries.
+func parseUUIDList(raw string) ([]uuid.UUID, error) {
+	var out []uuid.UUID
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		uid, err := uuid.Parse(entry)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, uid)
+	}
+	return out, nil
+}
+
+// loadCacheRouteTTLs reads the CACHE_TTL_ROUTES list ("pattern=seconds,..."),
+// keeping its order.
+func loadCacheRouteTTLs() ([]CacheRouteTTL, error) {
+	var routes []CacheRouteTTL
+	for _, entry := range strings.Split(os.Getenv("CACHE_TTL_ROUTES"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pattern, rawSeconds, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("parse CACHE_TTL_ROUTES: entry %q is not pattern=seconds", entry)
+		}
+		seconds, err := strconv.Atoi(strings.TrimSpace(rawSeconds))
+		if err != nil {
+			return nil, fmt.Errorf("parse CACHE_TTL_ROUTES: %w", err)
+		}
+		routes = append(routes, CacheRouteTTL{Pattern: strings.TrimSpace(pattern), TTLSeconds: seconds})
+	}
+	return routes, nil
+}
+
+// loadOrderBookThrottle reads ORDERBOOK_MIN_INTERVAL_MS and the per-instrument
+// ORDERBOOK_MIN_INTERVAL_OVERRIDES list ("uid=ms,uid=ms").
+func loadOrderBookThrottle() (OrderBookThrottleConfig, error) {
+	minIntervalMS, err := getInt("ORDERBOOK_MIN_INTERVAL_MS", 0)
+	if err != nil {
+		return OrderBookThrottleConfig{}, fmt.Errorf("parse ORDERBOOK_MIN_INTERVAL_MS: %w", err)
+	}
+	cfg := OrderBookThrottleConfig{
+		MinInterval: time.Duration(minIntervalMS) * time.Millisecond,
+		Overrides:   map[uuid.UUID]time.Duration{},
+	}
+	for _, entry := range strings.Split(os.Getenv("ORDERBOOK_MIN_INTERVAL_OVERRIDES"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		rawUID, rawMS, ok := strings.Cut(entry, "=")
+		if !ok {
+			return OrderBookThrottleConfig{}, fmt.Errorf("parse ORDERBOOK_MIN_INTERVAL_OVERRIDES: entry %q is not uid=ms", entry)
+		}
+		uid, err := uuid.Parse(strings.TrimSpace(rawUID))
+		if err != nil {
+			return OrderBookThrottleConfig{}, fmt.Errorf("parse ORDERBOOK_MIN_INTERVAL_OVERRIDES: %w", err)
+		}
+		ms, err := strconv.Atoi(strings.TrimSpace(rawMS))
+		if err != nil {
+			return OrderBookThrottleConfig{}, fmt.Errorf("parse ORDERBOOK_MIN_INTERVAL_OVERRIDES: %w", err)
+		}
+		cfg.Overrides[uid] = time.Duration(ms) * time.Millisecond
+	}
+	return cfg, nil
+}
+
+func loadOutboundHTTP() (OutboundHTTPConfig, error) {
+	timeoutSec, err := getInt("OUTBOUND_HTTP_TIMEOUT_SECONDS", defaultOutboundTimeoutS)
+	if err != nil {
+		return OutboundHTTPConfig{}, fmt.Errorf("parse OUTBOUND_HTTP_TIMEOUT_SECONDS: %w", err)
+	}
+	dialSec, err := getInt("OUTBOUND_HTTP_DIAL_TIMEOUT_SECONDS", defaultOutboundDialS)
+	if err != nil {
+		return OutboundHTTPConfig{}, fmt.Errorf("parse OUTBOUND_HTTP_DIAL_TIMEOUT_SECONDS: %w", err)
+	}
+	tlsSec, err := getInt("OUTBOUND_HTTP_TLS_TIMEOUT_SECONDS", defaultOutboundTLSS)
+	if err != nil {
+		return OutboundHTTPConfig{}, fmt.Errorf("parse OUTBOUND_HTTP_TLS_TIMEOUT_SECONDS: %w", err)
+	}
+	headerSec, err := getInt("OUTBOUND_HTTP_RESPONSE_HEADER_TIMEOUT_SECONDS", defaultOutboundHeaderS)
+	if err != nil {
+		return OutboundHTTPConfig{}, fmt.Errorf("parse OUTBOUND_HTTP_RESPONSE_HEADER_TIMEOUT_SECONDS: %w", err)
+	}
+	maxIdle, err := getInt("OUTBOUND_HTTP_MAX_IDLE_CONNS", defaultOutboundIdleConns)
+	if err != nil {
+		return OutboundHTTPConfig{}, fmt.Errorf("parse OUTBOUND_HTTP_MAX_IDLE_CONNS: %w", err)
+	}
+	maxIdlePerHost, err := getInt("OUTBOUND_HTTP_MAX_IDLE_CONNS_PER_HOST", defaultOutboundIdlePerHst)
+	if err != nil {
+		return OutboundHTTPConfig{}, fmt.Errorf("parse OUTBOUND_HTTP_MAX_IDLE_CONNS_PER_HOST: %w", err)
+	}
+
+	return OutboundHTTPConfig{
+		Timeout:               time.Duration(timeoutSec) * time.Second,
+		DialTimeout:           time.Duration(dialSec) * time.Second,
+		TLSHandshakeTimeout:   time.Duration(tlsSec) * time.Second,
+		ResponseHeaderTimeout: time.Duration(headerSec) * time.Second,
+		MaxIdleConns:          maxIdle,
+		MaxIdleConnsPerHost:   maxIdlePerHost,
 	}, nil
 }
 
+// ImmutableChanges lists the sections of next that differ from c but cannot be
+// applied without a restart.
+func (c *Config) ImmutableChanges(next *Config) []string {
+	var changed []string
+	if c.Env != next.Env {
+		changed = append(changed, "APP_ENV")
+	}
+	if c.HTTP != next.HTTP {
+		changed = append(changed, "HTTP")
+	}
+	if c.GRPC != next.GRPC {
+		changed = append(changed, "GRPC")
+	}
+	if c.Postgres != next.Postgres {
+		changed = append(changed, "Postgres")
+	}
+	if c.Redis != next.Redis {
+		changed = append(changed, "Redis")
+	}
+	if !c.RabbitMQ.equal(next.RabbitMQ) {
+		changed = append(changed, "RabbitMQ")
+	}
+	if c.OrderBookThrottle.MinInterval != next.OrderBookThrottle.MinInterval ||
+		!maps.Equal(c.OrderBookThrottle.Overrides, next.OrderBookThrottle.Overrides) {
+		changed = append(changed, "OrderBookThrottle")
+	}
+	if c.OrderBookQuery != next.OrderBookQuery {
+		changed = append(changed, "OrderBookQuery")
+	}
+	if c.RangeQuery != next.RangeQuery {
+		changed = append(changed, "RangeQuery")
+	}
+	if c.InstrumentQuery != next.InstrumentQuery {
+		changed = append(changed, "InstrumentQuery")
+	}
+	if c.Ingest != next.Ingest {
+		changed = append(changed, "Ingest")
+	}
+	if c.Market != next.Market {
+		changed = append(changed, "Market")
+	}
+	if c.Metadata != next.Metadata {
+		changed = append(changed, "Metadata")
+	}
+	if c.Outbound != next.Outbound {
+		changed = append(changed, "Outbound HTTP")
+	}
+	if c.Admin != next.Admin {
+		changed = append(changed, "Admin")
+	}
+	if c.Retention != next.Retention {
+		changed = append(changed, "Retention")
+	}
+	if c.Tracing != next.Tracing {
+		changed = append(changed, "Tracing")
+	}
+	return changed
+}
+
 func getString(key, fallback string) string {
 	value, ok := os.LookupEnv(key)
 	if !ok || value == "" {
@@ -160,3 +952,42 @@ func getInt(key string, fallback int) (int, error) {
 	}
 	return parsed, nil
 }
+
+func getDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("convert %s value %q to duration: %w", key, value, err)
+	}
+	return parsed, nil
+}
+
+func getFloat(key string, fallback float64) (float64, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("convert %s value %q to float: %w", key, value, err)
+	}
+	return parsed, nil
+}
+
+func getBool(key string, fallback bool) (bool, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("convert %s value %q to bool: %w", key, value, err)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// Validate checks ranges and cross-field rules that Load cannot catch while
+// parsing. It reports every problem at once, each naming the variable to fix.
+func (c *Config) Validate() error {
+	var problems []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			problems = append(problems, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.HTTP.Port > 0 && c.HTTP.Port <= 65535, "HTTP_PORT must be between 1 and 65535, got %d", c.HTTP.Port)
+	check(c.HTTP.GzipMinBytes >= 0, "HTTP_GZIP_MIN_BYTES must not be negative")
+	check(c.HTTP.StreamBuffer > 0, "HTTP_STREAM_BUFFER must be positive")
+	check(c.HTTP.MaxBodyBytes >= 0, "HTTP_MAX_BODY_BYTES must not be negative")
+	check(c.HTTP.ShutdownTimeout > 0, "HTTP_SHUTDOWN_TIMEOUT_SECONDS must be positive")
+	check(c.GRPC.Port >= 0 && c.GRPC.Port <= 65535, "GRPC_PORT must be between 0 and 65535, got %d", c.GRPC.Port)
+	check(c.GRPC.Port == 0 || c.GRPC.Port != c.HTTP.Port, "GRPC_PORT must differ from HTTP_PORT")
+
+	for _, route := range c.Cache.RouteTTLs {
+		_, err := path.Match(route.Pattern, "")
+		check(strings.HasPrefix(route.Pattern, "/") && err == nil, "CACHE_TTL_ROUTES pattern %q must be a path glob starting with /", route.Pattern)
+		check(route.TTLSeconds >= 0, "CACHE_TTL_ROUTES TTL of %q must not be negative", route.Pattern)
+	}
+
+	if err := validatePostgresDSN(c.Postgres.DSN); err != nil {
+		problems = append(problems, err)
+	}
+	check(c.Postgres.MaxConns >= 0, "PG_MAX_CONNS must not be negative")
+	check(c.Postgres.MinConns >= 0, "PG_MIN_CONNS must not be negative")
+	check(c.Postgres.MaxConns <= 0 || c.Postgres.MinConns <= c.Postgres.MaxConns, "PG_MIN_CONNS must not exceed PG_MAX_CONNS")
+	check(c.Postgres.MaxConnLifetime >= 0, "PG_MAX_CONN_LIFETIME must not be negative")
+	check(c.Postgres.MaxConnIdleTime >= 0, "PG_MAX_CONN_IDLE_TIME must not be negative")
+	problems = append(problems, validatePostgresTLS(c.Postgres)...)
+
+	rabbit := c.RabbitMQ
+	if err := validateAMQPURL(rabbit.URL); err != nil {
+		problems = append(problems, err)
+	}
+	check(rabbit.Prefetch > 0, "RABBITMQ_PREFETCH must be positive")
+	check(rabbit.BatchSize > 0, "RABBITMQ_BATCH_SIZE must be positive")
+	check(rabbit.BatchTimeout >= 0, "RABBITMQ_BATCH_TIMEOUT_MS must not be negative")
+	for _, override := range []struct {
+		entity string
+		batch  BatchOverride
+	}{
+		{"TRADES", rabbit.TradesBatch},
+		{"CANDLES", rabbit.CandlesBatch},
+		{"ORDERBOOKS", rabbit.OrderBooksBatch},
+	} {
+		check(override.batch.Size >= 0, "RABBITMQ_%s_BATCH_SIZE must not be negative", override.entity)
+		check(override.batch.Timeout >= 0, "RABBITMQ_%s_BATCH_TIMEOUT_MS must not be negative", override.entity)
+	}
+	check(rabbit.FlushAttempts > 0, "RABBITMQ_FLUSH_ATTEMPTS must be positive")
+	check(rabbit.FlushRetryBase >= 0, "RABBITMQ_FLUSH_RETRY_BASE_MS must not be negative")
+	check(rabbit.FlushWorkers > 0, "RABBITMQ_FLUSH_WORKERS must be positive")
+	// Parallel candle writes can commit an older update of an open candle
+	// after a newer one, and the upsert would keep the older one.
+	check(rabbit.FlushWorkers <= 1 || !c.Ingest.CandleDedup, "RABBITMQ_FLUSH_WORKERS must be 1 with INGEST_CANDLE_DEDUP=true, got %d", rabbit.FlushWorkers)
+	check(rabbit.Heartbeat >= 0, "RABBITMQ_HEARTBEAT_SECONDS must not be negative")
+	check(rabbit.DialTimeout >= 0, "RABBITMQ_DIAL_TIMEOUT_SECONDS must not be negative")
+	check(rabbit.ReconnectBase > 0, "RABBITMQ_RECONNECT_BASE_SECONDS must be positive")
+	check(rabbit.ReconnectMax >= rabbit.ReconnectBase, "RABBITMQ_RECONNECT_MAX_SECONDS must not be less than RABBITMQ_RECONNECT_BASE_SECONDS")
+	check(rabbit.MaxRetries >= 0, "RABBITMQ_MAX_RETRIES must not be negative")
+	check(rabbit.QueueDepthInterval >= 0, "RABBITMQ_QUEUE_DEPTH_INTERVAL_SECONDS must not be negative")
+	check(rabbit.QueueDepthWarn >= 0, "RABBITMQ_QUEUE_DEPTH_WARN must not be negative")
+	check(rabbit.PauseAfterFlushErrors >= 0, "RABBITMQ_PAUSE_AFTER_FLUSH_ERRORS must not be negative")
+	check(rabbit.PauseAfterFlushErrors == 0 || rabbit.PauseProbeInterval > 0, "RABBITMQ_PAUSE_PROBE_SECONDS must be positive")
+	check(rabbit.ExchangeType == "fanout" || rabbit.ExchangeType == "topic", "RABBITMQ_EXCHANGE_TYPE must be fanout or topic, got %q", rabbit.ExchangeType)
+	check(len(rabbit.BindInstruments) == 0 || rabbit.ExchangeType == "topic", "RABBITMQ_BIND_INSTRUMENTS requires RABBITMQ_EXCHANGE_TYPE=topic")
+
+	check(c.RateLimit.RequestsPerSecond >= 0, "RATE_LIMIT_RPS must not be negative")
+	check(c.RateLimit.RequestsPerSecond == 0 || c.RateLimit.Burst > 0, "RATE_LIMIT_BURST must be positive")
+
+	check(c.RangeQuery.MaxRange >= 0, "RANGE_QUERY_MAX_DAYS must not be negative")
+
+	check(c.InstrumentQuery.BatchGetMax > 0, "INSTRUMENTS_BATCH_GET_MAX must be positive")
+
+	check(c.Ingest.InstrumentTTL >= 0, "INGEST_INSTRUMENT_CACHE_SECONDS must not be negative")
+
+	check(c.Retention.DeleteChunkSize > 0, "RETENTION_DELETE_CHUNK_SIZE must be positive")
+
+	if c.Tracing.Endpoint != "" {
+		if err := validateOTLPEndpoint(c.Tracing.Endpoint); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "OTEL_TRACES_SAMPLE_RATIO must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration: %w", errors.Join(problems...))
+}
+
+// validateAMQPURL catches URLs the AMQP client would only reject when dialing.
+func validateAMQPURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("RABBITMQ_URL is not a valid URL: %w", urlParseCause(err))
+	}
+	if u.Scheme != "amqp" && u.Scheme != "amqps" {
+		return fmt.Errorf("RABBITMQ_URL must use the amqp or amqps scheme, got %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return errors.New("RABBITMQ_URL must name a host")
+	}
+	return nil
+}
+
+// validateOTLPEndpoint requires the full collector URL the OTLP/HTTP exporter
+// expects.
+func validateOTLPEndpoint(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("OTEL_EXPORTER_OTLP_ENDPOINT is not a valid URL: %w", urlParseCause(err))
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("OTEL_EXPORTER_OTLP_ENDPOINT must use the http or https scheme, got %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return errors.New("OTEL_EXPORTER_OTLP_ENDPOINT must name a host")
+	}
+	return nil
+}
+
+// validatePostgresDSN accepts the URL form (postgres://...) and the key=value
+// form (host=... dbname=...) that pgx parses.
+func validatePostgresDSN(raw string) error {
+	if !strings.Contains(raw, "://") {
+		if !strings.Contains(raw, "=") {
+			return errors.New("DATABASE_DSN must be a postgres:// URL or key=value pairs")
+		}
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("DATABASE_DSN is not a valid URL: %w", urlParseCause(err))
+	}
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return fmt.Errorf("DATABASE_DSN must use the postgres or postgresql scheme, got %q", u.Scheme)
+	}
+	return nil
+}
+
+// validatePostgresTLS checks the PG_SSL_* settings, including that the
+// certificate files can be read at startup rather than on the first connect.
+func validatePostgresTLS(pg PostgresConfig) []error {
+	if !pg.TLSConfigured() {
+		return nil
+	}
+	var problems []error
+	switch pg.SSLMode {
+	case "", PGSSLModeDisable, PGSSLModeRequire, PGSSLModeVerifyCA, PGSSLModeVerifyFull:
+	default:
+		problems = append(problems, fmt.Errorf("PG_SSL_MODE must be disable, require, verify-ca or verify-full, got %q", pg.SSLMode))
+	}
+	if pg.SSLMode == PGSSLModeDisable && (pg.SSLRootCert != "" || pg.SSLCert != "" || pg.SSLKey != "") {
+		problems = append(problems, errors.New("PG_SSL_ROOT_CERT, PG_SSL_CERT and PG_SSL_KEY must be unset with PG_SSL_MODE=disable"))
+	}
+	if (pg.SSLCert == "") != (pg.SSLKey == "") {
+		problems = append(problems, errors.New("PG_SSL_CERT and PG_SSL_KEY must be set together"))
+	}
+	for _, file := range []struct{ name, path string }{
+		{"PG_SSL_ROOT_CERT", pg.SSLRootCert},
+		{"PG_SSL_CERT", pg.SSLCert},
+		{"PG_SSL_KEY", pg.SSLKey},
+	} {
+		if file.path == "" {
+			continue
+		}
+		if info, err := os.Stat(file.path); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", file.name, err))
+		} else if info.IsDir() {
+			problems = append(problems, fmt.Errorf("%s: %s is a directory", file.name, file.path))
+		}
+	}
+	return problems
+}
+
+// urlParseCause drops the URL from a parse error, which may hold a password.
+func urlParseCause(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
@@ -1,12 +1,23 @@
 package instruments
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrInstrumentNotFound is returned by repositories when no instrument matches the lookup.
+var ErrInstrumentNotFound = errors.New("instrument not found")
+
+// ErrNilInstrument is returned when a write gets no instrument.
+var ErrNilInstrument = errors.New("instrument is nil")
+
+// ErrUIDConflict is returned by an upsert whose UID and figi name two
+// different instruments.
+var ErrUIDConflict = errors.New("instrument uid conflicts with figi")
+
 type InstrumentType string
 
 const (
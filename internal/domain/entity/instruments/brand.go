@@ -29,3 +29,12 @@ type Country struct {
 	Name      string
 	NameBrief string
 }
+
+// BrandDetails is a brand with the company, sector and country it links to.
+// A link whose row is missing is nil.
+type BrandDetails struct {
+	Brand
+	Company *Company
+	Sector  *Sector
+	Country *Country
+}
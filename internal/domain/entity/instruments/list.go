@@ -0,0 +1,55 @@
+package instruments
+
+import "github.com/google/uuid"
+
+// InstrumentFilter narrows an instrument listing. Empty fields do not filter.
+type InstrumentFilter struct {
+	Ticker     string
+	ClassCode  string
+	FigiPrefix string
+	// Type keeps only instruments with a row in the matching typed table.
+	Type InstrumentType
+	// SectorUID keeps instruments whose brand belongs to the sector; uuid.Nil
+	// does not filter.
+	SectorUID uuid.UUID
+	// CountryCode keeps instruments whose brand's country of risk has this
+	// ISO 3166 alpha-2 or alpha-3 code.
+	CountryCode string
+	// IncludeDeleted also returns soft-deleted rows (deleted_at set).
+	IncludeDeleted bool
+	Limit          int
+	Offset         int
+}
+
+// ListedInstrument is a base instrument row together with the typed table it
+// belongs to; Type is empty for instruments without a typed row.
+type ListedInstrument struct {
+	Instrument
+	Type InstrumentType
+}
+
+// InstrumentSearch is a free-text instrument search. Query is matched as a
+// case-insensitive substring of the ticker and of the brand and company names.
+type InstrumentSearch struct {
+	Query string
+	// IncludeDeleted also returns soft-deleted rows (deleted_at set).
+	IncludeDeleted bool
+	Limit          int
+	Offset         int
+}
+
+// Fields an InstrumentMatch can have matched on, best first.
+const (
+	MatchedOnTicker  = "ticker"
+	MatchedOnBrand   = "brand"
+	MatchedOnCompany = "company"
+)
+
+// InstrumentMatch is an instrument found by a search, with the names of its
+// brand and company, empty without a brand, and the field that matched.
+type InstrumentMatch struct {
+	ListedInstrument
+	BrandName   string
+	CompanyName string
+	MatchedOn   string
+}
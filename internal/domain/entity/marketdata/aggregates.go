@@ -0,0 +1,138 @@
+package marketdata
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TradeActivityBucket holds trade count and traded volume for one time bucket.
+type TradeActivityBucket struct {
+	BucketStart time.Time `json:"bucket_start"`
+	TradeCount  int64     `json:"trade_count"`
+	VolumeLots  int64     `json:"volume_lots"`
+}
+
+// SpreadBucket holds the average top-of-book spread and mid price of the order
+// book snapshots in one time bucket. The averages are nil for buckets without
+// a two-sided snapshot.
+type SpreadBucket struct {
+	BucketStart   time.Time `json:"bucket_start"`
+	SnapshotCount int64     `json:"snapshot_count"`
+	AvgSpread     *float64  `json:"avg_spread"`
+	AvgMid        *float64  `json:"avg_mid"`
+}
+
+// TopOfBook is the best bid and ask of one order book snapshot. A side is nil
+// when the snapshot has no levels on it, and Spread is nil unless both are set.
+type TopOfBook struct {
+	SnapshotAt time.Time `json:"snapshot_at"`
+	BestBid    *float64  `json:"best_bid"`
+	BestAsk    *float64  `json:"best_ask"`
+	Spread     *float64  `json:"spread"`
+}
+
+// OrderBookImbalance is the balance of resting quantity in one snapshot:
+// (bid - ask) / (bid + ask) over the levels counted. It runs from -1, asks
+// only, to 1, bids only, and is nil when neither side has any quantity.
+type OrderBookImbalance struct {
+	SnapshotAt  time.Time `json:"snapshot_at"`
+	Depth       int32     `json:"depth"`
+	BidQuantity int64     `json:"bid_quantity"`
+	AskQuantity int64     `json:"ask_quantity"`
+	Imbalance   *float64  `json:"imbalance"`
+}
+
+// VWAP is the volume-weighted average price of an instrument's trades over a
+// time range.
+type VWAP struct {
+	InstrumentUID uuid.UUID `json:"instrument_uid"`
+	From          time.Time `json:"from"`
+	To            time.Time `json:"to"`
+	VWAP          float64   `json:"vwap"`
+	VolumeLots    int64     `json:"volume_lots"`
+	TradeCount    int64     `json:"trade_count"`
+}
+
+// SectorVolatility is the realized volatility of a sector: the mean, over the
+// sector's instruments, of the standard deviation of daily close-to-close log
+// returns. Annualized scales it to a year of trading days.
+type SectorVolatility struct {
+	SectorUID   uuid.UUID `json:"sector_uid"`
+	Instruments int       `json:"instruments"`
+	DailyStddev float64   `json:"daily_stddev"`
+	Annualized  float64   `json:"annualized"`
+}
+
+// DailyVolume is the traded volume of one calendar day in the market timezone.
+// Day is midnight of that day in the market timezone.
+type DailyVolume struct {
+	Day        time.Time `json:"day"`
+	VolumeLots int64     `json:"volume_lots"`
+	TradeCount int64     `json:"trade_count"`
+}
+
+// ADV is the average daily traded volume over the Days complete calendar days
+// in [From, To). The average is taken over TradingDays, the days with at least
+// one trade, so weekends and holidays do not dilute it.
+type ADV struct {
+	InstrumentUID uuid.UUID     `json:"instrument_uid"`
+	Days          int           `json:"days"`
+	Timezone      string        `json:"timezone"`
+	From          time.Time     `json:"from"`
+	To            time.Time     `json:"to"`
+	TradingDays   int           `json:"trading_days"`
+	ADVLots       float64       `json:"adv_lots"`
+	Daily         []DailyVolume `json:"daily"`
+}
+
+// TradeSummary describes all stored trades of an instrument. The times and
+// prices are nil when there are none.
+type TradeSummary struct {
+	InstrumentUID uuid.UUID  `json:"instrument_uid"`
+	TradeCount    int64      `json:"trade_count"`
+	FirstTradeAt  *time.Time `json:"first_traded_at"`
+	LastTradeAt   *time.Time `json:"last_traded_at"`
+	MinPrice      *float64   `json:"min_price"`
+	MaxPrice      *float64   `json:"max_price"`
+	VolumeLots    int64      `json:"volume_lots"`
+}
+
+// CandleSummary describes the stored candles of one instrument and interval.
+// The periods and prices are nil when there are none.
+type CandleSummary struct {
+	InstrumentUID   uuid.UUID  `json:"instrument_uid"`
+	IntervalSeconds int64      `json:"interval_seconds"`
+	CandleCount     int64      `json:"candle_count"`
+	FirstPeriod     *time.Time `json:"first_period_start"`
+	LastPeriod      *time.Time `json:"last_period_start"`
+	Low             *float64   `json:"low"`
+	High            *float64   `json:"high"`
+	VolumeLots      int64      `json:"volume_lots"`
+}
+
+// OrderBookSummary describes all stored order book snapshots of an instrument.
+// Depths lists the depths they were stored at, ascending.
+type OrderBookSummary struct {
+	InstrumentUID uuid.UUID  `json:"instrument_uid"`
+	SnapshotCount int64      `json:"snapshot_count"`
+	FirstSnapshot *time.Time `json:"first_snapshot_at"`
+	LastSnapshot  *time.Time `json:"last_snapshot_at"`
+	Depths        []int32    `json:"depths"`
+}
+
+// LastPrice is the price of the newest trade of an instrument. Source names
+// where it was read from, so clients can tell a cached price from a stored one.
+type LastPrice struct {
+	InstrumentUID uuid.UUID `json:"instrument_uid"`
+	Price         float64   `json:"price"`
+	TradedAt      time.Time `json:"traded_at"`
+	Venue         string    `json:"venue,omitempty"`
+	Source        string    `json:"source"`
+}
+
+// Sources of a LastPrice.
+const (
+	LastPriceSourceCache    = "cache"
+	LastPriceSourceDatabase = "database"
+)
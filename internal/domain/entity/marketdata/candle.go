@@ -1,11 +1,15 @@
 package marketdata
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrCandleNotFound is returned by repositories when no candle has the requested id.
+var ErrCandleNotFound = errors.New("candle not found")
+
 // Candle represents an OHLCV record for a specific interval (docs/marketdata_doc.md).
 type Candle struct {
 	ID              uuid.UUID      `json:"id"`
@@ -22,3 +26,13 @@ type Candle struct {
 	LastTradeAt     *time.Time     `json:"last_trade_at,omitempty"`
 	Metadata        map[string]any `json:"metadata,omitempty"`
 }
+
+// CandleGap describes a run of missing candles between two stored ones.
+// From and To are the period starts of the first and last missing candle.
+type CandleGap struct {
+	InstrumentUID   uuid.UUID `json:"instrument_uid"`
+	IntervalSeconds int64     `json:"interval_seconds"`
+	From            time.Time `json:"from"`
+	To              time.Time `json:"to"`
+	Missing         int64     `json:"missing"`
+}
@@ -0,0 +1,13 @@
+package marketdata
+
+// ConflictPolicy decides what a batch insert does with rows that collide with
+// stored ones on a primary key or unique index.
+type ConflictPolicy string
+
+const (
+	// ConflictFail rejects the whole batch, as a plain COPY does.
+	ConflictFail ConflictPolicy = "fail"
+	// ConflictSkip stores the new rows and silently skips the colliding ones,
+	// which makes replaying a batch harmless.
+	ConflictSkip ConflictPolicy = "skip"
+)
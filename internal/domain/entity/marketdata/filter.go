@@ -0,0 +1,6 @@
+package marketdata
+
+// MetadataFilter keeps rows whose metadata holds every key with the given
+// string value, e.g. {"trade_source": "TRADE_SOURCE_EXCHANGE"}. An empty filter
+// keeps every row.
+type MetadataFilter map[string]string
@@ -1,17 +1,32 @@
 package marketdata
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrOrderBookNotFound is returned by repositories when no order book snapshot has the requested id.
+var ErrOrderBookNotFound = errors.New("order book snapshot not found")
+
 // OrderBookLevel holds price/quantity pair for bids/asks within a snapshot.
 type OrderBookLevel struct {
 	Price    float64 `json:"price"`
 	Quantity int64   `json:"quantity"`
 }
 
+// DepthMatch selects which stored snapshots a depth query matches.
+type DepthMatch string
+
+const (
+	// DepthMatchAtLeast matches snapshots stored at the requested depth or deeper;
+	// deeper ones are truncated to the requested depth.
+	DepthMatchAtLeast DepthMatch = "at_least"
+	// DepthMatchExact matches only snapshots stored at the requested depth.
+	DepthMatchExact DepthMatch = "exact"
+)
+
 // OrderBookSnapshot represents a captured order book at a specific time/depth (docs/marketdata_doc.md).
 type OrderBookSnapshot struct {
 	ID            uuid.UUID        `json:"id"`
@@ -20,5 +35,15 @@ type OrderBookSnapshot struct {
 	Depth         int32            `json:"depth"`
 	Bids          []OrderBookLevel `json:"bids"`
 	Asks          []OrderBookLevel `json:"asks"`
-	Metadata      map[string]any   `json:"metadata,omitempty"`
+	// Sequence is the per-instrument ingestion sequence assigned by the producer;
+	// nil for snapshots stored without one.
+	Sequence *int64 `json:"sequence,omitempty"`
+	// SequenceFrom is the sequence of the first snapshot the consumer's
+	// throttle dropped right before this one; the snapshot stands for
+	// SequenceFrom through Sequence. nil when nothing was dropped.
+	SequenceFrom *int64         `json:"sequence_from,omitempty"`
+	Metadata     map[string]any `json:"metadata,omitempty"`
+	// SequenceGap is set on read, when gap flagging is requested, for snapshots
+	// whose sequence does not directly follow the previous snapshot's.
+	SequenceGap bool `json:"sequence_gap,omitempty"`
 }
@@ -0,0 +1,9 @@
+package marketdata
+
+// Page selects a window of a range query result. Range queries order rows by
+// time and then by id, so consecutive pages neither skip nor repeat rows while
+// the range is not being written to.
+type Page struct {
+	Limit  int
+	Offset int
+}
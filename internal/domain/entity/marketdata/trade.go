@@ -1,26 +1,36 @@
 package marketdata
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrTradeNotFound is returned by repositories when no trade has the requested id.
+var ErrTradeNotFound = errors.New("trade not found")
+
 // TradeSide represents BUY/SELL direction derived from the incoming stream.
 type TradeSide string
 
 const (
 	TradeSideBuy  TradeSide = "BUY"
 	TradeSideSell TradeSide = "SELL"
+	// TradeSideUnknown marks trades whose direction the venue did not report,
+	// e.g. auction prints.
+	TradeSideUnknown TradeSide = "UNKNOWN"
 )
 
 // Trade models a single executed trade (see docs/marketdata_doc.md).
 type Trade struct {
-	ID            uuid.UUID      `json:"id"`
-	InstrumentUID uuid.UUID      `json:"instrument_uid"`
-	Side          TradeSide      `json:"side"`
-	Price         float64        `json:"price"`
-	QuantityLots  int64          `json:"quantity_lots"`
-	TradedAt      time.Time      `json:"traded_at"`
-	Metadata      map[string]any `json:"metadata,omitempty"`
+	ID            uuid.UUID `json:"id"`
+	InstrumentUID uuid.UUID `json:"instrument_uid"`
+	Side          TradeSide `json:"side"`
+	Price         float64   `json:"price"`
+	QuantityLots  int64     `json:"quantity_lots"`
+	TradedAt      time.Time `json:"traded_at"`
+	// Venue is the exchange board the trade executed on (e.g. TQBR), taken from
+	// the stream or derived from the instrument's class_code at ingestion.
+	Venue    string         `json:"venue,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
 }
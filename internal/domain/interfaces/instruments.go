@@ -10,28 +10,36 @@ import (
 
 type InstrumentsRepository interface {
 	CreateInstrument(ctx context.Context, instrument *domain.Instrument) error
-	GetInstrument(ctx context.Context, uid uuid.UUID) (*domain.Instrument, error)
+	GetInstrument(ctx context.Context, uid uuid.UUID, includeDeleted bool) (*domain.Instrument, error)
+	GetInstrumentType(ctx context.Context, uid uuid.UUID, includeDeleted bool) (domain.InstrumentType, error)
+	GetInstrumentBrand(ctx context.Context, uid uuid.UUID, includeDeleted bool) (*domain.BrandDetails, error)
+	GetExistingInstrumentUIDs(ctx context.Context, uids []uuid.UUID) ([]uuid.UUID, error)
+	GetInstrumentsByUIDs(ctx context.Context, uids []uuid.UUID, includeDeleted bool) ([]domain.Instrument, error)
 	UpdateInstrument(ctx context.Context, instrument *domain.Instrument) error
-	DeleteInstrument(ctx context.Context, uid uuid.UUID) error
+	UpsertInstrument(ctx context.Context, instrument *domain.Instrument, restore bool) error
+	DeleteInstrument(ctx context.Context, uid uuid.UUID, hard bool) error
 	CreateShare(ctx context.Context, share *domain.Share) error
 	UpdateShare(ctx context.Context, share *domain.Share) error
-	DeleteShare(ctx context.Context, uid uuid.UUID) error
-	GetShare(ctx context.Context, uid uuid.UUID) (*domain.Share, error)
+	DeleteShare(ctx context.Context, uid uuid.UUID, hard bool) error
+	GetShare(ctx context.Context, uid uuid.UUID, includeDeleted bool) (*domain.Share, error)
 	CreateBond(ctx context.Context, bond *domain.Bond) error
 	UpdateBond(ctx context.Context, bond *domain.Bond) error
-	DeleteBond(ctx context.Context, uid uuid.UUID) error
-	GetBond(ctx context.Context, uid uuid.UUID) (*domain.Bond, error)
+	DeleteBond(ctx context.Context, uid uuid.UUID, hard bool) error
+	GetBond(ctx context.Context, uid uuid.UUID, includeDeleted bool) (*domain.Bond, error)
 	CreateFuture(ctx context.Context, future *domain.Future) error
 	UpdateFuture(ctx context.Context, future *domain.Future) error
-	DeleteFuture(ctx context.Context, uid uuid.UUID) error
-	GetFuture(ctx context.Context, uid uuid.UUID) (*domain.Future, error)
+	DeleteFuture(ctx context.Context, uid uuid.UUID, hard bool) error
+	GetFuture(ctx context.Context, uid uuid.UUID, includeDeleted bool) (*domain.Future, error)
 	CreateCurrency(ctx context.Context, currency *domain.Currency) error
 	UpdateCurrency(ctx context.Context, currency *domain.Currency) error
-	DeleteCurrency(ctx context.Context, uid uuid.UUID) error
-	GetCurrency(ctx context.Context, uid uuid.UUID) (*domain.Currency, error)
+	DeleteCurrency(ctx context.Context, uid uuid.UUID, hard bool) error
+	GetCurrency(ctx context.Context, uid uuid.UUID, includeDeleted bool) (*domain.Currency, error)
 	CreateEtf(ctx context.Context, etf *domain.Etf) error
 	UpdateEtf(ctx context.Context, etf *domain.Etf) error
-	DeleteEtf(ctx context.Context, uid uuid.UUID) error
-	GetEtf(ctx context.Context, uid uuid.UUID) (*domain.Etf, error)
+	DeleteEtf(ctx context.Context, uid uuid.UUID, hard bool) error
+	GetEtf(ctx context.Context, uid uuid.UUID, includeDeleted bool) (*domain.Etf, error)
+	ListInstruments(ctx context.Context, filter domain.InstrumentFilter) ([]domain.ListedInstrument, error)
+	GetInstrumentByTicker(ctx context.Context, ticker, classCode string, includeDeleted bool) ([]domain.ListedInstrument, error)
+	SearchInstruments(ctx context.Context, search domain.InstrumentSearch) ([]domain.InstrumentMatch, error)
 	Close()
 }
@@ -11,19 +11,48 @@ import (
 
 type MarketDataRepository interface {
 	AddTrade(ctx context.Context, trade *marketdata.Trade) error
-	AddTrades(ctx context.Context, trades []marketdata.Trade) error
-	GetTradesBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time) ([]marketdata.Trade, error)
-	GetLastTrades(ctx context.Context, instrumentUID uuid.UUID, limit int) ([]marketdata.Trade, error)
+	AddTrades(ctx context.Context, trades []marketdata.Trade, onConflict marketdata.ConflictPolicy) (int64, error)
+	DeleteTradesBefore(ctx context.Context, instrumentUID uuid.UUID, cutoff time.Time, chunkSize int) (int64, error)
+	DeleteTradesBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, chunkSize int) (int64, error)
+	GetTradesBetween(ctx context.Context, instrumentUID uuid.UUID, venue string, from, to time.Time, meta marketdata.MetadataFilter, page marketdata.Page) ([]marketdata.Trade, error)
+	EachTradeBetween(ctx context.Context, instrumentUID uuid.UUID, venue string, from, to time.Time, meta marketdata.MetadataFilter, page marketdata.Page, fn func(marketdata.Trade) error) error
+	GetTradeByID(ctx context.Context, id uuid.UUID) (*marketdata.Trade, error)
+	GetLastTrades(ctx context.Context, instrumentUID uuid.UUID, venue string, limit int) ([]marketdata.Trade, error)
+	GetInstrumentClassCodes(ctx context.Context, instrumentUIDs []uuid.UUID) (map[uuid.UUID]string, error)
+	GetVWAP(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time) (*marketdata.VWAP, error)
+	GetTradeSummary(ctx context.Context, instrumentUID uuid.UUID) (*marketdata.TradeSummary, error)
+	GetDailyVolumes(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, timezone string) ([]marketdata.DailyVolume, error)
+	GetTradeActivity(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, bucketSeconds int64) ([]marketdata.TradeActivityBucket, error)
 
 	AddCandle(ctx context.Context, candle *marketdata.Candle) error
-	AddCandles(ctx context.Context, candles []marketdata.Candle) error
-	GetCandlesBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, intervalSeconds int64) ([]marketdata.Candle, error)
+	AddCandles(ctx context.Context, candles []marketdata.Candle, onConflict marketdata.ConflictPolicy) (int64, error)
+	UpsertCandle(ctx context.Context, candle *marketdata.Candle) error
+	UpsertCandles(ctx context.Context, candles []marketdata.Candle) (int64, error)
+	DeleteCandlesBefore(ctx context.Context, instrumentUID uuid.UUID, cutoff time.Time, chunkSize int) (int64, error)
+	DeleteCandlesBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, chunkSize int) (int64, error)
+	GetCandlesBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, intervalSeconds int64, meta marketdata.MetadataFilter, page marketdata.Page) ([]marketdata.Candle, error)
+	EachCandleBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, intervalSeconds int64, meta marketdata.MetadataFilter, page marketdata.Page, fn func(marketdata.Candle) error) error
+	GetCandleByID(ctx context.Context, id uuid.UUID) (*marketdata.Candle, error)
 	GetLastCandles(ctx context.Context, instrumentUID uuid.UUID, intervalSeconds int64, limit int) ([]marketdata.Candle, error)
+	GetCandleSummary(ctx context.Context, instrumentUID uuid.UUID, intervalSeconds int64) (*marketdata.CandleSummary, error)
+	GetCandleGaps(ctx context.Context, intervalSeconds int64, from, to time.Time, instrumentUIDs ...uuid.UUID) ([]marketdata.CandleGap, error)
+	GetCandlesFromTrades(ctx context.Context, instrumentUID uuid.UUID, intervalSeconds int64, from, to time.Time, timezone string) ([]marketdata.Candle, error)
+	GetCandlesResampled(ctx context.Context, instrumentUID uuid.UUID, baseInterval, targetInterval int64, from, to time.Time, timezone string) ([]marketdata.Candle, error)
 
 	AddOrderBookSnapshot(ctx context.Context, snapshot *marketdata.OrderBookSnapshot) error
-	AddOrderBookSnapshots(ctx context.Context, snapshots []marketdata.OrderBookSnapshot) error
-	GetOrderBookSnapshotsBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, depth int32) ([]marketdata.OrderBookSnapshot, error)
-	GetLastOrderBookSnapshots(ctx context.Context, instrumentUID uuid.UUID, depth int32, limit int) ([]marketdata.OrderBookSnapshot, error)
+	AddOrderBookSnapshots(ctx context.Context, snapshots []marketdata.OrderBookSnapshot, onConflict marketdata.ConflictPolicy) (int64, error)
+	DeleteOrderBookSnapshotsBefore(ctx context.Context, instrumentUID uuid.UUID, cutoff time.Time, chunkSize int) (int64, error)
+	DeleteOrderBookSnapshotsBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, chunkSize int) (int64, error)
+	GetOrderBookSnapshotsBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, depth int32, match marketdata.DepthMatch, meta marketdata.MetadataFilter, page marketdata.Page) ([]marketdata.OrderBookSnapshot, error)
+	EachOrderBookSnapshotBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, depth int32, match marketdata.DepthMatch, meta marketdata.MetadataFilter, page marketdata.Page, fn func(marketdata.OrderBookSnapshot) error) error
+	GetOrderBookByID(ctx context.Context, id uuid.UUID) (*marketdata.OrderBookSnapshot, error)
+	GetLastOrderBookSnapshots(ctx context.Context, instrumentUID uuid.UUID, depth int32, match marketdata.DepthMatch, limit int) ([]marketdata.OrderBookSnapshot, error)
+	GetOrderBookDepths(ctx context.Context, instrumentUID uuid.UUID) ([]int32, error)
+	GetOrderBookSummary(ctx context.Context, instrumentUID uuid.UUID) (*marketdata.OrderBookSummary, error)
+	GetSectorVolatility(ctx context.Context, intervalSeconds int64, from, to time.Time, timezone string, minReturns int) ([]marketdata.SectorVolatility, error)
+	GetTopOfBook(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, page marketdata.Page) ([]marketdata.TopOfBook, error)
+	GetSpreadSeries(ctx context.Context, instrumentUID uuid.UUID, depth int32, from, to time.Time, bucketSeconds int64) ([]marketdata.SpreadBucket, error)
+	GetOrderBookImbalance(ctx context.Context, instrumentUID uuid.UUID, levels int32, from, to time.Time, page marketdata.Page) ([]marketdata.OrderBookImbalance, error)
 
 	Close()
 }
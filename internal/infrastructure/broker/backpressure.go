@@ -0,0 +1,131 @@
+package broker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/sirupsen/logrus"
+)
+
+// backpressure pauses the consume loops while Postgres is failing. After
+// threshold batch flushes in a row failed with a transient error, the loops
+// stop reading deliveries until a health probe succeeds. They do not cancel
+// their consumers: the queues are auto-delete and would go away with the last
+// one. The broker stops pushing once the prefetch window is full, so the
+// messages stay in the queue instead of being acked into a writer that cannot
+// store them.
+type backpressure struct {
+	threshold int
+	interval  time.Duration
+	probe     func(context.Context) error
+	log       *logrus.Entry
+	metrics   *consumerMetrics
+
+	mu       sync.Mutex
+	ctx      context.Context
+	failures int
+	// resumed is nil while consuming; it is closed when the pause ends.
+	resumed chan struct{}
+	probes  sync.WaitGroup
+}
+
+func newBackpressure(threshold int, interval time.Duration, probe func(context.Context) error, logger *logrus.Logger, metrics *consumerMetrics) *backpressure {
+	metrics.paused.Set(0)
+	return &backpressure{
+		threshold: threshold,
+		interval:  interval,
+		probe:     probe,
+		log:       logger.WithField("component", "rabbitmq_consumer"),
+		metrics:   metrics,
+	}
+}
+
+// setContext sets the context the health probe runs under; no pause starts
+// before it is set.
+func (b *backpressure) setContext(ctx context.Context) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.ctx = ctx
+}
+
+// observe counts consecutive failed flushes and starts a pause once they reach
+// the threshold. Only transient errors count: a batch that Postgres rejects
+// for its content would fail again whether or not consumption pauses.
+func (b *backpressure) observe(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		if b.resumed == nil {
+			b.failures = 0
+		}
+		return
+	}
+	if !isRetriableFlushError(err) {
+		return
+	}
+	b.failures++
+	if b.failures < b.threshold || b.resumed != nil || b.ctx == nil || b.ctx.Err() != nil {
+		return
+	}
+	b.resumed = make(chan struct{})
+	b.metrics.paused.Set(1)
+	b.log.WithError(err).WithField("failed_flushes", b.failures).Warn("batch flushes keep failing; pausing consumption until postgres is healthy")
+	b.probes.Add(1)
+	go b.probeUntilHealthy(b.ctx, b.resumed)
+}
+
+// probeUntilHealthy runs the health probe every interval and ends the pause on
+// the first success. A pause still running when ctx is done is left in place;
+// the consume loops stop with ctx anyway.
+func (b *backpressure) probeUntilHealthy(ctx context.Context, resumed chan struct{}) {
+	defer b.probes.Done()
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		probeCtx, cancel := context.WithTimeout(ctx, b.interval)
+		err := b.probe(probeCtx)
+		cancel()
+		if err != nil {
+			b.log.WithError(err).WithField("attempt", attempt).Debug("postgres health probe failed; consumption stays paused")
+			continue
+		}
+		b.mu.Lock()
+		b.failures = 0
+		b.resumed = nil
+		b.mu.Unlock()
+		close(resumed)
+		b.metrics.paused.Set(0)
+		b.log.WithField("attempts", attempt).Info("postgres healthy again; resuming consumption")
+		return
+	}
+}
+
+// await blocks while consumption is paused. It reports false when ctx is done
+// or the channel closed during the wait, so the consume loop should return.
+// A nil backpressure never pauses.
+func (b *backpressure) await(ctx context.Context, closed <-chan *amqp.Error) bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	resumed := b.resumed
+	b.mu.Unlock()
+	if resumed == nil {
+		return true
+	}
+	select {
+	case <-resumed:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-closed:
+		return false
+	}
+}
@@ -3,12 +3,19 @@ package broker
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	appmarketdata "main/internal/application/service/marketdata"
 	domain "main/internal/domain/entity/marketdata"
 
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 )
 
@@ -16,34 +23,134 @@ import (
 type BatchConfig struct {
 	Size    int
 	Timeout time.Duration
+	// Per-entity overrides; zero values fall back to Size and Timeout.
+	TradesSize        int
+	TradesTimeout     time.Duration
+	CandlesSize       int
+	CandlesTimeout    time.Duration
+	OrderBooksSize    int
+	OrderBooksTimeout time.Duration
+	// FlushAttempts bounds how often a batch write is tried when it fails with
+	// a retriable Postgres error; values below 2 disable retries. The delay
+	// starts at FlushRetryBase and doubles after each attempt.
+	FlushAttempts  int
+	FlushRetryBase time.Duration
+	// FlushWorkers is how many batches of one entity type may be written at
+	// once. With 1 or less, a full batch is written by the goroutine whose
+	// entity filled it, and its error is returned from the add. With more, the
+	// batch is handed to a worker and the add only waits while every worker is
+	// busy; a failure is then reported like a timer flush, through the log,
+	// the metrics and OnFlushError. Batches of the same type may commit in
+	// any order.
+	FlushWorkers int
+	// OnFlushError, when set, is called after every failed flush with the
+	// entity type, the number of entities in the batch and the error. A batch
+	// flushed by the timer is lost after the callback returns.
+	OnFlushError func(entity string, batch int, err error)
+}
+
+// withOverride returns the global thresholds with the non-zero overrides applied.
+func (c BatchConfig) withOverride(size int, timeout time.Duration) BatchConfig {
+	resolved := c
+	resolved.TradesSize, resolved.TradesTimeout = 0, 0
+	resolved.CandlesSize, resolved.CandlesTimeout = 0, 0
+	resolved.OrderBooksSize, resolved.OrderBooksTimeout = 0, 0
+	if size > 0 {
+		resolved.Size = size
+	}
+	if timeout > 0 {
+		resolved.Timeout = timeout
+	}
+	return resolved
 }
 
 // BatchWriter buffers market data entities and flushes them via the service.
 type BatchWriter struct {
 	service *appmarketdata.Service
+	metrics *batchMetrics
 
 	trades     *batchBuffer[domain.Trade]
 	candles    *batchBuffer[domain.Candle]
 	orderBooks *batchBuffer[domain.OrderBookSnapshot]
+	candleHub  *appmarketdata.Hub[domain.Candle]
 }
 
 // NewBatchWriter configures a batch writer for all market data entity types.
 func NewBatchWriter(cfg BatchConfig, service *appmarketdata.Service, logger *logrus.Logger) *BatchWriter {
 	componentLogger := logger.WithField("component", "batch_writer")
-	return &BatchWriter{
+	metrics := newBatchMetrics()
+	writer := &BatchWriter{
 		service: service,
-		trades: newBatchBuffer(cfg, func(ctx context.Context, batch []domain.Trade) error {
-			return service.AddTrades(ctx, batch)
-		}, componentLogger.WithField("entity", "trade")),
-		candles: newBatchBuffer(cfg, func(ctx context.Context, batch []domain.Candle) error {
-			return service.AddCandles(ctx, batch)
-		}, componentLogger.WithField("entity", "candle")),
-		orderBooks: newBatchBuffer(cfg, func(ctx context.Context, batch []domain.OrderBookSnapshot) error {
-			return service.AddOrderBookSnapshots(ctx, batch)
-		}, componentLogger.WithField("entity", "orderbook")),
+		metrics: metrics,
+		trades: newBatchBuffer("trade", cfg.withOverride(cfg.TradesSize, cfg.TradesTimeout), func(ctx context.Context, batch []domain.Trade) error {
+			if _, err := service.AddTrades(ctx, batch); err != nil {
+				return err
+			}
+			// Best-effort: the trades are stored either way, and a last price
+			// left behind carries the time of its trade.
+			if err := service.RecordLastPrices(ctx, batch); err != nil {
+				componentLogger.WithError(err).WithField("batch", len(batch)).Warn("failed to record last prices")
+			}
+			return nil
+		}, componentLogger, metrics),
+		orderBooks: newBatchBuffer("orderbook", cfg.withOverride(cfg.OrderBooksSize, cfg.OrderBooksTimeout), func(ctx context.Context, batch []domain.OrderBookSnapshot) error {
+			_, err := service.AddOrderBookSnapshots(ctx, batch)
+			return err
+		}, componentLogger, metrics),
+	}
+	writer.candles = newBatchBuffer("candle", cfg.withOverride(cfg.CandlesSize, cfg.CandlesTimeout), func(ctx context.Context, batch []domain.Candle) error {
+		if _, err := service.AddCandles(ctx, batch); err != nil {
+			return err
+		}
+		writer.publishCandles(batch)
+		return nil
+	}, componentLogger, metrics)
+	return writer
+}
+
+// SetCandleHub publishes every candle after its batch is stored, for live
+// streaming to HTTP clients. Call it before Run.
+func (b *BatchWriter) SetCandleHub(hub *appmarketdata.Hub[domain.Candle]) {
+	b.candleHub = hub
+}
+
+func (b *BatchWriter) publishCandles(batch []domain.Candle) {
+	if b.candleHub == nil {
+		return
+	}
+	for _, candle := range batch {
+		b.candleHub.Publish(&candle)
 	}
 }
 
+// Collectors returns the Prometheus collectors of the writer: batches, items
+// and errors flushed, and the current buffer depth, all per entity type.
+func (b *BatchWriter) Collectors() []prometheus.Collector {
+	return b.metrics.collectors()
+}
+
+// SetFlushErrorHandler replaces the OnFlushError callback; nil removes it. It
+// is safe to call while the writer is running.
+func (b *BatchWriter) SetFlushErrorHandler(fn func(entity string, batch int, err error)) {
+	b.trades.setFlushErrorHandler(fn)
+	b.candles.setFlushErrorHandler(fn)
+	b.orderBooks.setFlushErrorHandler(fn)
+}
+
+// setFlushObserver installs a callback that sees the outcome of every flush,
+// nil for a success; nil removes it.
+func (b *BatchWriter) setFlushObserver(fn func(err error)) {
+	b.trades.setFlushObserver(fn)
+	b.candles.setFlushObserver(fn)
+	b.orderBooks.setFlushObserver(fn)
+}
+
+// setOrderBooksFlushed installs a callback that sees every order book batch
+// after its flush, with the error; nil removes it.
+func (b *BatchWriter) setOrderBooksFlushed(fn func(batch []domain.OrderBookSnapshot, err error)) {
+	b.orderBooks.setFlushedHook(fn)
+}
+
 // Run sets the base context for asynchronous flush operations.
 func (b *BatchWriter) Run(ctx context.Context) {
 	if ctx == nil {
@@ -62,7 +169,11 @@ func (b *BatchWriter) Stop(ctx context.Context) error {
 	b.trades.setContext(ctx)
 	b.candles.setContext(ctx)
 	b.orderBooks.setContext(ctx)
+	return b.Flush(ctx)
+}
 
+// Flush writes out everything buffered so far and keeps the writer running.
+func (b *BatchWriter) Flush(ctx context.Context) error {
 	var errs []error
 	if err := b.trades.drain(ctx); err != nil {
 		errs = append(errs, err)
@@ -81,44 +192,124 @@ func (b *BatchWriter) AddTrade(trade *domain.Trade) error {
 	if trade == nil {
 		return errors.New("trade is nil")
 	}
+	if err := b.service.ValidateTrade(trade); err != nil {
+		return err
+	}
 	copyTrade := *trade
 	return b.trades.enqueue(copyTrade)
 }
 
+// AddTrades appends the trades of a batched message to the trade buffer. All of
+// them are validated before any is buffered, so the message is taken as a
+// whole or not at all.
+func (b *BatchWriter) AddTrades(trades []domain.Trade) error {
+	for i := range trades {
+		if err := b.service.ValidateTrade(&trades[i]); err != nil {
+			return fmt.Errorf("trade %d: %w", i, err)
+		}
+	}
+	return b.trades.enqueue(trades...)
+}
+
 // AddCandle appends a candle to the candle buffer.
 func (b *BatchWriter) AddCandle(candle *domain.Candle) error {
 	if candle == nil {
 		return errors.New("candle is nil")
 	}
+	if err := b.service.ValidateMetadata(candle.Metadata); err != nil {
+		return err
+	}
 	copyCandle := *candle
 	return b.candles.enqueue(copyCandle)
 }
 
+// AddCandles appends the candles of a batched message to the candle buffer,
+// all or none like AddTrades.
+func (b *BatchWriter) AddCandles(candles []domain.Candle) error {
+	for i := range candles {
+		if err := b.service.ValidateMetadata(candles[i].Metadata); err != nil {
+			return fmt.Errorf("candle %d: %w", i, err)
+		}
+	}
+	return b.candles.enqueue(candles...)
+}
+
 // AddOrderBook appends an order book snapshot to its buffer.
 func (b *BatchWriter) AddOrderBook(snapshot *domain.OrderBookSnapshot) error {
 	if snapshot == nil {
 		return errors.New("order book snapshot is nil")
 	}
+	if err := b.service.ValidateOrderBookSnapshot(snapshot); err != nil {
+		return err
+	}
 	copySnapshot := *snapshot
 	return b.orderBooks.enqueue(copySnapshot)
 }
 
+// AddOrderBooks appends the snapshots of a batched message to their buffer, all
+// or none like AddTrades.
+func (b *BatchWriter) AddOrderBooks(snapshots []domain.OrderBookSnapshot) error {
+	for i := range snapshots {
+		if err := b.service.ValidateOrderBookSnapshot(&snapshots[i]); err != nil {
+			return fmt.Errorf("order book snapshot %d: %w", i, err)
+		}
+	}
+	return b.orderBooks.enqueue(snapshots...)
+}
+
 type batchBuffer[T any] struct {
-	cfg     BatchConfig
-	mu      sync.Mutex
-	items   []T
-	timer   *time.Timer
-	flushFn func(context.Context, []T) error
+	entity       string
+	cfg          BatchConfig
+	mu           sync.Mutex
+	items        []T
+	timer        *time.Timer
+	flushFn      func(context.Context, []T) error
+	onFlushError func(entity string, batch int, err error)
+	// observer, when set, sees the outcome of every flush.
+	observer func(err error)
+	// flushed, when set, sees every batch after its flush, with the error.
+	flushed func(batch []T, err error)
 	logger  *logrus.Entry
+	metrics *batchMetrics
 	ctx     context.Context
+	// workers holds a token per running flush worker; it is nil when batches
+	// are written synchronously.
+	workers chan struct{}
 }
 
-func newBatchBuffer[T any](cfg BatchConfig, flushFn func(context.Context, []T) error, logger *logrus.Entry) *batchBuffer[T] {
-	return &batchBuffer[T]{
-		cfg:     cfg,
-		flushFn: flushFn,
-		logger:  logger,
+func newBatchBuffer[T any](entity string, cfg BatchConfig, flushFn func(context.Context, []T) error, logger *logrus.Entry, metrics *batchMetrics) *batchBuffer[T] {
+	metrics.depth.WithLabelValues(entity).Set(0)
+	metrics.inFlight.WithLabelValues(entity).Set(0)
+	bb := &batchBuffer[T]{
+		entity:       entity,
+		cfg:          cfg,
+		flushFn:      flushFn,
+		onFlushError: cfg.OnFlushError,
+		logger:       logger.WithField("entity", entity),
+		metrics:      metrics,
+	}
+	if cfg.FlushWorkers > 1 {
+		bb.workers = make(chan struct{}, cfg.FlushWorkers)
 	}
+	return bb
+}
+
+func (bb *batchBuffer[T]) setFlushErrorHandler(fn func(entity string, batch int, err error)) {
+	bb.mu.Lock()
+	defer bb.mu.Unlock()
+	bb.onFlushError = fn
+}
+
+func (bb *batchBuffer[T]) setFlushObserver(fn func(err error)) {
+	bb.mu.Lock()
+	defer bb.mu.Unlock()
+	bb.observer = fn
+}
+
+func (bb *batchBuffer[T]) setFlushedHook(fn func(batch []T, err error)) {
+	bb.mu.Lock()
+	defer bb.mu.Unlock()
+	bb.flushed = fn
 }
 
 func (bb *batchBuffer[T]) setContext(ctx context.Context) {
@@ -130,7 +321,9 @@ func (bb *batchBuffer[T]) setContext(ctx context.Context) {
 	bb.ctx = ctx
 }
 
-func (bb *batchBuffer[T]) enqueue(item T) error {
+// enqueue buffers items together: either all of them end up in the buffer, or
+// in the same flush.
+func (bb *batchBuffer[T]) enqueue(items ...T) error {
 	bb.mu.Lock()
 	ctx := bb.ctx
 	if ctx == nil {
@@ -141,7 +334,8 @@ func (bb *batchBuffer[T]) enqueue(item T) error {
 		bb.mu.Unlock()
 		return err
 	}
-	bb.items = append(bb.items, item)
+	bb.items = append(bb.items, items...)
+	bb.metrics.depth.WithLabelValues(bb.entity).Set(float64(len(bb.items)))
 	var batch []T
 	limit := bb.cfg.Size
 	if limit <= 0 {
@@ -157,9 +351,33 @@ func (bb *batchBuffer[T]) enqueue(item T) error {
 	if len(batch) == 0 {
 		return nil
 	}
+	if bb.workers != nil {
+		bb.dispatch(ctx, batch)
+		return nil
+	}
 	return bb.flushWithContext(ctx, batch)
 }
 
+// dispatch writes batch on a flush worker of its own, after waiting for one of
+// the FlushWorkers slots to free up. The wait is not cut short by ctx: the
+// running flushes give up quickly once it is done, and the batch is then
+// counted as failed like any other.
+func (bb *batchBuffer[T]) dispatch(ctx context.Context, batch []T) {
+	queued := time.Now()
+	bb.workers <- struct{}{}
+	bb.metrics.queueWait.WithLabelValues(bb.entity).Observe(time.Since(queued).Seconds())
+	bb.metrics.inFlight.WithLabelValues(bb.entity).Inc()
+	go func() {
+		defer func() {
+			bb.metrics.inFlight.WithLabelValues(bb.entity).Dec()
+			<-bb.workers
+		}()
+		if err := bb.flushWithContext(ctx, batch); err != nil && bb.logger != nil {
+			bb.logger.WithError(err).Warn("batch flush failed")
+		}
+	}()
+}
+
 func (bb *batchBuffer[T]) startTimerLocked() {
 	timeout := bb.cfg.Timeout
 	if timeout <= 0 {
@@ -170,6 +388,10 @@ func (bb *batchBuffer[T]) startTimerLocked() {
 		if len(batch) == 0 {
 			return
 		}
+		if bb.workers != nil {
+			bb.dispatch(bb.currentContext(), batch)
+			return
+		}
 		if err := bb.flushWithCurrentContext(batch); err != nil && bb.logger != nil {
 			bb.logger.WithError(err).Warn("batch flush failed")
 		}
@@ -193,14 +415,18 @@ func (bb *batchBuffer[T]) takeBatchLocked() []T {
 	batch := make([]T, len(bb.items))
 	copy(batch, bb.items)
 	bb.items = bb.items[:0]
+	bb.metrics.depth.WithLabelValues(bb.entity).Set(0)
 	return batch
 }
 
 func (bb *batchBuffer[T]) flushWithCurrentContext(batch []T) error {
+	return bb.flushWithContext(bb.currentContext(), batch)
+}
+
+func (bb *batchBuffer[T]) currentContext() context.Context {
 	bb.mu.Lock()
-	ctx := bb.ctx
-	bb.mu.Unlock()
-	return bb.flushWithContext(ctx, batch)
+	defer bb.mu.Unlock()
+	return bb.ctx
 }
 
 func (bb *batchBuffer[T]) flushWithContext(ctx context.Context, batch []T) error {
@@ -211,9 +437,25 @@ func (bb *batchBuffer[T]) flushWithContext(ctx context.Context, batch []T) error
 		ctx = context.Background()
 	}
 	start := time.Now()
-	if err := bb.flushFn(ctx, batch); err != nil {
+	err := bb.flushWithRetry(ctx, batch)
+	bb.mu.Lock()
+	onFlushError, observer, flushed := bb.onFlushError, bb.observer, bb.flushed
+	bb.mu.Unlock()
+	if observer != nil {
+		observer(err)
+	}
+	if flushed != nil {
+		flushed(batch, err)
+	}
+	if err != nil {
+		bb.metrics.errors.WithLabelValues(bb.entity).Inc()
+		if onFlushError != nil {
+			onFlushError(bb.entity, len(batch), err)
+		}
 		return err
 	}
+	bb.metrics.batches.WithLabelValues(bb.entity).Inc()
+	bb.metrics.items.WithLabelValues(bb.entity).Add(float64(len(batch)))
 	if bb.logger != nil {
 		bb.logger.WithFields(logrus.Fields{
 			"size":    len(batch),
@@ -223,10 +465,75 @@ func (bb *batchBuffer[T]) flushWithContext(ctx context.Context, batch []T) error
 	return nil
 }
 
+// flushWithRetry calls flushFn, retrying retriable errors with exponential
+// backoff. A done context stops it at once, also while waiting.
+func (bb *batchBuffer[T]) flushWithRetry(ctx context.Context, batch []T) error {
+	delay := bb.cfg.FlushRetryBase
+	for attempt := 1; ; attempt++ {
+		err := bb.flushFn(ctx, batch)
+		if err == nil || attempt >= bb.cfg.FlushAttempts || !isRetriableFlushError(err) {
+			return err
+		}
+		if bb.logger != nil {
+			bb.logger.WithError(err).WithFields(logrus.Fields{
+				"attempt":  attempt,
+				"retry_in": delay.String(),
+			}).Warn("batch flush failed; retrying")
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, context.Cause(ctx))
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// drain writes what is buffered. With flush workers it first takes every
+// worker slot, which waits for the batches still being written and holds off
+// new ones until the buffered batch is written too. Errors of the workers'
+// batches were reported when they failed; only the buffered batch's error is
+// returned.
 func (bb *batchBuffer[T]) drain(ctx context.Context) error {
+	if bb.workers != nil {
+		for range cap(bb.workers) {
+			bb.workers <- struct{}{}
+		}
+		defer func() {
+			for range cap(bb.workers) {
+				<-bb.workers
+			}
+		}()
+	}
 	batch := bb.takeBatch()
 	if len(batch) == 0 {
 		return nil
 	}
 	return bb.flushWithContext(ctx, batch)
 }
+
+// isRetriableFlushError reports whether a failed batch write may succeed when
+// repeated: serialization failures, deadlocks, lock timeouts, connection
+// problems and errors pgx marks safe to retry. Each batch is written in one
+// transaction, so a failed attempt left nothing behind.
+func isRetriableFlushError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "40001", "40P01", "55P03", "53300", "57P01":
+			return true
+		}
+		// Class 08: connection exceptions.
+		return strings.HasPrefix(pgErr.Code, "08")
+	}
+	if pgconn.SafeToRetry(err) || pgconn.Timeout(err) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET)
+}
@@ -6,16 +6,22 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	appmarketdata "main/internal/application/service/marketdata"
 	"main/internal/config"
+	domain "main/internal/domain/entity/marketdata"
 
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/sirupsen/logrus"
 )
 
-// Consumer subscribes to RabbitMQ fanout exchanges and forwards messages
-// into the market data service via buffered batch writers.
+// Consumer subscribes to RabbitMQ fanout or topic exchanges and forwards messages
+// into the market data service via buffered batch writers. When the connection
+// or one of its channels closes, it flushes pending batches, re-dials with
+// exponential backoff and declares its queues again.
 type Consumer struct {
 	cfg     config.RabbitMQConfig
 	service *appmarketdata.Service
@@ -23,8 +29,18 @@ type Consumer struct {
 
 	conn     *amqp.Connection
 	channels []*amqp.Channel
+	// queues names the server-named queue of each stream on conn.
+	queues map[streamType]string
+	// loops tracks the consume loops of the current connection; wg tracks the
+	// supervisor that replaces the connection.
+	loops    sync.WaitGroup
 	wg       sync.WaitGroup
+	stop     context.CancelFunc
 	batcher  *BatchWriter
+	throttle *orderBookThrottle
+	trades   *appmarketdata.Hub[domain.Trade]
+	metrics  *consumerMetrics
+	pause    *backpressure
 }
 
 // NewConsumer prepares a consumer for the given configuration.
@@ -32,66 +48,258 @@ func NewConsumer(cfg config.RabbitMQConfig, service *appmarketdata.Service, logg
 	if cfg.URL == "" {
 		return nil, errors.New("rabbitmq url is required")
 	}
+	if cfg.ExchangeType == "" {
+		cfg.ExchangeType = ExchangeFanout
+	}
+	if err := ValidateExchangeType(cfg.ExchangeType); err != nil {
+		return nil, err
+	}
 	batchCfg := BatchConfig{
-		Size:    cfg.BatchSize,
-		Timeout: cfg.BatchTimeout,
+		Size:              cfg.BatchSize,
+		Timeout:           cfg.BatchTimeout,
+		TradesSize:        cfg.TradesBatch.Size,
+		TradesTimeout:     cfg.TradesBatch.Timeout,
+		CandlesSize:       cfg.CandlesBatch.Size,
+		CandlesTimeout:    cfg.CandlesBatch.Timeout,
+		OrderBooksSize:    cfg.OrderBooksBatch.Size,
+		OrderBooksTimeout: cfg.OrderBooksBatch.Timeout,
+		FlushAttempts:     cfg.FlushAttempts,
+		FlushRetryBase:    cfg.FlushRetryBase,
+		FlushWorkers:      cfg.FlushWorkers,
 	}
 	consumer := &Consumer{
 		cfg:     cfg,
 		service: service,
 		logger:  logger,
 		batcher: NewBatchWriter(batchCfg, service, logger),
+		metrics: newConsumerMetrics(),
 	}
 	return consumer, nil
 }
 
-// Start establishes the AMQP connection and begins consuming fanout exchanges.
+// SetOrderBookThrottle limits how often order book snapshots are persisted per
+// instrument. Call it before Start.
+func (c *Consumer) SetOrderBookThrottle(cfg config.OrderBookThrottleConfig) {
+	c.throttle = newOrderBookThrottle(cfg)
+	c.batcher.setOrderBooksFlushed(c.throttle.done)
+}
+
+// SetTradeHub publishes every trade accepted for persistence to hub, for live
+// streaming to HTTP clients. Call it before Start.
+func (c *Consumer) SetTradeHub(hub *appmarketdata.Hub[domain.Trade]) {
+	c.trades = hub
+}
+
+// SetCandleHub publishes every stored candle to hub, for live streaming to
+// HTTP clients. Call it before Start.
+func (c *Consumer) SetCandleHub(hub *appmarketdata.Hub[domain.Candle]) {
+	c.batcher.SetCandleHub(hub)
+}
+
+// SetHealthProbe enables backpressure: once PauseAfterFlushErrors batch
+// flushes in a row have failed with a transient error, the consume loops stop
+// taking deliveries, and probe is called every PauseProbeInterval until it
+// succeeds. The AMQP connection stays up meanwhile. nil, or a zero
+// PauseAfterFlushErrors, disables it. Call it before Start.
+func (c *Consumer) SetHealthProbe(probe func(ctx context.Context) error) {
+	if probe == nil || c.cfg.PauseAfterFlushErrors <= 0 {
+		c.pause = nil
+		c.batcher.setFlushObserver(nil)
+		return
+	}
+	c.pause = newBackpressure(c.cfg.PauseAfterFlushErrors, c.cfg.PauseProbeInterval, probe, c.logger, c.metrics)
+	c.batcher.setFlushObserver(c.pause.observe)
+}
+
+// Collectors returns the Prometheus collectors of the consumer and its batch
+// writer.
+func (c *Consumer) Collectors() []prometheus.Collector {
+	return append(c.batcher.Collectors(), c.metrics.collectors()...)
+}
+
+// SetFlushErrorHandler installs a callback invoked after every failed batch
+// flush; see BatchConfig.OnFlushError. nil removes it.
+func (c *Consumer) SetFlushErrorHandler(fn func(entity string, batch int, err error)) {
+	c.batcher.SetFlushErrorHandler(fn)
+}
+
+// Start establishes the AMQP connection and begins consuming the exchanges.
+// Only the first connection attempt is reported; later connection losses are
+// handled in the background until ctx is done or Close is called.
 func (c *Consumer) Start(ctx context.Context) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	conn, err := amqp.Dial(c.cfg.URL)
+	c.batcher.Run(ctx)
+	runCtx, stop := context.WithCancel(ctx)
+	c.stop = stop
+	if c.pause != nil {
+		c.pause.setContext(runCtx)
+	}
+	if err := c.connect(runCtx); err != nil {
+		c.Close(ctx)
+		return err
+	}
+
+	c.wg.Add(1)
+	go c.supervise(runCtx, c)
+
+	c.logger.Infof("rabbitmq consumer started: exchanges=%s,%s,%s", c.cfg.TradesExchange, c.cfg.CandlesExchange, c.cfg.OrderBooksExchange)
+	return nil
+}
+
+// Close stops consumption, flushes pending batches, and releases resources.
+func (c *Consumer) Close(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if c.stop != nil {
+		c.stop()
+	}
+	c.wg.Wait()
+	if c.pause != nil {
+		c.pause.probes.Wait()
+	}
+	c.teardown()
+	if c.batcher == nil {
+		return nil
+	}
+	return c.batcher.Stop(ctx)
+}
+
+// connect dials the broker and starts one consume loop per exchange.
+func (c *Consumer) connect(ctx context.Context) error {
+	conn, err := amqp.DialConfig(c.cfg.URL, dialConfig(c.cfg))
 	if err != nil {
 		return fmt.Errorf("connect to rabbitmq: %w", err)
 	}
 	c.conn = conn
-	c.batcher.Run(ctx)
 
 	if err := c.startStream(ctx, streamTrade, c.cfg.TradesExchange); err != nil {
-		c.Close(ctx)
+		c.teardown()
 		return err
 	}
 	if err := c.startStream(ctx, streamCandle, c.cfg.CandlesExchange); err != nil {
-		c.Close(ctx)
+		c.teardown()
 		return err
 	}
 	if err := c.startStream(ctx, streamOrderBook, c.cfg.OrderBooksExchange); err != nil {
-		c.Close(ctx)
+		c.teardown()
 		return err
 	}
-
-	c.logger.Infof("rabbitmq consumer started: exchanges=%s,%s,%s", c.cfg.TradesExchange, c.cfg.CandlesExchange, c.cfg.OrderBooksExchange)
+	c.startQueueDepthPoller(ctx)
 	return nil
 }
 
-// Close stops consumption, flushes pending batches, and releases resources.
-func (c *Consumer) Close(ctx context.Context) error {
-	if ctx == nil {
-		ctx = context.Background()
-	}
+// teardown closes the channels and the connection and waits for the consume
+// loops to return.
+func (c *Consumer) teardown() {
 	for _, ch := range c.channels {
 		_ = ch.Close()
 	}
 	c.channels = nil
+	c.queues = nil
 	if c.conn != nil {
 		_ = c.conn.Close()
 		c.conn = nil
 	}
-	c.wg.Wait()
-	if c.batcher == nil {
-		return nil
+	c.loops.Wait()
+}
+
+// session is the broker connection supervise keeps alive. The Consumer
+// implements it over amqp091; tests stand in for the broker with a fake.
+type session interface {
+	connect(ctx context.Context) error
+	notifyClose() <-chan *amqp.Error
+	teardown()
+}
+
+// supervise waits for the connection or any channel to close and replaces the
+// connection. Buffered batches are flushed before the old connection is torn
+// down, so nothing acknowledged waits on a connection that is gone.
+func (c *Consumer) supervise(ctx context.Context, conn session) {
+	defer c.wg.Done()
+	for {
+		closed := conn.notifyClose()
+		select {
+		case <-ctx.Done():
+			return
+		case amqpErr := <-closed:
+			entry := c.logger.WithField("component", "rabbitmq_consumer")
+			if amqpErr != nil {
+				entry = entry.WithError(amqpErr)
+			}
+			entry.Warn("rabbitmq connection lost; reconnecting")
+		}
+		if err := c.batcher.Flush(ctx); err != nil {
+			c.logger.WithError(err).Warn("flush before reconnect failed")
+		}
+		conn.teardown()
+		if !c.reconnect(ctx, conn) {
+			return
+		}
 	}
-	return c.batcher.Stop(ctx)
+}
+
+// notifyClose returns a channel that receives once the connection or any of
+// the channels closes. The value is nil for a close without an AMQP error.
+func (c *Consumer) notifyClose() <-chan *amqp.Error {
+	sources := []chan *amqp.Error{c.conn.NotifyClose(make(chan *amqp.Error, 1))}
+	for _, ch := range c.channels {
+		sources = append(sources, ch.NotifyClose(make(chan *amqp.Error, 1)))
+	}
+	// Each forwarder sends once into a buffer sized for all of them, so none
+	// blocks; they all return when teardown closes their source.
+	closed := make(chan *amqp.Error, len(sources))
+	for _, source := range sources {
+		go func() {
+			closed <- <-source
+		}()
+	}
+	return closed
+}
+
+// reconnect re-dials with exponential backoff until it succeeds or ctx is done.
+func (c *Consumer) reconnect(ctx context.Context, conn session) bool {
+	delay := c.cfg.ReconnectBase
+	if delay <= 0 {
+		delay = time.Second
+	}
+	for attempt := 1; ; attempt++ {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+		err := conn.connect(ctx)
+		if err == nil {
+			c.logger.WithField("attempt", attempt).Info("rabbitmq consumer reconnected")
+			return true
+		}
+		delay *= 2
+		if c.cfg.ReconnectMax > 0 && delay > c.cfg.ReconnectMax {
+			delay = c.cfg.ReconnectMax
+		}
+		c.logger.WithError(err).WithFields(logrus.Fields{
+			"attempt":  attempt,
+			"retry_in": delay.String(),
+		}).Warn("rabbitmq reconnect failed")
+	}
+}
+
+// dialConfig applies the configured heartbeat and dial timeout; zero values keep
+// the amqp091 defaults (10s heartbeat, 30s dial timeout).
+func dialConfig(cfg config.RabbitMQConfig) amqp.Config {
+	amqpCfg := amqp.Config{
+		Heartbeat: cfg.Heartbeat,
+		Locale:    "en_US",
+	}
+	if cfg.DialTimeout > 0 {
+		amqpCfg.Dial = amqp.DefaultDial(cfg.DialTimeout)
+	}
+	return amqpCfg
 }
 
 func (c *Consumer) startStream(ctx context.Context, stream streamType, exchange string) error {
@@ -99,18 +307,25 @@ func (c *Consumer) startStream(ctx context.Context, stream streamType, exchange
 	if err != nil {
 		return fmt.Errorf("open channel for %s: %w", stream, err)
 	}
-	if err := ch.ExchangeDeclare(exchange, "fanout", true, false, false, false, nil); err != nil {
+	if err := ch.ExchangeDeclare(exchange, c.cfg.ExchangeType, true, false, false, false, nil); err != nil {
 		ch.Close()
 		return fmt.Errorf("declare exchange %s: %w", exchange, err)
 	}
-	queue, err := ch.QueueDeclare("", false, true, true, false, nil)
+	if err := declareDeadLetter(ch, c.cfg.DeadLetterExchange); err != nil {
+		ch.Close()
+		return err
+	}
+	queueArgs := amqp.Table{"x-dead-letter-exchange": c.cfg.DeadLetterExchange}
+	queue, err := ch.QueueDeclare("", false, true, true, false, queueArgs)
 	if err != nil {
 		ch.Close()
 		return fmt.Errorf("declare queue for %s: %w", stream, err)
 	}
-	if err := ch.QueueBind(queue.Name, "", exchange, false, nil); err != nil {
-		ch.Close()
-		return fmt.Errorf("bind queue %s to %s: %w", queue.Name, exchange, err)
+	for _, key := range c.bindingKeys(stream) {
+		if err := ch.QueueBind(queue.Name, key, exchange, false, nil); err != nil {
+			ch.Close()
+			return fmt.Errorf("bind queue %s to %s with %q: %w", queue.Name, exchange, key, err)
+		}
 	}
 	prefetch := c.cfg.Prefetch
 	if prefetch <= 0 {
@@ -126,15 +341,23 @@ func (c *Consumer) startStream(ctx context.Context, stream streamType, exchange
 		return fmt.Errorf("start consume for %s: %w", stream, err)
 	}
 	c.channels = append(c.channels, ch)
-	c.wg.Add(1)
-	go c.consumeLoop(ctx, stream, deliveries)
+	if c.queues == nil {
+		c.queues = make(map[streamType]string, 3)
+	}
+	c.queues[stream] = queue.Name
+	c.loops.Add(1)
+	go c.consumeLoop(ctx, stream, ch, queue.Name, deliveries)
 	return nil
 }
 
-func (c *Consumer) consumeLoop(ctx context.Context, stream streamType, deliveries <-chan amqp.Delivery) {
-	defer c.wg.Done()
+func (c *Consumer) consumeLoop(ctx context.Context, stream streamType, ch *amqp.Channel, queue string, deliveries <-chan amqp.Delivery) {
+	defer c.loops.Done()
 	log := c.logger.WithField("stream", string(stream))
+	closed := ch.NotifyClose(make(chan *amqp.Error, 1))
 	for {
+		if !c.pause.await(ctx, closed) {
+			return
+		}
 		select {
 		case <-ctx.Done():
 			return
@@ -142,44 +365,99 @@ func (c *Consumer) consumeLoop(ctx context.Context, stream streamType, deliverie
 			if !ok {
 				return
 			}
-			if err := c.handleDelivery(stream, &delivery); err != nil {
-				log.WithError(err).Warn("failed to process message")
-				_ = delivery.Nack(false, true)
+			if err := c.handleDelivery(ctx, stream, &delivery); err != nil {
+				c.rejectDelivery(ctx, ch, stream, queue, &delivery, err, log)
 				continue
 			}
 			if err := delivery.Ack(false); err != nil {
 				log.WithError(err).Warn("failed to ack delivery")
+				continue
 			}
+			c.metrics.processed.WithLabelValues(string(stream)).Inc()
 		}
 	}
 }
 
-func (c *Consumer) handleDelivery(stream streamType, delivery *amqp.Delivery) error {
+// handleDelivery decodes a message and buffers its entities. With the instrument
+// check on, a message naming an unknown instrument is rejected here, before it
+// can fail the batch it would have been flushed with.
+func (c *Consumer) handleDelivery(ctx context.Context, stream streamType, delivery *amqp.Delivery) error {
 	var payload BaseMessage
 	if err := json.Unmarshal(delivery.Body, &payload); err != nil {
-		return fmt.Errorf("decode payload: %w", err)
+		return fmt.Errorf("%w: decode payload: %w", errMalformedMessage, err)
 	}
 	switch stream {
 	case streamTrade:
-		if payload.Trade == nil {
-			return errors.New("trade payload is nil")
+		trades := payload.trades()
+		if len(trades) == 0 {
+			return fmt.Errorf("%w: trade payload is nil", errMalformedMessage)
 		}
-		return c.batcher.AddTrade(payload.Trade)
+		if err := checkInstruments(ctx, c.service, trades, func(trade domain.Trade) uuid.UUID { return trade.InstrumentUID }); err != nil {
+			return err
+		}
+		if err := c.batcher.AddTrades(trades); err != nil {
+			return err
+		}
+		if c.trades != nil {
+			for i := range trades {
+				c.trades.Publish(&trades[i])
+			}
+		}
+		return nil
 	case streamCandle:
-		if payload.Candle == nil {
-			return errors.New("candle payload is nil")
+		candles := payload.candles()
+		if len(candles) == 0 {
+			return fmt.Errorf("%w: candle payload is nil", errMalformedMessage)
 		}
-		return c.batcher.AddCandle(payload.Candle)
+		if err := checkInstruments(ctx, c.service, candles, func(candle domain.Candle) uuid.UUID { return candle.InstrumentUID }); err != nil {
+			return err
+		}
+		return c.batcher.AddCandles(candles)
 	case streamOrderBook:
-		if payload.OrderBookSnapshot == nil {
-			return errors.New("order book payload is nil")
+		snapshots := payload.orderBookSnapshots()
+		if len(snapshots) == 0 {
+			return fmt.Errorf("%w: order book payload is nil", errMalformedMessage)
+		}
+		if c.throttle == nil {
+			if err := checkInstruments(ctx, c.service, snapshots, func(snapshot domain.OrderBookSnapshot) uuid.UUID { return snapshot.InstrumentUID }); err != nil {
+				return err
+			}
+			return c.batcher.AddOrderBooks(snapshots)
+		}
+		kept := snapshots[:0]
+		for i := range snapshots {
+			if c.throttle.allow(&snapshots[i]) {
+				kept = append(kept, snapshots[i])
+			}
 		}
-		return c.batcher.AddOrderBook(payload.OrderBookSnapshot)
+		snapshots = kept
+		if len(snapshots) == 0 {
+			return nil
+		}
+		err := checkInstruments(ctx, c.service, snapshots, func(snapshot domain.OrderBookSnapshot) uuid.UUID { return snapshot.InstrumentUID })
+		if err == nil {
+			err = c.batcher.AddOrderBooks(snapshots)
+		}
+		if err != nil {
+			// Snapshots that never reached a batch are not pending any more.
+			c.throttle.done(snapshots, err)
+		}
+		return err
 	default:
 		return fmt.Errorf("unsupported stream: %s", stream)
 	}
 }
 
+// checkInstruments runs the service's instrument check on the instruments of
+// items.
+func checkInstruments[T any](ctx context.Context, service *appmarketdata.Service, items []T, instrumentUID func(T) uuid.UUID) error {
+	uids := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		uids[i] = instrumentUID(item)
+	}
+	return service.CheckInstruments(ctx, uids...)
+}
+
 type streamType string
 
 func (s streamType) String() string {
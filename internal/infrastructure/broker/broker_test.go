@@ -0,0 +1,194 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	appmarketdata "main/internal/application/service/marketdata"
+	"main/internal/config"
+	domain "main/internal/domain/entity/marketdata"
+	"main/internal/domain/interfaces"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/sirupsen/logrus"
+)
+
+// tradeRepo stores trades in memory. Any other repository call panics.
+type tradeRepo struct {
+	interfaces.MarketDataRepository
+
+	mu     sync.Mutex
+	trades []domain.Trade
+}
+
+func (r *tradeRepo) AddTrades(_ context.Context, trades []domain.Trade, _ domain.ConflictPolicy) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.trades = append(r.trades, trades...)
+	return int64(len(trades)), nil
+}
+
+func (r *tradeRepo) stored() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.trades)
+}
+
+// fakeSession stands in for the broker connection. Each connect opens a new
+// session that close ends; the first failConnects reconnects fail.
+type fakeSession struct {
+	repo         *tradeRepo
+	failConnects int
+
+	mu       sync.Mutex
+	closed   chan *amqp.Error
+	connects int
+	// storedAtTeardown records how many trades were stored when each session
+	// was torn down.
+	storedAtTeardown []int
+	reconnected      chan struct{}
+}
+
+func newFakeSession(repo *tradeRepo, failConnects int) *fakeSession {
+	return &fakeSession{
+		repo:         repo,
+		failConnects: failConnects,
+		closed:       make(chan *amqp.Error, 1),
+		reconnected:  make(chan struct{}, 1),
+	}
+}
+
+func (s *fakeSession) connect(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.connects++
+	if s.failConnects > 0 {
+		s.failConnects--
+		return errors.New("dial refused")
+	}
+	s.closed = make(chan *amqp.Error, 1)
+	s.reconnected <- struct{}{}
+	return nil
+}
+
+func (s *fakeSession) notifyClose() <-chan *amqp.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *fakeSession) teardown() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.storedAtTeardown = append(s.storedAtTeardown, s.repo.stored())
+}
+
+// close simulates the broker closing a channel of the current session.
+func (s *fakeSession) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed <- &amqp.Error{Code: amqp.ChannelError, Reason: "channel closed"}
+}
+
+func testConsumer(repo *tradeRepo) *Consumer {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	cfg := config.RabbitMQConfig{
+		BatchSize:     100,
+		BatchTimeout:  time.Hour,
+		ReconnectBase: time.Millisecond,
+		ReconnectMax:  5 * time.Millisecond,
+	}
+	service := appmarketdata.NewService(repo)
+	return &Consumer{
+		cfg:     cfg,
+		service: service,
+		logger:  logger,
+		batcher: NewBatchWriter(BatchConfig{Size: cfg.BatchSize, Timeout: cfg.BatchTimeout}, service, logger),
+	}
+}
+
+func TestConsumerReconnectsAfterChannelClose(t *testing.T) {
+	tests := []struct {
+		name         string
+		failConnects int
+		wantConnects int
+	}{
+		{"reconnects at once", 0, 1},
+		{"retries a failed dial", 2, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &tradeRepo{}
+			consumer := testConsumer(repo)
+			session := newFakeSession(repo, tt.failConnects)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			consumer.batcher.Run(ctx)
+
+			trade := domain.Trade{
+				ID:            uuid.New(),
+				InstrumentUID: uuid.New(),
+				Side:          domain.TradeSideBuy,
+				Price:         100,
+				QuantityLots:  1,
+				TradedAt:      time.Now().UTC(),
+				Venue:         "TQBR",
+			}
+			if err := consumer.batcher.AddTrade(&trade); err != nil {
+				t.Fatalf("buffer trade: %v", err)
+			}
+
+			consumer.wg.Add(1)
+			go consumer.supervise(ctx, session)
+			session.close()
+
+			select {
+			case <-session.reconnected:
+			case <-time.After(5 * time.Second):
+				t.Fatal("consumer did not reconnect after the channel closed")
+			}
+			cancel()
+			consumer.wg.Wait()
+
+			session.mu.Lock()
+			defer session.mu.Unlock()
+			if session.connects != tt.wantConnects {
+				t.Fatalf("connect called %d times, want %d", session.connects, tt.wantConnects)
+			}
+			if len(session.storedAtTeardown) != 1 || session.storedAtTeardown[0] != 1 {
+				t.Fatalf("trades stored at teardown = %v, want [1]: the buffered batch must be flushed first", session.storedAtTeardown)
+			}
+		})
+	}
+}
+
+func TestConsumerStopsReconnectingOnShutdown(t *testing.T) {
+	repo := &tradeRepo{}
+	consumer := testConsumer(repo)
+	// Every dial fails, so only the context ends the reconnect loop.
+	session := newFakeSession(repo, 1<<30)
+	ctx, cancel := context.WithCancel(context.Background())
+	consumer.batcher.Run(ctx)
+
+	done := make(chan struct{})
+	consumer.wg.Add(1)
+	go func() {
+		consumer.supervise(ctx, session)
+		close(done)
+	}()
+	session.close()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("supervise kept reconnecting after shutdown")
+	}
+}
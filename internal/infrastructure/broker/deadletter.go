@@ -0,0 +1,108 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	appmarketdata "main/internal/application/service/marketdata"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/sirupsen/logrus"
+)
+
+// retryCountHeader counts how often a message was put back on its queue after
+// a transient failure.
+const retryCountHeader = "x-retry-count"
+
+// errMalformedMessage marks payloads that no redelivery can fix.
+var errMalformedMessage = errors.New("malformed message")
+
+// declareDeadLetter declares the dead-letter exchange and its durable queue.
+func declareDeadLetter(ch *amqp.Channel, exchange string) error {
+	if err := ch.ExchangeDeclare(exchange, "fanout", true, false, false, false, nil); err != nil {
+		return fmt.Errorf("declare dead-letter exchange %s: %w", exchange, err)
+	}
+	queue := exchange + ".queue"
+	if _, err := ch.QueueDeclare(queue, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("declare dead-letter queue %s: %w", queue, err)
+	}
+	if err := ch.QueueBind(queue, "", exchange, false, nil); err != nil {
+		return fmt.Errorf("bind dead-letter queue %s to %s: %w", queue, exchange, err)
+	}
+	return nil
+}
+
+// isPermanent reports whether err comes from the message itself rather than
+// from the service or the database.
+func isPermanent(err error) bool {
+	return errors.Is(err, errMalformedMessage) ||
+		errors.Is(err, appmarketdata.ErrMetadataLimit) ||
+		errors.Is(err, appmarketdata.ErrInvalidTrade) ||
+		errors.Is(err, appmarketdata.ErrInvalidOrderBook) ||
+		errors.Is(err, appmarketdata.ErrUnknownInstrument)
+}
+
+// rejectDelivery dead-letters a message that failed permanently or ran out of
+// retries. Any other failure is republished to the consumer queue with an
+// incremented retry count, since a plain requeue cannot carry the count.
+func (c *Consumer) rejectDelivery(ctx context.Context, ch *amqp.Channel, stream streamType, queue string, delivery *amqp.Delivery, cause error, log *logrus.Entry) {
+	retries := retryCount(delivery.Headers)
+	log = log.WithError(cause).WithField("retries", retries)
+	if isPermanent(cause) || retries >= c.cfg.MaxRetries {
+		log.Warn("dead-lettering message")
+		if err := delivery.Nack(false, false); err != nil {
+			log.WithError(err).Warn("failed to nack delivery")
+			return
+		}
+		c.metrics.nacked.WithLabelValues(string(stream)).Inc()
+		return
+	}
+
+	headers := amqp.Table{}
+	for key, value := range delivery.Headers {
+		headers[key] = value
+	}
+	headers[retryCountHeader] = int32(retries + 1)
+	err := ch.PublishWithContext(ctx, "", queue, false, false, amqp.Publishing{
+		Headers:         headers,
+		ContentType:     delivery.ContentType,
+		ContentEncoding: delivery.ContentEncoding,
+		DeliveryMode:    delivery.DeliveryMode,
+		MessageId:       delivery.MessageId,
+		Timestamp:       delivery.Timestamp,
+		Type:            delivery.Type,
+		Body:            delivery.Body,
+	})
+	if err != nil {
+		// Without the republish the message would be lost; requeue it as is.
+		log.WithError(err).Warn("failed to republish message for retry; requeueing")
+		if delivery.Nack(false, true) == nil {
+			c.metrics.nacked.WithLabelValues(string(stream)).Inc()
+		}
+		return
+	}
+	log.Warn("failed to process message; retrying")
+	if err := delivery.Ack(false); err != nil {
+		log.WithError(err).Warn("failed to ack delivery")
+	}
+}
+
+// retryCount reads retryCountHeader, tolerating the integer types AMQP
+// clients encode it as.
+func retryCount(headers amqp.Table) int {
+	switch value := headers[retryCountHeader].(type) {
+	case int32:
+		return int(value)
+	case int64:
+		return int(value)
+	case int:
+		return value
+	case int16:
+		return int(value)
+	case int8:
+		return int(value)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,86 @@
+package broker
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// batchMetrics counts batch writer activity per entity type (trade, candle,
+// orderbook). The collectors are not registered anywhere; whoever serves
+// metrics registers them through Collectors.
+type batchMetrics struct {
+	batches *prometheus.CounterVec
+	items   *prometheus.CounterVec
+	errors  *prometheus.CounterVec
+	depth   *prometheus.GaugeVec
+	// inFlight and queueWait describe the flush workers; see
+	// BatchConfig.FlushWorkers.
+	inFlight  *prometheus.GaugeVec
+	queueWait *prometheus.HistogramVec
+}
+
+func newBatchMetrics() *batchMetrics {
+	return &batchMetrics{
+		batches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ingest_batches_flushed_total",
+			Help: "Batches written to the database by entity type.",
+		}, []string{"entity"}),
+		items: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ingest_items_flushed_total",
+			Help: "Entities written to the database in batches by entity type.",
+		}, []string{"entity"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ingest_flush_errors_total",
+			Help: "Failed batch writes by entity type.",
+		}, []string{"entity"}),
+		depth: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ingest_buffer_depth",
+			Help: "Entities buffered and not yet flushed by entity type. A depth that keeps growing means the database falls behind.",
+		}, []string{"entity"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ingest_flushes_in_flight",
+			Help: "Batches being written to the database by entity type.",
+		}, []string{"entity"}),
+		queueWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ingest_flush_queue_wait_seconds",
+			Help:    "Time a full batch waited for a free flush worker by entity type. Long waits mean every worker is busy writing.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"entity"}),
+	}
+}
+
+func (m *batchMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.batches, m.items, m.errors, m.depth, m.inFlight, m.queueWait}
+}
+
+// consumerMetrics tracks the consumer side per stream (trades, candles,
+// orderbooks): how much waits in the broker queue and what became of the
+// deliveries taken from it, plus whether consumption is paused.
+type consumerMetrics struct {
+	queueDepth *prometheus.GaugeVec
+	processed  *prometheus.CounterVec
+	nacked     *prometheus.CounterVec
+	paused     prometheus.Gauge
+}
+
+func newConsumerMetrics() *consumerMetrics {
+	return &consumerMetrics{
+		queueDepth: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "rabbitmq_queue_depth",
+			Help: "Messages ready in the consumer queue by stream, as of the last poll. A depth that keeps growing means the consumer falls behind.",
+		}, []string{"stream"}),
+		processed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "rabbitmq_messages_processed_total",
+			Help: "Deliveries handed to the batch writer and acked by stream.",
+		}, []string{"stream"}),
+		nacked: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "rabbitmq_messages_nacked_total",
+			Help: "Deliveries nacked by stream, either dead-lettered or requeued.",
+		}, []string{"stream"}),
+		paused: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "rabbitmq_consumer_paused",
+			Help: "1 while the consumer takes no deliveries because batch flushes keep failing, 0 otherwise.",
+		}),
+	}
+}
+
+func (m *consumerMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.queueDepth, m.processed, m.nacked, m.paused}
+}
@@ -2,8 +2,39 @@ package broker
 
 import domain "main/internal/domain/entity/marketdata"
 
+// BaseMessage is the body of a delivery. It carries either one entity, or, from
+// a producer publishing in batches, a list of entities of its stream. The
+// consumer accepts both forms on every stream.
 type BaseMessage struct {
 	Trade             *domain.Trade             `json:"trade,omitempty"`
 	Candle            *domain.Candle            `json:"candle,omitempty"`
 	OrderBookSnapshot *domain.OrderBookSnapshot `json:"order_book_snapshot,omitempty"`
+
+	Trades             []domain.Trade             `json:"trades,omitempty"`
+	Candles            []domain.Candle            `json:"candles,omitempty"`
+	OrderBookSnapshots []domain.OrderBookSnapshot `json:"order_book_snapshots,omitempty"`
+}
+
+// trades returns the message's trades, single or batched.
+func (m *BaseMessage) trades() []domain.Trade {
+	if m.Trade != nil {
+		return append(m.Trades, *m.Trade)
+	}
+	return m.Trades
+}
+
+// candles returns the message's candles, single or batched.
+func (m *BaseMessage) candles() []domain.Candle {
+	if m.Candle != nil {
+		return append(m.Candles, *m.Candle)
+	}
+	return m.Candles
+}
+
+// orderBookSnapshots returns the message's snapshots, single or batched.
+func (m *BaseMessage) orderBookSnapshots() []domain.OrderBookSnapshot {
+	if m.OrderBookSnapshot != nil {
+		return append(m.OrderBookSnapshots, *m.OrderBookSnapshot)
+	}
+	return m.OrderBookSnapshots
 }
@@ -0,0 +1,82 @@
+package broker
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/sirupsen/logrus"
+)
+
+// startQueueDepthPoller starts polling the depth of the current connection's
+// consumer queues every QueueDepthInterval. It stops with the connection.
+func (c *Consumer) startQueueDepthPoller(ctx context.Context) {
+	if c.cfg.QueueDepthInterval <= 0 {
+		return
+	}
+	c.loops.Add(1)
+	go c.pollQueueDepth(ctx, c.conn, maps.Clone(c.queues))
+}
+
+// pollQueueDepth inspects every queue on a channel of its own, since a failed
+// passive declare closes its channel and the consume channels must not be
+// affected. The warning is logged once when a queue crosses
+// QueueDepthWarn, and an info line once it drops back.
+func (c *Consumer) pollQueueDepth(ctx context.Context, conn *amqp.Connection, queues map[streamType]string) {
+	defer c.loops.Done()
+	log := c.logger.WithField("component", "rabbitmq_consumer")
+	ticker := time.NewTicker(c.cfg.QueueDepthInterval)
+	defer ticker.Stop()
+	connClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	var ch *amqp.Channel
+	defer func() {
+		if ch != nil {
+			_ = ch.Close()
+		}
+	}()
+	behind := make(map[streamType]bool, len(queues))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-connClosed:
+			return
+		case <-ticker.C:
+		}
+		if ch == nil || ch.IsClosed() {
+			var err error
+			if ch, err = conn.Channel(); err != nil {
+				log.WithError(err).Warn("open channel for queue depth failed")
+				ch = nil
+				continue
+			}
+		}
+		for stream, name := range queues {
+			queue, err := ch.QueueDeclarePassive(name, false, true, true, false, nil)
+			if err != nil {
+				log.WithError(err).WithField("stream", string(stream)).Warn("inspect queue depth failed")
+				break
+			}
+			c.metrics.queueDepth.WithLabelValues(string(stream)).Set(float64(queue.Messages))
+			threshold := c.cfg.QueueDepthWarn
+			if threshold <= 0 {
+				continue
+			}
+			entry := log.WithFields(logrus.Fields{
+				"stream":    string(stream),
+				"queue":     name,
+				"depth":     queue.Messages,
+				"threshold": threshold,
+			})
+			switch {
+			case queue.Messages >= threshold && !behind[stream]:
+				behind[stream] = true
+				entry.Warn("consumer queue depth above threshold; ingestion is falling behind")
+			case queue.Messages < threshold && behind[stream]:
+				behind[stream] = false
+				entry.Info("consumer queue depth back below threshold")
+			}
+		}
+	}
+}
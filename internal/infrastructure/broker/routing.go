@@ -0,0 +1,67 @@
+package broker
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Exchange types the producer and the consumer can agree on. Under fanout every
+// consumer queue receives every message; under topic each message carries a
+// routing key of the form <prefix>.<instrument_uid> and a queue only receives
+// the instruments it is bound to.
+const (
+	ExchangeFanout = "fanout"
+	ExchangeTopic  = "topic"
+)
+
+// Routing key prefixes, one per stream.
+const (
+	TradeRoutingPrefix     = "trade"
+	CandleRoutingPrefix    = "candle"
+	OrderBookRoutingPrefix = "orderbook"
+)
+
+// RoutingKey is the key a message about instrumentUID is published with.
+// Fanout exchanges ignore it, so publishers can always set it.
+func RoutingKey(prefix string, instrumentUID uuid.UUID) string {
+	return prefix + "." + instrumentUID.String()
+}
+
+// ValidateExchangeType rejects anything but ExchangeFanout and ExchangeTopic.
+func ValidateExchangeType(kind string) error {
+	if kind != ExchangeFanout && kind != ExchangeTopic {
+		return fmt.Errorf("exchange type must be %s or %s, got %q", ExchangeFanout, ExchangeTopic, kind)
+	}
+	return nil
+}
+
+// routingPrefix is the routing key prefix of the stream's messages.
+func (s streamType) routingPrefix() string {
+	switch s {
+	case streamTrade:
+		return TradeRoutingPrefix
+	case streamCandle:
+		return CandleRoutingPrefix
+	default:
+		return OrderBookRoutingPrefix
+	}
+}
+
+// bindingKeys lists the keys a consumer queue of the stream is bound with: the
+// empty key for fanout, and under topic one key per configured instrument or
+// a wildcard for all of them.
+func (c *Consumer) bindingKeys(stream streamType) []string {
+	if c.cfg.ExchangeType != ExchangeTopic {
+		return []string{""}
+	}
+	prefix := stream.routingPrefix()
+	if len(c.cfg.BindInstruments) == 0 {
+		return []string{prefix + ".*"}
+	}
+	keys := make([]string, 0, len(c.cfg.BindInstruments))
+	for _, uid := range c.cfg.BindInstruments {
+		keys = append(keys, RoutingKey(prefix, uid))
+	}
+	return keys
+}
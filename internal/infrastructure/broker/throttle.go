@@ -0,0 +1,107 @@
+package broker
+
+import (
+	"sync"
+	"time"
+
+	"main/internal/config"
+	domain "main/internal/domain/entity/marketdata"
+
+	"github.com/google/uuid"
+)
+
+// orderBookThrottle enforces a minimum interval between persisted order book
+// snapshots of one instrument. Snapshots closer than the interval to the last
+// accepted snapshot of their instrument are dropped; nothing is averaged or
+// resampled.
+//
+// An accepted snapshot counts as pending until its batch is written. Pending
+// snapshots throttle the ones after them like persisted ones, but a failed
+// write forgets them, so a redelivered snapshot is accepted again.
+type orderBookThrottle struct {
+	minInterval time.Duration
+	overrides   map[uuid.UUID]time.Duration
+
+	mu sync.Mutex
+	// last is the time of the instrument's latest persisted snapshot.
+	last map[uuid.UUID]time.Time
+	// pending is the time of the instrument's latest accepted snapshot that
+	// is not written yet.
+	pending map[uuid.UUID]time.Time
+	// dropped is the sequence of the first snapshot dropped since the
+	// instrument's last accepted one.
+	dropped map[uuid.UUID]int64
+}
+
+func newOrderBookThrottle(cfg config.OrderBookThrottleConfig) *orderBookThrottle {
+	return &orderBookThrottle{
+		minInterval: cfg.MinInterval,
+		overrides:   cfg.Overrides,
+		last:        make(map[uuid.UUID]time.Time),
+		pending:     make(map[uuid.UUID]time.Time),
+		dropped:     make(map[uuid.UUID]int64),
+	}
+}
+
+func (t *orderBookThrottle) interval(instrumentUID uuid.UUID) time.Duration {
+	if interval, ok := t.overrides[instrumentUID]; ok {
+		return interval
+	}
+	return t.minInterval
+}
+
+// allow reports whether snapshot should be persisted and, if so, marks it
+// pending. An accepted snapshot that follows dropped ones gets SequenceFrom
+// set to the first dropped sequence, so the drop is not read as a gap.
+func (t *orderBookThrottle) allow(snapshot *domain.OrderBookSnapshot) bool {
+	uid := snapshot.InstrumentUID
+	interval := t.interval(uid)
+	if interval <= 0 {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	last, ok := t.last[uid]
+	if pending, isPending := t.pending[uid]; isPending && (!ok || pending.After(last)) {
+		last, ok = pending, true
+	}
+	if ok && snapshot.SnapshotAt.Sub(last) < interval {
+		if _, seen := t.dropped[uid]; !seen && snapshot.Sequence != nil {
+			t.dropped[uid] = *snapshot.Sequence
+		}
+		return false
+	}
+	t.pending[uid] = snapshot.SnapshotAt
+	if first, seen := t.dropped[uid]; seen && snapshot.Sequence != nil {
+		snapshot.SequenceFrom = &first
+	}
+	delete(t.dropped, uid)
+	return true
+}
+
+// done settles the pending snapshots of a written batch: with a nil err they
+// become the instruments' last persisted ones, otherwise they are forgotten.
+func (t *orderBookThrottle) done(snapshots []domain.OrderBookSnapshot, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, snapshot := range snapshots {
+		uid := snapshot.InstrumentUID
+		if t.interval(uid) <= 0 {
+			continue
+		}
+		if pending, ok := t.pending[uid]; ok && !pending.After(snapshot.SnapshotAt) {
+			delete(t.pending, uid)
+		}
+		if err == nil {
+			if last, ok := t.last[uid]; !ok || snapshot.SnapshotAt.After(last) {
+				t.last[uid] = snapshot.SnapshotAt
+			}
+			continue
+		}
+		// The drops before a lost snapshot still happened; keep them for
+		// the snapshot accepted in its place.
+		if snapshot.SequenceFrom != nil {
+			t.dropped[uid] = *snapshot.SequenceFrom
+		}
+	}
+}
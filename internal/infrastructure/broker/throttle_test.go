@@ -0,0 +1,93 @@
+package broker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"main/internal/config"
+	domain "main/internal/domain/entity/marketdata"
+
+	"github.com/google/uuid"
+)
+
+func throttleSnapshot(uid uuid.UUID, base time.Time, offset time.Duration, seq int64) domain.OrderBookSnapshot {
+	return domain.OrderBookSnapshot{InstrumentUID: uid, SnapshotAt: base.Add(offset), Sequence: &seq}
+}
+
+func TestOrderBookThrottleAllow(t *testing.T) {
+	uid := uuid.New()
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	throttle := newOrderBookThrottle(config.OrderBookThrottleConfig{MinInterval: time.Second})
+
+	tests := []struct {
+		name   string
+		offset time.Duration
+		seq    int64
+		want   bool
+	}{
+		{"first snapshot", 0, 1, true},
+		{"inside the interval of a pending one", 500 * time.Millisecond, 2, false},
+		{"interval elapsed", time.Second, 3, true},
+		{"just before the next interval", 1999 * time.Millisecond, 4, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshot := throttleSnapshot(uid, base, tt.offset, tt.seq)
+			if got := throttle.allow(&snapshot); got != tt.want {
+				t.Fatalf("allow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderBookThrottleFailedFlushAcceptsRedelivery(t *testing.T) {
+	uid := uuid.New()
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	throttle := newOrderBookThrottle(config.OrderBookThrottleConfig{MinInterval: time.Second})
+
+	first := throttleSnapshot(uid, base, 0, 1)
+	if !throttle.allow(&first) {
+		t.Fatal("first snapshot throttled")
+	}
+	throttle.done([]domain.OrderBookSnapshot{first}, errors.New("flush failed"))
+
+	redelivered := throttleSnapshot(uid, base, 0, 1)
+	if !throttle.allow(&redelivered) {
+		t.Fatal("redelivered snapshot throttled after a failed flush")
+	}
+	throttle.done([]domain.OrderBookSnapshot{redelivered}, nil)
+
+	again := throttleSnapshot(uid, base, 0, 1)
+	if throttle.allow(&again) {
+		t.Fatal("snapshot accepted again after it was persisted")
+	}
+}
+
+func TestOrderBookThrottleSetsSequenceFrom(t *testing.T) {
+	uid := uuid.New()
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	throttle := newOrderBookThrottle(config.OrderBookThrottleConfig{MinInterval: time.Second})
+
+	snapshots := []domain.OrderBookSnapshot{
+		throttleSnapshot(uid, base, 0, 10),
+		throttleSnapshot(uid, base, 100*time.Millisecond, 11),
+		throttleSnapshot(uid, base, 200*time.Millisecond, 12),
+		throttleSnapshot(uid, base, time.Second, 13),
+	}
+	var kept []domain.OrderBookSnapshot
+	for i := range snapshots {
+		if throttle.allow(&snapshots[i]) {
+			kept = append(kept, snapshots[i])
+		}
+	}
+	if len(kept) != 2 {
+		t.Fatalf("kept %d snapshots, want 2", len(kept))
+	}
+	if kept[0].SequenceFrom != nil {
+		t.Fatalf("first snapshot SequenceFrom = %d, want nil", *kept[0].SequenceFrom)
+	}
+	if kept[1].SequenceFrom == nil || *kept[1].SequenceFrom != 11 {
+		t.Fatalf("second snapshot SequenceFrom = %v, want 11", kept[1].SequenceFrom)
+	}
+}
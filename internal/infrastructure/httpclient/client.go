@@ -0,0 +1,36 @@
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"main/internal/config"
+)
+
+const idleConnTimeout = 90 * time.Second
+
+// New builds the shared client for outbound HTTP calls. Build it once and pass it
+// to whatever needs it so connections are pooled instead of using
+// http.DefaultClient, which has no timeouts.
+func New(cfg config.OutboundHTTPConfig) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		IdleConnTimeout:       idleConnTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout,
+	}
+}
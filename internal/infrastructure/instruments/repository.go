@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"time"
 
+	"main/internal/config"
 	domain "main/internal/domain/entity/instruments"
+	"main/internal/infrastructure/postgres"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -14,20 +16,16 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-var ErrInstrumentNotFound = errors.New("instrument not found")
+var ErrInstrumentNotFound = domain.ErrInstrumentNotFound
 
 type Repository struct {
 	pool *pgxpool.Pool
 }
 
-func NewRepository(ctx context.Context, dsn string) (*Repository, error) {
-	cfg, err := pgxpool.ParseConfig(dsn)
+func NewRepository(ctx context.Context, cfg config.PostgresConfig) (*Repository, error) {
+	pool, err := postgres.NewPool(ctx, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("parse pgx config: %w", err)
-	}
-	pool, err := pgxpool.NewWithConfig(ctx, cfg)
-	if err != nil {
-		return nil, fmt.Errorf("create pgx pool: %w", err)
+		return nil, err
 	}
 	return &Repository{pool: pool}, nil
 }
@@ -39,17 +37,27 @@ func (r *Repository) Close() {
 	r.pool.Close()
 }
 
+// Ping checks that the database is reachable through the pool.
+func (r *Repository) Ping(ctx context.Context) error {
+	return r.pool.Ping(ctx)
+}
+
+// Stat returns a snapshot of the pool's connection statistics.
+func (r *Repository) Stat() *pgxpool.Stat {
+	return r.pool.Stat()
+}
+
 func (r *Repository) CreateInstrument(ctx context.Context, instrument *domain.Instrument) error {
 	return r.createInstrumentWith(ctx, r.pool, instrument)
 }
 
-func (r *Repository) GetInstrument(ctx context.Context, uid uuid.UUID) (*domain.Instrument, error) {
+func (r *Repository) GetInstrument(ctx context.Context, uid uuid.UUID, includeDeleted bool) (*domain.Instrument, error) {
 	const query = `
-		SELECT uid, figi, ticker, lot, class_code, logo_url, created_at, updated_at, deleted_at
+		SELECT uid, figi, ticker, lot, class_code, logo_url, brand_uid, created_at, updated_at, deleted_at
 		FROM instruments
-		WHERE uid = $1`
+		WHERE uid = $1 AND ($2 OR deleted_at IS NULL)`
 
-	row := r.pool.QueryRow(ctx, query, uid)
+	row := r.pool.QueryRow(ctx, query, uid, includeDeleted)
 	instrument := &domain.Instrument{}
 	if err := scanInstrumentInto(row, instrument); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -60,16 +68,169 @@ func (r *Repository) GetInstrument(ctx context.Context, uid uuid.UUID) (*domain.
 	return instrument, nil
 }
 
+// GetInstrumentType resolves which typed table holds the instrument, like
+// ListInstruments does. An instrument without a typed row has the empty type.
+func (r *Repository) GetInstrumentType(ctx context.Context, uid uuid.UUID, includeDeleted bool) (domain.InstrumentType, error) {
+	const query = `
+		SELECT ` + instrumentTypeColumn + `
+		FROM instruments i
+		WHERE i.uid = $1 AND ($2 OR i.deleted_at IS NULL)`
+
+	var instrumentTy string
+	if err := r.pool.QueryRow(ctx, query, uid, includeDeleted).Scan(&instrumentTy); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrInstrumentNotFound
+		}
+		return "", err
+	}
+	return domain.InstrumentType(instrumentTy), nil
+}
+
+// GetExistingInstrumentUIDs returns the listed UIDs that have an instrument
+// row, including soft-deleted instruments, in no particular order.
+func (r *Repository) GetExistingInstrumentUIDs(ctx context.Context, uids []uuid.UUID) ([]uuid.UUID, error) {
+	const query = `SELECT uid FROM instruments WHERE uid = ANY($1)`
+
+	rows, err := r.pool.Query(ctx, query, uids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	existing := make([]uuid.UUID, 0, len(uids))
+	for rows.Next() {
+		var uid uuid.UUID
+		if err := rows.Scan(&uid); err != nil {
+			return nil, err
+		}
+		existing = append(existing, uid)
+	}
+	return existing, rows.Err()
+}
+
+// GetInstrumentsByUIDs returns the instruments among uids in one query, in no
+// particular order. UIDs without an instrument are left out.
+func (r *Repository) GetInstrumentsByUIDs(ctx context.Context, uids []uuid.UUID, includeDeleted bool) ([]domain.Instrument, error) {
+	const query = `
+		SELECT uid, figi, ticker, lot, class_code, logo_url, brand_uid, created_at, updated_at, deleted_at
+		FROM instruments
+		WHERE uid = ANY($1) AND ($2 OR deleted_at IS NULL)`
+
+	rows, err := r.pool.Query(ctx, query, uids, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	instruments := make([]domain.Instrument, 0, len(uids))
+	for rows.Next() {
+		var instrument domain.Instrument
+		if err := scanInstrumentInto(rows, &instrument); err != nil {
+			return nil, err
+		}
+		instruments = append(instruments, instrument)
+	}
+	return instruments, rows.Err()
+}
+
 func (r *Repository) UpdateInstrument(ctx context.Context, instrument *domain.Instrument) error {
 	return r.updateInstrumentWith(ctx, r.pool, instrument)
 }
 
-func (r *Repository) DeleteInstrument(ctx context.Context, uid uuid.UUID) error {
-	return r.deleteInstrumentWith(ctx, r.pool, uid)
+// UpsertInstrument inserts the instrument, or updates the live one with the
+// same figi. The update keeps the stored UID, created_at and deleted_at. A UID
+// that is set must match the stored instrument's, and must not belong to
+// another instrument; either mismatch is ErrUIDConflict and changes nothing. A
+// single statement does both, so concurrent upserts of one figi cannot both
+// insert.
+//
+// With restore and no live instrument of the figi, the soft-deleted one is
+// updated and its deleted_at cleared instead: the one with the given UID, or
+// the one deleted last when no UID is set.
+func (r *Repository) UpsertInstrument(ctx context.Context, instrument *domain.Instrument, restore bool) error {
+	if instrument == nil {
+		return domain.ErrNilInstrument
+	}
+	anyUID := instrument.UID == uuid.Nil
+	uid := instrument.UID
+	if anyUID {
+		uid = uuid.New()
+	}
+	now := time.Now().UTC()
+
+	const restoreQuery = `
+		UPDATE instruments
+		SET ticker=$3,
+			lot=$4,
+			class_code=$5,
+			logo_url=$6,
+			brand_uid=$7,
+			updated_at=$8,
+			deleted_at=NULL
+		WHERE uid = (
+			SELECT uid FROM instruments
+			WHERE figi=$2 AND deleted_at IS NOT NULL AND ($9 OR uid=$1)
+			ORDER BY deleted_at DESC
+			LIMIT 1
+		)
+		AND NOT EXISTS (SELECT 1 FROM instruments WHERE figi=$2 AND deleted_at IS NULL)
+		RETURNING uid, figi, ticker, lot, class_code, logo_url, brand_uid, created_at, updated_at, deleted_at`
+
+	const query = `
+		INSERT INTO instruments (uid, figi, ticker, lot, class_code, logo_url, brand_uid, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$8)
+		ON CONFLICT (figi) WHERE deleted_at IS NULL DO UPDATE
+		SET ticker=EXCLUDED.ticker,
+			lot=EXCLUDED.lot,
+			class_code=EXCLUDED.class_code,
+			logo_url=EXCLUDED.logo_url,
+			brand_uid=EXCLUDED.brand_uid,
+			updated_at=EXCLUDED.updated_at
+		WHERE $9 OR instruments.uid = EXCLUDED.uid
+		RETURNING uid, figi, ticker, lot, class_code, logo_url, brand_uid, created_at, updated_at, deleted_at`
+
+	args := []interface{}{
+		uid,
+		instrument.Figi,
+		instrument.Ticker,
+		instrument.Lot,
+		instrument.ClassCode,
+		instrument.LogoURL,
+		nullableUUID(instrument.BrandUID),
+		now,
+		anyUID,
+	}
+	err := r.withTx(ctx, func(tx pgx.Tx) error {
+		if restore {
+			err := scanInstrumentInto(tx.QueryRow(ctx, restoreQuery, args...), instrument)
+			if !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+		}
+		return scanInstrumentInto(tx.QueryRow(ctx, query, args...), instrument)
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return fmt.Errorf("%w: figi %s belongs to another uid", domain.ErrUIDConflict, instrument.Figi)
+		case errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "instruments_pkey":
+			return fmt.Errorf("%w: uid %s belongs to another figi or to a deleted instrument", domain.ErrUIDConflict, uid)
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) DeleteInstrument(ctx context.Context, uid uuid.UUID, hard bool) error {
+	return r.deleteInstrumentWith(ctx, r.pool, uid, hard)
 }
 
 func scanInstrumentInto(row pgx.Row, instrument *domain.Instrument, extras ...interface{}) error {
-	var deletedAt *time.Time
+	var (
+		brandUID  *uuid.UUID
+		deletedAt *time.Time
+	)
 	args := []interface{}{
 		&instrument.UID,
 		&instrument.Figi,
@@ -77,6 +238,7 @@ func scanInstrumentInto(row pgx.Row, instrument *domain.Instrument, extras ...in
 		&instrument.Lot,
 		&instrument.ClassCode,
 		&instrument.LogoURL,
+		&brandUID,
 		&instrument.CreatedAt,
 		&instrument.UpdatedAt,
 		&deletedAt,
@@ -86,10 +248,22 @@ func scanInstrumentInto(row pgx.Row, instrument *domain.Instrument, extras ...in
 	if err := row.Scan(args...); err != nil {
 		return err
 	}
+	instrument.BrandUID = uuid.Nil
+	if brandUID != nil {
+		instrument.BrandUID = *brandUID
+	}
 	instrument.DeletedAt = deletedAt
 	return nil
 }
 
+// nullableUUID stores uuid.Nil as NULL.
+func nullableUUID(id uuid.UUID) *uuid.UUID {
+	if id == uuid.Nil {
+		return nil
+	}
+	return &id
+}
+
 type queryRower interface {
 	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
 }
@@ -116,7 +290,7 @@ func (r *Repository) withTx(ctx context.Context, fn func(pgx.Tx) error) (err err
 
 func (r *Repository) createInstrumentWith(ctx context.Context, runner queryRower, instrument *domain.Instrument) error {
 	if instrument == nil {
-		return errors.New("instrument is nil")
+		return domain.ErrNilInstrument
 	}
 	if instrument.UID == uuid.Nil {
 		instrument.UID = uuid.New()
@@ -128,9 +302,9 @@ func (r *Repository) createInstrumentWith(ctx context.Context, runner queryRower
 	instrument.UpdatedAt = now
 
 	const query = `
-		INSERT INTO instruments (uid, figi, ticker, lot, class_code, logo_url, created_at, updated_at, deleted_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
-		RETURNING uid, figi, ticker, lot, class_code, logo_url, created_at, updated_at, deleted_at`
+		INSERT INTO instruments (uid, figi, ticker, lot, class_code, logo_url, brand_uid, created_at, updated_at, deleted_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+		RETURNING uid, figi, ticker, lot, class_code, logo_url, brand_uid, created_at, updated_at, deleted_at`
 
 	row := runner.QueryRow(ctx, query,
 		instrument.UID,
@@ -139,6 +313,7 @@ func (r *Repository) createInstrumentWith(ctx context.Context, runner queryRower
 		instrument.Lot,
 		instrument.ClassCode,
 		instrument.LogoURL,
+		nullableUUID(instrument.BrandUID),
 		instrument.CreatedAt,
 		instrument.UpdatedAt,
 		instrument.DeletedAt,
@@ -149,7 +324,7 @@ func (r *Repository) createInstrumentWith(ctx context.Context, runner queryRower
 
 func (r *Repository) updateInstrumentWith(ctx context.Context, runner queryRower, instrument *domain.Instrument) error {
 	if instrument == nil {
-		return errors.New("instrument is nil")
+		return domain.ErrNilInstrument
 	}
 	if instrument.UID == uuid.Nil {
 		return errors.New("instrument UID is required")
@@ -163,10 +338,10 @@ func (r *Repository) updateInstrumentWith(ctx context.Context, runner queryRower
 			lot=$4,
 			class_code=$5,
 			logo_url=$6,
-			updated_at=$7,
-			deleted_at=$8
+			brand_uid=$7,
+			updated_at=$8
 		WHERE uid=$1
-		RETURNING uid, figi, ticker, lot, class_code, logo_url, created_at, updated_at, deleted_at`
+		RETURNING uid, figi, ticker, lot, class_code, logo_url, brand_uid, created_at, updated_at, deleted_at`
 
 	row := runner.QueryRow(ctx, query,
 		instrument.UID,
@@ -175,8 +350,8 @@ func (r *Repository) updateInstrumentWith(ctx context.Context, runner queryRower
 		instrument.Lot,
 		instrument.ClassCode,
 		instrument.LogoURL,
+		nullableUUID(instrument.BrandUID),
 		instrument.UpdatedAt,
-		instrument.DeletedAt,
 	)
 
 	if err := scanInstrumentInto(row, instrument); err != nil {
@@ -188,8 +363,18 @@ func (r *Repository) updateInstrumentWith(ctx context.Context, runner queryRower
 	return nil
 }
 
-func (r *Repository) deleteInstrumentWith(ctx context.Context, execer commandTagExecutor, uid uuid.UUID) error {
-	const query = `DELETE FROM instruments WHERE uid=$1`
+// deleteInstrumentWith soft-deletes an instrument by setting deleted_at, or
+// removes the row when hard is set. Soft-deleting an already soft-deleted
+// instrument reports ErrInstrumentNotFound.
+func (r *Repository) deleteInstrumentWith(ctx context.Context, execer commandTagExecutor, uid uuid.UUID, hard bool) error {
+	query := `
+		UPDATE instruments
+		SET deleted_at = NOW(),
+			updated_at = NOW()
+		WHERE uid=$1 AND deleted_at IS NULL`
+	if hard {
+		query = `DELETE FROM instruments WHERE uid=$1`
+	}
 	cmdTag, err := execer.Exec(ctx, query, uid)
 	if err != nil {
 		return err
@@ -56,24 +56,24 @@ func (r *Repository) UpdateBond(ctx context.Context, bond *domain.Bond) error {
 	})
 }
 
-func (r *Repository) DeleteBond(ctx context.Context, uid uuid.UUID) error {
+func (r *Repository) DeleteBond(ctx context.Context, uid uuid.UUID, hard bool) error {
 	return r.withTx(ctx, func(tx pgx.Tx) error {
 		if err := ensureTypedRowExists(ctx, tx, "bonds", uid); err != nil {
 			return err
 		}
-		return r.deleteInstrumentWith(ctx, tx, uid)
+		return r.deleteInstrumentWith(ctx, tx, uid, hard)
 	})
 }
 
-func (r *Repository) GetBond(ctx context.Context, uid uuid.UUID) (*domain.Bond, error) {
+func (r *Repository) GetBond(ctx context.Context, uid uuid.UUID, includeDeleted bool) (*domain.Bond, error) {
 	const query = `
-		SELECT i.uid, i.figi, i.ticker, i.lot, i.class_code, i.logo_url, i.created_at, i.updated_at, i.deleted_at,
+		SELECT i.uid, i.figi, i.ticker, i.lot, i.class_code, i.logo_url, i.brand_uid, i.created_at, i.updated_at, i.deleted_at,
 		       b.nominal, b.aci_value
 		FROM instruments i
 		INNER JOIN bonds b ON b.uid = i.uid
-		WHERE i.uid = $1`
+		WHERE i.uid = $1 AND ($2 OR i.deleted_at IS NULL)`
 
-	row := r.pool.QueryRow(ctx, query, uid)
+	row := r.pool.QueryRow(ctx, query, uid, includeDeleted)
 	bond := &domain.Bond{}
 	var nominal, aciValue float64
 	if err := scanInstrumentInto(row, &bond.Instrument, &nominal, &aciValue); err != nil {
@@ -0,0 +1,87 @@
+package instruments
+
+import (
+	"context"
+	"errors"
+
+	domain "main/internal/domain/entity/instruments"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetInstrumentBrand returns the brand of an instrument joined with its
+// company, sector and country. It returns nil without an error when the
+// instrument has no brand, and ErrInstrumentNotFound when there is no
+// instrument.
+func (r *Repository) GetInstrumentBrand(ctx context.Context, uid uuid.UUID, includeDeleted bool) (*domain.BrandDetails, error) {
+	const query = `
+		SELECT b.uid, b.name, b.description, b.info, b.company_uid, b.sector_uid, b.country_code,
+		       c.uid, c.name,
+		       s.uid, s.name, s.volatility,
+		       co.alfa_two, co.alfa_three, co.name, co.name_brief
+		FROM instruments i
+		LEFT JOIN brands b ON b.uid = i.brand_uid
+		LEFT JOIN companies c ON c.uid = b.company_uid
+		LEFT JOIN sectors s ON s.uid = b.sector_uid
+		LEFT JOIN countries co ON co.alfa_two = b.country_code
+		WHERE i.uid = $1 AND ($2 OR i.deleted_at IS NULL)`
+
+	var (
+		brandUID, companyUID, sectorUID               *uuid.UUID
+		brandName, description, info, countryCode     *string
+		companyRowUID, sectorRowUID                   *uuid.UUID
+		companyName, sectorName                       *string
+		volatility                                    *int32
+		alfaTwo, alfaThree, countryName, countryBrief *string
+	)
+	err := r.pool.QueryRow(ctx, query, uid, includeDeleted).Scan(
+		&brandUID, &brandName, &description, &info, &companyUID, &sectorUID, &countryCode,
+		&companyRowUID, &companyName,
+		&sectorRowUID, &sectorName, &volatility,
+		&alfaTwo, &alfaThree, &countryName, &countryBrief,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInstrumentNotFound
+		}
+		return nil, err
+	}
+	if brandUID == nil {
+		return nil, nil
+	}
+
+	details := &domain.BrandDetails{Brand: domain.Brand{
+		UID:         *brandUID,
+		Name:        deref(brandName),
+		Description: deref(description),
+		Info:        deref(info),
+		CompanyUID:  deref(companyUID),
+		SectorUID:   deref(sectorUID),
+		CountryCode: deref(countryCode),
+	}}
+	if companyRowUID != nil {
+		details.Company = &domain.Company{UID: *companyRowUID, Name: deref(companyName)}
+	}
+	if sectorRowUID != nil {
+		details.Sector = &domain.Sector{UID: *sectorRowUID, Name: deref(sectorName), Volatility: deref(volatility)}
+	}
+	if alfaTwo != nil {
+		details.Country = &domain.Country{
+			AlfaTwo:   *alfaTwo,
+			AlfaThree: deref(alfaThree),
+			Name:      deref(countryName),
+			NameBrief: deref(countryBrief),
+		}
+	}
+	return details, nil
+}
+
+// deref returns the value p points to, or the zero value for a NULL column.
+func deref[T any](p *T) T {
+	var value T
+	if p != nil {
+		value = *p
+	}
+	return value
+}
@@ -41,23 +41,23 @@ func (r *Repository) UpdateCurrency(ctx context.Context, currency *domain.Curren
 	})
 }
 
-func (r *Repository) DeleteCurrency(ctx context.Context, uid uuid.UUID) error {
+func (r *Repository) DeleteCurrency(ctx context.Context, uid uuid.UUID, hard bool) error {
 	return r.withTx(ctx, func(tx pgx.Tx) error {
 		if err := ensureTypedRowExists(ctx, tx, "currencies", uid); err != nil {
 			return err
 		}
-		return r.deleteInstrumentWith(ctx, tx, uid)
+		return r.deleteInstrumentWith(ctx, tx, uid, hard)
 	})
 }
 
-func (r *Repository) GetCurrency(ctx context.Context, uid uuid.UUID) (*domain.Currency, error) {
+func (r *Repository) GetCurrency(ctx context.Context, uid uuid.UUID, includeDeleted bool) (*domain.Currency, error) {
 	const query = `
-		SELECT i.uid, i.figi, i.ticker, i.lot, i.class_code, i.logo_url, i.created_at, i.updated_at, i.deleted_at
+		SELECT i.uid, i.figi, i.ticker, i.lot, i.class_code, i.logo_url, i.brand_uid, i.created_at, i.updated_at, i.deleted_at
 		FROM instruments i
 		INNER JOIN currencies c ON c.uid = i.uid
-		WHERE i.uid = $1`
+		WHERE i.uid = $1 AND ($2 OR i.deleted_at IS NULL)`
 
-	row := r.pool.QueryRow(ctx, query, uid)
+	row := r.pool.QueryRow(ctx, query, uid, includeDeleted)
 	currency := &domain.Currency{}
 	if err := scanInstrumentInto(row, &currency.Instrument); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
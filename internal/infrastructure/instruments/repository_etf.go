@@ -55,24 +55,24 @@ func (r *Repository) UpdateEtf(ctx context.Context, etf *domain.Etf) error {
 	})
 }
 
-func (r *Repository) DeleteEtf(ctx context.Context, uid uuid.UUID) error {
+func (r *Repository) DeleteEtf(ctx context.Context, uid uuid.UUID, hard bool) error {
 	return r.withTx(ctx, func(tx pgx.Tx) error {
 		if err := ensureTypedRowExists(ctx, tx, "etfs", uid); err != nil {
 			return err
 		}
-		return r.deleteInstrumentWith(ctx, tx, uid)
+		return r.deleteInstrumentWith(ctx, tx, uid, hard)
 	})
 }
 
-func (r *Repository) GetEtf(ctx context.Context, uid uuid.UUID) (*domain.Etf, error) {
+func (r *Repository) GetEtf(ctx context.Context, uid uuid.UUID, includeDeleted bool) (*domain.Etf, error) {
 	const query = `
-		SELECT i.uid, i.figi, i.ticker, i.lot, i.class_code, i.logo_url, i.created_at, i.updated_at, i.deleted_at,
+		SELECT i.uid, i.figi, i.ticker, i.lot, i.class_code, i.logo_url, i.brand_uid, i.created_at, i.updated_at, i.deleted_at,
 		       e.min_price_increment
 		FROM instruments i
 		INNER JOIN etfs e ON e.uid = i.uid
-		WHERE i.uid = $1`
+		WHERE i.uid = $1 AND ($2 OR i.deleted_at IS NULL)`
 
-	row := r.pool.QueryRow(ctx, query, uid)
+	row := r.pool.QueryRow(ctx, query, uid, includeDeleted)
 	etf := &domain.Etf{}
 	var minIncrement float64
 	if err := scanInstrumentInto(row, &etf.Instrument, &minIncrement); err != nil {
@@ -67,24 +67,24 @@ func (r *Repository) UpdateFuture(ctx context.Context, future *domain.Future) er
 	})
 }
 
-func (r *Repository) DeleteFuture(ctx context.Context, uid uuid.UUID) error {
+func (r *Repository) DeleteFuture(ctx context.Context, uid uuid.UUID, hard bool) error {
 	return r.withTx(ctx, func(tx pgx.Tx) error {
 		if err := ensureTypedRowExists(ctx, tx, "futures", uid); err != nil {
 			return err
 		}
-		return r.deleteInstrumentWith(ctx, tx, uid)
+		return r.deleteInstrumentWith(ctx, tx, uid, hard)
 	})
 }
 
-func (r *Repository) GetFuture(ctx context.Context, uid uuid.UUID) (*domain.Future, error) {
+func (r *Repository) GetFuture(ctx context.Context, uid uuid.UUID, includeDeleted bool) (*domain.Future, error) {
 	const query = `
-		SELECT i.uid, i.figi, i.ticker, i.lot, i.class_code, i.logo_url, i.created_at, i.updated_at, i.deleted_at,
+		SELECT i.uid, i.figi, i.ticker, i.lot, i.class_code, i.logo_url, i.brand_uid, i.created_at, i.updated_at, i.deleted_at,
 		       f.min_price_increment, f.min_price_increment_amount, f.asset_type
 		FROM instruments i
 		INNER JOIN futures f ON f.uid = i.uid
-		WHERE i.uid = $1`
+		WHERE i.uid = $1 AND ($2 OR i.deleted_at IS NULL)`
 
-	row := r.pool.QueryRow(ctx, query, uid)
+	row := r.pool.QueryRow(ctx, query, uid, includeDeleted)
 	future := &domain.Future{}
 	var minIncrement, minIncrementAmount float64
 	var assetTypeStr string
@@ -0,0 +1,106 @@
+package instruments
+
+import (
+	"context"
+
+	domain "main/internal/domain/entity/instruments"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// instrumentTypeColumn resolves the typed table that holds the instrument
+// aliased i; it is empty for an instrument with no typed row.
+const instrumentTypeColumn = `CASE
+		WHEN EXISTS (SELECT 1 FROM shares t WHERE t.uid = i.uid) THEN 'share'
+		WHEN EXISTS (SELECT 1 FROM bonds t WHERE t.uid = i.uid) THEN 'bond'
+		WHEN EXISTS (SELECT 1 FROM futures t WHERE t.uid = i.uid) THEN 'future'
+		WHEN EXISTS (SELECT 1 FROM currencies t WHERE t.uid = i.uid) THEN 'currency'
+		WHEN EXISTS (SELECT 1 FROM etfs t WHERE t.uid = i.uid) THEN 'etf'
+		ELSE ''
+	END`
+
+// ListInstruments returns the instruments matching filter ordered by ticker and
+// UID, with the instrument type resolved from the typed tables. Sector and
+// country filters join through the instrument's brand.
+func (r *Repository) ListInstruments(ctx context.Context, filter domain.InstrumentFilter) ([]domain.ListedInstrument, error) {
+	const query = `
+		SELECT uid, figi, ticker, lot, class_code, logo_url, brand_uid, created_at, updated_at, deleted_at, type
+		FROM (
+			SELECT i.uid, i.figi, i.ticker, i.lot, i.class_code, i.logo_url, i.brand_uid,
+			       i.created_at, i.updated_at, i.deleted_at,
+			       ` + instrumentTypeColumn + ` AS type
+			FROM instruments i
+			LEFT JOIN brands b ON b.uid = i.brand_uid
+			LEFT JOIN countries co ON co.alfa_two = b.country_code
+			WHERE ($1 = '' OR i.ticker = $1)
+			  AND ($2 = '' OR i.class_code = $2)
+			  AND ($3 = '' OR left(i.figi, length($3)) = $3)
+			  AND ($4 OR i.deleted_at IS NULL)
+			  AND ($8::uuid IS NULL OR b.sector_uid = $8)
+			  AND ($9::text = '' OR co.alfa_two = $9::text OR co.alfa_three = $9::text)
+		) listed
+		WHERE $5 = '' OR type = $5
+		ORDER BY ticker, uid
+		LIMIT $6 OFFSET $7`
+	var sectorUID *uuid.UUID
+	if filter.SectorUID != uuid.Nil {
+		sectorUID = &filter.SectorUID
+	}
+	rows, err := r.pool.Query(ctx, query,
+		filter.Ticker,
+		filter.ClassCode,
+		filter.FigiPrefix,
+		filter.IncludeDeleted,
+		string(filter.Type),
+		filter.Limit,
+		filter.Offset,
+		sectorUID,
+		filter.CountryCode,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanListedInstruments(rows)
+}
+
+// GetInstrumentByTicker returns the instruments with exactly this ticker,
+// ordered by class code and UID, with their types resolved. An empty
+// classCode matches every class code; a ticker listed on several boards
+// yields one row per board.
+func (r *Repository) GetInstrumentByTicker(ctx context.Context, ticker, classCode string, includeDeleted bool) ([]domain.ListedInstrument, error) {
+	const query = `
+		SELECT i.uid, i.figi, i.ticker, i.lot, i.class_code, i.logo_url, i.brand_uid,
+		       i.created_at, i.updated_at, i.deleted_at,
+		       ` + instrumentTypeColumn + ` AS type
+		FROM instruments i
+		WHERE i.ticker = $1
+		  AND ($2 = '' OR i.class_code = $2)
+		  AND ($3 OR i.deleted_at IS NULL)
+		ORDER BY i.class_code, i.uid`
+	rows, err := r.pool.Query(ctx, query, ticker, classCode, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	return scanListedInstruments(rows)
+}
+
+// scanListedInstruments reads instrument rows whose last column is the type
+// and closes rows.
+func scanListedInstruments(rows pgx.Rows) ([]domain.ListedInstrument, error) {
+	defer rows.Close()
+
+	var instruments []domain.ListedInstrument
+	for rows.Next() {
+		var (
+			listed       domain.ListedInstrument
+			instrumentTy string
+		)
+		if err := scanInstrumentInto(rows, &listed.Instrument, &instrumentTy); err != nil {
+			return nil, err
+		}
+		listed.Type = domain.InstrumentType(instrumentTy)
+		instruments = append(instruments, listed)
+	}
+	return instruments, rows.Err()
+}
@@ -0,0 +1,76 @@
+package instruments
+
+import (
+	"context"
+	"strings"
+
+	domain "main/internal/domain/entity/instruments"
+)
+
+// likeEscaper makes a search query match literally inside an ILIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchInstruments returns a page of the instruments whose ticker, brand name
+// or company name contains search.Query, case-insensitively. Matches are
+// ranked: an exact ticker first, then tickers starting with the query, tickers
+// containing it, names starting with it and names containing it; within a rank
+// shorter tickers come first. The substring match can use trigram indexes on
+// the three columns (see migrations/DDL.sql).
+func (r *Repository) SearchInstruments(ctx context.Context, search domain.InstrumentSearch) ([]domain.InstrumentMatch, error) {
+	const query = `
+		SELECT uid, figi, ticker, lot, class_code, logo_url, brand_uid, created_at, updated_at, deleted_at, type,
+		       brand_name, company_name, matched_on
+		FROM (
+			SELECT i.uid, i.figi, i.ticker, i.lot, i.class_code, i.logo_url, i.brand_uid,
+			       i.created_at, i.updated_at, i.deleted_at,
+			       ` + instrumentTypeColumn + ` AS type,
+			       COALESCE(b.name, '') AS brand_name,
+			       COALESCE(c.name, '') AS company_name,
+			       CASE
+			           WHEN i.ticker ILIKE $2 THEN 'ticker'
+			           WHEN b.name ILIKE $2 THEN 'brand'
+			           ELSE 'company'
+			       END AS matched_on,
+			       CASE
+			           WHEN upper(i.ticker) = upper($1) THEN 0
+			           WHEN i.ticker ILIKE $3 THEN 1
+			           WHEN i.ticker ILIKE $2 THEN 2
+			           WHEN b.name ILIKE $3 OR c.name ILIKE $3 THEN 3
+			           ELSE 4
+			       END AS rank
+			FROM instruments i
+			LEFT JOIN brands b ON b.uid = i.brand_uid
+			LEFT JOIN companies c ON c.uid = b.company_uid
+			WHERE (i.ticker ILIKE $2 OR b.name ILIKE $2 OR c.name ILIKE $2)
+			  AND ($4 OR i.deleted_at IS NULL)
+		) matches
+		ORDER BY rank, length(ticker), ticker, uid
+		LIMIT $5 OFFSET $6`
+	escaped := likeEscaper.Replace(search.Query)
+	rows, err := r.pool.Query(ctx, query,
+		search.Query,
+		"%"+escaped+"%",
+		escaped+"%",
+		search.IncludeDeleted,
+		search.Limit,
+		search.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []domain.InstrumentMatch
+	for rows.Next() {
+		var (
+			match        domain.InstrumentMatch
+			instrumentTy string
+		)
+		if err := scanInstrumentInto(rows, &match.Instrument, &instrumentTy, &match.BrandName, &match.CompanyName, &match.MatchedOn); err != nil {
+			return nil, err
+		}
+		match.Type = domain.InstrumentType(instrumentTy)
+		matches = append(matches, match)
+	}
+	return matches, rows.Err()
+}
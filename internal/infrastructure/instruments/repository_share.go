@@ -41,23 +41,23 @@ func (r *Repository) UpdateShare(ctx context.Context, share *domain.Share) error
 	})
 }
 
-func (r *Repository) DeleteShare(ctx context.Context, uid uuid.UUID) error {
+func (r *Repository) DeleteShare(ctx context.Context, uid uuid.UUID, hard bool) error {
 	return r.withTx(ctx, func(tx pgx.Tx) error {
 		if err := ensureTypedRowExists(ctx, tx, "shares", uid); err != nil {
 			return err
 		}
-		return r.deleteInstrumentWith(ctx, tx, uid)
+		return r.deleteInstrumentWith(ctx, tx, uid, hard)
 	})
 }
 
-func (r *Repository) GetShare(ctx context.Context, uid uuid.UUID) (*domain.Share, error) {
+func (r *Repository) GetShare(ctx context.Context, uid uuid.UUID, includeDeleted bool) (*domain.Share, error) {
 	const query = `
-		SELECT i.uid, i.figi, i.ticker, i.lot, i.class_code, i.logo_url, i.created_at, i.updated_at, i.deleted_at
+		SELECT i.uid, i.figi, i.ticker, i.lot, i.class_code, i.logo_url, i.brand_uid, i.created_at, i.updated_at, i.deleted_at
 		FROM instruments i
 		INNER JOIN shares s ON s.uid = i.uid
-		WHERE i.uid = $1`
+		WHERE i.uid = $1 AND ($2 OR i.deleted_at IS NULL)`
 
-	row := r.pool.QueryRow(ctx, query, uid)
+	row := r.pool.QueryRow(ctx, query, uid, includeDeleted)
 	share := &domain.Share{}
 	if err := scanInstrumentInto(row, &share.Instrument); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
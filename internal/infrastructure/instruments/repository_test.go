@@ -0,0 +1,113 @@
+package instruments
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	domain "main/internal/domain/entity/instruments"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// valuesRow is a pgx.Row holding the values of one result row. A nil value
+// scans as the zero value of the destination, like NULL into a pointer.
+type valuesRow []any
+
+func (r valuesRow) Scan(dest ...any) error {
+	for i, d := range dest {
+		target := reflect.ValueOf(d).Elem()
+		if r[i] == nil {
+			target.Set(reflect.Zero(target.Type()))
+			continue
+		}
+		target.Set(reflect.ValueOf(r[i]))
+	}
+	return nil
+}
+
+// fakeInstrumentTable keeps instrument rows in the column order of the
+// repository's RETURNING clauses and answers its insert and update statements.
+type fakeInstrumentTable struct {
+	rows map[uuid.UUID][]any
+}
+
+func (t *fakeInstrumentTable) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+	switch {
+	case strings.HasPrefix(strings.TrimSpace(sql), "INSERT"):
+		t.rows[args[0].(uuid.UUID)] = args
+		return valuesRow(args)
+	case strings.HasPrefix(strings.TrimSpace(sql), "UPDATE"):
+		row, ok := t.rows[args[0].(uuid.UUID)]
+		if !ok {
+			return errRow{pgx.ErrNoRows}
+		}
+		// figi through brand_uid, then updated_at; created_at and deleted_at stay.
+		copy(row[1:7], args[1:7])
+		row[8] = args[7]
+		return valuesRow(row)
+	}
+	return errRow{pgx.ErrNoRows}
+}
+
+// brandUID returns the stored brand_uid of an instrument; nil stands for NULL.
+func (t *fakeInstrumentTable) brandUID(uid uuid.UUID) *uuid.UUID {
+	stored, _ := t.rows[uid][6].(*uuid.UUID)
+	return stored
+}
+
+type errRow struct{ err error }
+
+func (r errRow) Scan(...any) error { return r.err }
+
+func TestInstrumentBrandUIDRoundTrip(t *testing.T) {
+	brandUID := uuid.New()
+	otherBrandUID := uuid.New()
+	tests := []struct {
+		name          string
+		created       uuid.UUID
+		updated       uuid.UUID
+		wantCreateArg bool // whether create stores a brand_uid rather than NULL
+		wantUpdateArg bool
+	}{
+		{"brand kept", brandUID, brandUID, true, true},
+		{"brand changed", brandUID, otherBrandUID, true, true},
+		{"brand removed", brandUID, uuid.Nil, true, false},
+		{"no brand", uuid.Nil, uuid.Nil, false, false},
+		{"brand added", uuid.Nil, brandUID, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := &fakeInstrumentTable{rows: map[uuid.UUID][]any{}}
+			repo := &Repository{}
+			ctx := context.Background()
+
+			instrument := &domain.Instrument{Figi: "BBG000000001", Ticker: "TEST", Lot: 1, BrandUID: tt.created}
+			if err := repo.createInstrumentWith(ctx, table, instrument); err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			if got := table.brandUID(instrument.UID) != nil; got != tt.wantCreateArg {
+				t.Fatalf("create stored a brand_uid = %v, want %v", got, tt.wantCreateArg)
+			}
+			if instrument.BrandUID != tt.created {
+				t.Fatalf("created BrandUID = %s, want %s", instrument.BrandUID, tt.created)
+			}
+
+			update := &domain.Instrument{UID: instrument.UID, Figi: instrument.Figi, Ticker: "TEST", Lot: 1, BrandUID: tt.updated}
+			if err := repo.updateInstrumentWith(ctx, table, update); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+			if got := table.brandUID(instrument.UID) != nil; got != tt.wantUpdateArg {
+				t.Fatalf("update stored a brand_uid = %v, want %v", got, tt.wantUpdateArg)
+			}
+			if update.BrandUID != tt.updated {
+				t.Fatalf("updated BrandUID = %s, want %s", update.BrandUID, tt.updated)
+			}
+			if !update.CreatedAt.Equal(instrument.CreatedAt) {
+				t.Fatalf("updated CreatedAt = %s, want %s", update.CreatedAt, instrument.CreatedAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package lastprice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	domain "main/internal/domain/entity/marketdata"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Key is the Redis hash holding the last prices, one field per instrument UID.
+const Key = "marketdata:last_price"
+
+// setNewer writes each (field, traded_at_us, value) triple of ARGV unless the
+// hash already holds a trade at least as new. Doing the comparison in Redis
+// keeps a late, older batch from overwriting the price of a newer one, even
+// with several consumers writing at once.
+var setNewer = redis.NewScript(`
+for i = 1, #ARGV, 3 do
+  local current = redis.call('HGET', KEYS[1], ARGV[i])
+  if not current or cjson.decode(current).traded_at_us < tonumber(ARGV[i + 1]) then
+    redis.call('HSET', KEYS[1], ARGV[i], ARGV[i + 2])
+  end
+end
+return 0
+`)
+
+// entry is a hash value. The time is kept in microseconds, the precision
+// Postgres stores trades with, so Lua compares it exactly as a number.
+type entry struct {
+	Price      float64 `json:"price"`
+	TradedAtUS int64   `json:"traded_at_us"`
+	Venue      string  `json:"venue,omitempty"`
+}
+
+// RedisStore keeps the last price of every instrument in one Redis hash.
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// SetLastPrices records the newest of trades per instrument in one round trip.
+func (s *RedisStore) SetLastPrices(ctx context.Context, trades []domain.Trade) error {
+	newest := make(map[uuid.UUID]domain.Trade, len(trades))
+	for _, trade := range trades {
+		if current, ok := newest[trade.InstrumentUID]; !ok || !trade.TradedAt.Before(current.TradedAt) {
+			newest[trade.InstrumentUID] = trade
+		}
+	}
+	args := make([]any, 0, 3*len(newest))
+	for uid, trade := range newest {
+		value, err := json.Marshal(entry{Price: trade.Price, TradedAtUS: trade.TradedAt.UnixMicro(), Venue: trade.Venue})
+		if err != nil {
+			return err
+		}
+		args = append(args, uid.String(), trade.TradedAt.UnixMicro(), value)
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	return setNewer.Run(ctx, s.client, []string{Key}, args...).Err()
+}
+
+// GetLastPrice returns nil without an error when the hash has no price for
+// the instrument.
+func (s *RedisStore) GetLastPrice(ctx context.Context, instrumentUID uuid.UUID) (*domain.LastPrice, error) {
+	raw, err := s.client.HGet(ctx, Key, instrumentUID.String()).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var value entry
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+	return &domain.LastPrice{
+		InstrumentUID: instrumentUID,
+		Price:         value.Price,
+		TradedAt:      time.UnixMicro(value.TradedAtUS).UTC(),
+		Venue:         value.Venue,
+	}, nil
+}
@@ -6,9 +6,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"main/internal/config"
 	domain "main/internal/domain/entity/marketdata"
+	"main/internal/infrastructure/postgres"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -19,14 +22,10 @@ type Repository struct {
 	pool *pgxpool.Pool
 }
 
-func NewRepository(ctx context.Context, dsn string) (*Repository, error) {
-	cfg, err := pgxpool.ParseConfig(dsn)
+func NewRepository(ctx context.Context, cfg config.PostgresConfig) (*Repository, error) {
+	pool, err := postgres.NewPool(ctx, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("parse pgx config: %w", err)
-	}
-	pool, err := pgxpool.NewWithConfig(ctx, cfg)
-	if err != nil {
-		return nil, fmt.Errorf("create pgx pool: %w", err)
+		return nil, err
 	}
 	return &Repository{pool: pool}, nil
 }
@@ -38,11 +37,21 @@ func (r *Repository) Close() {
 	r.pool.Close()
 }
 
+// Ping checks that the database is reachable through the pool.
+func (r *Repository) Ping(ctx context.Context) error {
+	return r.pool.Ping(ctx)
+}
+
+// Stat returns a snapshot of the pool's connection statistics.
+func (r *Repository) Stat() *pgxpool.Stat {
+	return r.pool.Stat()
+}
+
 // Trades
 
 const insertTradeQuery = `
-	INSERT INTO trades (trade_id, instrument_uid, side, price, quantity_lots, traded_at, metadata)
-	VALUES ($1,$2,$3,$4,$5,$6,$7)`
+	INSERT INTO trades (trade_id, instrument_uid, side, price, quantity_lots, traded_at, venue, metadata)
+	VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`
 
 func (r *Repository) AddTrade(ctx context.Context, trade *domain.Trade) error {
 	if trade == nil {
@@ -62,14 +71,15 @@ func (r *Repository) AddTrade(ctx context.Context, trade *domain.Trade) error {
 		trade.Price,
 		trade.QuantityLots,
 		trade.TradedAt,
+		nullableString(trade.Venue),
 		meta,
 	)
 	return err
 }
 
-func (r *Repository) AddTrades(ctx context.Context, trades []domain.Trade) error {
+func (r *Repository) AddTrades(ctx context.Context, trades []domain.Trade, onConflict domain.ConflictPolicy) (int64, error) {
 	if len(trades) == 0 {
-		return nil
+		return 0, nil
 	}
 	rows := make([][]interface{}, 0, len(trades))
 	for i := range trades {
@@ -78,7 +88,7 @@ func (r *Repository) AddTrades(ctx context.Context, trades []domain.Trade) error
 		}
 		meta, err := marshalJSON(trades[i].Metadata)
 		if err != nil {
-			return err
+			return 0, err
 		}
 		rows = append(rows, []interface{}{
 			trades[i].ID,
@@ -87,52 +97,97 @@ func (r *Repository) AddTrades(ctx context.Context, trades []domain.Trade) error
 			trades[i].Price,
 			trades[i].QuantityLots,
 			trades[i].TradedAt,
+			nullableString(trades[i].Venue),
 			meta,
 		})
 	}
-	_, err := r.pool.CopyFrom(
-		ctx,
-		pgx.Identifier{"trades"},
-		[]string{"trade_id", "instrument_uid", "side", "price", "quantity_lots", "traded_at", "metadata"},
-		pgx.CopyFromRows(rows),
-	)
-	return err
+	return r.copyRows(ctx, "trades",
+		[]string{"trade_id", "instrument_uid", "side", "price", "quantity_lots", "traded_at", "venue", "metadata"},
+		rows, onConflict)
+}
+
+// DeleteTradesBefore removes an instrument's trades executed before cutoff,
+// chunkSize rows per statement, and returns how many were removed.
+func (r *Repository) DeleteTradesBefore(ctx context.Context, instrumentUID uuid.UUID, cutoff time.Time, chunkSize int) (int64, error) {
+	return r.deleteChunked(ctx, "trades", "trade_id", "traded_at", deleteBefore, chunkSize, instrumentUID, cutoff)
 }
 
-func (r *Repository) GetTradesBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time) ([]domain.Trade, error) {
+// DeleteTradesBetween removes an instrument's trades in the range, bounds
+// included, chunkSize rows per statement.
+func (r *Repository) DeleteTradesBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, chunkSize int) (int64, error) {
+	return r.deleteChunked(ctx, "trades", "trade_id", "traded_at", deleteBetween, chunkSize, instrumentUID, from, to)
+}
+
+func (r *Repository) GetTradesBetween(ctx context.Context, instrumentUID uuid.UUID, venue string, from, to time.Time, meta domain.MetadataFilter, page domain.Page) ([]domain.Trade, error) {
+	var trades []domain.Trade
+	err := r.EachTradeBetween(ctx, instrumentUID, venue, from, to, meta, page, func(trade domain.Trade) error {
+		trades = append(trades, trade)
+		return nil
+	})
+	return trades, err
+}
+
+// EachTradeBetween calls fn for every trade GetTradesBetween would return, as
+// rows arrive, stopping at the first error. A zero page limit means no limit.
+func (r *Repository) EachTradeBetween(ctx context.Context, instrumentUID uuid.UUID, venue string, from, to time.Time, meta domain.MetadataFilter, page domain.Page, fn func(domain.Trade) error) error {
 	const query = `
-		SELECT trade_id, instrument_uid, side, price, quantity_lots, traded_at, metadata
+		SELECT trade_id, instrument_uid, side, price, quantity_lots, traded_at, venue, metadata
 		FROM trades
 		WHERE instrument_uid=$1 AND traded_at >= $2 AND traded_at <= $3
-		ORDER BY traded_at ASC`
-	rows, err := r.pool.Query(ctx, query, instrumentUID, from, to)
+		  AND ($6 = '' OR venue = $6)
+		  AND ($7::jsonb IS NULL OR metadata @> $7::jsonb)
+		ORDER BY traded_at ASC, trade_id ASC
+		LIMIT $4 OFFSET $5`
+	metaArg, err := metadataContains(meta)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	rows, err := r.pool.Query(ctx, query, instrumentUID, from, to, pageLimit(page), page.Offset, venue, metaArg)
+	if err != nil {
+		return err
 	}
 	defer rows.Close()
 
-	var trades []domain.Trade
 	for rows.Next() {
 		trade, err := scanTrade(rows)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(trade); err != nil {
+			return err
 		}
-		trades = append(trades, trade)
 	}
-	return trades, rows.Err()
+	return rows.Err()
 }
 
-func (r *Repository) GetLastTrades(ctx context.Context, instrumentUID uuid.UUID, limit int) ([]domain.Trade, error) {
+// GetTradeByID returns the trade with the given id, or domain.ErrTradeNotFound.
+func (r *Repository) GetTradeByID(ctx context.Context, id uuid.UUID) (*domain.Trade, error) {
+	const query = `
+		SELECT trade_id, instrument_uid, side, price, quantity_lots, traded_at, venue, metadata
+		FROM trades
+		WHERE trade_id=$1
+		LIMIT 1`
+	trade, err := scanTrade(r.pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrTradeNotFound
+		}
+		return nil, err
+	}
+	return &trade, nil
+}
+
+func (r *Repository) GetLastTrades(ctx context.Context, instrumentUID uuid.UUID, venue string, limit int) ([]domain.Trade, error) {
 	if limit <= 0 {
 		return nil, errors.New("limit must be positive")
 	}
 	const query = `
-		SELECT trade_id, instrument_uid, side, price, quantity_lots, traded_at, metadata
+		SELECT trade_id, instrument_uid, side, price, quantity_lots, traded_at, venue, metadata
 		FROM trades
-		WHERE instrument_uid=$1
+		WHERE instrument_uid=$1 AND ($3 = '' OR venue = $3)
 		ORDER BY traded_at DESC
 		LIMIT $2`
-	rows, err := r.pool.Query(ctx, query, instrumentUID, limit)
+	rows, err := r.pool.Query(ctx, query, instrumentUID, limit, venue)
 	if err != nil {
 		return nil, err
 	}
@@ -149,8 +204,210 @@ func (r *Repository) GetLastTrades(ctx context.Context, instrumentUID uuid.UUID,
 	return trades, rows.Err()
 }
 
+// GetInstrumentClassCodes returns the class_code of each listed instrument that
+// has one, including soft-deleted instruments.
+func (r *Repository) GetInstrumentClassCodes(ctx context.Context, instrumentUIDs []uuid.UUID) (map[uuid.UUID]string, error) {
+	const query = `
+		SELECT uid, class_code
+		FROM instruments
+		WHERE uid = ANY($1) AND class_code IS NOT NULL AND class_code <> ''`
+	rows, err := r.pool.Query(ctx, query, instrumentUIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	classCodes := make(map[uuid.UUID]string, len(instrumentUIDs))
+	for rows.Next() {
+		var uid uuid.UUID
+		var classCode string
+		if err := rows.Scan(&uid, &classCode); err != nil {
+			return nil, err
+		}
+		classCodes[uid] = classCode
+	}
+	return classCodes, rows.Err()
+}
+
+// GetVWAP computes the volume-weighted average price of the trades in [from, to].
+// It returns nil when the range holds no traded volume.
+func (r *Repository) GetVWAP(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time) (*domain.VWAP, error) {
+	const query = `
+		SELECT (SUM(price * quantity_lots) / NULLIF(SUM(quantity_lots), 0))::float8,
+		       COALESCE(SUM(quantity_lots), 0),
+		       COUNT(*)
+		FROM trades
+		WHERE instrument_uid=$1 AND traded_at >= $2 AND traded_at <= $3`
+	var price sql.NullFloat64
+	vwap := domain.VWAP{InstrumentUID: instrumentUID, From: from, To: to}
+	if err := r.pool.QueryRow(ctx, query, instrumentUID, from, to).Scan(&price, &vwap.VolumeLots, &vwap.TradeCount); err != nil {
+		return nil, err
+	}
+	if !price.Valid {
+		return nil, nil
+	}
+	vwap.VWAP = price.Float64
+	return &vwap, nil
+}
+
+// GetTradeSummary aggregates all trades of an instrument in one pass.
+func (r *Repository) GetTradeSummary(ctx context.Context, instrumentUID uuid.UUID) (*domain.TradeSummary, error) {
+	const query = `
+		SELECT COUNT(*),
+		       MIN(traded_at),
+		       MAX(traded_at),
+		       MIN(price)::float8,
+		       MAX(price)::float8,
+		       COALESCE(SUM(quantity_lots), 0)
+		FROM trades
+		WHERE instrument_uid=$1`
+	summary := domain.TradeSummary{InstrumentUID: instrumentUID}
+	err := r.pool.QueryRow(ctx, query, instrumentUID).Scan(
+		&summary.TradeCount,
+		&summary.FirstTradeAt,
+		&summary.LastTradeAt,
+		&summary.MinPrice,
+		&summary.MaxPrice,
+		&summary.VolumeLots,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// GetDailyVolumes returns the traded volume per calendar day in timezone for the
+// trades in [from, to). Days without trades are left out.
+func (r *Repository) GetDailyVolumes(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, timezone string) ([]domain.DailyVolume, error) {
+	const query = `
+		SELECT date_trunc('day', traded_at, $4) AS day,
+		       SUM(quantity_lots),
+		       COUNT(*)
+		FROM trades
+		WHERE instrument_uid=$1 AND traded_at >= $2 AND traded_at < $3
+		GROUP BY 1
+		ORDER BY 1 ASC`
+	rows, err := r.pool.Query(ctx, query, instrumentUID, from, to, timezone)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []domain.DailyVolume
+	for rows.Next() {
+		var day domain.DailyVolume
+		if err := rows.Scan(&day.Day, &day.VolumeLots, &day.TradeCount); err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+	return days, rows.Err()
+}
+
+// GetSectorVolatility returns, per sector, the mean standard deviation of daily
+// log returns of its instruments. Each instrument's daily close is the close of
+// its last candle of intervalSeconds on each calendar day in timezone within
+// [from, to). Instruments with fewer than minReturns returns, and soft-deleted
+// ones, are left out; so are sectors without any instrument left.
+func (r *Repository) GetSectorVolatility(ctx context.Context, intervalSeconds int64, from, to time.Time, timezone string, minReturns int) ([]domain.SectorVolatility, error) {
+	const query = `
+		WITH closes AS (
+			SELECT DISTINCT ON (c.instrument_uid, date_trunc('day', c.period_start, $4))
+			       b.sector_uid, c.instrument_uid, c.period_start, c.close
+			FROM candles c
+			JOIN instruments i ON i.uid = c.instrument_uid AND i.deleted_at IS NULL
+			JOIN brands b ON b.uid = i.brand_uid
+			WHERE c.interval_seconds = $1
+			  AND c.period_start >= $2
+			  AND c.period_start < $3
+			  AND c.close > 0
+			ORDER BY c.instrument_uid, date_trunc('day', c.period_start, $4), c.period_start DESC
+		), returns AS (
+			SELECT sector_uid, instrument_uid,
+			       ln(close / LAG(close) OVER (PARTITION BY instrument_uid ORDER BY period_start)) AS log_return
+			FROM closes
+		), per_instrument AS (
+			SELECT sector_uid, instrument_uid, stddev_samp(log_return) AS stddev
+			FROM returns
+			WHERE log_return IS NOT NULL
+			GROUP BY sector_uid, instrument_uid
+			HAVING COUNT(log_return) >= $5
+		)
+		SELECT sector_uid, COUNT(*), AVG(stddev)::double precision
+		FROM per_instrument
+		GROUP BY sector_uid
+		ORDER BY sector_uid`
+	rows, err := r.pool.Query(ctx, query, intervalSeconds, from, to, timezone, minReturns)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sectors []domain.SectorVolatility
+	for rows.Next() {
+		var sector domain.SectorVolatility
+		if err := rows.Scan(&sector.SectorUID, &sector.Instruments, &sector.DailyStddev); err != nil {
+			return nil, err
+		}
+		sectors = append(sectors, sector)
+	}
+	return sectors, rows.Err()
+}
+
+// GetTradeActivity returns trade count and volume per bucket of bucketSeconds,
+// aligned to the Unix epoch. Buckets without trades are returned with zeros.
+func (r *Repository) GetTradeActivity(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, bucketSeconds int64) ([]domain.TradeActivityBucket, error) {
+	if bucketSeconds <= 0 {
+		return nil, errors.New("bucket seconds must be positive")
+	}
+	const query = `
+		WITH buckets AS (
+			SELECT generate_series(
+				to_timestamp(floor(extract(epoch FROM $2::timestamptz) / $4::bigint) * $4::bigint),
+				$3::timestamptz,
+				make_interval(secs => $4::bigint)
+			) AS bucket_start
+		), activity AS (
+			SELECT to_timestamp(floor(extract(epoch FROM traded_at) / $4::bigint) * $4::bigint) AS bucket_start,
+			       COUNT(*) AS trade_count,
+			       SUM(quantity_lots) AS volume_lots
+			FROM trades
+			WHERE instrument_uid=$1 AND traded_at >= $2 AND traded_at <= $3
+			GROUP BY 1
+		)
+		SELECT b.bucket_start, COALESCE(a.trade_count, 0), COALESCE(a.volume_lots, 0)
+		FROM buckets b
+		LEFT JOIN activity a ON a.bucket_start = b.bucket_start
+		ORDER BY b.bucket_start ASC`
+	rows, err := r.pool.Query(ctx, query, instrumentUID, from, to, bucketSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buckets []domain.TradeActivityBucket
+	for rows.Next() {
+		var bucket domain.TradeActivityBucket
+		if err := rows.Scan(&bucket.BucketStart, &bucket.TradeCount, &bucket.VolumeLots); err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets, rows.Err()
+}
+
+// pageLimit is the LIMIT argument of a page; NULL, meaning no limit, for a zero
+// limit.
+func pageLimit(page domain.Page) any {
+	if page.Limit == 0 {
+		return nil
+	}
+	return page.Limit
+}
+
 func scanTrade(row pgx.Row) (domain.Trade, error) {
 	var metadataBytes []byte
+	var venue sql.NullString
 	trade := domain.Trade{}
 	err := row.Scan(
 		&trade.ID,
@@ -159,11 +416,13 @@ func scanTrade(row pgx.Row) (domain.Trade, error) {
 		&trade.Price,
 		&trade.QuantityLots,
 		&trade.TradedAt,
+		&venue,
 		&metadataBytes,
 	)
 	if err != nil {
 		return domain.Trade{}, err
 	}
+	trade.Venue = venue.String
 	meta, err := unmarshalMetadata(metadataBytes)
 	if err != nil {
 		return domain.Trade{}, err
@@ -182,18 +441,51 @@ const insertCandleQuery = `
 		last_trade_at, metadata
 	) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)`
 
-func (r *Repository) AddCandle(ctx context.Context, candle *domain.Candle) error {
-	if candle == nil {
-		return errors.New("nil candle")
-	}
+// upsertCandleQuery stores a candle or, when one is already stored for the
+// same instrument, interval and period start (ux_candles_natural), overwrites
+// its OHLCV and the rest of its data with the new one. The stored row keeps
+// its candle_id, which the query returns.
+const upsertCandleQuery = insertCandleQuery + `
+	ON CONFLICT (instrument_uid, interval_seconds, period_start) DO UPDATE SET
+		open = EXCLUDED.open,
+		high = EXCLUDED.high,
+		low = EXCLUDED.low,
+		close = EXCLUDED.close,
+		volume_lots = EXCLUDED.volume_lots,
+		volume_buy_lots = EXCLUDED.volume_buy_lots,
+		volume_sell_lots = EXCLUDED.volume_sell_lots,
+		last_trade_at = EXCLUDED.last_trade_at,
+		metadata = EXCLUDED.metadata
+	RETURNING candle_id`
+
+// candleColumns are the columns candleRow fills, in insertCandleQuery order.
+var candleColumns = []string{
+	"candle_id",
+	"instrument_uid",
+	"interval_seconds",
+	"period_start",
+	"open",
+	"high",
+	"low",
+	"close",
+	"volume_lots",
+	"volume_buy_lots",
+	"volume_sell_lots",
+	"last_trade_at",
+	"metadata",
+}
+
+// candleRow assigns the candle an ID if it has none and returns its column
+// values.
+func candleRow(candle *domain.Candle) ([]interface{}, error) {
 	if candle.ID == uuid.Nil {
 		candle.ID = uuid.New()
 	}
 	meta, err := marshalJSON(candle.Metadata)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	_, err = r.pool.Exec(ctx, insertCandleQuery,
+	return []interface{}{
 		candle.ID,
 		candle.InstrumentUID,
 		candle.IntervalSeconds,
@@ -207,63 +499,113 @@ func (r *Repository) AddCandle(ctx context.Context, candle *domain.Candle) error
 		nullableInt64(candle.VolumeSellLots),
 		candle.LastTradeAt,
 		meta,
-	)
+	}, nil
+}
+
+func (r *Repository) AddCandle(ctx context.Context, candle *domain.Candle) error {
+	if candle == nil {
+		return errors.New("nil candle")
+	}
+	args, err := candleRow(candle)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, insertCandleQuery, args...)
 	return err
 }
 
-func (r *Repository) AddCandles(ctx context.Context, candles []domain.Candle) error {
+func (r *Repository) AddCandles(ctx context.Context, candles []domain.Candle, onConflict domain.ConflictPolicy) (int64, error) {
 	if len(candles) == 0 {
-		return nil
+		return 0, nil
 	}
 	rows := make([][]interface{}, 0, len(candles))
 	for i := range candles {
-		if candles[i].ID == uuid.Nil {
-			candles[i].ID = uuid.New()
+		row, err := candleRow(&candles[i])
+		if err != nil {
+			return 0, err
 		}
-		meta, err := marshalJSON(candles[i].Metadata)
+		rows = append(rows, row)
+	}
+	return r.copyRows(ctx, "candles", candleColumns, rows, onConflict)
+}
+
+// UpsertCandle stores candle, replacing the candle already stored for its
+// instrument, interval and period start. candle.ID is set to the ID of the
+// stored row, which is the old one on a replace.
+func (r *Repository) UpsertCandle(ctx context.Context, candle *domain.Candle) error {
+	return r.upsertCandleWith(ctx, r.pool, candle)
+}
+
+func (r *Repository) upsertCandleWith(ctx context.Context, runner queryRower, candle *domain.Candle) error {
+	if candle == nil {
+		return errors.New("nil candle")
+	}
+	args, err := candleRow(candle)
+	if err != nil {
+		return err
+	}
+	return runner.QueryRow(ctx, upsertCandleQuery, args...).Scan(&candle.ID)
+}
+
+// UpsertCandles upserts candles like UpsertCandle, as one batch of statements
+// in a single transaction: COPY cannot update rows, so the batch path does not
+// use it here. Candles for the same period within the batch are applied in
+// order, so the last one wins. It returns how many candles were stored, new
+// or replacing.
+func (r *Repository) UpsertCandles(ctx context.Context, candles []domain.Candle) (int64, error) {
+	return r.upsertCandlesWith(ctx, r.pool, candles)
+}
+
+func (r *Repository) upsertCandlesWith(ctx context.Context, db txBeginner, candles []domain.Candle) (int64, error) {
+	if len(candles) == 0 {
+		return 0, nil
+	}
+	batch := &pgx.Batch{}
+	for i := range candles {
+		args, err := candleRow(&candles[i])
 		if err != nil {
-			return err
+			return 0, err
 		}
-		rows = append(rows, []interface{}{
-			candles[i].ID,
-			candles[i].InstrumentUID,
-			candles[i].IntervalSeconds,
-			candles[i].PeriodStart,
-			candles[i].Open,
-			candles[i].High,
-			candles[i].Low,
-			candles[i].Close,
-			candles[i].VolumeLots,
-			nullableInt64(candles[i].VolumeBuyLots),
-			nullableInt64(candles[i].VolumeSellLots),
-			candles[i].LastTradeAt,
-			meta,
-		})
+		batch.Queue(upsertCandleQuery, args...)
 	}
-	_, err := r.pool.CopyFrom(
-		ctx,
-		pgx.Identifier{"candles"},
-		[]string{
-			"candle_id",
-			"instrument_uid",
-			"interval_seconds",
-			"period_start",
-			"open",
-			"high",
-			"low",
-			"close",
-			"volume_lots",
-			"volume_buy_lots",
-			"volume_sell_lots",
-			"last_trade_at",
-			"metadata",
-		},
-		pgx.CopyFromRows(rows),
-	)
-	return err
+	err := pgx.BeginFunc(ctx, db, func(tx pgx.Tx) error {
+		results := tx.SendBatch(ctx, batch)
+		for i := range candles {
+			if err := results.QueryRow().Scan(&candles[i].ID); err != nil {
+				results.Close()
+				return fmt.Errorf("candle %d: %w", i, err)
+			}
+		}
+		return results.Close()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(candles)), nil
 }
 
-func (r *Repository) GetCandlesBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, intervalSeconds int64) ([]domain.Candle, error) {
+// DeleteCandlesBefore removes an instrument's candles of every interval whose
+// period started before cutoff.
+func (r *Repository) DeleteCandlesBefore(ctx context.Context, instrumentUID uuid.UUID, cutoff time.Time, chunkSize int) (int64, error) {
+	return r.deleteChunked(ctx, "candles", "candle_id", "period_start", deleteBefore, chunkSize, instrumentUID, cutoff)
+}
+
+func (r *Repository) DeleteCandlesBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, chunkSize int) (int64, error) {
+	return r.deleteChunked(ctx, "candles", "candle_id", "period_start", deleteBetween, chunkSize, instrumentUID, from, to)
+}
+
+func (r *Repository) GetCandlesBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, intervalSeconds int64, meta domain.MetadataFilter, page domain.Page) ([]domain.Candle, error) {
+	var candles []domain.Candle
+	err := r.EachCandleBetween(ctx, instrumentUID, from, to, intervalSeconds, meta, page, func(candle domain.Candle) error {
+		candles = append(candles, candle)
+		return nil
+	})
+	return candles, err
+}
+
+// EachCandleBetween calls fn for every candle GetCandlesBetween would return, as
+// rows arrive, stopping at the first error. A zero page limit means no limit.
+func (r *Repository) EachCandleBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, intervalSeconds int64, meta domain.MetadataFilter, page domain.Page, fn func(domain.Candle) error) error {
 	const query = `
 		SELECT candle_id, instrument_uid, interval_seconds, period_start,
 		       open, high, low, close,
@@ -274,22 +616,50 @@ func (r *Repository) GetCandlesBetween(ctx context.Context, instrumentUID uuid.U
 		  AND interval_seconds=$2
 		  AND period_start >= $3
 		  AND period_start <= $4
-		ORDER BY period_start ASC`
-	rows, err := r.pool.Query(ctx, query, instrumentUID, intervalSeconds, from, to)
+		  AND ($7::jsonb IS NULL OR metadata @> $7::jsonb)
+		ORDER BY period_start ASC, candle_id ASC
+		LIMIT $5 OFFSET $6`
+	metaArg, err := metadataContains(meta)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	rows, err := r.pool.Query(ctx, query, instrumentUID, intervalSeconds, from, to, pageLimit(page), page.Offset, metaArg)
+	if err != nil {
+		return err
 	}
 	defer rows.Close()
 
-	var candles []domain.Candle
 	for rows.Next() {
 		candle, err := scanCandle(rows)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(candle); err != nil {
+			return err
 		}
-		candles = append(candles, candle)
 	}
-	return candles, rows.Err()
+	return rows.Err()
+}
+
+// GetCandleByID returns the candle with the given id, or
+// domain.ErrCandleNotFound.
+func (r *Repository) GetCandleByID(ctx context.Context, id uuid.UUID) (*domain.Candle, error) {
+	const query = `
+		SELECT candle_id, instrument_uid, interval_seconds, period_start,
+		       open, high, low, close,
+		       volume_lots, volume_buy_lots, volume_sell_lots,
+		       last_trade_at, metadata
+		FROM candles
+		WHERE candle_id=$1
+		LIMIT 1`
+	candle, err := scanCandle(r.pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrCandleNotFound
+		}
+		return nil, err
+	}
+	return &candle, nil
 }
 
 func (r *Repository) GetLastCandles(ctx context.Context, instrumentUID uuid.UUID, intervalSeconds int64, limit int) ([]domain.Candle, error) {
@@ -322,6 +692,247 @@ func (r *Repository) GetLastCandles(ctx context.Context, instrumentUID uuid.UUID
 	return candles, rows.Err()
 }
 
+// GetCandleSummary aggregates all candles of an instrument and interval in one
+// pass.
+func (r *Repository) GetCandleSummary(ctx context.Context, instrumentUID uuid.UUID, intervalSeconds int64) (*domain.CandleSummary, error) {
+	const query = `
+		SELECT COUNT(*),
+		       MIN(period_start),
+		       MAX(period_start),
+		       MIN(low)::float8,
+		       MAX(high)::float8,
+		       COALESCE(SUM(volume_lots), 0)
+		FROM candles
+		WHERE instrument_uid=$1 AND interval_seconds=$2`
+	summary := domain.CandleSummary{InstrumentUID: instrumentUID, IntervalSeconds: intervalSeconds}
+	err := r.pool.QueryRow(ctx, query, instrumentUID, intervalSeconds).Scan(
+		&summary.CandleCount,
+		&summary.FirstPeriod,
+		&summary.LastPeriod,
+		&summary.Low,
+		&summary.High,
+		&summary.VolumeLots,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// GetCandleGaps finds runs of missing candles between consecutive stored candles
+// of the given interval. Gaps before the first or after the last stored candle in
+// the range are not reported. An empty instrumentUIDs list searches all instruments.
+func (r *Repository) GetCandleGaps(ctx context.Context, intervalSeconds int64, from, to time.Time, instrumentUIDs ...uuid.UUID) ([]domain.CandleGap, error) {
+	if intervalSeconds <= 0 {
+		return nil, errors.New("interval seconds must be positive")
+	}
+	const query = `
+		SELECT instrument_uid,
+		       prev_start + make_interval(secs => $1::bigint) AS gap_from,
+		       period_start - make_interval(secs => $1::bigint) AS gap_to,
+		       extract(epoch FROM period_start - prev_start)::bigint / $1::bigint - 1 AS missing
+		FROM (
+			SELECT instrument_uid, period_start,
+			       LAG(period_start) OVER (PARTITION BY instrument_uid ORDER BY period_start) AS prev_start
+			FROM candles
+			WHERE interval_seconds=$1
+			  AND period_start >= $2
+			  AND period_start <= $3
+			  AND (cardinality($4::uuid[]) = 0 OR instrument_uid = ANY($4::uuid[]))
+		) c
+		WHERE prev_start IS NOT NULL
+		  AND period_start - prev_start > make_interval(secs => $1::bigint)
+		ORDER BY instrument_uid, gap_from`
+	uids := make([]string, 0, len(instrumentUIDs))
+	for _, uid := range instrumentUIDs {
+		uids = append(uids, uid.String())
+	}
+	rows, err := r.pool.Query(ctx, query, intervalSeconds, from, to, uids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var gaps []domain.CandleGap
+	for rows.Next() {
+		gap := domain.CandleGap{IntervalSeconds: intervalSeconds}
+		if err := rows.Scan(&gap.InstrumentUID, &gap.From, &gap.To, &gap.Missing); err != nil {
+			return nil, err
+		}
+		gaps = append(gaps, gap)
+	}
+	return gaps, rows.Err()
+}
+
+// GetCandlesFromTrades aggregates trades into candles of intervalSeconds. A
+// candle's period starts at a multiple of the interval since the Unix epoch, or
+// with a timezone at the start of a calendar day or week there (see
+// calendarUnits), and only candles whose period start lies within [from, to]
+// are returned, matching GetCandlesBetween. Open and close are the first and
+// last trade by time, ties broken by trade id. Periods without trades are
+// skipped. Derived candles are not stored, so their ID is uuid.Nil.
+func (r *Repository) GetCandlesFromTrades(ctx context.Context, instrumentUID uuid.UUID, intervalSeconds int64, from, to time.Time, timezone string) ([]domain.Candle, error) {
+	if intervalSeconds <= 0 {
+		return nil, errors.New("interval seconds must be positive")
+	}
+	unit, err := calendarUnit(intervalSeconds, timezone)
+	if err != nil {
+		return nil, err
+	}
+	const query = `
+		SELECT bucket_start,
+		       (array_agg(price ORDER BY traded_at ASC, trade_id ASC))[1],
+		       MAX(price),
+		       MIN(price),
+		       (array_agg(price ORDER BY traded_at DESC, trade_id DESC))[1],
+		       SUM(quantity_lots),
+		       COALESCE(SUM(quantity_lots) FILTER (WHERE side = 'BUY'), 0),
+		       COALESCE(SUM(quantity_lots) FILTER (WHERE side = 'SELL'), 0),
+		       MAX(traded_at),
+		       COUNT(*)
+		FROM (
+			SELECT CASE WHEN $5::text = '' THEN to_timestamp(floor(extract(epoch FROM traded_at) / $4::bigint) * $4::bigint)
+			            ELSE date_trunc($6::text, traded_at AT TIME ZONE $5::text) AT TIME ZONE $5::text
+			       END AS bucket_start,
+			       trade_id, side, price, quantity_lots, traded_at
+			FROM trades
+			WHERE instrument_uid=$1
+			  AND traded_at >= $2
+			  AND traded_at < $3::timestamptz + make_interval(hours => 3, secs => $4::bigint)
+		) t
+		WHERE bucket_start >= $2 AND bucket_start <= $3
+		GROUP BY bucket_start
+		ORDER BY bucket_start ASC`
+	rows, err := r.pool.Query(ctx, query, instrumentUID, from, to, intervalSeconds, timezone, unit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candles []domain.Candle
+	for rows.Next() {
+		var (
+			volumeBuy  int64
+			volumeSell int64
+			lastTrade  time.Time
+			tradeCount int64
+		)
+		candle := domain.Candle{InstrumentUID: instrumentUID, IntervalSeconds: intervalSeconds}
+		if err := rows.Scan(
+			&candle.PeriodStart,
+			&candle.Open,
+			&candle.High,
+			&candle.Low,
+			&candle.Close,
+			&candle.VolumeLots,
+			&volumeBuy,
+			&volumeSell,
+			&lastTrade,
+			&tradeCount,
+		); err != nil {
+			return nil, err
+		}
+		candle.PeriodStart = candle.PeriodStart.UTC()
+		candle.VolumeBuyLots = &volumeBuy
+		candle.VolumeSellLots = &volumeSell
+		candle.LastTradeAt = &lastTrade
+		candle.Metadata = map[string]any{"source": "trades", "trade_count": tradeCount}
+		candles = append(candles, candle)
+	}
+	return candles, rows.Err()
+}
+
+// GetCandlesResampled merges stored candles of baseInterval into candles of
+// targetInterval, aligned to the Unix epoch, or with a timezone to the
+// calendar days or weeks there. Buy and sell volume are only summed when every
+// merged candle has them.
+func (r *Repository) GetCandlesResampled(ctx context.Context, instrumentUID uuid.UUID, baseInterval, targetInterval int64, from, to time.Time, timezone string) ([]domain.Candle, error) {
+	if baseInterval <= 0 || targetInterval <= 0 {
+		return nil, errors.New("interval seconds must be positive")
+	}
+	unit, err := calendarUnit(targetInterval, timezone)
+	if err != nil {
+		return nil, err
+	}
+	const query = `
+		SELECT bucket_start,
+		       (array_agg(open ORDER BY period_start ASC))[1],
+		       MAX(high),
+		       MIN(low),
+		       (array_agg(close ORDER BY period_start DESC))[1],
+		       SUM(volume_lots),
+		       CASE WHEN COUNT(volume_buy_lots) = COUNT(*) THEN SUM(volume_buy_lots) END,
+		       CASE WHEN COUNT(volume_sell_lots) = COUNT(*) THEN SUM(volume_sell_lots) END,
+		       MAX(last_trade_at),
+		       COUNT(*)
+		FROM (
+			SELECT CASE WHEN $6::text = '' THEN to_timestamp(floor(extract(epoch FROM period_start) / $5::bigint) * $5::bigint)
+			            ELSE date_trunc($7::text, period_start AT TIME ZONE $6::text) AT TIME ZONE $6::text
+			       END AS bucket_start,
+			       period_start, open, high, low, close,
+			       volume_lots, volume_buy_lots, volume_sell_lots, last_trade_at
+			FROM candles
+			WHERE instrument_uid=$1
+			  AND interval_seconds=$4
+			  AND period_start >= $2
+			  AND period_start < $3::timestamptz + make_interval(hours => 3, secs => $5::bigint)
+		) c
+		WHERE bucket_start >= $2 AND bucket_start <= $3
+		GROUP BY bucket_start
+		ORDER BY bucket_start ASC`
+	rows, err := r.pool.Query(ctx, query, instrumentUID, from, to, baseInterval, targetInterval, timezone, unit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candles []domain.Candle
+	for rows.Next() {
+		var merged int64
+		candle := domain.Candle{InstrumentUID: instrumentUID, IntervalSeconds: targetInterval}
+		if err := rows.Scan(
+			&candle.PeriodStart,
+			&candle.Open,
+			&candle.High,
+			&candle.Low,
+			&candle.Close,
+			&candle.VolumeLots,
+			&candle.VolumeBuyLots,
+			&candle.VolumeSellLots,
+			&candle.LastTradeAt,
+			&merged,
+		); err != nil {
+			return nil, err
+		}
+		candle.PeriodStart = candle.PeriodStart.UTC()
+		candle.Metadata = map[string]any{"source": "candles", "base_interval_seconds": baseInterval, "candle_count": merged}
+		candles = append(candles, candle)
+	}
+	return candles, rows.Err()
+}
+
+// calendarUnits are the date_trunc units of the intervals that can be aligned
+// to the calendar of a timezone. Weeks start on Monday, as in ISO 8601. A
+// calendar day or week is longer than its interval when the clocks move back
+// within it, so the candle queries scan 3 hours past the last period.
+var calendarUnits = map[int64]string{
+	86400:     "day",
+	7 * 86400: "week",
+}
+
+// calendarUnit returns the date_trunc unit for intervalSeconds, or "" without
+// a timezone.
+func calendarUnit(intervalSeconds int64, timezone string) (string, error) {
+	if timezone == "" {
+		return "", nil
+	}
+	unit, ok := calendarUnits[intervalSeconds]
+	if !ok {
+		return "", fmt.Errorf("interval of %d seconds cannot be aligned to a timezone", intervalSeconds)
+	}
+	return unit, nil
+}
+
 func scanCandle(row pgx.Row) (domain.Candle, error) {
 	var (
 		volumeBuy  sql.NullInt64
@@ -372,8 +983,8 @@ func scanCandle(row pgx.Row) (domain.Candle, error) {
 
 const insertOrderBookQuery = `
 	INSERT INTO order_book_snapshots (
-		snapshot_id, instrument_uid, snapshot_at, depth, bids, asks, metadata
-	) VALUES ($1,$2,$3,$4,$5,$6,$7)`
+		snapshot_id, instrument_uid, snapshot_at, depth, bids, asks, sequence, sequence_from, metadata
+	) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)`
 
 func (r *Repository) AddOrderBookSnapshot(ctx context.Context, snapshot *domain.OrderBookSnapshot) error {
 	if snapshot == nil {
@@ -401,14 +1012,16 @@ func (r *Repository) AddOrderBookSnapshot(ctx context.Context, snapshot *domain.
 		snapshot.Depth,
 		bidsJSON,
 		asksJSON,
+		snapshot.Sequence,
+		snapshot.SequenceFrom,
 		meta,
 	)
 	return err
 }
 
-func (r *Repository) AddOrderBookSnapshots(ctx context.Context, snapshots []domain.OrderBookSnapshot) error {
+func (r *Repository) AddOrderBookSnapshots(ctx context.Context, snapshots []domain.OrderBookSnapshot, onConflict domain.ConflictPolicy) (int64, error) {
 	if len(snapshots) == 0 {
-		return nil
+		return 0, nil
 	}
 	rows := make([][]interface{}, 0, len(snapshots))
 	for i := range snapshots {
@@ -417,15 +1030,15 @@ func (r *Repository) AddOrderBookSnapshots(ctx context.Context, snapshots []doma
 		}
 		bidsJSON, err := marshalJSON(snapshots[i].Bids)
 		if err != nil {
-			return err
+			return 0, err
 		}
 		asksJSON, err := marshalJSON(snapshots[i].Asks)
 		if err != nil {
-			return err
+			return 0, err
 		}
 		meta, err := marshalJSON(snapshots[i].Metadata)
 		if err != nil {
-			return err
+			return 0, err
 		}
 		rows = append(rows, []interface{}{
 			snapshots[i].ID,
@@ -434,12 +1047,12 @@ func (r *Repository) AddOrderBookSnapshots(ctx context.Context, snapshots []doma
 			snapshots[i].Depth,
 			bidsJSON,
 			asksJSON,
+			snapshots[i].Sequence,
+			snapshots[i].SequenceFrom,
 			meta,
 		})
 	}
-	_, err := r.pool.CopyFrom(
-		ctx,
-		pgx.Identifier{"order_book_snapshots"},
+	return r.copyRows(ctx, "order_book_snapshots",
 		[]string{
 			"snapshot_id",
 			"instrument_uid",
@@ -447,50 +1060,105 @@ func (r *Repository) AddOrderBookSnapshots(ctx context.Context, snapshots []doma
 			"depth",
 			"bids",
 			"asks",
+			"sequence",
+			"sequence_from",
 			"metadata",
 		},
-		pgx.CopyFromRows(rows),
-	)
-	return err
+		rows, onConflict)
+}
+
+// DeleteOrderBookSnapshotsBefore removes an instrument's snapshots of every
+// depth taken before cutoff.
+func (r *Repository) DeleteOrderBookSnapshotsBefore(ctx context.Context, instrumentUID uuid.UUID, cutoff time.Time, chunkSize int) (int64, error) {
+	return r.deleteChunked(ctx, "order_book_snapshots", "snapshot_id", "snapshot_at", deleteBefore, chunkSize, instrumentUID, cutoff)
+}
+
+func (r *Repository) DeleteOrderBookSnapshotsBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, chunkSize int) (int64, error) {
+	return r.deleteChunked(ctx, "order_book_snapshots", "snapshot_id", "snapshot_at", deleteBetween, chunkSize, instrumentUID, from, to)
+}
+
+// GetOrderBookSnapshotsBetween returns a page of snapshots in the range. With
+// DepthMatchAtLeast, snapshots stored deeper than depth match too, and of several
+// snapshots taken at the same time the shallowest one is returned. Levels are
+// returned as stored.
+func (r *Repository) GetOrderBookSnapshotsBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, depth int32, match domain.DepthMatch, meta domain.MetadataFilter, page domain.Page) ([]domain.OrderBookSnapshot, error) {
+	var snapshots []domain.OrderBookSnapshot
+	err := r.EachOrderBookSnapshotBetween(ctx, instrumentUID, from, to, depth, match, meta, page, func(snapshot domain.OrderBookSnapshot) error {
+		snapshots = append(snapshots, snapshot)
+		return nil
+	})
+	return snapshots, err
 }
 
-func (r *Repository) GetOrderBookSnapshotsBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, depth int32) ([]domain.OrderBookSnapshot, error) {
+// EachOrderBookSnapshotBetween calls fn for every snapshot
+// GetOrderBookSnapshotsBetween would return, as rows arrive, stopping at the
+// first error. A zero page limit means no limit.
+func (r *Repository) EachOrderBookSnapshotBetween(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, depth int32, match domain.DepthMatch, meta domain.MetadataFilter, page domain.Page, fn func(domain.OrderBookSnapshot) error) error {
 	const query = `
-		SELECT snapshot_id, instrument_uid, snapshot_at, depth, bids, asks, metadata
+		SELECT DISTINCT ON (snapshot_at)
+		       snapshot_id, instrument_uid, snapshot_at, depth, bids, asks, sequence, sequence_from, metadata
 		FROM order_book_snapshots
 		WHERE instrument_uid=$1
-		  AND depth=$2
+		  AND (depth=$2 OR ($7 AND depth > $2))
 		  AND snapshot_at >= $3
 		  AND snapshot_at <= $4
-		ORDER BY snapshot_at ASC`
-	rows, err := r.pool.Query(ctx, query, instrumentUID, depth, from, to)
+		  AND ($8::jsonb IS NULL OR metadata @> $8::jsonb)
+		ORDER BY snapshot_at ASC, depth ASC
+		LIMIT $5 OFFSET $6`
+	metaArg, err := metadataContains(meta)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	rows, err := r.pool.Query(ctx, query, instrumentUID, depth, from, to, pageLimit(page), page.Offset, match == domain.DepthMatchAtLeast, metaArg)
+	if err != nil {
+		return err
 	}
 	defer rows.Close()
 
-	var snapshots []domain.OrderBookSnapshot
 	for rows.Next() {
 		snapshot, err := scanOrderBook(rows)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(snapshot); err != nil {
+			return err
 		}
-		snapshots = append(snapshots, snapshot)
 	}
-	return snapshots, rows.Err()
+	return rows.Err()
+}
+
+// GetLastOrderBookSnapshots returns the latest snapshots, matching depth the same
+// way as GetOrderBookSnapshotsBetween.
+// GetOrderBookByID returns the snapshot with the given id, or
+// domain.ErrOrderBookNotFound.
+func (r *Repository) GetOrderBookByID(ctx context.Context, id uuid.UUID) (*domain.OrderBookSnapshot, error) {
+	const query = `
+		SELECT snapshot_id, instrument_uid, snapshot_at, depth, bids, asks, sequence, sequence_from, metadata
+		FROM order_book_snapshots
+		WHERE snapshot_id=$1
+		LIMIT 1`
+	snapshot, err := scanOrderBook(r.pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrOrderBookNotFound
+		}
+		return nil, err
+	}
+	return &snapshot, nil
 }
 
-func (r *Repository) GetLastOrderBookSnapshots(ctx context.Context, instrumentUID uuid.UUID, depth int32, limit int) ([]domain.OrderBookSnapshot, error) {
+func (r *Repository) GetLastOrderBookSnapshots(ctx context.Context, instrumentUID uuid.UUID, depth int32, match domain.DepthMatch, limit int) ([]domain.OrderBookSnapshot, error) {
 	if limit <= 0 {
 		return nil, errors.New("limit must be positive")
 	}
 	const query = `
-		SELECT snapshot_id, instrument_uid, snapshot_at, depth, bids, asks, metadata
+		SELECT DISTINCT ON (snapshot_at)
+		       snapshot_id, instrument_uid, snapshot_at, depth, bids, asks, sequence, sequence_from, metadata
 		FROM order_book_snapshots
-		WHERE instrument_uid=$1 AND depth=$2
-		ORDER BY snapshot_at DESC
+		WHERE instrument_uid=$1 AND (depth=$2 OR ($4 AND depth > $2))
+		ORDER BY snapshot_at DESC, depth ASC
 		LIMIT $3`
-	rows, err := r.pool.Query(ctx, query, instrumentUID, depth, limit)
+	rows, err := r.pool.Query(ctx, query, instrumentUID, depth, limit, match == domain.DepthMatchAtLeast)
 	if err != nil {
 		return nil, err
 	}
@@ -507,6 +1175,179 @@ func (r *Repository) GetLastOrderBookSnapshots(ctx context.Context, instrumentUI
 	return snapshots, rows.Err()
 }
 
+// GetTopOfBook returns a page of best bid, best ask and spread per snapshot in the
+// range, read from the first level of each side without loading the full books.
+// Snapshots stored at several depths at the same instant are returned once.
+func (r *Repository) GetTopOfBook(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time, page domain.Page) ([]domain.TopOfBook, error) {
+	const query = `
+		SELECT snapshot_at, best_bid, best_ask, best_ask - best_bid
+		FROM (
+			SELECT DISTINCT ON (snapshot_at)
+			       snapshot_at,
+			       (bids->0->>'price')::double precision AS best_bid,
+			       (asks->0->>'price')::double precision AS best_ask
+			FROM order_book_snapshots
+			WHERE instrument_uid=$1 AND snapshot_at >= $2 AND snapshot_at <= $3
+			ORDER BY snapshot_at ASC, depth ASC
+			LIMIT $4 OFFSET $5
+		) tops
+		ORDER BY snapshot_at ASC`
+	rows, err := r.pool.Query(ctx, query, instrumentUID, from, to, page.Limit, page.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tops []domain.TopOfBook
+	for rows.Next() {
+		var top domain.TopOfBook
+		if err := rows.Scan(&top.SnapshotAt, &top.BestBid, &top.BestAsk, &top.Spread); err != nil {
+			return nil, err
+		}
+		tops = append(tops, top)
+	}
+	return tops, rows.Err()
+}
+
+// GetSpreadSeries returns the average best-ask minus best-bid spread and mid
+// price per bucket of bucketSeconds, aligned to the Unix epoch. Only snapshots
+// with both sides present are counted; empty buckets have nil averages.
+func (r *Repository) GetSpreadSeries(ctx context.Context, instrumentUID uuid.UUID, depth int32, from, to time.Time, bucketSeconds int64) ([]domain.SpreadBucket, error) {
+	if bucketSeconds <= 0 {
+		return nil, errors.New("bucket seconds must be positive")
+	}
+	const query = `
+		WITH buckets AS (
+			SELECT generate_series(
+				to_timestamp(floor(extract(epoch FROM $3::timestamptz) / $5::bigint) * $5::bigint),
+				$4::timestamptz,
+				make_interval(secs => $5::bigint)
+			) AS bucket_start
+		), tops AS (
+			SELECT to_timestamp(floor(extract(epoch FROM snapshot_at) / $5::bigint) * $5::bigint) AS bucket_start,
+			       (bids->0->>'price')::double precision AS best_bid,
+			       (asks->0->>'price')::double precision AS best_ask
+			FROM order_book_snapshots
+			WHERE instrument_uid=$1 AND depth=$2 AND snapshot_at >= $3 AND snapshot_at <= $4
+			  AND bids->0->>'price' IS NOT NULL AND asks->0->>'price' IS NOT NULL
+		), spreads AS (
+			SELECT bucket_start,
+			       COUNT(*) AS snapshot_count,
+			       AVG(best_ask - best_bid) AS avg_spread,
+			       AVG((best_ask + best_bid) / 2) AS avg_mid
+			FROM tops
+			GROUP BY 1
+		)
+		SELECT b.bucket_start, COALESCE(s.snapshot_count, 0), s.avg_spread, s.avg_mid
+		FROM buckets b
+		LEFT JOIN spreads s ON s.bucket_start = b.bucket_start
+		ORDER BY b.bucket_start ASC`
+	rows, err := r.pool.Query(ctx, query, instrumentUID, depth, from, to, bucketSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buckets []domain.SpreadBucket
+	for rows.Next() {
+		var bucket domain.SpreadBucket
+		if err := rows.Scan(&bucket.BucketStart, &bucket.SnapshotCount, &bucket.AvgSpread, &bucket.AvgMid); err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets, rows.Err()
+}
+
+// GetOrderBookImbalance returns a page of bid and ask quantity per snapshot in
+// the range, summed over the first levels levels of each side, or all of them
+// for zero, with their imbalance. Of snapshots stored at several depths at the
+// same instant only the deepest is counted.
+func (r *Repository) GetOrderBookImbalance(ctx context.Context, instrumentUID uuid.UUID, levels int32, from, to time.Time, page domain.Page) ([]domain.OrderBookImbalance, error) {
+	const query = `
+		SELECT snapshot_at, depth, bid_qty, ask_qty,
+		       CASE WHEN bid_qty + ask_qty > 0
+		            THEN (bid_qty - ask_qty)::double precision / (bid_qty + ask_qty)
+		       END
+		FROM (
+			SELECT DISTINCT ON (snapshot_at)
+			       snapshot_at, depth,
+			       (SELECT COALESCE(SUM((level->>'quantity')::bigint), 0)
+			        FROM jsonb_array_elements(bids) WITH ORDINALITY AS b(level, n)
+			        WHERE $4 = 0 OR n <= $4) AS bid_qty,
+			       (SELECT COALESCE(SUM((level->>'quantity')::bigint), 0)
+			        FROM jsonb_array_elements(asks) WITH ORDINALITY AS a(level, n)
+			        WHERE $4 = 0 OR n <= $4) AS ask_qty
+			FROM order_book_snapshots
+			WHERE instrument_uid=$1 AND snapshot_at >= $2 AND snapshot_at <= $3
+			ORDER BY snapshot_at ASC, depth DESC
+			LIMIT $5 OFFSET $6
+		) sums
+		ORDER BY snapshot_at ASC`
+	rows, err := r.pool.Query(ctx, query, instrumentUID, from, to, levels, page.Limit, page.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var imbalances []domain.OrderBookImbalance
+	for rows.Next() {
+		var imbalance domain.OrderBookImbalance
+		if err := rows.Scan(&imbalance.SnapshotAt, &imbalance.Depth, &imbalance.BidQuantity, &imbalance.AskQuantity, &imbalance.Imbalance); err != nil {
+			return nil, err
+		}
+		imbalances = append(imbalances, imbalance)
+	}
+	return imbalances, rows.Err()
+}
+
+// GetOrderBookDepths lists the distinct depths stored for an instrument, ascending.
+func (r *Repository) GetOrderBookDepths(ctx context.Context, instrumentUID uuid.UUID) ([]int32, error) {
+	const query = `
+		SELECT DISTINCT depth
+		FROM order_book_snapshots
+		WHERE instrument_uid=$1
+		ORDER BY depth`
+	rows, err := r.pool.Query(ctx, query, instrumentUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var depths []int32
+	for rows.Next() {
+		var depth int32
+		if err := rows.Scan(&depth); err != nil {
+			return nil, err
+		}
+		depths = append(depths, depth)
+	}
+	return depths, rows.Err()
+}
+
+// GetOrderBookSummary aggregates all order book snapshots of an instrument in
+// one pass.
+func (r *Repository) GetOrderBookSummary(ctx context.Context, instrumentUID uuid.UUID) (*domain.OrderBookSummary, error) {
+	const query = `
+		SELECT COUNT(*),
+		       MIN(snapshot_at),
+		       MAX(snapshot_at),
+		       COALESCE(array_agg(DISTINCT depth ORDER BY depth), '{}')
+		FROM order_book_snapshots
+		WHERE instrument_uid=$1`
+	summary := domain.OrderBookSummary{InstrumentUID: instrumentUID}
+	err := r.pool.QueryRow(ctx, query, instrumentUID).Scan(
+		&summary.SnapshotCount,
+		&summary.FirstSnapshot,
+		&summary.LastSnapshot,
+		&summary.Depths,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
 func scanOrderBook(row pgx.Row) (domain.OrderBookSnapshot, error) {
 	var (
 		bidsJSON []byte
@@ -521,6 +1362,8 @@ func scanOrderBook(row pgx.Row) (domain.OrderBookSnapshot, error) {
 		&snapshot.Depth,
 		&bidsJSON,
 		&asksJSON,
+		&snapshot.Sequence,
+		&snapshot.SequenceFrom,
 		&metaJSON,
 	)
 	if err != nil {
@@ -553,6 +1396,21 @@ func marshalJSON(v interface{}) ([]byte, error) {
 	return data, nil
 }
 
+// metadataContains turns a metadata filter into the argument of a
+// "metadata @> $n::jsonb" condition. It is sent as a bound parameter, never
+// spliced into the query, and is NULL for an empty filter so the condition
+// keeps every row.
+func metadataContains(meta domain.MetadataFilter) (interface{}, error) {
+	if len(meta) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
 func unmarshalMetadata(data []byte) (map[string]any, error) {
 	if len(data) == 0 {
 		return nil, nil
@@ -570,3 +1428,101 @@ func nullableInt64(value *int64) interface{} {
 	}
 	return *value
 }
+
+// copyRows bulk-loads rows into table with COPY and returns how many rows were
+// stored. A batch is stored as a whole or not at all. A single COPY is already
+// atomic; the transaction matters with ConflictSkip, where the rows are copied
+// into a temporary staging table first and moved over with
+// INSERT ... ON CONFLICT DO NOTHING, because COPY itself cannot skip rows that
+// violate a primary key or unique index.
+func (r *Repository) copyRows(ctx context.Context, table string, columns []string, rows [][]interface{}, onConflict domain.ConflictPolicy) (int64, error) {
+	return r.copyRowsWith(ctx, r.pool, table, columns, rows, onConflict)
+}
+
+func (r *Repository) copyRowsWith(ctx context.Context, db txBeginner, table string, columns []string, rows [][]interface{}, onConflict domain.ConflictPolicy) (int64, error) {
+	var inserted int64
+	err := pgx.BeginFunc(ctx, db, func(tx pgx.Tx) error {
+		if onConflict != domain.ConflictSkip {
+			copied, err := tx.CopyFrom(ctx, pgx.Identifier{table}, columns, pgx.CopyFromRows(rows))
+			if err != nil {
+				return err
+			}
+			inserted = copied
+			return nil
+		}
+		staging := "staging_" + table
+		create := fmt.Sprintf("CREATE TEMP TABLE %s (LIKE %s INCLUDING DEFAULTS) ON COMMIT DROP", staging, table)
+		if _, err := tx.Exec(ctx, create); err != nil {
+			return fmt.Errorf("create staging table: %w", err)
+		}
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{staging}, columns, pgx.CopyFromRows(rows)); err != nil {
+			return err
+		}
+		list := strings.Join(columns, ", ")
+		insert := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s ON CONFLICT DO NOTHING", table, list, list, staging)
+		tag, err := tx.Exec(ctx, insert)
+		if err != nil {
+			return err
+		}
+		inserted = tag.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return inserted, nil
+}
+
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// txBeginner starts the transaction of a batch write; *pgxpool.Pool is one.
+type txBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Time filters of deleteChunked, formatted with the time column. Bounds are
+// read from $3 on, after the instrument ($1) and the chunk size ($2).
+const (
+	deleteBefore  = "%[1]s < $3"
+	deleteBetween = "%[1]s >= $3 AND %[1]s <= $4"
+)
+
+// deleteChunked removes an instrument's rows of table that match timeFilter,
+// at most chunkSize per statement, until none are left. Every chunk commits on
+// its own, so locks are held briefly and a cancelled purge keeps the chunks
+// already removed. Rows are picked by idColumn and timeColumn, the primary key
+// of the hypertables.
+func (r *Repository) deleteChunked(ctx context.Context, table, idColumn, timeColumn, timeFilter string, chunkSize int, instrumentUID uuid.UUID, bounds ...interface{}) (int64, error) {
+	if chunkSize <= 0 {
+		return 0, fmt.Errorf("delete chunk size must be positive, got %d", chunkSize)
+	}
+	query := fmt.Sprintf(`
+		DELETE FROM %[1]s
+		WHERE (%[2]s, %[3]s) IN (
+			SELECT %[2]s, %[3]s FROM %[1]s
+			WHERE instrument_uid = $1 AND %[4]s
+			LIMIT $2)`, table, idColumn, timeColumn, fmt.Sprintf(timeFilter, timeColumn))
+	args := append([]interface{}{instrumentUID, chunkSize}, bounds...)
+
+	var deleted int64
+	for {
+		tag, err := r.pool.Exec(ctx, query, args...)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += tag.RowsAffected()
+		if tag.RowsAffected() < int64(chunkSize) {
+			return deleted, nil
+		}
+	}
+}
+
+// nullableString stores an empty string as NULL.
+func nullableString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,318 @@
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	domain "main/internal/domain/entity/marketdata"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// valuesRow is a pgx.Row holding the values of one result row.
+type valuesRow []any
+
+func (r valuesRow) Scan(dest ...any) error {
+	for i, d := range dest {
+		reflect.ValueOf(d).Elem().Set(reflect.ValueOf(r[i]))
+	}
+	return nil
+}
+
+// fakeDB keeps committed rows per table, in the column order of the
+// repository's statements, and answers the statements of its batch writes.
+// A transaction works on a copy of the rows that replaces them on Commit.
+type fakeDB struct {
+	tables map[string][][]any
+	// failExec fails the statements starting with it.
+	failExec string
+}
+
+func newFakeDB() *fakeDB {
+	return &fakeDB{tables: map[string][][]any{}}
+}
+
+func (db *fakeDB) Begin(context.Context) (pgx.Tx, error) {
+	tables := make(map[string][][]any, len(db.tables))
+	for name, rows := range db.tables {
+		copied := make([][]any, len(rows))
+		for i, row := range rows {
+			copied[i] = slices.Clone(row)
+		}
+		tables[name] = copied
+	}
+	return &fakeTx{db: db, tables: tables}, nil
+}
+
+func (db *fakeDB) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+	return upsertCandleRow(db.tables, sql, args)
+}
+
+// upsertCandleRow applies upsertCandleQuery: a row for the same instrument,
+// interval and period start gets the columns of its DO UPDATE SET list, any
+// other candle is inserted. It returns the candle_id of the stored row.
+func upsertCandleRow(tables map[string][][]any, sql string, args []any) pgx.Row {
+	for _, row := range tables["candles"] {
+		if row[1] == args[1] && row[2] == args[2] && row[3].(time.Time).Equal(args[3].(time.Time)) {
+			for _, column := range updatedColumns(sql) {
+				i := slices.Index(candleColumns, column)
+				row[i] = args[i]
+			}
+			return valuesRow{row[0]}
+		}
+	}
+	tables["candles"] = append(tables["candles"], slices.Clone(args))
+	return valuesRow{args[0]}
+}
+
+// updatedColumns returns the columns named in the DO UPDATE SET list of sql.
+func updatedColumns(sql string) []string {
+	_, set, ok := strings.Cut(sql, "DO UPDATE SET")
+	if !ok {
+		return nil
+	}
+	set, _, _ = strings.Cut(set, "RETURNING")
+	var columns []string
+	for _, assignment := range strings.Split(set, ",") {
+		column, _, _ := strings.Cut(assignment, "=")
+		columns = append(columns, strings.TrimSpace(column))
+	}
+	return columns
+}
+
+type fakeTx struct {
+	pgx.Tx
+	db     *fakeDB
+	tables map[string][][]any
+	temp   []string // dropped on commit
+	closed bool
+}
+
+func (tx *fakeTx) Commit(context.Context) error {
+	if tx.closed {
+		return pgx.ErrTxClosed
+	}
+	for _, name := range tx.temp {
+		delete(tx.tables, name)
+	}
+	tx.db.tables = tx.tables
+	tx.closed = true
+	return nil
+}
+
+func (tx *fakeTx) Rollback(context.Context) error {
+	if tx.closed {
+		return pgx.ErrTxClosed
+	}
+	tx.closed = true
+	return nil
+}
+
+func (tx *fakeTx) SendBatch(_ context.Context, batch *pgx.Batch) pgx.BatchResults {
+	return &fakeBatchResults{tx: tx, queued: batch.QueuedQueries}
+}
+
+// CopyFrom appends the rows as they are read, so a bad row leaves the rows
+// before it in the transaction, and fails like pgx on a row of the wrong width.
+func (tx *fakeTx) CopyFrom(_ context.Context, table pgx.Identifier, columns []string, source pgx.CopyFromSource) (int64, error) {
+	name := table[len(table)-1]
+	var copied int64
+	for source.Next() {
+		values, err := source.Values()
+		if err != nil {
+			return 0, err
+		}
+		if len(values) != len(columns) {
+			return 0, fmt.Errorf("expected %d values, got %d values", len(columns), len(values))
+		}
+		tx.tables[name] = append(tx.tables[name], values)
+		copied++
+	}
+	return copied, source.Err()
+}
+
+// Exec answers the staging statements of copyRows.
+func (tx *fakeTx) Exec(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	if tx.db.failExec != "" && strings.HasPrefix(sql, tx.db.failExec) {
+		return pgconn.CommandTag{}, errors.New("exec failed")
+	}
+	fields := strings.Fields(sql)
+	switch {
+	case strings.HasPrefix(sql, "CREATE TEMP TABLE"):
+		tx.tables[fields[3]] = nil
+		tx.temp = append(tx.temp, fields[3])
+		return pgconn.NewCommandTag("CREATE TABLE"), nil
+	case strings.HasPrefix(sql, "INSERT INTO") && strings.HasSuffix(sql, "ON CONFLICT DO NOTHING"):
+		table, staging := fields[2], fields[slices.Index(fields, "FROM")+1]
+		var inserted int
+		for _, row := range tx.tables[staging] {
+			if !slices.ContainsFunc(tx.tables[table], func(stored []any) bool { return stored[0] == row[0] }) {
+				tx.tables[table] = append(tx.tables[table], row)
+				inserted++
+			}
+		}
+		return pgconn.NewCommandTag(fmt.Sprintf("INSERT 0 %d", inserted)), nil
+	}
+	return pgconn.CommandTag{}, fmt.Errorf("unexpected statement %q", sql)
+}
+
+type fakeBatchResults struct {
+	pgx.BatchResults
+	tx     *fakeTx
+	queued []*pgx.QueuedQuery
+}
+
+func (r *fakeBatchResults) QueryRow() pgx.Row {
+	query := r.queued[0]
+	r.queued = r.queued[1:]
+	return upsertCandleRow(r.tx.tables, query.SQL, query.Arguments)
+}
+
+func (r *fakeBatchResults) Close() error { return nil }
+
+// storedCandle returns the stored row of the candle for period, by column.
+func (db *fakeDB) storedCandle(t *testing.T, instrumentUID uuid.UUID, period time.Time) map[string]any {
+	t.Helper()
+	for _, row := range db.tables["candles"] {
+		if row[1] == instrumentUID && row[3].(time.Time).Equal(period) {
+			stored := make(map[string]any, len(row))
+			for i, column := range candleColumns {
+				stored[column] = row[i]
+			}
+			return stored
+		}
+	}
+	t.Fatalf("no candle stored for %s", period)
+	return nil
+}
+
+func checkOHLCV(t *testing.T, stored map[string]any, want domain.Candle) {
+	t.Helper()
+	got := []any{stored["open"], stored["high"], stored["low"], stored["close"], stored["volume_lots"]}
+	wanted := []any{want.Open, want.High, want.Low, want.Close, want.VolumeLots}
+	if !reflect.DeepEqual(got, wanted) {
+		t.Fatalf("stored OHLCV = %v, want %v", got, wanted)
+	}
+}
+
+func TestUpsertCandleReplacesOHLCVAndKeepsID(t *testing.T) {
+	db := newFakeDB()
+	repo := &Repository{}
+	ctx := context.Background()
+	instrumentUID := uuid.New()
+	period := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	first := domain.Candle{InstrumentUID: instrumentUID, IntervalSeconds: 60, PeriodStart: period, Open: 100, High: 101, Low: 99, Close: 100.5, VolumeLots: 10}
+	if err := repo.upsertCandleWith(ctx, db, &first); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	update := domain.Candle{InstrumentUID: instrumentUID, IntervalSeconds: 60, PeriodStart: period, Open: 100, High: 103, Low: 98, Close: 102, VolumeLots: 25}
+	if err := repo.upsertCandleWith(ctx, db, &update); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	if n := len(db.tables["candles"]); n != 1 {
+		t.Fatalf("stored %d candles, want 1", n)
+	}
+	if update.ID != first.ID {
+		t.Fatalf("upsert returned candle_id %s, want the original %s", update.ID, first.ID)
+	}
+	stored := db.storedCandle(t, instrumentUID, period)
+	if stored["candle_id"] != first.ID {
+		t.Fatalf("stored candle_id = %v, want the original %s", stored["candle_id"], first.ID)
+	}
+	checkOHLCV(t, stored, update)
+}
+
+func TestUpsertCandlesLastInBatchWins(t *testing.T) {
+	db := newFakeDB()
+	repo := &Repository{}
+	ctx := context.Background()
+	instrumentUID := uuid.New()
+	period := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	next := period.Add(time.Minute)
+
+	stored := domain.Candle{InstrumentUID: instrumentUID, IntervalSeconds: 60, PeriodStart: period, Open: 100, High: 101, Low: 99, Close: 100.5, VolumeLots: 10}
+	if err := repo.upsertCandleWith(ctx, db, &stored); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	batch := []domain.Candle{
+		{InstrumentUID: instrumentUID, IntervalSeconds: 60, PeriodStart: period, Open: 100, High: 102, Low: 99, Close: 101, VolumeLots: 15},
+		{InstrumentUID: instrumentUID, IntervalSeconds: 60, PeriodStart: next, Open: 101, High: 101, Low: 100, Close: 100, VolumeLots: 3},
+		{InstrumentUID: instrumentUID, IntervalSeconds: 60, PeriodStart: period, Open: 100, High: 104, Low: 97, Close: 103, VolumeLots: 30},
+	}
+	n, err := repo.upsertCandlesWith(ctx, db, batch)
+	if err != nil {
+		t.Fatalf("upsert batch: %v", err)
+	}
+	if n != int64(len(batch)) {
+		t.Fatalf("stored count = %d, want %d", n, len(batch))
+	}
+	if rows := len(db.tables["candles"]); rows != 2 {
+		t.Fatalf("stored %d candles, want 2", rows)
+	}
+	if batch[0].ID != stored.ID || batch[2].ID != stored.ID {
+		t.Fatalf("batch candle_ids = %s, %s, want the original %s", batch[0].ID, batch[2].ID, stored.ID)
+	}
+	replaced := db.storedCandle(t, instrumentUID, period)
+	if replaced["candle_id"] != stored.ID {
+		t.Fatalf("stored candle_id = %v, want the original %s", replaced["candle_id"], stored.ID)
+	}
+	checkOHLCV(t, replaced, batch[2])
+	checkOHLCV(t, db.storedCandle(t, instrumentUID, next), batch[1])
+}
+
+func TestCopyRowsAbortsWholeBatch(t *testing.T) {
+	columns := []string{"trade_id", "price"}
+	existing := []any{uuid.New(), 100.0}
+	good := func() []any { return []any{uuid.New(), 101.0} }
+	tests := []struct {
+		name       string
+		onConflict domain.ConflictPolicy
+		rows       [][]any
+		failExec   string
+		wantRows   int
+		wantErr    bool
+	}{
+		{"stored", domain.ConflictFail, [][]any{good(), good()}, "", 3, false},
+		{"skip stores new rows", domain.ConflictSkip, [][]any{good(), existing, good()}, "", 3, false},
+		{"bad row", domain.ConflictFail, [][]any{good(), {uuid.New()}, good()}, "", 1, true},
+		{"bad row when skipping", domain.ConflictSkip, [][]any{good(), {uuid.New()}, good()}, "", 1, true},
+		{"move from staging fails", domain.ConflictSkip, [][]any{good(), good()}, "INSERT INTO", 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newFakeDB()
+			db.tables["trades"] = [][]any{slices.Clone(existing)}
+			db.failExec = tt.failExec
+			repo := &Repository{}
+
+			n, err := repo.copyRowsWith(context.Background(), db, "trades", columns, tt.rows, tt.onConflict)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if rows := len(db.tables["trades"]); rows != tt.wantRows {
+				t.Fatalf("table holds %d rows, want %d", rows, tt.wantRows)
+			}
+			if _, ok := db.tables["staging_trades"]; ok {
+				t.Fatal("staging table outlived the transaction")
+			}
+			want := int64(tt.wantRows - 1)
+			if tt.wantErr {
+				want = 0
+			}
+			if n != want {
+				t.Fatalf("stored count = %d, want %d", n, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"main/internal/config"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NewPool opens a pgx pool for cfg.DSN with the configured pool limits. Zero
+// limits keep the pgx defaults. The PG_SSL_* settings, when set, replace the
+// DSN's TLS setup. Queries are traced once tracing is set up.
+func NewPool(ctx context.Context, cfg config.PostgresConfig) (*pgxpool.Pool, error) {
+	poolCfg, err := pgxpool.ParseConfig(cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("parse pgx config: %w", err)
+	}
+	if cfg.MaxConns > 0 {
+		poolCfg.MaxConns = cfg.MaxConns
+	}
+	if cfg.MinConns > 0 {
+		poolCfg.MinConns = cfg.MinConns
+	}
+	if cfg.MaxConnLifetime > 0 {
+		poolCfg.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		poolCfg.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+	if err := applyTLS(&poolCfg.ConnConfig.Config, cfg); err != nil {
+		return nil, err
+	}
+	poolCfg.ConnConfig.Tracer = newQueryTracer()
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
+	if err != nil {
+		return nil, fmt.Errorf("create pgx pool: %w", err)
+	}
+	return pool, nil
+}
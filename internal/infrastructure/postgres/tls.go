@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"main/internal/config"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// applyTLS replaces the TLS setup pgx derived from the DSN with the one from
+// the PG_SSL_* settings. It leaves the DSN's setup alone when none is set.
+// Every host of the DSN gets its own tls.Config, and the plaintext fallbacks
+// pgx adds for sslmode=prefer are dropped, so a configured mode is never
+// silently downgraded.
+func applyTLS(connCfg *pgconn.Config, cfg config.PostgresConfig) error {
+	if !cfg.TLSConfigured() {
+		return nil
+	}
+	base, err := baseTLSConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("configure postgres tls: %w", err)
+	}
+	forHost := func(host string) *tls.Config {
+		// No TLS for Unix sockets, like pgx itself.
+		if base == nil || strings.HasPrefix(host, "/") {
+			return nil
+		}
+		tlsCfg := base.Clone()
+		if !tlsCfg.InsecureSkipVerify {
+			tlsCfg.ServerName = host
+		}
+		return tlsCfg
+	}
+
+	connCfg.TLSConfig = forHost(connCfg.Host)
+	seen := map[string]bool{net.JoinHostPort(connCfg.Host, strconv.Itoa(int(connCfg.Port))): true}
+	var fallbacks []*pgconn.FallbackConfig
+	for _, fallback := range connCfg.Fallbacks {
+		key := net.JoinHostPort(fallback.Host, strconv.Itoa(int(fallback.Port)))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		fallbacks = append(fallbacks, &pgconn.FallbackConfig{
+			Host:      fallback.Host,
+			Port:      fallback.Port,
+			TLSConfig: forHost(fallback.Host),
+		})
+	}
+	connCfg.Fallbacks = fallbacks
+	return nil
+}
+
+// baseTLSConfig builds the host-independent part of the TLS setup, or nil for
+// sslmode disable. Modes follow libpq: require encrypts without verifying,
+// verify-ca checks the chain against the root certificates, and verify-full
+// also checks the host name. Without PG_SSL_ROOT_CERT the system roots are used.
+func baseTLSConfig(cfg config.PostgresConfig) (*tls.Config, error) {
+	mode := cfg.EffectiveSSLMode()
+	if mode == config.PGSSLModeDisable {
+		return nil, nil
+	}
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.SSLRootCert != "" {
+		pem, err := os.ReadFile(cfg.SSLRootCert)
+		if err != nil {
+			return nil, fmt.Errorf("read PG_SSL_ROOT_CERT: %w", err)
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, errors.New("PG_SSL_ROOT_CERT holds no PEM certificate")
+		}
+		tlsCfg.RootCAs = roots
+	}
+	if cfg.SSLCert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.SSLCert, cfg.SSLKey)
+		if err != nil {
+			return nil, fmt.Errorf("load PG_SSL_CERT and PG_SSL_KEY: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	switch mode {
+	case config.PGSSLModeRequire:
+		tlsCfg.InsecureSkipVerify = true
+	case config.PGSSLModeVerifyCA:
+		// crypto/tls verifies the chain only together with the host name, so
+		// the chain is checked by hand.
+		tlsCfg.InsecureSkipVerify = true
+		tlsCfg.VerifyPeerCertificate = verifyChain(tlsCfg.RootCAs)
+	}
+	return tlsCfg, nil
+}
+
+// verifyChain checks the server certificate against roots without looking at
+// the host name; nil roots means the system pool.
+func verifyChain(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("server sent no certificate")
+		}
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return fmt.Errorf("parse server certificate: %w", err)
+			}
+			certs[i] = cert
+		}
+		opts := x509.VerifyOptions{Roots: roots, Intermediates: x509.NewCertPool()}
+		for _, cert := range certs[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(opts)
+		return err
+	}
+}
@@ -0,0 +1,277 @@
+package grpc
+
+import (
+	"fmt"
+	"time"
+
+	aggregatorv1 "main/api/proto/aggregator/v1"
+	domaininstruments "main/internal/domain/entity/instruments"
+	domainmarketdata "main/internal/domain/entity/marketdata"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var tradeSides = map[aggregatorv1.TradeSide]domainmarketdata.TradeSide{
+	aggregatorv1.TradeSide_TRADE_SIDE_BUY:     domainmarketdata.TradeSideBuy,
+	aggregatorv1.TradeSide_TRADE_SIDE_SELL:    domainmarketdata.TradeSideSell,
+	aggregatorv1.TradeSide_TRADE_SIDE_UNKNOWN: domainmarketdata.TradeSideUnknown,
+}
+
+// parseUUID reads a UUID field; an empty optional field is uuid.Nil.
+func parseUUID(field, value string, required bool) (uuid.UUID, error) {
+	if value == "" && !required {
+		return uuid.Nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, invalidArgument("%s must be a UUID, got %q", field, value)
+	}
+	return id, nil
+}
+
+// parseTimeRange reads the bounds of a range query, which are both required.
+func parseTimeRange(r *aggregatorv1.TimeRange) (time.Time, time.Time, error) {
+	if r.GetFrom() == nil || r.GetTo() == nil {
+		return time.Time{}, time.Time{}, invalidArgument("range.from and range.to are required")
+	}
+	if err := r.GetFrom().CheckValid(); err != nil {
+		return time.Time{}, time.Time{}, invalidArgument("range.from: %v", err)
+	}
+	if err := r.GetTo().CheckValid(); err != nil {
+		return time.Time{}, time.Time{}, invalidArgument("range.to: %v", err)
+	}
+	return r.GetFrom().AsTime(), r.GetTo().AsTime(), nil
+}
+
+func pageFromProto(p *aggregatorv1.Page) domainmarketdata.Page {
+	return domainmarketdata.Page{Limit: int(p.GetLimit()), Offset: int(p.GetOffset())}
+}
+
+// metadataFilterFromProto rejects an empty key like the HTTP meta parameter
+// does; an empty map does not filter.
+func metadataFilterFromProto(meta map[string]string) (domainmarketdata.MetadataFilter, error) {
+	if len(meta) == 0 {
+		return nil, nil
+	}
+	for key := range meta {
+		if key == "" {
+			return nil, invalidArgument("meta keys must not be empty")
+		}
+	}
+	return domainmarketdata.MetadataFilter(meta), nil
+}
+
+func depthMatchFromProto(match aggregatorv1.DepthMatch) domainmarketdata.DepthMatch {
+	if match == aggregatorv1.DepthMatch_DEPTH_MATCH_EXACT {
+		return domainmarketdata.DepthMatchExact
+	}
+	return domainmarketdata.DepthMatchAtLeast
+}
+
+func optionalTime(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// timeFromProto leaves a missing timestamp as the zero time, which the service
+// validation rejects where the time is required.
+func timeFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+func metadataFromProto(s *structpb.Struct) map[string]any {
+	if s == nil {
+		return nil
+	}
+	return s.AsMap()
+}
+
+func metadataToProto(metadata map[string]any) (*structpb.Struct, error) {
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+	s, err := structpb.NewStruct(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("encode metadata: %w", err)
+	}
+	return s, nil
+}
+
+func tradeFromProto(t *aggregatorv1.Trade) (domainmarketdata.Trade, error) {
+	id, err := parseUUID("id", t.GetId(), false)
+	if err != nil {
+		return domainmarketdata.Trade{}, err
+	}
+	instrumentUID, err := parseUUID("instrument_uid", t.GetInstrumentUid(), true)
+	if err != nil {
+		return domainmarketdata.Trade{}, err
+	}
+	return domainmarketdata.Trade{
+		ID:            id,
+		InstrumentUID: instrumentUID,
+		Side:          tradeSides[t.GetSide()],
+		Price:         t.GetPrice(),
+		QuantityLots:  t.GetQuantityLots(),
+		TradedAt:      timeFromProto(t.GetTradedAt()),
+		Venue:         t.GetVenue(),
+		Metadata:      metadataFromProto(t.GetMetadata()),
+	}, nil
+}
+
+func tradeToProto(t domainmarketdata.Trade) (*aggregatorv1.Trade, error) {
+	metadata, err := metadataToProto(t.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	side := aggregatorv1.TradeSide_TRADE_SIDE_UNSPECIFIED
+	for candidate, value := range tradeSides {
+		if value == t.Side {
+			side = candidate
+		}
+	}
+	return &aggregatorv1.Trade{
+		Id:            t.ID.String(),
+		InstrumentUid: t.InstrumentUID.String(),
+		Side:          side,
+		Price:         t.Price,
+		QuantityLots:  t.QuantityLots,
+		TradedAt:      timestamppb.New(t.TradedAt),
+		Venue:         t.Venue,
+		Metadata:      metadata,
+	}, nil
+}
+
+func candleFromProto(c *aggregatorv1.Candle) (domainmarketdata.Candle, error) {
+	id, err := parseUUID("id", c.GetId(), false)
+	if err != nil {
+		return domainmarketdata.Candle{}, err
+	}
+	instrumentUID, err := parseUUID("instrument_uid", c.GetInstrumentUid(), true)
+	if err != nil {
+		return domainmarketdata.Candle{}, err
+	}
+	return domainmarketdata.Candle{
+		ID:              id,
+		InstrumentUID:   instrumentUID,
+		IntervalSeconds: c.GetIntervalSeconds(),
+		PeriodStart:     timeFromProto(c.GetPeriodStart()),
+		Open:            c.GetOpen(),
+		High:            c.GetHigh(),
+		Low:             c.GetLow(),
+		Close:           c.GetClose(),
+		VolumeLots:      c.GetVolumeLots(),
+		VolumeBuyLots:   c.VolumeBuyLots,
+		VolumeSellLots:  c.VolumeSellLots,
+		LastTradeAt:     optionalTime(c.GetLastTradeAt()),
+		Metadata:        metadataFromProto(c.GetMetadata()),
+	}, nil
+}
+
+func candleToProto(c domainmarketdata.Candle) (*aggregatorv1.Candle, error) {
+	metadata, err := metadataToProto(c.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	return &aggregatorv1.Candle{
+		Id:              c.ID.String(),
+		InstrumentUid:   c.InstrumentUID.String(),
+		IntervalSeconds: c.IntervalSeconds,
+		PeriodStart:     timestamppb.New(c.PeriodStart),
+		Open:            c.Open,
+		High:            c.High,
+		Low:             c.Low,
+		Close:           c.Close,
+		VolumeLots:      c.VolumeLots,
+		VolumeBuyLots:   c.VolumeBuyLots,
+		VolumeSellLots:  c.VolumeSellLots,
+		LastTradeAt:     optionalTimestamp(c.LastTradeAt),
+		Metadata:        metadata,
+	}, nil
+}
+
+func orderBookFromProto(s *aggregatorv1.OrderBookSnapshot) (domainmarketdata.OrderBookSnapshot, error) {
+	id, err := parseUUID("id", s.GetId(), false)
+	if err != nil {
+		return domainmarketdata.OrderBookSnapshot{}, err
+	}
+	instrumentUID, err := parseUUID("instrument_uid", s.GetInstrumentUid(), true)
+	if err != nil {
+		return domainmarketdata.OrderBookSnapshot{}, err
+	}
+	return domainmarketdata.OrderBookSnapshot{
+		ID:            id,
+		InstrumentUID: instrumentUID,
+		SnapshotAt:    timeFromProto(s.GetSnapshotAt()),
+		Depth:         s.GetDepth(),
+		Bids:          levelsFromProto(s.GetBids()),
+		Asks:          levelsFromProto(s.GetAsks()),
+		Sequence:      s.Sequence,
+		Metadata:      metadataFromProto(s.GetMetadata()),
+	}, nil
+}
+
+func orderBookToProto(s domainmarketdata.OrderBookSnapshot) (*aggregatorv1.OrderBookSnapshot, error) {
+	metadata, err := metadataToProto(s.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	return &aggregatorv1.OrderBookSnapshot{
+		Id:            s.ID.String(),
+		InstrumentUid: s.InstrumentUID.String(),
+		SnapshotAt:    timestamppb.New(s.SnapshotAt),
+		Depth:         s.Depth,
+		Bids:          levelsToProto(s.Bids),
+		Asks:          levelsToProto(s.Asks),
+		Sequence:      s.Sequence,
+		Metadata:      metadata,
+	}, nil
+}
+
+func levelsFromProto(levels []*aggregatorv1.OrderBookLevel) []domainmarketdata.OrderBookLevel {
+	out := make([]domainmarketdata.OrderBookLevel, len(levels))
+	for i, level := range levels {
+		out[i] = domainmarketdata.OrderBookLevel{Price: level.GetPrice(), Quantity: level.GetQuantity()}
+	}
+	return out
+}
+
+func levelsToProto(levels []domainmarketdata.OrderBookLevel) []*aggregatorv1.OrderBookLevel {
+	out := make([]*aggregatorv1.OrderBookLevel, len(levels))
+	for i, level := range levels {
+		out[i] = &aggregatorv1.OrderBookLevel{Price: level.Price, Quantity: level.Quantity}
+	}
+	return out
+}
+
+func instrumentToProto(inst domaininstruments.Instrument) *aggregatorv1.Instrument {
+	out := &aggregatorv1.Instrument{
+		Uid:       inst.UID.String(),
+		Figi:      inst.Figi,
+		Ticker:    inst.Ticker,
+		Lot:       inst.Lot,
+		ClassCode: inst.ClassCode,
+		LogoUrl:   inst.LogoURL,
+		CreatedAt: timestamppb.New(inst.CreatedAt),
+		UpdatedAt: timestamppb.New(inst.UpdatedAt),
+		DeletedAt: optionalTimestamp(inst.DeletedAt),
+	}
+	if inst.BrandUID != uuid.Nil {
+		out.BrandUid = inst.BrandUID.String()
+	}
+	return out
+}
@@ -0,0 +1,146 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	appinstruments "main/internal/application/service/instruments"
+	appmarketdata "main/internal/application/service/marketdata"
+	domaininstruments "main/internal/domain/entity/instruments"
+	domainmarketdata "main/internal/domain/entity/marketdata"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// invalidArgumentErrors are service-level validation errors that are the
+// caller's fault; the HTTP API answers them with 400.
+var invalidArgumentErrors = []error{
+	appinstruments.ErrInvalidListLimit,
+	appinstruments.ErrInvalidListOffset,
+	appinstruments.ErrInvalidSearchQuery,
+	appinstruments.ErrInvalidSearchLimit,
+	appinstruments.ErrInvalidInstrumentType,
+	appinstruments.ErrInvalidCountryCode,
+	appinstruments.ErrInvalidPoints,
+	appinstruments.ErrMissingTicker,
+	appmarketdata.ErrNilTrade,
+	appmarketdata.ErrNilCandle,
+	appmarketdata.ErrNilOrderBook,
+	appmarketdata.ErrInvalidLimit,
+	appmarketdata.ErrLimitTooLarge,
+	appmarketdata.ErrInvalidOffset,
+	appmarketdata.ErrInvalidInterval,
+	appmarketdata.ErrInvalidDepth,
+	appmarketdata.ErrInvalidDepthMatch,
+	appmarketdata.ErrRangeTooWide,
+	appmarketdata.ErrMetadataLimit,
+	appmarketdata.ErrInvalidTrade,
+	appmarketdata.ErrInvalidOrderBook,
+	appmarketdata.ErrUnknownInstrument,
+}
+
+// notFoundErrors mean the requested data does not exist; the HTTP API answers
+// them with 404.
+var notFoundErrors = []error{
+	domaininstruments.ErrInstrumentNotFound,
+	appinstruments.ErrNotPriceable,
+	appmarketdata.ErrDepthUnavailable,
+	domainmarketdata.ErrTradeNotFound,
+	domainmarketdata.ErrCandleNotFound,
+	domainmarketdata.ErrOrderBookNotFound,
+}
+
+// Postgres error codes that mean the database is refusing work for now, as in
+// the HTTP API's 503 backpressure responses.
+const (
+	pgLockNotAvailable   = "55P03"
+	pgTooManyConnections = "53300"
+)
+
+// statusError converts an error returned by a service call into a gRPC status
+// error. Every method maps service errors through it. An internal error, one
+// without a code of its own or raised by Postgres, is wrapped in internalError
+// so logCalls can keep its message from the client.
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	code := errorCode(err)
+	var pgErr *pgconn.PgError
+	if code == codes.Internal || errors.As(err, &pgErr) {
+		return &internalError{code: code, err: err}
+	}
+	return status.Error(code, err.Error())
+}
+
+// internalError is an error whose message may carry SQL, table names or
+// addresses. Its status only says what went wrong in general; logCalls logs
+// the message and, with HTTP_DEBUG_ERRORS, sends it to the client.
+type internalError struct {
+	code codes.Code
+	err  error
+}
+
+func (e *internalError) Error() string { return e.err.Error() }
+
+func (e *internalError) Unwrap() error { return e.err }
+
+func (e *internalError) GRPCStatus() *status.Status {
+	return status.New(e.code, genericMessage(e.code))
+}
+
+// genericMessage is the message an internal error is sent with.
+func genericMessage(code codes.Code) string {
+	if code == codes.Unavailable {
+		return "service unavailable"
+	}
+	return "internal error"
+}
+
+// publicError is the status sent for an internal error of the call with the
+// request ID id.
+func publicError(internal *internalError, id string, debug bool) error {
+	message := genericMessage(internal.code)
+	if debug {
+		message = internal.err.Error()
+	}
+	return status.Errorf(internal.code, "%s (request_id %s)", message, id)
+}
+
+func errorCode(err error) codes.Code {
+	for _, target := range invalidArgumentErrors {
+		if errors.Is(err, target) {
+			return codes.InvalidArgument
+		}
+	}
+	for _, target := range notFoundErrors {
+		if errors.Is(err, target) {
+			return codes.NotFound
+		}
+	}
+	if errors.Is(err, appinstruments.ErrAmbiguousTicker) {
+		return codes.FailedPrecondition
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && (pgErr.Code == pgLockNotAvailable || pgErr.Code == pgTooManyConnections) {
+		return codes.Unavailable
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
+
+// invalidArgument reports a malformed request field.
+func invalidArgument(format string, args ...any) error {
+	return status.Errorf(codes.InvalidArgument, format, args...)
+}
@@ -0,0 +1,68 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	appmarketdata "main/internal/application/service/marketdata"
+	"main/internal/config"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestLogCallsHidesInternalErrors(t *testing.T) {
+	const secret = `relation "trades_secret" does not exist`
+	tests := []struct {
+		name      string
+		err       error
+		debug     bool
+		wantCode  codes.Code
+		wantShown bool
+	}{
+		{"database error", fmt.Errorf("get trades: %w", &pgconn.PgError{Code: "42P01", Message: secret}), false, codes.Internal, false},
+		{"database backpressure", &pgconn.PgError{Code: pgLockNotAvailable, Message: secret}, false, codes.Unavailable, false},
+		{"unknown error", errors.New(secret), false, codes.Internal, false},
+		{"debug errors", errors.New(secret), true, codes.Internal, true},
+		{"caller error", fmt.Errorf("%w: "+secret, appmarketdata.ErrInvalidLimit), false, codes.InvalidArgument, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			logger := logrus.New()
+			logger.SetOutput(&logs)
+			s := &Server{logger: logger}
+			var cfg config.Config
+			cfg.HTTP.DebugErrors = tt.debug
+			s.SetConfig(cfg)
+
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestIDMetadata, "req-1"))
+			info := &grpc.UnaryServerInfo{FullMethod: "/aggregator.v1.MarketDataService/GetTrades"}
+			_, err := s.logCalls(ctx, nil, info, func(context.Context, any) (any, error) {
+				return nil, statusError(tt.err)
+			})
+
+			st := status.Convert(err)
+			if st.Code() != tt.wantCode {
+				t.Fatalf("code = %s, want %s", st.Code(), tt.wantCode)
+			}
+			if shown := strings.Contains(st.Message(), secret); shown != tt.wantShown {
+				t.Fatalf("message %q shows the error = %v, want %v", st.Message(), shown, tt.wantShown)
+			}
+			if !tt.wantShown && !strings.Contains(st.Message(), "req-1") {
+				t.Fatalf("message %q lacks the request ID", st.Message())
+			}
+			if tt.wantCode != codes.InvalidArgument && !strings.Contains(logs.String(), "trades_secret") {
+				t.Fatalf("log lacks the full error: %s", logs.String())
+			}
+		})
+	}
+}
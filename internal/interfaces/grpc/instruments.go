@@ -0,0 +1,52 @@
+package grpc
+
+import (
+	"context"
+
+	aggregatorv1 "main/api/proto/aggregator/v1"
+	appinstruments "main/internal/application/service/instruments"
+	domaininstruments "main/internal/domain/entity/instruments"
+)
+
+type instrumentServer struct {
+	aggregatorv1.UnimplementedInstrumentServiceServer
+	instruments *appinstruments.Service
+}
+
+func (s *instrumentServer) GetInstrument(ctx context.Context, req *aggregatorv1.GetInstrumentRequest) (*aggregatorv1.Instrument, error) {
+	uid, err := parseUUID("uid", req.GetUid(), true)
+	if err != nil {
+		return nil, err
+	}
+	inst, err := s.instruments.GetInstrument(ctx, uid, req.GetIncludeDeleted())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return instrumentToProto(*inst), nil
+}
+
+func (s *instrumentServer) ListInstruments(ctx context.Context, req *aggregatorv1.ListInstrumentsRequest) (*aggregatorv1.ListInstrumentsResponse, error) {
+	sectorUID, err := parseUUID("sector_uid", req.GetSectorUid(), false)
+	if err != nil {
+		return nil, err
+	}
+	instruments, err := s.instruments.ListInstruments(ctx, domaininstruments.InstrumentFilter{
+		Ticker:         req.GetTicker(),
+		ClassCode:      req.GetClassCode(),
+		FigiPrefix:     req.GetFigiPrefix(),
+		Type:           domaininstruments.InstrumentType(req.GetType()),
+		SectorUID:      sectorUID,
+		CountryCode:    req.GetCountryCode(),
+		IncludeDeleted: req.GetIncludeDeleted(),
+		Limit:          int(req.GetLimit()),
+		Offset:         int(req.GetOffset()),
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+	out := make([]*aggregatorv1.ListedInstrument, len(instruments))
+	for i, inst := range instruments {
+		out[i] = &aggregatorv1.ListedInstrument{Instrument: instrumentToProto(inst.Instrument), Type: string(inst.Type)}
+	}
+	return &aggregatorv1.ListInstrumentsResponse{Instruments: out}, nil
+}
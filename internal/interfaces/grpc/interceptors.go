@@ -0,0 +1,130 @@
+package grpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	aggregatorv1 "main/api/proto/aggregator/v1"
+	appmarketdata "main/internal/application/service/marketdata"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// apiKeyMetadata and requestIDMetadata are the gRPC counterparts of the
+	// X-API-Key and X-Request-ID headers.
+	apiKeyMetadata    = "x-api-key"
+	requestIDMetadata = "x-request-id"
+)
+
+// writeMethods need an API key whenever keys are configured; every other
+// method is a read.
+var writeMethods = map[string]bool{
+	aggregatorv1.MarketDataService_AddTrade_FullMethodName:              true,
+	aggregatorv1.MarketDataService_AddTrades_FullMethodName:             true,
+	aggregatorv1.MarketDataService_AddCandle_FullMethodName:             true,
+	aggregatorv1.MarketDataService_AddCandles_FullMethodName:            true,
+	aggregatorv1.MarketDataService_AddOrderBookSnapshot_FullMethodName:  true,
+	aggregatorv1.MarketDataService_AddOrderBookSnapshots_FullMethodName: true,
+}
+
+// logCalls writes a log line per call with its request ID, taken from
+// x-request-id when the client sent one, and returns the ID in the response
+// header. Internal errors are logged in full but sent with a generic message
+// and the request ID.
+func (s *Server) logCalls(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	id := firstMetadata(ctx, requestIDMetadata)
+	if id == "" || len(id) > 128 {
+		id = uuid.NewString()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, id))
+
+	resp, err := handler(ctx, req)
+
+	logged := err
+	var internal *internalError
+	if errors.As(err, &internal) {
+		cfg := s.config.Load()
+		err = publicError(internal, id, cfg != nil && cfg.HTTP.DebugErrors)
+	}
+
+	code := status.Code(err)
+	entry := s.logger.WithFields(logrus.Fields{
+		"request_id": id,
+		"method":     info.FullMethod,
+		"code":       code.String(),
+		"latency_ms": time.Since(start).Milliseconds(),
+	})
+	switch code {
+	case codes.OK:
+		entry.Info("call served")
+	case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss:
+		entry.WithField("error", logged.Error()).Error("call served")
+	default:
+		entry.Warn("call served")
+	}
+	return resp, err
+}
+
+// recoverPanics turns a panicking call into an Internal error, like
+// gin.Recovery does for HTTP requests. The panic value is logged, never sent.
+func recoverPanics(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &internalError{code: codes.Internal, err: fmt.Errorf("panic: %v", r)}
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// authenticate applies the HTTP API's key rules: writes need a key from
+// API_KEYS, reads only with API_KEY_PROTECT_READS. A call that carries the
+// admin token as a bearer token is exempt from the range query window.
+func (s *Server) authenticate(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	cfg := s.config.Load()
+	if cfg == nil {
+		return handler(ctx, req)
+	}
+	if len(cfg.Auth.APIKeys) > 0 && (writeMethods[info.FullMethod] || cfg.Auth.ProtectReads) {
+		if !validAPIKey(firstMetadata(ctx, apiKeyMetadata), cfg.Auth.APIKeys) {
+			return nil, status.Error(codes.Unauthenticated, "missing or invalid API key")
+		}
+	}
+	if cfg.Admin.Token != "" {
+		token, ok := strings.CutPrefix(firstMetadata(ctx, "authorization"), "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(token), []byte(cfg.Admin.Token)) == 1 {
+			ctx = appmarketdata.WithoutRangeLimit(ctx)
+		}
+	}
+	return handler(ctx, req)
+}
+
+// validAPIKey compares key with every configured key in constant time.
+func validAPIKey(key string, keys []string) bool {
+	if key == "" {
+		return false
+	}
+	match := 0
+	for _, candidate := range keys {
+		match |= subtle.ConstantTimeCompare([]byte(key), []byte(candidate))
+	}
+	return match == 1
+}
+
+func firstMetadata(ctx context.Context, key string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,315 @@
+package grpc
+
+import (
+	"context"
+
+	aggregatorv1 "main/api/proto/aggregator/v1"
+	appmarketdata "main/internal/application/service/marketdata"
+	domainmarketdata "main/internal/domain/entity/marketdata"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type marketDataServer struct {
+	aggregatorv1.UnimplementedMarketDataServiceServer
+	marketdata *appmarketdata.Service
+}
+
+// convertAll converts every item, stopping at the first failure.
+func convertAll[T, P any](items []T, convert func(T) (P, error)) ([]P, error) {
+	out := make([]P, len(items))
+	for i, item := range items {
+		converted, err := convert(item)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = converted
+	}
+	return out, nil
+}
+
+func newBatchResult(given int, inserted int64) *aggregatorv1.BatchResult {
+	return &aggregatorv1.BatchResult{Inserted: inserted, Skipped: max(int64(given)-inserted, 0)}
+}
+
+// Trades
+
+func (s *marketDataServer) AddTrade(ctx context.Context, req *aggregatorv1.AddTradeRequest) (*aggregatorv1.Trade, error) {
+	if req.GetTrade() == nil {
+		return nil, statusError(appmarketdata.ErrNilTrade)
+	}
+	trade, err := tradeFromProto(req.GetTrade())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.marketdata.AddTrade(ctx, &trade); err != nil {
+		return nil, statusError(err)
+	}
+	return s.trade(trade)
+}
+
+func (s *marketDataServer) AddTrades(ctx context.Context, req *aggregatorv1.AddTradesRequest) (*aggregatorv1.BatchResult, error) {
+	trades, err := convertAll(req.GetTrades(), tradeFromProto)
+	if err != nil {
+		return nil, err
+	}
+	inserted, err := s.marketdata.AddTrades(ctx, trades)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return newBatchResult(len(trades), inserted), nil
+}
+
+func (s *marketDataServer) GetTrade(ctx context.Context, req *aggregatorv1.GetByIDRequest) (*aggregatorv1.Trade, error) {
+	id, err := parseUUID("id", req.GetId(), true)
+	if err != nil {
+		return nil, err
+	}
+	trade, err := s.marketdata.GetTradeByID(ctx, id)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return s.trade(*trade)
+}
+
+func (s *marketDataServer) GetTradesBetween(ctx context.Context, req *aggregatorv1.GetTradesBetweenRequest) (*aggregatorv1.Trades, error) {
+	instrumentUID, err := parseUUID("instrument_uid", req.GetInstrumentUid(), true)
+	if err != nil {
+		return nil, err
+	}
+	from, to, err := parseTimeRange(req.GetRange())
+	if err != nil {
+		return nil, err
+	}
+	meta, err := metadataFilterFromProto(req.GetMeta())
+	if err != nil {
+		return nil, err
+	}
+	trades, err := s.marketdata.GetTradesBetween(ctx, instrumentUID, req.GetVenue(), from, to, meta, pageFromProto(req.GetPage()))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return s.trades(trades)
+}
+
+func (s *marketDataServer) GetLastTrades(ctx context.Context, req *aggregatorv1.GetLastTradesRequest) (*aggregatorv1.Trades, error) {
+	instrumentUID, err := parseUUID("instrument_uid", req.GetInstrumentUid(), true)
+	if err != nil {
+		return nil, err
+	}
+	trades, err := s.marketdata.GetLastTrades(ctx, instrumentUID, req.GetVenue(), int(req.GetLimit()))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return s.trades(trades)
+}
+
+func (s *marketDataServer) trade(trade domainmarketdata.Trade) (*aggregatorv1.Trade, error) {
+	out, err := tradeToProto(trade)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return out, nil
+}
+
+func (s *marketDataServer) trades(trades []domainmarketdata.Trade) (*aggregatorv1.Trades, error) {
+	out, err := convertAll(trades, tradeToProto)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &aggregatorv1.Trades{Trades: out}, nil
+}
+
+// Candles
+
+func (s *marketDataServer) AddCandle(ctx context.Context, req *aggregatorv1.AddCandleRequest) (*aggregatorv1.Candle, error) {
+	if req.GetCandle() == nil {
+		return nil, statusError(appmarketdata.ErrNilCandle)
+	}
+	candle, err := candleFromProto(req.GetCandle())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.marketdata.AddCandle(ctx, &candle); err != nil {
+		return nil, statusError(err)
+	}
+	return s.candle(candle)
+}
+
+func (s *marketDataServer) AddCandles(ctx context.Context, req *aggregatorv1.AddCandlesRequest) (*aggregatorv1.BatchResult, error) {
+	candles, err := convertAll(req.GetCandles(), candleFromProto)
+	if err != nil {
+		return nil, err
+	}
+	inserted, err := s.marketdata.AddCandles(ctx, candles)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return newBatchResult(len(candles), inserted), nil
+}
+
+func (s *marketDataServer) GetCandle(ctx context.Context, req *aggregatorv1.GetByIDRequest) (*aggregatorv1.Candle, error) {
+	id, err := parseUUID("id", req.GetId(), true)
+	if err != nil {
+		return nil, err
+	}
+	candle, err := s.marketdata.GetCandleByID(ctx, id)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return s.candle(*candle)
+}
+
+func (s *marketDataServer) GetCandlesBetween(ctx context.Context, req *aggregatorv1.GetCandlesBetweenRequest) (*aggregatorv1.Candles, error) {
+	instrumentUID, err := parseUUID("instrument_uid", req.GetInstrumentUid(), true)
+	if err != nil {
+		return nil, err
+	}
+	from, to, err := parseTimeRange(req.GetRange())
+	if err != nil {
+		return nil, err
+	}
+	meta, err := metadataFilterFromProto(req.GetMeta())
+	if err != nil {
+		return nil, err
+	}
+	candles, err := s.marketdata.GetCandlesBetween(ctx, instrumentUID, req.GetIntervalSeconds(), from, to, meta, pageFromProto(req.GetPage()))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return s.candles(candles)
+}
+
+func (s *marketDataServer) GetLastCandles(ctx context.Context, req *aggregatorv1.GetLastCandlesRequest) (*aggregatorv1.Candles, error) {
+	instrumentUID, err := parseUUID("instrument_uid", req.GetInstrumentUid(), true)
+	if err != nil {
+		return nil, err
+	}
+	candles, err := s.marketdata.GetLastCandles(ctx, instrumentUID, req.GetIntervalSeconds(), int(req.GetLimit()))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return s.candles(candles)
+}
+
+func (s *marketDataServer) candle(candle domainmarketdata.Candle) (*aggregatorv1.Candle, error) {
+	out, err := candleToProto(candle)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return out, nil
+}
+
+func (s *marketDataServer) candles(candles []domainmarketdata.Candle) (*aggregatorv1.Candles, error) {
+	out, err := convertAll(candles, candleToProto)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &aggregatorv1.Candles{Candles: out}, nil
+}
+
+// Order books
+
+func (s *marketDataServer) AddOrderBookSnapshot(ctx context.Context, req *aggregatorv1.AddOrderBookSnapshotRequest) (*aggregatorv1.OrderBookSnapshot, error) {
+	if req.GetSnapshot() == nil {
+		return nil, statusError(appmarketdata.ErrNilOrderBook)
+	}
+	snapshot, err := orderBookFromProto(req.GetSnapshot())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.marketdata.AddOrderBookSnapshot(ctx, &snapshot); err != nil {
+		return nil, statusError(err)
+	}
+	return s.orderBook(snapshot)
+}
+
+func (s *marketDataServer) AddOrderBookSnapshots(ctx context.Context, req *aggregatorv1.AddOrderBookSnapshotsRequest) (*aggregatorv1.BatchResult, error) {
+	snapshots, err := convertAll(req.GetSnapshots(), orderBookFromProto)
+	if err != nil {
+		return nil, err
+	}
+	inserted, err := s.marketdata.AddOrderBookSnapshots(ctx, snapshots)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return newBatchResult(len(snapshots), inserted), nil
+}
+
+func (s *marketDataServer) GetOrderBookSnapshot(ctx context.Context, req *aggregatorv1.GetByIDRequest) (*aggregatorv1.OrderBookSnapshot, error) {
+	id, err := parseUUID("id", req.GetId(), true)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := s.marketdata.GetOrderBookByID(ctx, id)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return s.orderBook(*snapshot)
+}
+
+func (s *marketDataServer) GetOrderBookSnapshotsBetween(ctx context.Context, req *aggregatorv1.GetOrderBookSnapshotsBetweenRequest) (*aggregatorv1.OrderBookSnapshots, error) {
+	instrumentUID, err := parseUUID("instrument_uid", req.GetInstrumentUid(), true)
+	if err != nil {
+		return nil, err
+	}
+	from, to, err := parseTimeRange(req.GetRange())
+	if err != nil {
+		return nil, err
+	}
+	meta, err := metadataFilterFromProto(req.GetMeta())
+	if err != nil {
+		return nil, err
+	}
+	match := depthMatchFromProto(req.GetDepthMatch())
+	page := pageFromProto(req.GetPage())
+	return s.orderBooks(ctx, instrumentUID, req.GetDepth(), match, func(depth int32) ([]domainmarketdata.OrderBookSnapshot, error) {
+		return s.marketdata.GetOrderBookSnapshotsBetween(ctx, instrumentUID, depth, match, from, to, meta, page)
+	})
+}
+
+func (s *marketDataServer) GetLastOrderBookSnapshots(ctx context.Context, req *aggregatorv1.GetLastOrderBookSnapshotsRequest) (*aggregatorv1.OrderBookSnapshots, error) {
+	instrumentUID, err := parseUUID("instrument_uid", req.GetInstrumentUid(), true)
+	if err != nil {
+		return nil, err
+	}
+	match := depthMatchFromProto(req.GetDepthMatch())
+	return s.orderBooks(ctx, instrumentUID, req.GetDepth(), match, func(depth int32) ([]domainmarketdata.OrderBookSnapshot, error) {
+		return s.marketdata.GetLastOrderBookSnapshots(ctx, instrumentUID, depth, match, int(req.GetLimit()))
+	})
+}
+
+// orderBooks fetches snapshots at depth and, when there are none, at the depth
+// ResolveOrderBookDepth falls back to, like the HTTP endpoints do.
+func (s *marketDataServer) orderBooks(ctx context.Context, instrumentUID uuid.UUID, depth int32, match domainmarketdata.DepthMatch, fetch func(depth int32) ([]domainmarketdata.OrderBookSnapshot, error)) (*aggregatorv1.OrderBookSnapshots, error) {
+	served := depth
+	snapshots, err := fetch(depth)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	if len(snapshots) == 0 {
+		if served, err = s.marketdata.ResolveOrderBookDepth(ctx, instrumentUID, depth, match); err != nil {
+			return nil, statusError(err)
+		}
+		if served != depth {
+			if snapshots, err = fetch(served); err != nil {
+				return nil, statusError(err)
+			}
+		}
+	}
+	out, err := convertAll(snapshots, orderBookToProto)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &aggregatorv1.OrderBookSnapshots{Snapshots: out, ServedDepth: served}, nil
+}
+
+func (s *marketDataServer) orderBook(snapshot domainmarketdata.OrderBookSnapshot) (*aggregatorv1.OrderBookSnapshot, error) {
+	out, err := orderBookToProto(snapshot)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return out, nil
+}
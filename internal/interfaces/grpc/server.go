@@ -0,0 +1,73 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+
+	aggregatorv1 "main/api/proto/aggregator/v1"
+	appinstruments "main/internal/application/service/instruments"
+	appmarketdata "main/internal/application/service/marketdata"
+	"main/internal/config"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// Server exposes the instruments and market data services over gRPC. It calls
+// the same application services as the HTTP handler, so both APIs validate and
+// store data the same way.
+type Server struct {
+	server      *grpc.Server
+	instruments *appinstruments.Service
+	marketdata  *appmarketdata.Service
+	config      atomic.Pointer[config.Config]
+	logger      *logrus.Logger
+}
+
+func NewServer(inst *appinstruments.Service, md *appmarketdata.Service) *Server {
+	s := &Server{
+		instruments: inst,
+		marketdata:  md,
+		logger:      logrus.StandardLogger(),
+	}
+	s.server = grpc.NewServer(grpc.ChainUnaryInterceptor(s.logCalls, recoverPanics, s.authenticate))
+	aggregatorv1.RegisterMarketDataServiceServer(s.server, &marketDataServer{marketdata: md})
+	aggregatorv1.RegisterInstrumentServiceServer(s.server, &instrumentServer{instruments: inst})
+	return s
+}
+
+// SetConfig replaces the configuration the API key checks read. It is safe to
+// call while the server is serving, so a reload rotates keys like it does for
+// the HTTP API.
+func (s *Server) SetConfig(cfg config.Config) {
+	s.config.Store(&cfg)
+}
+
+// SetLogger replaces the logger used for the call log. Call it before serving.
+func (s *Server) SetLogger(logger *logrus.Logger) {
+	s.logger = logger
+}
+
+// Serve accepts connections on lis until Shutdown is called.
+func (s *Server) Serve(lis net.Listener) error {
+	return s.server.Serve(lis)
+}
+
+// Shutdown stops accepting connections and waits for running calls to finish.
+// When ctx ends first, the remaining calls are cancelled.
+func (s *Server) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		<-done
+		return ctx.Err()
+	}
+}
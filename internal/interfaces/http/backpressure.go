@@ -0,0 +1,95 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// backpressureReason tells a throttled client why the request was refused, so it
+// can pick a retry strategy without parsing the message.
+type backpressureReason string
+
+const (
+	// reasonRateLimited: the client exceeded its request rate (429).
+	reasonRateLimited backpressureReason = "RATE_LIMITED"
+	// reasonOverloaded: the server is at its concurrency limit (503).
+	reasonOverloaded backpressureReason = "OVERLOADED"
+	// reasonLockTimeout: the database could not take a row or table lock in time (503).
+	reasonLockTimeout backpressureReason = "LOCK_TIMEOUT"
+	// reasonDatabaseBusy: the database refused a new connection (503).
+	reasonDatabaseBusy backpressureReason = "DATABASE_BUSY"
+)
+
+// Suggested retry delays for refusals whose source gives no delay of its own.
+const (
+	lockTimeoutRetryAfter  = time.Second
+	databaseBusyRetryAfter = 5 * time.Second
+)
+
+// Postgres SQLSTATE codes that mean "try again later" rather than a failure.
+const (
+	pgLockNotAvailable   = "55P03"
+	pgTooManyConnections = "53300"
+)
+
+// backpressureError is a refusal the client should retry after RetryAfter.
+// writeError answers it with Retry-After and the reason in the body.
+type backpressureError struct {
+	Status     int
+	Reason     backpressureReason
+	RetryAfter time.Duration
+	Err        error
+}
+
+func newBackpressureError(status int, reason backpressureReason, retryAfter time.Duration, err error) *backpressureError {
+	return &backpressureError{Status: status, Reason: reason, RetryAfter: retryAfter, Err: err}
+}
+
+func (e *backpressureError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("request refused: %s", e.Reason)
+	}
+	return e.Err.Error()
+}
+
+func (e *backpressureError) Unwrap() error { return e.Err }
+
+// asBackpressure finds a backpressureError in err's chain, or classifies a
+// database refusal as one.
+func asBackpressure(err error) (*backpressureError, bool) {
+	var bp *backpressureError
+	if errors.As(err, &bp) {
+		return bp, true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgLockNotAvailable:
+			return newBackpressureError(http.StatusServiceUnavailable, reasonLockTimeout, lockTimeoutRetryAfter, err), true
+		case pgTooManyConnections:
+			return newBackpressureError(http.StatusServiceUnavailable, reasonDatabaseBusy, databaseBusyRetryAfter, err), true
+		}
+	}
+	return nil, false
+}
+
+// writeBackpressure answers a refusal with Retry-After in whole seconds (at
+// least one) and the same delay in the JSON body.
+func writeBackpressure(c *gin.Context, bp *backpressureError) {
+	seconds := max(int(math.Ceil(bp.RetryAfter.Seconds())), 1)
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	if bp.Status >= http.StatusInternalServerError {
+		_ = c.Error(bp)
+	}
+	resp := newErrorResponse(c, bp.Status, bp)
+	resp.Reason = bp.Reason
+	resp.RetryAfterSeconds = seconds
+	c.JSON(bp.Status, resp)
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"net/http"
+
+	domaininstruments "main/internal/domain/entity/instruments"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// batchGetPath is a read sent as POST, since its UIDs do not fit a query
+// string; requireAPIKey treats it as a read.
+const batchGetPath = instrumentsBasePath + "/batch-get"
+
+// batchGetView is the response of POST /instruments/batch-get.
+type batchGetView struct {
+	Instruments []domaininstruments.Instrument `json:"instruments"`
+	Missing     []uuid.UUID                    `json:"missing"`
+}
+
+// getInstrumentsBatch retrieves several instruments by UID
+// @Summary      Batch get instruments
+// @Description  Get the instruments of up to INSTRUMENTS_BATCH_GET_MAX UIDs in one request. Found instruments come in the order of the request; UIDs without an instrument are listed in missing. A repeated UID is answered once. Like other reads it needs no API key unless API_KEY_PROTECT_READS is set.
+// @Tags         instruments
+// @Accept       json
+// @Produce      json
+// @Param        uids             body      []string  true   "Instrument UIDs"
+// @Param        include_deleted  query     bool      false  "Also return soft-deleted instruments"
+// @Success      200              {object}  batchGetView
+// @Failure      400              {object}  errorResponse
+// @Failure      500              {object}  errorResponse
+// @Router       /instruments/batch-get [post]
+func (h *Handler) getInstrumentsBatch(c *gin.Context) {
+	includeDeleted, err := parseOptionalBoolQuery(c, "include_deleted")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	var uids []uuid.UUID
+	if err := c.ShouldBindJSON(&uids); err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	result, err := h.instruments.GetInstrumentsByUIDs(c.Request.Context(), uids, includeDeleted)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, batchGetView{Instruments: result.Instruments, Missing: result.Missing})
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errBodyTooLarge = errors.New("request body too large")
+
+// SetMaxBodyBytes caps the request body of writes at limit bytes; zero or less
+// removes the cap. It is safe to call while serving requests.
+func (h *Handler) SetMaxBodyBytes(limit int64) {
+	h.maxBody.Store(max(limit, 0))
+}
+
+// bodyLimitMiddleware caps the body of every request but GET and HEAD. A
+// Content-Length over the limit is refused at once; a body that only turns
+// out too long while it is read makes the read fail, and writeError answers
+// that with 413 wherever the handler reports it.
+func (h *Handler) bodyLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := h.maxBody.Load()
+		if limit <= 0 || c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > limit {
+			writeError(c, http.StatusRequestEntityTooLarge, bodyTooLarge(limit))
+			c.Abort()
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+func bodyTooLarge(limit int64) error {
+	return fmt.Errorf("%w: the limit is %d bytes", errBodyTooLarge, limit)
+}
+
+// asBodyTooLarge turns the error of a body read cut off by the limit into
+// errBodyTooLarge naming the limit. Other errors give nil.
+func asBodyTooLarge(err error) error {
+	if errors.Is(err, errBodyTooLarge) {
+		return err
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return bodyTooLarge(maxBytesErr.Limit)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	appmarketdata "main/internal/application/service/marketdata"
+
+	"github.com/google/uuid"
+)
+
+// batchResult is the response to a batch insert. Skipped counts the records
+// left out as duplicates of stored ones under the skip conflict policy.
+type batchResult struct {
+	Inserted int64 `json:"inserted"`
+	Skipped  int64 `json:"skipped"`
+}
+
+func newBatchResult(given int, inserted int64) batchResult {
+	return batchResult{Inserted: inserted, Skipped: max(int64(given)-inserted, 0)}
+}
+
+// validationReport is the response to a batch sent with validate_only=true.
+// Errors lists the failing records in batch order.
+type validationReport struct {
+	Valid   int               `json:"valid"`
+	Invalid int               `json:"invalid"`
+	Errors  []recordViolation `json:"errors"`
+}
+
+// recordViolation is one record that would be rejected, with the error and
+// code a single write of it would answer.
+type recordViolation struct {
+	Index int       `json:"index"`
+	Error string    `json:"error"`
+	Code  errorCode `json:"code"`
+}
+
+func newValidationReport(given int, violations []appmarketdata.Violation) validationReport {
+	report := validationReport{
+		Valid:   given - len(violations),
+		Invalid: len(violations),
+		Errors:  make([]recordViolation, len(violations)),
+	}
+	for i, violation := range violations {
+		report.Errors[i] = recordViolation{
+			Index: violation.Index,
+			Error: violation.Err.Error(),
+			Code:  errorCodeFor(violation.Err, serviceErrorStatus(violation.Err)),
+		}
+	}
+	return report
+}
+
+// recordLocation is the URL of a stored market data record, e.g.
+// /api/v1/marketdata/trades/{id}.
+func recordLocation(kind string, id uuid.UUID) string {
+	return marketdataBasePath + "/" + kind + "/" + id.String()
+}
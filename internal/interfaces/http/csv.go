@@ -0,0 +1,268 @@
+package http
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	appmarketdata "main/internal/application/service/marketdata"
+	domainmarketdata "main/internal/domain/entity/marketdata"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	formatJSON = "json"
+	formatCSV  = "csv"
+	mimeCSV    = "text/csv"
+
+	// csvFlushRows is how many rows are written between flushes to the client.
+	csvFlushRows = 500
+)
+
+var errInvalidFormat = errors.New("format must be json or csv")
+
+var (
+	tradeCSVHeader  = []string{"id", "instrument_uid", "side", "price", "quantity_lots", "traded_at", "venue", "metadata"}
+	candleCSVHeader = []string{
+		"id", "instrument_uid", "interval_seconds", "period_start",
+		"open", "high", "low", "close",
+		"volume_lots", "volume_buy_lots", "volume_sell_lots",
+		"last_trade_at", "metadata",
+	}
+	orderBookCSVHeader = []string{"id", "instrument_uid", "snapshot_at", "depth", "bids", "asks", "sequence", "metadata", "sequence_gap"}
+)
+
+// wantsCSV reports whether a range request asked for CSV, through ?format=csv or
+// an Accept header preferring text/csv. An explicit format wins over Accept.
+func wantsCSV(c *gin.Context) (bool, error) {
+	switch c.Query("format") {
+	case "":
+		return c.NegotiateFormat(gin.MIMEJSON, mimeCSV) == mimeCSV, nil
+	case formatJSON:
+		return false, nil
+	case formatCSV:
+		return true, nil
+	default:
+		return false, errInvalidFormat
+	}
+}
+
+// csvExport streams rows to the client as they are produced. Nothing is sent
+// until the first row or finish, so an error raised before that still gets a
+// regular JSON error response.
+type csvExport struct {
+	c        *gin.Context
+	w        *csv.Writer
+	filename string
+	header   []string
+	started  bool
+	rows     int
+}
+
+func newCSVExport(c *gin.Context, filename string, header []string) *csvExport {
+	return &csvExport{c: c, w: csv.NewWriter(c.Writer), filename: filename, header: header}
+}
+
+func (e *csvExport) write(record []string) error {
+	if !e.started {
+		e.start()
+	}
+	if err := e.w.Write(record); err != nil {
+		return err
+	}
+	e.rows++
+	if e.rows%csvFlushRows == 0 {
+		e.w.Flush()
+		e.c.Writer.Flush()
+	}
+	return e.w.Error()
+}
+
+func (e *csvExport) start() {
+	e.started = true
+	e.c.Header("Content-Type", mimeCSV+"; charset=utf-8")
+	e.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", e.filename))
+	e.c.Status(http.StatusOK)
+	_ = e.w.Write(e.header)
+}
+
+// finish completes the export after the rows were produced. An error before the
+// first row is answered like any other; after it the body is cut short, since
+// the status line is already sent.
+func (e *csvExport) finish(err error) {
+	if err != nil && !e.started {
+		writeError(e.c, serviceErrorStatus(err), err)
+		return
+	}
+	if !e.started {
+		e.start()
+	}
+	e.w.Flush()
+	if err == nil {
+		err = e.w.Error()
+	}
+	if err != nil {
+		_ = e.c.Error(err)
+	}
+}
+
+// csvFilename names an export after its entity, instrument and range.
+func csvFilename(entity, instrumentUID string, from, to time.Time) string {
+	return fmt.Sprintf("%s_%s_%s_%s.csv", entity, instrumentUID, from.UTC().Format("20060102T150405Z"), to.UTC().Format("20060102T150405Z"))
+}
+
+func tradeCSVRecord(trade domainmarketdata.Trade) []string {
+	return []string{
+		trade.ID.String(),
+		trade.InstrumentUID.String(),
+		string(trade.Side),
+		formatCSVFloat(trade.Price),
+		strconv.FormatInt(trade.QuantityLots, 10),
+		formatCSVTime(trade.TradedAt),
+		trade.Venue,
+		formatCSVJSON(trade.Metadata),
+	}
+}
+
+func candleCSVRecord(candle domainmarketdata.Candle) []string {
+	return []string{
+		candle.ID.String(),
+		candle.InstrumentUID.String(),
+		strconv.FormatInt(candle.IntervalSeconds, 10),
+		formatCSVTime(candle.PeriodStart),
+		formatCSVFloat(candle.Open),
+		formatCSVFloat(candle.High),
+		formatCSVFloat(candle.Low),
+		formatCSVFloat(candle.Close),
+		strconv.FormatInt(candle.VolumeLots, 10),
+		formatCSVOptionalInt(candle.VolumeBuyLots),
+		formatCSVOptionalInt(candle.VolumeSellLots),
+		formatCSVOptionalTime(candle.LastTradeAt),
+		formatCSVJSON(candle.Metadata),
+	}
+}
+
+// orderBookCSVRecord renders bids and asks as JSON arrays in their own columns.
+func orderBookCSVRecord(snapshot domainmarketdata.OrderBookSnapshot) []string {
+	return []string{
+		snapshot.ID.String(),
+		snapshot.InstrumentUID.String(),
+		formatCSVTime(snapshot.SnapshotAt),
+		strconv.FormatInt(int64(snapshot.Depth), 10),
+		formatCSVJSON(snapshot.Bids),
+		formatCSVJSON(snapshot.Asks),
+		formatCSVOptionalInt(snapshot.Sequence),
+		formatCSVJSON(snapshot.Metadata),
+		strconv.FormatBool(snapshot.SequenceGap),
+	}
+}
+
+func formatCSVFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func formatCSVTime(value time.Time) string {
+	return value.UTC().Format(time.RFC3339Nano)
+}
+
+func formatCSVOptionalTime(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+	return formatCSVTime(*value)
+}
+
+func formatCSVOptionalInt(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatInt(*value, 10)
+}
+
+// formatCSVJSON renders a nested value as a JSON string cell; empty maps and nil
+// values are left blank.
+func formatCSVJSON(value any) string {
+	if m, ok := value.(map[string]any); ok && len(m) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(value)
+	if err != nil || string(data) == "null" {
+		return ""
+	}
+	return string(data)
+}
+
+// parseExportPage reads limit and offset for a CSV export, where an absent limit
+// exports the whole range.
+func parseExportPage(c *gin.Context) (domainmarketdata.Page, error) {
+	limit, offset, err := parseLimitOffset(c, 0)
+	if err != nil {
+		return domainmarketdata.Page{}, err
+	}
+	return domainmarketdata.Page{Limit: limit, Offset: offset}, nil
+}
+
+func (h *Handler) exportTrades(c *gin.Context, instrumentUID uuid.UUID, venue string, from, to time.Time, meta domainmarketdata.MetadataFilter) {
+	page, err := parseExportPage(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	export := newCSVExport(c, csvFilename("trades", instrumentUID.String(), from, to), tradeCSVHeader)
+	err = h.marketdata.EachTradeBetween(c.Request.Context(), instrumentUID, venue, from, to, meta, page, func(trade domainmarketdata.Trade) error {
+		return export.write(tradeCSVRecord(trade))
+	})
+	export.finish(err)
+}
+
+func (h *Handler) exportCandles(c *gin.Context, instrumentUID uuid.UUID, intervalSeconds int64, from, to time.Time, meta domainmarketdata.MetadataFilter) {
+	page, err := parseExportPage(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	export := newCSVExport(c, csvFilename("candles", instrumentUID.String(), from, to), candleCSVHeader)
+	err = h.marketdata.EachCandleBetween(c.Request.Context(), instrumentUID, intervalSeconds, from, to, meta, page, func(candle domainmarketdata.Candle) error {
+		return export.write(candleCSVRecord(candle))
+	})
+	export.finish(err)
+}
+
+// exportOrderBooks falls back to another depth like fetchOrderBooks when the
+// requested one has no snapshots, and flags sequence gaps as rows stream by.
+func (h *Handler) exportOrderBooks(c *gin.Context, instrumentUID uuid.UUID, depth int32, match domainmarketdata.DepthMatch, from, to time.Time, meta domainmarketdata.MetadataFilter, flagGaps bool) {
+	page, err := parseExportPage(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	ctx := c.Request.Context()
+	export := newCSVExport(c, csvFilename("orderbooks", instrumentUID.String(), from, to), orderBookCSVHeader)
+	var prev *int64
+	each := func(depth int32) error {
+		return h.marketdata.EachOrderBookSnapshotBetween(ctx, instrumentUID, depth, match, from, to, meta, page, func(snapshot domainmarketdata.OrderBookSnapshot) error {
+			if flagGaps {
+				snapshot.SequenceGap = appmarketdata.IsSequenceGap(prev, snapshot)
+				prev = snapshot.Sequence
+			}
+			return export.write(orderBookCSVRecord(snapshot))
+		})
+	}
+
+	err = each(depth)
+	if err == nil && !export.started {
+		var served int32
+		served, err = h.marketdata.ResolveOrderBookDepth(ctx, instrumentUID, depth, match)
+		if err == nil && served != depth {
+			c.Header("X-Orderbook-Depth", strconv.Itoa(int(served)))
+			err = each(served)
+		}
+	}
+	export.finish(err)
+}
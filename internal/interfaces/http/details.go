@@ -0,0 +1,99 @@
+package http
+
+import (
+	"context"
+
+	appinstruments "main/internal/application/service/instruments"
+	domaininstruments "main/internal/domain/entity/instruments"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// instrumentDetailsView is the response of GET /instruments/{uid}/details.
+// Brand is null when the instrument has no brand, and so is any link of the
+// brand whose row is missing.
+type instrumentDetailsView struct {
+	Type       domaininstruments.InstrumentType `json:"type"`
+	Instrument any                              `json:"instrument"`
+	Brand      *brandView                       `json:"brand"`
+}
+
+type brandView struct {
+	UID         uuid.UUID    `json:"uid"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Info        string       `json:"info"`
+	Company     *companyView `json:"company"`
+	Sector      *sectorView  `json:"sector"`
+	Country     *countryView `json:"country"`
+}
+
+type companyView struct {
+	UID  uuid.UUID `json:"uid"`
+	Name string    `json:"name"`
+}
+
+type sectorView struct {
+	UID        uuid.UUID `json:"uid"`
+	Name       string    `json:"name"`
+	Volatility int32     `json:"volatility"`
+}
+
+type countryView struct {
+	AlfaTwo   string `json:"alfa_two"`
+	AlfaThree string `json:"alfa_three"`
+	Name      string `json:"name"`
+	NameBrief string `json:"name_brief"`
+}
+
+func newInstrumentDetailsView(details *appinstruments.InstrumentDetails) instrumentDetailsView {
+	view := instrumentDetailsView{Type: details.Type, Instrument: details.Instrument}
+	brand := details.Brand
+	if brand == nil {
+		return view
+	}
+	view.Brand = &brandView{
+		UID:         brand.UID,
+		Name:        brand.Name,
+		Description: brand.Description,
+		Info:        brand.Info,
+	}
+	if brand.Company != nil {
+		view.Brand.Company = &companyView{UID: brand.Company.UID, Name: brand.Company.Name}
+	}
+	if brand.Sector != nil {
+		view.Brand.Sector = &sectorView{UID: brand.Sector.UID, Name: brand.Sector.Name, Volatility: brand.Sector.Volatility}
+	}
+	if brand.Country != nil {
+		view.Brand.Country = &countryView{
+			AlfaTwo:   brand.Country.AlfaTwo,
+			AlfaThree: brand.Country.AlfaThree,
+			Name:      brand.Country.Name,
+			NameBrief: brand.Country.NameBrief,
+		}
+	}
+	return view
+}
+
+// getInstrumentDetails retrieves an instrument with its brand
+// @Summary      Get instrument details
+// @Description  Get an instrument of any type with its typed fields and its brand, company, sector and country. type is empty and instrument holds only the base fields when the instrument has no typed row; brand is null when it has no brand.
+// @Tags         instruments
+// @Produce      json
+// @Param        uid              path      string  true   "Instrument UID"
+// @Param        include_deleted  query     bool    false  "Also return a soft-deleted instrument"
+// @Success      200              {object}  instrumentDetailsView
+// @Failure      400              {object}  errorResponse
+// @Failure      404              {object}  errorResponse
+// @Failure      500              {object}  errorResponse
+// @Router       /instruments/{uid}/details [get]
+func (h *Handler) getInstrumentDetails(c *gin.Context) {
+	h.handleTypedInstrument(c, func(ctx context.Context, uid uuid.UUID, includeDeleted bool) (interface{}, error) {
+		details, err := h.instruments.GetInstrumentDetails(ctx, uid, includeDeleted)
+		if err != nil {
+			return nil, err
+		}
+		return newInstrumentDetailsView(details), nil
+	})
+}
@@ -0,0 +1,305 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	appinstruments "main/internal/application/service/instruments"
+	appmarketdata "main/internal/application/service/marketdata"
+	domaininstruments "main/internal/domain/entity/instruments"
+	domainmarketdata "main/internal/domain/entity/marketdata"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// errorCode is a stable, machine-readable identifier sent alongside the error message
+// so clients can branch on the failure without parsing human text.
+type errorCode string
+
+const (
+	codeInvalidRequest     errorCode = "INVALID_REQUEST"
+	codeInvalidID          errorCode = "INVALID_ID"
+	codeInvalidMetaFilter  errorCode = "INVALID_META_FILTER"
+	codeMissingUID         errorCode = "MISSING_UID"
+	codeMissingInstrument  errorCode = "MISSING_INSTRUMENT"
+	codeInvalidRange       errorCode = "INVALID_RANGE"
+	codeInvalidLimit       errorCode = "INVALID_LIMIT"
+	codeInvalidOffset      errorCode = "INVALID_OFFSET"
+	codeInvalidQuery       errorCode = "INVALID_QUERY"
+	codeInvalidType        errorCode = "INVALID_INSTRUMENT_TYPE"
+	codeInvalidSector      errorCode = "INVALID_SECTOR"
+	codeInvalidCountry     errorCode = "INVALID_COUNTRY"
+	codeInvalidPoints      errorCode = "INVALID_POINTS"
+	codeMissingTicker      errorCode = "MISSING_TICKER"
+	codeMissingFigi        errorCode = "MISSING_FIGI"
+	codeAmbiguousTicker    errorCode = "AMBIGUOUS_TICKER"
+	codeUIDConflict        errorCode = "UID_CONFLICT"
+	codeTooManyUIDs        errorCode = "TOO_MANY_UIDS"
+	codeNotPriceable       errorCode = "NOT_PRICEABLE"
+	codeInvalidInterval    errorCode = "INVALID_INTERVAL"
+	codeInvalidDepth       errorCode = "INVALID_DEPTH"
+	codeInvalidLevels      errorCode = "INVALID_LEVELS"
+	codeDepthUnavailable   errorCode = "DEPTH_UNAVAILABLE"
+	codeInvalidDepthMatch  errorCode = "INVALID_DEPTH_MATCH"
+	codeInvalidBucket      errorCode = "INVALID_BUCKET"
+	codeInvalidDays        errorCode = "INVALID_DAYS"
+	codeInvalidTimezone    errorCode = "INVALID_TIMEZONE"
+	codeInvalidLayout      errorCode = "INVALID_LAYOUT"
+	codeInvalidOrder       errorCode = "INVALID_ORDER"
+	codeInvalidFormat      errorCode = "INVALID_FORMAT"
+	codeTooManyBuckets     errorCode = "TOO_MANY_BUCKETS"
+	codeRangeTooWide       errorCode = "RANGE_TOO_WIDE"
+	codeEmptyPayload       errorCode = "EMPTY_PAYLOAD"
+	codeMetadataLimit      errorCode = "METADATA_LIMIT_EXCEEDED"
+	codeInvalidTrade       errorCode = "INVALID_TRADE"
+	codeInvalidOrderBook   errorCode = "INVALID_ORDER_BOOK"
+	codeCrossedBook        errorCode = "CROSSED_BOOK"
+	codeInstrumentNotFound errorCode = "INSTRUMENT_NOT_FOUND"
+	codeUnknownInstrument  errorCode = "UNKNOWN_INSTRUMENT"
+	codeNoTrades           errorCode = "NO_TRADES"
+	codeNotFound           errorCode = "NOT_FOUND"
+	codeUnauthorized       errorCode = "UNAUTHORIZED"
+	codeRateLimited        errorCode = "RATE_LIMITED"
+	codePayloadTooLarge    errorCode = "PAYLOAD_TOO_LARGE"
+	codeUnavailable        errorCode = "SERVICE_UNAVAILABLE"
+	codeInternal           errorCode = "INTERNAL_ERROR"
+)
+
+// errorCodes maps sentinel errors to their codes. Lookups use errors.Is, so wrapped
+// errors resolve to the code of the sentinel they wrap.
+var errorCodes = []struct {
+	err  error
+	code errorCode
+}{
+	{errMissingUID, codeMissingUID},
+	{errInvalidID, codeInvalidID},
+	{errInvalidMetaFilter, codeInvalidMetaFilter},
+	{errMissingInstrument, codeMissingInstrument},
+	{errMissingRange, codeInvalidRange},
+	{errDeleteWindow, codeInvalidRange},
+	{errMissingBucket, codeInvalidBucket},
+	{errInvalidLayout, codeInvalidLayout},
+	{errInvalidOrder, codeInvalidOrder},
+	{errInvalidFormat, codeInvalidFormat},
+	{errInvalidSector, codeInvalidSector},
+	{errMissingPoints, codeInvalidPoints},
+	{errInvalidTimezone, codeInvalidTimezone},
+	{errBodyTooLarge, codePayloadTooLarge},
+	{domaininstruments.ErrInstrumentNotFound, codeInstrumentNotFound},
+	{appinstruments.ErrNilInstrument, codeEmptyPayload},
+	{appinstruments.ErrInvalidListLimit, codeInvalidLimit},
+	{appinstruments.ErrInvalidListOffset, codeInvalidOffset},
+	{appinstruments.ErrInvalidSearchQuery, codeInvalidQuery},
+	{appinstruments.ErrInvalidSearchLimit, codeInvalidLimit},
+	{appinstruments.ErrInvalidInstrumentType, codeInvalidType},
+	{appinstruments.ErrInvalidCountryCode, codeInvalidCountry},
+	{appinstruments.ErrInvalidPoints, codeInvalidPoints},
+	{appinstruments.ErrNotPriceable, codeNotPriceable},
+	{appinstruments.ErrMissingTicker, codeMissingTicker},
+	{appinstruments.ErrAmbiguousTicker, codeAmbiguousTicker},
+	{appinstruments.ErrMissingFigi, codeMissingFigi},
+	{domaininstruments.ErrUIDConflict, codeUIDConflict},
+	{appinstruments.ErrTooManyUIDs, codeTooManyUIDs},
+	{appmarketdata.ErrNilTrade, codeEmptyPayload},
+	{appmarketdata.ErrNilCandle, codeEmptyPayload},
+	{appmarketdata.ErrNilOrderBook, codeEmptyPayload},
+	{appmarketdata.ErrInvalidLimit, codeInvalidLimit},
+	{appmarketdata.ErrLimitTooLarge, codeInvalidLimit},
+	{appmarketdata.ErrInvalidOffset, codeInvalidOffset},
+	{appmarketdata.ErrInvalidInterval, codeInvalidInterval},
+	{appmarketdata.ErrInvalidResample, codeInvalidInterval},
+	{appmarketdata.ErrCalendarAlign, codeInvalidTimezone},
+	{appmarketdata.ErrInvalidDepth, codeInvalidDepth},
+	{appmarketdata.ErrInvalidLevels, codeInvalidLevels},
+	{appmarketdata.ErrDepthUnavailable, codeDepthUnavailable},
+	{appmarketdata.ErrInvalidDepthMatch, codeInvalidDepthMatch},
+	{appmarketdata.ErrInvalidBucket, codeInvalidBucket},
+	{appmarketdata.ErrTooManyBuckets, codeTooManyBuckets},
+	{appmarketdata.ErrRangeTooWide, codeRangeTooWide},
+	{appmarketdata.ErrMetadataLimit, codeMetadataLimit},
+	{appmarketdata.ErrInvalidTrade, codeInvalidTrade},
+	{appmarketdata.ErrCrossedBook, codeCrossedBook},
+	{appmarketdata.ErrInvalidOrderBook, codeInvalidOrderBook},
+	{appmarketdata.ErrUnknownInstrument, codeUnknownInstrument},
+	{appmarketdata.ErrNoTrades, codeNoTrades},
+	{appmarketdata.ErrInvalidDays, codeInvalidDays},
+}
+
+// badRequestErrors are service-level validation errors that are the caller's fault.
+var badRequestErrors = []error{
+	appinstruments.ErrInvalidListLimit,
+	appinstruments.ErrInvalidListOffset,
+	appinstruments.ErrInvalidSearchQuery,
+	appinstruments.ErrInvalidSearchLimit,
+	appinstruments.ErrInvalidInstrumentType,
+	appinstruments.ErrInvalidCountryCode,
+	appinstruments.ErrInvalidPoints,
+	appinstruments.ErrMissingTicker,
+	appinstruments.ErrMissingFigi,
+	appinstruments.ErrTooManyUIDs,
+	appmarketdata.ErrInvalidLimit,
+	appmarketdata.ErrLimitTooLarge,
+	appmarketdata.ErrInvalidOffset,
+	appmarketdata.ErrInvalidInterval,
+	appmarketdata.ErrInvalidResample,
+	appmarketdata.ErrCalendarAlign,
+	appmarketdata.ErrInvalidDepth,
+	appmarketdata.ErrInvalidLevels,
+	appmarketdata.ErrInvalidDepthMatch,
+	appmarketdata.ErrInvalidBucket,
+	appmarketdata.ErrTooManyBuckets,
+	appmarketdata.ErrRangeTooWide,
+	appmarketdata.ErrMetadataLimit,
+	appmarketdata.ErrInvalidDays,
+	appmarketdata.ErrInvalidTrade,
+	appmarketdata.ErrInvalidOrderBook,
+	appmarketdata.ErrUnknownInstrument,
+}
+
+// notFoundErrors are service errors meaning the requested data does not exist.
+var notFoundErrors = []error{
+	domaininstruments.ErrInstrumentNotFound,
+	appinstruments.ErrNotPriceable,
+	appmarketdata.ErrDepthUnavailable,
+	appmarketdata.ErrNoTrades,
+	domainmarketdata.ErrTradeNotFound,
+	domainmarketdata.ErrCandleNotFound,
+	domainmarketdata.ErrOrderBookNotFound,
+}
+
+// conflictErrors are service errors meaning the request matches more than one
+// record and has to be narrowed down, or clashes with a stored one.
+var conflictErrors = []error{
+	appinstruments.ErrAmbiguousTicker,
+	domaininstruments.ErrUIDConflict,
+}
+
+// serviceErrorStatus picks the HTTP status for an error returned by a service call.
+// Every handler maps service errors through it: caller mistakes are 400, missing
+// data is 404, ambiguous lookups and clashes are 409 and anything else is 500.
+func serviceErrorStatus(err error) int {
+	for _, target := range badRequestErrors {
+		if errors.Is(err, target) {
+			return http.StatusBadRequest
+		}
+	}
+	for _, target := range notFoundErrors {
+		if errors.Is(err, target) {
+			return http.StatusNotFound
+		}
+	}
+	for _, target := range conflictErrors {
+		if errors.Is(err, target) {
+			return http.StatusConflict
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// errorResponse is the JSON envelope written for every failed request.
+type errorResponse struct {
+	Code    errorCode `json:"code"`
+	Message string    `json:"message"`
+	// Error repeats Message for clients written before it existed.
+	Error string `json:"error"`
+	// Details carries machine-readable facts about some errors, e.g. the
+	// failing index of a batch; see errorDetails.
+	Details map[string]any `json:"details,omitempty"`
+	// Reason and RetryAfterSeconds are set on 429 and 503 refusals only.
+	Reason            backpressureReason `json:"reason,omitempty"`
+	RetryAfterSeconds int                `json:"retry_after_seconds,omitempty"`
+	// RequestID matches the X-Request-ID header and the server's access log.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// debugErrorsKey marks a request whose internal error messages may be sent to
+// the client.
+const debugErrorsKey = "debug_errors"
+
+// SetDebugErrors makes error responses carry the message of internal errors,
+// such as database errors, instead of the generic status text. It is safe to
+// call while serving requests.
+func (h *Handler) SetDebugErrors(enabled bool) {
+	h.debugErrors.Store(enabled)
+}
+
+// debugErrorsMiddleware tells writeError whether internal messages may be shown.
+func (h *Handler) debugErrorsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.debugErrors.Load() {
+			c.Set(debugErrorsKey, true)
+		}
+		c.Next()
+	}
+}
+
+// newErrorResponse builds the envelope for err. An internal error, one without
+// a code of its own or raised by Postgres, only gets the status text unless
+// debug errors are on: its message may carry SQL, table names or addresses.
+// The full message still reaches the access log under the request ID.
+func newErrorResponse(c *gin.Context, status int, err error) errorResponse {
+	code := errorCodeFor(err, status)
+	message := err.Error()
+	var pgErr *pgconn.PgError
+	if (code == codeInternal || errors.As(err, &pgErr)) && !c.GetBool(debugErrorsKey) {
+		message = http.StatusText(status)
+	}
+	return errorResponse{
+		Code:      code,
+		Message:   message,
+		Error:     message,
+		Details:   errorDetails(err),
+		RequestID: requestID(c),
+	}
+}
+
+// errorDetails lists the structured facts of errors that have them; it is nil
+// for every other error.
+func errorDetails(err error) map[string]any {
+	var (
+		itemErr  *appmarketdata.BatchItemError
+		depthErr *appmarketdata.DepthUnavailableError
+	)
+	switch {
+	case errors.As(err, &itemErr):
+		return map[string]any{"item": itemErr.Item, "index": itemErr.Index}
+	case errors.As(err, &depthErr):
+		return map[string]any{"requested_depth": depthErr.Requested, "available_depths": depthErr.Available}
+	}
+	return nil
+}
+
+// recoverWithError answers a request whose handler panicked with the usual
+// error envelope. The panic value is logged, never sent.
+func recoverWithError(c *gin.Context, recovered any) {
+	writeError(c, http.StatusInternalServerError, fmt.Errorf("panic: %v", recovered))
+	c.Abort()
+}
+
+// errorCodeFor resolves the code for err, falling back to a generic code derived
+// from the HTTP status when err is not a known sentinel.
+func errorCodeFor(err error, status int) errorCode {
+	for _, entry := range errorCodes {
+		if errors.Is(err, entry.err) {
+			return entry.code
+		}
+	}
+	switch status {
+	case http.StatusBadRequest:
+		return codeInvalidRequest
+	case http.StatusUnauthorized:
+		return codeUnauthorized
+	case http.StatusNotFound:
+		return codeNotFound
+	case http.StatusRequestEntityTooLarge:
+		return codePayloadTooLarge
+	case http.StatusTooManyRequests:
+		return codeRateLimited
+	case http.StatusServiceUnavailable:
+		return codeUnavailable
+	default:
+		return codeInternal
+	}
+}
@@ -0,0 +1,183 @@
+package http
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gzipDisabled is the stored minimum size while compression is switched off.
+const gzipDisabled = -1
+
+var gzipWriters = sync.Pool{
+	New: func() any {
+		return gzip.NewWriter(nil)
+	},
+}
+
+// SetCompression switches gzip response compression on or off. Bodies shorter than
+// minBytes are sent uncompressed. It is safe to call while serving requests.
+func (h *Handler) SetCompression(enabled bool, minBytes int) {
+	if !enabled {
+		h.gzipMin.Store(gzipDisabled)
+		return
+	}
+	h.gzipMin.Store(int64(max(minBytes, 0)))
+}
+
+// gzipMiddleware compresses responses for clients that accept gzip. It sits in
+// front of the cache middleware, so cached bodies are stored uncompressed and
+// compressed again for each response that asks for it.
+func (h *Handler) gzipMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		minBytes := h.gzipMin.Load()
+		if minBytes == gzipDisabled || c.Request.Method == http.MethodHead || c.IsWebsocket() || !acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Next()
+			return
+		}
+
+		writer := &gzipResponseWriter{ResponseWriter: c.Writer, minBytes: int(minBytes)}
+		c.Writer = writer
+		defer func() {
+			if err := writer.finish(); err != nil {
+				_ = c.Error(err)
+			}
+			c.Writer = writer.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header admits gzip, either by
+// name or through a wildcard, with a non-zero quality.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "gzip" && name != "*" {
+			continue
+		}
+		q, ok := strings.CutPrefix(strings.ReplaceAll(params, " ", ""), "q=")
+		if !ok {
+			return true
+		}
+		if quality, err := strconv.ParseFloat(q, 64); err == nil && quality > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter buffers the start of a body until it reaches minBytes, then
+// decides whether to compress. Shorter bodies are written through unchanged.
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	minBytes int
+	pending  []byte
+	decided  bool
+	gz       *gzip.Writer
+}
+
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.pending = append(w.pending, data...)
+	if len(w.pending) < w.minBytes {
+		return len(data), nil
+	}
+	if err := w.decide(true); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written reports whether the body has started, including bytes still held back
+// while the compression decision is pending.
+func (w *gzipResponseWriter) Written() bool {
+	return len(w.pending) > 0 || w.ResponseWriter.Written()
+}
+
+// Flush sends whatever is buffered. A body flushed before reaching minBytes is
+// sent uncompressed, which keeps streaming responses unbuffered.
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		if err := w.decide(false); err != nil {
+			return
+		}
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *gzipResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.gz != nil || len(w.pending) > 0 {
+		return nil, nil, errors.New("gzip: cannot hijack a connection with a pending body")
+	}
+	w.decided = true
+	return w.ResponseWriter.Hijack()
+}
+
+// decide fixes the encoding and writes the pending bytes. Compression is skipped
+// when the handler already chose an encoding or the status carries no body.
+func (w *gzipResponseWriter) decide(compress bool) error {
+	w.decided = true
+	header := w.Header()
+	status := w.Status()
+	if compress && header.Get("Content-Encoding") == "" && bodyAllowed(status) {
+		header.Set("Content-Encoding", "gzip")
+		header.Add("Vary", "Accept-Encoding")
+		header.Del("Content-Length")
+		gz := gzipWriters.Get().(*gzip.Writer)
+		gz.Reset(w.ResponseWriter)
+		w.gz = gz
+	}
+
+	pending := w.pending
+	w.pending = nil
+	if len(pending) == 0 {
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(pending)
+	} else {
+		_, err = w.ResponseWriter.Write(pending)
+	}
+	return err
+}
+
+// finish writes a body that never reached minBytes and closes the gzip stream.
+func (w *gzipResponseWriter) finish() error {
+	if !w.decided {
+		return w.decide(false)
+	}
+	if w.gz == nil {
+		return nil
+	}
+	err := w.gz.Close()
+	gzipWriters.Put(w.gz)
+	w.gz = nil
+	return err
+}
+
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
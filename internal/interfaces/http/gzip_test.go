@@ -0,0 +1,142 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"deflate, gzip", true},
+		{"GZIP", true},
+		{"br;q=1.0, gzip;q=0.5", true},
+		{"gzip;q=0", false},
+		{"gzip; q=0.0", false},
+		{"*", true},
+		{"*;q=0", false},
+		{"deflate, br", false},
+		{"identity", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := acceptsGzip(tt.header); got != tt.want {
+				t.Fatalf("acceptsGzip(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+// gzipRouter serves body at /body behind the gzip middleware. record, when set,
+// wraps the writer like the cache middleware does.
+func gzipRouter(enabled bool, minBytes int, body string, record *responseRecorder) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{}
+	h.SetCompression(enabled, minBytes)
+	router := gin.New()
+	router.Use(h.gzipMiddleware())
+	if record != nil {
+		router.Use(func(c *gin.Context) {
+			record.ResponseWriter = c.Writer
+			c.Writer = record
+			c.Next()
+		})
+	}
+	router.GET("/body", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", []byte(body))
+	})
+	router.GET("/empty", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return router
+}
+
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		return rec.Body.String()
+	}
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("open gzip body: %v", err)
+	}
+	body, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("read gzip body: %v", err)
+	}
+	return string(body)
+}
+
+func TestGzipMiddleware(t *testing.T) {
+	large := `[` + strings.Repeat(`{"price":100.5,"quantity_lots":1},`, 100) + `{}]`
+	tests := []struct {
+		name           string
+		enabled        bool
+		acceptEncoding string
+		path           string
+		body           string
+		wantGzip       bool
+	}{
+		{"large body compressed", true, "gzip", "/body", large, true},
+		{"small body sent as is", true, "gzip", "/body", `{"ok":true}`, false},
+		{"client without gzip", true, "", "/body", large, false},
+		{"client refusing gzip", true, "gzip;q=0", "/body", large, false},
+		{"compression disabled", false, "gzip", "/body", large, false},
+		{"no content", true, "gzip", "/empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gzipRouter(tt.enabled, 256, tt.body, nil)
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if gotGzip := rec.Header().Get("Content-Encoding") == "gzip"; gotGzip != tt.wantGzip {
+				t.Fatalf("compressed = %v, want %v", gotGzip, tt.wantGzip)
+			}
+			if tt.wantGzip && rec.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", rec.Header().Get("Vary"))
+			}
+			if got := decodeBody(t, rec); got != tt.body {
+				t.Fatalf("body = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestGzipMiddlewareRecordsUncompressedBody(t *testing.T) {
+	body := strings.Repeat("x", 1024)
+	record := &responseRecorder{status: http.StatusOK, body: &bytes.Buffer{}}
+	router := gzipRouter(true, 256, body, record)
+	req := httptest.NewRequest(http.MethodGet, "/body", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatal("response not compressed")
+	}
+	if record.body.String() != body {
+		t.Fatalf("recorded body has %d bytes, want the %d uncompressed ones", record.body.Len(), len(body))
+	}
+	if record.contentEncoding != "" {
+		t.Fatalf("recorded content encoding = %q, want none", record.contentEncoding)
+	}
+	if got := decodeBody(t, rec); got != body {
+		t.Fatal("decompressed response differs from the handler's body")
+	}
+}
@@ -13,25 +13,36 @@
 // @host      localhost:8080
 // @BasePath  /api/v1
 
+// @securityDefinitions.apikey  ApiKeyAuth
+// @in                          header
+// @name                        X-API-Key
+// @description                 Required on writes, and on reads with API_KEY_PROTECT_READS, once API_KEYS is set.
+
 package http
 
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	appinterfaces "main/internal/application/interfaces"
 	appinstruments "main/internal/application/service/instruments"
 	appmarketdata "main/internal/application/service/marketdata"
+	"main/internal/config"
 	domaininstruments "main/internal/domain/entity/instruments"
 	domainmarketdata "main/internal/domain/entity/marketdata"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -44,30 +55,63 @@ const (
 var (
 	errMissingUID        = errors.New("missing uid")
 	errMissingInstrument = errors.New("instrument_uid query param required")
-	errMissingRange      = errors.New("from/to query params required")
+	errMissingRange      = errors.New("from/to or from_unix_ms/to_unix_ms query params required")
+	errMissingBucket     = errors.New("bucket_seconds query param required")
+	errInvalidLayout     = errors.New("layout must be objects or columnar")
+	errInvalidOrder      = errors.New("order must be asc or desc")
+	errInvalidSector     = errors.New("sector must be a sector UID")
+	errInvalidID         = errors.New("id must be a UUID")
+	errInvalidMetaFilter = errors.New("meta query param must be key=value")
+	errMissingPoints     = errors.New("points query param must be a number")
+	errInvalidTimezone   = errors.New("tz must be an IANA timezone name")
 )
 
+// unixMillisSuffix names the Unix milliseconds form of a time query param,
+// e.g. from_unix_ms for from.
+const unixMillisSuffix = "_unix_ms"
+
 type Handler struct {
 	router      *gin.Engine
 	instruments *appinstruments.Service
 	marketdata  *appmarketdata.Service
 	cache       *redis.Client
-	cacheTTL    time.Duration
+	cacheTTL    atomic.Int64
+	cacheRoutes atomic.Pointer[[]config.CacheRouteTTL]
+	gzipMin     atomic.Int64 // compression threshold in bytes, or gzipDisabled
+	maxBody     atomic.Int64 // request body cap of writes in bytes, or 0
+	debugErrors atomic.Bool
+	tradeHub    *appmarketdata.Hub[domainmarketdata.Trade]
+	tradeBuf    int
+	candleHub   *appmarketdata.Hub[domainmarketdata.Candle]
+	candleBuf   int
+	config      atomic.Pointer[config.Config]
+	metrics     *httpMetrics
+	readiness   []readinessCheck
+	pools       []poolSource
+	logger      *logrus.Logger
+	limiter     atomic.Pointer[rateLimiter]
+	tracing     gin.HandlerFunc
 }
 
 var _ appinterfaces.HTTPHandler = (*Handler)(nil)
 
 func NewHandler(inst *appinstruments.Service, md *appmarketdata.Service, cache *redis.Client, cacheTTL time.Duration) *Handler {
+	metrics := newHTTPMetrics()
 	router := gin.New()
-	router.Use(gin.Recovery())
 
 	h := &Handler{
 		router:      router,
 		instruments: inst,
 		marketdata:  md,
 		cache:       cache,
-		cacheTTL:    cacheTTL,
-	}
+		metrics:     metrics,
+		tradeBuf:    defaultStreamBuffer,
+		candleBuf:   defaultStreamBuffer,
+		logger:      logrus.StandardLogger(),
+	}
+	h.SetCacheTTL(cacheTTL)
+	h.SetCompression(false, 0)
+	router.Use(h.tracingMiddleware(), h.requestLogger(), h.debugErrorsMiddleware(), gin.CustomRecovery(recoverWithError), metrics.middleware(), h.gzipMiddleware(), h.bodyLimitMiddleware())
 	h.registerRoutes()
 	return h
 }
@@ -76,17 +120,65 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.router.ServeHTTP(w, r)
 }
 
+// SetCacheTTL changes the TTL applied to newly cached responses. It is safe to call
+// while the handler is serving requests.
+func (h *Handler) SetCacheTTL(ttl time.Duration) {
+	h.cacheTTL.Store(int64(ttl))
+}
+
+// SetCacheRouteTTLs replaces the per-route TTLs applied to newly cached
+// responses; see config.CacheRouteTTL. Routes matching none of them use
+// CacheTTL. It is safe to call while the handler is serving requests.
+func (h *Handler) SetCacheRouteTTLs(routes []config.CacheRouteTTL) {
+	routes = slices.Clone(routes)
+	h.cacheRoutes.Store(&routes)
+}
+
+// cacheTTLFor returns the TTL for responses of the route template, and false
+// when a route TTL of zero keeps the route out of the cache.
+func (h *Handler) cacheTTLFor(route string) (time.Duration, bool) {
+	if routes := h.cacheRoutes.Load(); routes != nil {
+		for _, r := range *routes {
+			if r.Matches(route) {
+				return time.Duration(r.TTLSeconds) * time.Second, r.TTLSeconds > 0
+			}
+		}
+	}
+	return h.CacheTTL(), true
+}
+
+// SetConfig records the effective configuration shown by the admin endpoint and
+// supplies the admin token. It is safe to call while serving requests.
+func (h *Handler) SetConfig(cfg config.Config) {
+	h.config.Store(&cfg)
+}
+
+// CacheTTL returns the TTL currently applied to cached responses.
+func (h *Handler) CacheTTL() time.Duration {
+	return time.Duration(h.cacheTTL.Load())
+}
+
 func (h *Handler) registerRoutes() {
 	h.router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	h.router.GET(metricsPath, h.metrics.handler())
+	h.router.GET("/healthz", h.healthz)
+	h.router.GET("/readyz", h.readyz)
 
-	inst := h.router.Group(instrumentsBasePath)
+	inst := h.router.Group(instrumentsBasePath, h.requireAPIKey())
 	if h.cache != nil {
 		inst.Use(h.cacheMiddleware())
 	}
 	{
 		inst.POST("/", h.createInstrument)
 		inst.PUT("/", h.updateInstrument)
+		inst.PUT("/upsert", h.upsertInstrument)
 		inst.GET("/", h.getInstrument)
+		inst.GET("/list", h.listInstruments)
+		inst.GET("/search", h.searchInstruments)
+		inst.GET("/price", h.getInstrumentPrice)
+		inst.GET("/by-ticker", h.getInstrumentByTicker)
+		inst.GET("/:uid/details", h.getInstrumentDetails)
+		inst.POST("/batch-get", h.getInstrumentsBatch)
 		inst.DELETE("/", h.deleteInstrument)
 
 		inst.POST("/shares", h.createShare)
@@ -115,7 +207,28 @@ func (h *Handler) registerRoutes() {
 		inst.GET("/etfs/:uid", h.getEtf)
 	}
 
-	md := h.router.Group(marketdataBasePath)
+	admin := h.router.Group(adminBasePath, h.requireAdminToken())
+	{
+		admin.GET("/config", h.getAdminConfig)
+		admin.GET("/pool-stats", h.getAdminPoolStats)
+	}
+
+	// Live streams never finish like a normal response, so they stay outside
+	// the response cache.
+	live := h.router.Group(marketdataBasePath, h.requireAPIKey())
+	{
+		live.GET("/trades/stream", h.streamTrades)
+		live.GET("/candles/sse", h.streamCandles)
+	}
+
+	// The last price is already read from Redis; the response cache would
+	// only make it staler.
+	price := h.router.Group(marketdataBasePath+"/price", h.requireAPIKey(), h.rateLimitMiddleware())
+	{
+		price.GET("/last", h.getLastPrice)
+	}
+
+	md := h.router.Group(marketdataBasePath, h.requireAPIKey(), h.rateLimitMiddleware(), h.liftRangeLimitForAdmin())
 	if h.cache != nil {
 		md.Use(h.cacheMiddleware())
 	}
@@ -124,24 +237,42 @@ func (h *Handler) registerRoutes() {
 		{
 			trades.POST("/", h.addTrade)
 			trades.POST("/batch", h.addTradesBatch)
+			trades.DELETE("/", h.deleteTrades)
 			trades.GET("/", h.getTradesRange)
 			trades.GET("/last", h.getTradesLast)
+			trades.GET("/activity", h.getTradesActivity)
+			trades.GET("/vwap", h.getTradesVWAP)
+			trades.GET("/adv", h.getTradesADV)
+			trades.GET("/summary", h.getTradesSummary)
+			trades.GET("/:id", h.getTrade)
 		}
 
 		candles := md.Group("/candles")
 		{
 			candles.POST("/", h.addCandle)
 			candles.POST("/batch", h.addCandlesBatch)
+			candles.DELETE("/", h.deleteCandles)
 			candles.GET("/", h.getCandlesRange)
 			candles.GET("/last", h.getCandlesLast)
+			candles.GET("/gaps", h.getCandleGaps)
+			candles.GET("/derived", h.getDerivedCandles)
+			candles.GET("/resampled", h.getResampledCandles)
+			candles.GET("/summary", h.getCandlesSummary)
+			candles.GET("/:id", h.getCandle)
 		}
 
 		orderbooks := md.Group("/orderbooks")
 		{
 			orderbooks.POST("/", h.addOrderBook)
 			orderbooks.POST("/batch", h.addOrderBooksBatch)
+			orderbooks.DELETE("/", h.deleteOrderBooks)
 			orderbooks.GET("/", h.getOrderBooksRange)
 			orderbooks.GET("/last", h.getOrderBooksLast)
+			orderbooks.GET("/spread", h.getOrderBooksSpread)
+			orderbooks.GET("/spread-series", h.getOrderBooksSpreadSeries)
+			orderbooks.GET("/imbalance", h.getOrderBooksImbalance)
+			orderbooks.GET("/summary", h.getOrderBooksSummary)
+			orderbooks.GET("/:id", h.getOrderBook)
 		}
 	}
 }
@@ -156,8 +287,8 @@ func (h *Handler) registerRoutes() {
 // @Produce      json
 // @Param        instrument  body      instrumentPayload  true  "Instrument data"
 // @Success      201         {object}  domaininstruments.Instrument
-// @Failure      400         {object}  map[string]string
-// @Failure      500         {object}  map[string]string
+// @Failure      400         {object}  errorResponse
+// @Failure      500         {object}  errorResponse
 // @Router       /instruments [post]
 func (h *Handler) createInstrument(c *gin.Context) {
 	var payload instrumentPayload
@@ -171,7 +302,7 @@ func (h *Handler) createInstrument(c *gin.Context) {
 		return
 	}
 	if err := h.instruments.CreateInstrument(c.Request.Context(), inst); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
 	c.JSON(http.StatusCreated, inst)
@@ -185,8 +316,9 @@ func (h *Handler) createInstrument(c *gin.Context) {
 // @Produce      json
 // @Param        instrument  body      instrumentPayload  true  "Instrument data with UID"
 // @Success      200         {object}  domaininstruments.Instrument
-// @Failure      400         {object}  map[string]string
-// @Failure      500         {object}  map[string]string
+// @Failure      400         {object}  errorResponse
+// @Failure      404         {object}  errorResponse
+// @Failure      500         {object}  errorResponse
 // @Router       /instruments [put]
 func (h *Handler) updateInstrument(c *gin.Context) {
 	var payload instrumentPayload
@@ -204,7 +336,43 @@ func (h *Handler) updateInstrument(c *gin.Context) {
 		return
 	}
 	if err := h.instruments.UpdateInstrument(c.Request.Context(), inst); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, inst)
+}
+
+// upsertInstrument creates or updates an instrument by figi
+// @Summary      Upsert instrument
+// @Description  Create the instrument, or update the one with the same figi that is not deleted. The update keeps the stored uid, created_at and deleted_at. A uid in the payload is optional; one that differs from the stored instrument's, or belongs to another instrument, answers 409 with UID_CONFLICT and changes nothing. With restore=true and no live instrument of the figi, a soft-deleted one is restored and updated instead of creating a new one.
+// @Tags         instruments
+// @Accept       json
+// @Produce      json
+// @Param        instrument  body      instrumentPayload  true   "Instrument data with figi"
+// @Param        restore     query     bool               false  "Restore a soft-deleted instrument of the figi"
+// @Success      200         {object}  domaininstruments.Instrument
+// @Failure      400         {object}  errorResponse
+// @Failure      409         {object}  errorResponse
+// @Failure      500         {object}  errorResponse
+// @Router       /instruments/upsert [put]
+func (h *Handler) upsertInstrument(c *gin.Context) {
+	restore, err := parseOptionalBoolQuery(c, "restore")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	var payload instrumentPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	inst, err := payload.toDomain()
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.instruments.UpsertInstrument(c.Request.Context(), inst, restore); err != nil {
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
 	c.JSON(http.StatusOK, inst)
@@ -212,40 +380,178 @@ func (h *Handler) updateInstrument(c *gin.Context) {
 
 // getInstrument retrieves an instrument by UID
 // @Summary      Get instrument
-// @Description  Get a financial instrument by UID
+// @Description  Get a financial instrument by UID. Without uid but with sector or country, it answers like GET /instruments/list with the same query.
 // @Tags         instruments
 // @Accept       json
 // @Produce      json
 // @Param        uid   query     string  true  "Instrument UID"
+// @Param        include_deleted  query  bool    false  "Also return a soft-deleted instrument"
 // @Success      200   {object}  domaininstruments.Instrument
-// @Failure      400   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Failure      400   {object}  errorResponse
+// @Failure      404   {object}  errorResponse
+// @Failure      500   {object}  errorResponse
 // @Router       /instruments [get]
 func (h *Handler) getInstrument(c *gin.Context) {
+	if c.Query("uid") == "" && (c.Query("sector") != "" || c.Query("country") != "") {
+		h.listInstruments(c)
+		return
+	}
 	uidStr := c.Query("uid")
 	uid, err := uuid.Parse(uidStr)
 	if err != nil {
 		writeError(c, http.StatusBadRequest, errMissingUID)
 		return
 	}
-	inst, err := h.instruments.GetInstrument(c.Request.Context(), uid)
+	includeDeleted, err := parseOptionalBoolQuery(c, "include_deleted")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	inst, err := h.instruments.GetInstrument(c.Request.Context(), uid, includeDeleted)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, inst)
+}
+
+// listInstruments lists instruments matching the filters
+// @Summary      List instruments
+// @Description  List instruments ordered by ticker, optionally filtered. Soft-deleted instruments are left out unless include_deleted is set.
+// @Tags         instruments
+// @Accept       json
+// @Produce      json
+// @Param        ticker           query     string  false "Exact ticker"
+// @Param        class_code       query     string  false "Exact class code"
+// @Param        figi             query     string  false "FIGI prefix"
+// @Param        type             query     string  false "Instrument type" Enums(share, bond, future, currency, etf)
+// @Param        sector           query     string  false "Sector UID of the instrument's brand"
+// @Param        country          query     string  false "Country of risk of the instrument's brand, ISO 3166 alpha-2 or alpha-3"
+// @Param        include_deleted  query     bool    false "Include soft-deleted instruments"
+// @Param        limit            query     int     false "Page size" default(100) maximum(1000)
+// @Param        offset           query     int     false "Number of instruments to skip" default(0)
+// @Success      200              {array}   domaininstruments.ListedInstrument
+// @Header       200              {integer} X-Next-Offset  "Offset of the next page; absent on the last page"
+// @Failure      400              {object}  errorResponse
+// @Failure      500              {object}  errorResponse
+// @Router       /instruments/list [get]
+func (h *Handler) listInstruments(c *gin.Context) {
+	includeDeleted, err := parseOptionalBoolQuery(c, "include_deleted")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	limit, offset, err := parseLimitOffset(c, appinstruments.DefaultListLimit)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	var sectorUID uuid.UUID
+	if value := c.Query("sector"); value != "" {
+		if sectorUID, err = uuid.Parse(value); err != nil {
+			writeError(c, http.StatusBadRequest, errInvalidSector)
+			return
+		}
+	}
+	filter := domaininstruments.InstrumentFilter{
+		Ticker:         c.Query("ticker"),
+		ClassCode:      c.Query("class_code"),
+		FigiPrefix:     c.Query("figi"),
+		Type:           domaininstruments.InstrumentType(c.Query("type")),
+		SectorUID:      sectorUID,
+		CountryCode:    c.Query("country"),
+		IncludeDeleted: includeDeleted,
+		Limit:          limit,
+		Offset:         offset,
+	}
+	instruments, err := h.instruments.ListInstruments(c.Request.Context(), filter)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	setNextOffset(c, offset, limit, len(instruments))
+	c.JSON(http.StatusOK, instruments)
+}
+
+// getInstrumentByTicker resolves a ticker to an instrument
+// @Summary      Get instrument by ticker
+// @Description  Resolve a ticker, e.g. SBER, to the instrument and its type. A ticker traded on several boards is ambiguous without class_code and answers 409 listing the class codes.
+// @Tags         instruments
+// @Accept       json
+// @Produce      json
+// @Param        ticker           query     string  true   "Exact ticker"
+// @Param        class_code       query     string  false  "Class code of the board, e.g. TQBR"
+// @Param        include_deleted  query     bool    false  "Also match soft-deleted instruments"
+// @Success      200              {object}  domaininstruments.ListedInstrument
+// @Failure      400              {object}  errorResponse
+// @Failure      404              {object}  errorResponse
+// @Failure      409              {object}  errorResponse
+// @Failure      500              {object}  errorResponse
+// @Router       /instruments/by-ticker [get]
+func (h *Handler) getInstrumentByTicker(c *gin.Context) {
+	includeDeleted, err := parseOptionalBoolQuery(c, "include_deleted")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	inst, err := h.instruments.GetInstrumentByTicker(c.Request.Context(), c.Query("ticker"), c.Query("class_code"), includeDeleted)
 	if err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
 	c.JSON(http.StatusOK, inst)
 }
 
+// getInstrumentPrice converts a price in points to currency
+// @Summary      Get instrument price
+// @Description  Convert a price in points to the instrument's currency with the rule of its type: bonds are quoted in percent of the nominal, futures in min price increments worth min_price_increment_amount each, shares, currencies and ETFs per unit and multiplied by the lot.
+// @Tags         instruments
+// @Accept       json
+// @Produce      json
+// @Param        uid              query     string  true   "Instrument UID"
+// @Param        points           query     number  true   "Price in points"
+// @Param        include_deleted  query     bool    false  "Also price a soft-deleted instrument"
+// @Success      200              {object}  appinstruments.InstrumentPrice
+// @Failure      400              {object}  errorResponse
+// @Failure      404              {object}  errorResponse
+// @Failure      500              {object}  errorResponse
+// @Router       /instruments/price [get]
+func (h *Handler) getInstrumentPrice(c *gin.Context) {
+	uid, err := uuid.Parse(c.Query("uid"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingUID)
+		return
+	}
+	points, err := strconv.ParseFloat(c.Query("points"), 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingPoints)
+		return
+	}
+	includeDeleted, err := parseOptionalBoolQuery(c, "include_deleted")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	price, err := h.instruments.GetPrice(c.Request.Context(), uid, points, includeDeleted)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, price)
+}
+
 // deleteInstrument deletes an instrument by UID
 // @Summary      Delete instrument
-// @Description  Delete a financial instrument by UID
+// @Description  Soft-delete a financial instrument by UID by setting deleted_at; with hard=true the row is removed
 // @Tags         instruments
 // @Accept       json
 // @Produce      json
 // @Param        uid   query     string  true  "Instrument UID"
+// @Param        hard  query     bool    false  "Remove the row instead of setting deleted_at"
 // @Success      204   "No Content"
-// @Failure      400   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Failure      400   {object}  errorResponse
+// @Failure      404   {object}  errorResponse
+// @Failure      500   {object}  errorResponse
 // @Router       /instruments [delete]
 func (h *Handler) deleteInstrument(c *gin.Context) {
 	uidStr := c.Query("uid")
@@ -254,8 +560,13 @@ func (h *Handler) deleteInstrument(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, errMissingUID)
 		return
 	}
-	if err := h.instruments.DeleteInstrument(c.Request.Context(), uid); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+	hard, err := parseOptionalBoolQuery(c, "hard")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.instruments.DeleteInstrument(c.Request.Context(), uid, hard); err != nil {
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -269,8 +580,8 @@ func (h *Handler) deleteInstrument(c *gin.Context) {
 // @Produce      json
 // @Param        share  body      sharePayload  true  "Share data"
 // @Success      201    {object}  domaininstruments.Share
-// @Failure      400    {object}  map[string]string
-// @Failure      500    {object}  map[string]string
+// @Failure      400    {object}  errorResponse
+// @Failure      500    {object}  errorResponse
 // @Router       /instruments/shares [post]
 func (h *Handler) createShare(c *gin.Context) {
 	var payload sharePayload
@@ -284,7 +595,7 @@ func (h *Handler) createShare(c *gin.Context) {
 		return
 	}
 	if err := h.instruments.CreateShare(c.Request.Context(), share); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
 	c.JSON(http.StatusCreated, share)
@@ -298,8 +609,9 @@ func (h *Handler) createShare(c *gin.Context) {
 // @Produce      json
 // @Param        share  body      sharePayload  true  "Share data with UID"
 // @Success      200    {object}  domaininstruments.Share
-// @Failure      400    {object}  map[string]string
-// @Failure      500    {object}  map[string]string
+// @Failure      400    {object}  errorResponse
+// @Failure      404    {object}  errorResponse
+// @Failure      500    {object}  errorResponse
 // @Router       /instruments/shares [put]
 func (h *Handler) updateShare(c *gin.Context) {
 	var payload sharePayload
@@ -317,7 +629,7 @@ func (h *Handler) updateShare(c *gin.Context) {
 		return
 	}
 	if err := h.instruments.UpdateShare(c.Request.Context(), share); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
 	c.JSON(http.StatusOK, share)
@@ -325,14 +637,16 @@ func (h *Handler) updateShare(c *gin.Context) {
 
 // deleteShare deletes a share instrument
 // @Summary      Delete share
-// @Description  Delete a share instrument by UID
+// @Description  Delete a share instrument by UID. Soft delete by default; hard=true removes the row
 // @Tags         shares
 // @Accept       json
 // @Produce      json
 // @Param        uid   path      string  true  "Share UID"
+// @Param        hard  query     bool    false  "Remove the row instead of setting deleted_at"
 // @Success      204   "No Content"
-// @Failure      400   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Failure      400   {object}  errorResponse
+// @Failure      404   {object}  errorResponse
+// @Failure      500   {object}  errorResponse
 // @Router       /instruments/shares/{uid} [delete]
 func (h *Handler) deleteShare(c *gin.Context) {
 	uid, err := parseUIDParam(c)
@@ -340,8 +654,13 @@ func (h *Handler) deleteShare(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, err)
 		return
 	}
-	if err := h.instruments.DeleteShare(c.Request.Context(), uid); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+	hard, err := parseOptionalBoolQuery(c, "hard")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.instruments.DeleteShare(c.Request.Context(), uid, hard); err != nil {
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -355,8 +674,8 @@ func (h *Handler) deleteShare(c *gin.Context) {
 // @Produce      json
 // @Param        bond  body      bondPayload  true  "Bond data"
 // @Success      201   {object}  domaininstruments.Bond
-// @Failure      400   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Failure      400   {object}  errorResponse
+// @Failure      500   {object}  errorResponse
 // @Router       /instruments/bonds [post]
 func (h *Handler) createBond(c *gin.Context) {
 	var payload bondPayload
@@ -370,7 +689,7 @@ func (h *Handler) createBond(c *gin.Context) {
 		return
 	}
 	if err := h.instruments.CreateBond(c.Request.Context(), bond); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
 	c.JSON(http.StatusCreated, bond)
@@ -384,8 +703,9 @@ func (h *Handler) createBond(c *gin.Context) {
 // @Produce      json
 // @Param        bond  body      bondPayload  true  "Bond data with UID"
 // @Success      200   {object}  domaininstruments.Bond
-// @Failure      400   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Failure      400   {object}  errorResponse
+// @Failure      404   {object}  errorResponse
+// @Failure      500   {object}  errorResponse
 // @Router       /instruments/bonds [put]
 func (h *Handler) updateBond(c *gin.Context) {
 	var payload bondPayload
@@ -403,7 +723,7 @@ func (h *Handler) updateBond(c *gin.Context) {
 		return
 	}
 	if err := h.instruments.UpdateBond(c.Request.Context(), bond); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
 	c.JSON(http.StatusOK, bond)
@@ -411,14 +731,16 @@ func (h *Handler) updateBond(c *gin.Context) {
 
 // deleteBond deletes a bond instrument
 // @Summary      Delete bond
-// @Description  Delete a bond instrument by UID
+// @Description  Delete a bond instrument by UID. Soft delete by default; hard=true removes the row
 // @Tags         bonds
 // @Accept       json
 // @Produce      json
 // @Param        uid   path      string  true  "Bond UID"
+// @Param        hard  query     bool    false  "Remove the row instead of setting deleted_at"
 // @Success      204   "No Content"
-// @Failure      400   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Failure      400   {object}  errorResponse
+// @Failure      404   {object}  errorResponse
+// @Failure      500   {object}  errorResponse
 // @Router       /instruments/bonds/{uid} [delete]
 func (h *Handler) deleteBond(c *gin.Context) {
 	uid, err := parseUIDParam(c)
@@ -426,8 +748,13 @@ func (h *Handler) deleteBond(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, err)
 		return
 	}
-	if err := h.instruments.DeleteBond(c.Request.Context(), uid); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+	hard, err := parseOptionalBoolQuery(c, "hard")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.instruments.DeleteBond(c.Request.Context(), uid, hard); err != nil {
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -441,8 +768,8 @@ func (h *Handler) deleteBond(c *gin.Context) {
 // @Produce      json
 // @Param        future  body      futurePayload  true  "Future data"
 // @Success      201     {object}  domaininstruments.Future
-// @Failure      400     {object}  map[string]string
-// @Failure      500     {object}  map[string]string
+// @Failure      400     {object}  errorResponse
+// @Failure      500     {object}  errorResponse
 // @Router       /instruments/futures [post]
 func (h *Handler) createFuture(c *gin.Context) {
 	var payload futurePayload
@@ -456,7 +783,7 @@ func (h *Handler) createFuture(c *gin.Context) {
 		return
 	}
 	if err := h.instruments.CreateFuture(c.Request.Context(), future); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
 	c.JSON(http.StatusCreated, future)
@@ -470,8 +797,9 @@ func (h *Handler) createFuture(c *gin.Context) {
 // @Produce      json
 // @Param        future  body      futurePayload  true  "Future data with UID"
 // @Success      200     {object}  domaininstruments.Future
-// @Failure      400     {object}  map[string]string
-// @Failure      500     {object}  map[string]string
+// @Failure      400     {object}  errorResponse
+// @Failure      404     {object}  errorResponse
+// @Failure      500     {object}  errorResponse
 // @Router       /instruments/futures [put]
 func (h *Handler) updateFuture(c *gin.Context) {
 	var payload futurePayload
@@ -489,7 +817,7 @@ func (h *Handler) updateFuture(c *gin.Context) {
 		return
 	}
 	if err := h.instruments.UpdateFuture(c.Request.Context(), future); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
 	c.JSON(http.StatusOK, future)
@@ -497,14 +825,16 @@ func (h *Handler) updateFuture(c *gin.Context) {
 
 // deleteFuture deletes a future instrument
 // @Summary      Delete future
-// @Description  Delete a future instrument by UID
+// @Description  Delete a future instrument by UID. Soft delete by default; hard=true removes the row
 // @Tags         futures
 // @Accept       json
 // @Produce      json
 // @Param        uid   path      string  true  "Future UID"
+// @Param        hard  query     bool    false  "Remove the row instead of setting deleted_at"
 // @Success      204   "No Content"
-// @Failure      400   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Failure      400   {object}  errorResponse
+// @Failure      404   {object}  errorResponse
+// @Failure      500   {object}  errorResponse
 // @Router       /instruments/futures/{uid} [delete]
 func (h *Handler) deleteFuture(c *gin.Context) {
 	uid, err := parseUIDParam(c)
@@ -512,8 +842,13 @@ func (h *Handler) deleteFuture(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, err)
 		return
 	}
-	if err := h.instruments.DeleteFuture(c.Request.Context(), uid); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+	hard, err := parseOptionalBoolQuery(c, "hard")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.instruments.DeleteFuture(c.Request.Context(), uid, hard); err != nil {
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -527,8 +862,8 @@ func (h *Handler) deleteFuture(c *gin.Context) {
 // @Produce      json
 // @Param        currency  body      currencyPayload  true  "Currency data"
 // @Success      201       {object}  domaininstruments.Currency
-// @Failure      400       {object}  map[string]string
-// @Failure      500       {object}  map[string]string
+// @Failure      400       {object}  errorResponse
+// @Failure      500       {object}  errorResponse
 // @Router       /instruments/currencies [post]
 func (h *Handler) createCurrency(c *gin.Context) {
 	var payload currencyPayload
@@ -542,7 +877,7 @@ func (h *Handler) createCurrency(c *gin.Context) {
 		return
 	}
 	if err := h.instruments.CreateCurrency(c.Request.Context(), currency); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
 	c.JSON(http.StatusCreated, currency)
@@ -556,8 +891,9 @@ func (h *Handler) createCurrency(c *gin.Context) {
 // @Produce      json
 // @Param        currency  body      currencyPayload  true  "Currency data with UID"
 // @Success      200       {object}  domaininstruments.Currency
-// @Failure      400       {object}  map[string]string
-// @Failure      500       {object}  map[string]string
+// @Failure      400       {object}  errorResponse
+// @Failure      404       {object}  errorResponse
+// @Failure      500       {object}  errorResponse
 // @Router       /instruments/currencies [put]
 func (h *Handler) updateCurrency(c *gin.Context) {
 	var payload currencyPayload
@@ -575,7 +911,7 @@ func (h *Handler) updateCurrency(c *gin.Context) {
 		return
 	}
 	if err := h.instruments.UpdateCurrency(c.Request.Context(), currency); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
 	c.JSON(http.StatusOK, currency)
@@ -583,14 +919,16 @@ func (h *Handler) updateCurrency(c *gin.Context) {
 
 // deleteCurrency deletes a currency instrument
 // @Summary      Delete currency
-// @Description  Delete a currency instrument by UID
+// @Description  Delete a currency instrument by UID. Soft delete by default; hard=true removes the row
 // @Tags         currencies
 // @Accept       json
 // @Produce      json
 // @Param        uid   path      string  true  "Currency UID"
+// @Param        hard  query     bool    false  "Remove the row instead of setting deleted_at"
 // @Success      204   "No Content"
-// @Failure      400   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Failure      400   {object}  errorResponse
+// @Failure      404   {object}  errorResponse
+// @Failure      500   {object}  errorResponse
 // @Router       /instruments/currencies/{uid} [delete]
 func (h *Handler) deleteCurrency(c *gin.Context) {
 	uid, err := parseUIDParam(c)
@@ -598,8 +936,13 @@ func (h *Handler) deleteCurrency(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, err)
 		return
 	}
-	if err := h.instruments.DeleteCurrency(c.Request.Context(), uid); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+	hard, err := parseOptionalBoolQuery(c, "hard")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.instruments.DeleteCurrency(c.Request.Context(), uid, hard); err != nil {
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -613,8 +956,8 @@ func (h *Handler) deleteCurrency(c *gin.Context) {
 // @Produce      json
 // @Param        etf  body      etfPayload  true  "ETF data"
 // @Success      201  {object}  domaininstruments.Etf
-// @Failure      400  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Failure      400  {object}  errorResponse
+// @Failure      500  {object}  errorResponse
 // @Router       /instruments/etfs [post]
 func (h *Handler) createEtf(c *gin.Context) {
 	var payload etfPayload
@@ -628,7 +971,7 @@ func (h *Handler) createEtf(c *gin.Context) {
 		return
 	}
 	if err := h.instruments.CreateEtf(c.Request.Context(), etf); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
 	c.JSON(http.StatusCreated, etf)
@@ -642,8 +985,9 @@ func (h *Handler) createEtf(c *gin.Context) {
 // @Produce      json
 // @Param        etf  body      etfPayload  true  "ETF data with UID"
 // @Success      200  {object}  domaininstruments.Etf
-// @Failure      400  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Failure      400  {object}  errorResponse
+// @Failure      404  {object}  errorResponse
+// @Failure      500  {object}  errorResponse
 // @Router       /instruments/etfs [put]
 func (h *Handler) updateEtf(c *gin.Context) {
 	var payload etfPayload
@@ -661,7 +1005,7 @@ func (h *Handler) updateEtf(c *gin.Context) {
 		return
 	}
 	if err := h.instruments.UpdateEtf(c.Request.Context(), etf); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
 	c.JSON(http.StatusOK, etf)
@@ -669,14 +1013,16 @@ func (h *Handler) updateEtf(c *gin.Context) {
 
 // deleteEtf deletes an ETF instrument
 // @Summary      Delete ETF
-// @Description  Delete an ETF instrument by UID
+// @Description  Delete an ETF instrument by UID. Soft delete by default; hard=true removes the row
 // @Tags         etfs
 // @Accept       json
 // @Produce      json
 // @Param        uid   path      string  true  "ETF UID"
+// @Param        hard  query     bool    false  "Remove the row instead of setting deleted_at"
 // @Success      204   "No Content"
-// @Failure      400   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Failure      400   {object}  errorResponse
+// @Failure      404   {object}  errorResponse
+// @Failure      500   {object}  errorResponse
 // @Router       /instruments/etfs/{uid} [delete]
 func (h *Handler) deleteEtf(c *gin.Context) {
 	uid, err := parseUIDParam(c)
@@ -684,8 +1030,13 @@ func (h *Handler) deleteEtf(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, err)
 		return
 	}
-	if err := h.instruments.DeleteEtf(c.Request.Context(), uid); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+	hard, err := parseOptionalBoolQuery(c, "hard")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.instruments.DeleteEtf(c.Request.Context(), uid, hard); err != nil {
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -698,13 +1049,15 @@ func (h *Handler) deleteEtf(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        uid   path      string  true  "Share UID"
+// @Param        include_deleted  query  bool    false  "Also return a soft-deleted instrument"
 // @Success      200   {object}  map[string]interface{}
-// @Failure      400   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Failure      400   {object}  errorResponse
+// @Failure      404   {object}  errorResponse
+// @Failure      500   {object}  errorResponse
 // @Router       /instruments/shares/{uid} [get]
 func (h *Handler) getShare(c *gin.Context) {
-	h.handleTypedInstrument(c, func(ctx context.Context, uid uuid.UUID) (interface{}, error) {
-		return h.instruments.GetShare(ctx, uid)
+	h.handleTypedInstrument(c, func(ctx context.Context, uid uuid.UUID, includeDeleted bool) (interface{}, error) {
+		return h.instruments.GetShare(ctx, uid, includeDeleted)
 	})
 }
 
@@ -715,13 +1068,15 @@ func (h *Handler) getShare(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        uid   path      string  true  "Bond UID"
+// @Param        include_deleted  query  bool    false  "Also return a soft-deleted instrument"
 // @Success      200   {object}  map[string]interface{}
-// @Failure      400   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Failure      400   {object}  errorResponse
+// @Failure      404   {object}  errorResponse
+// @Failure      500   {object}  errorResponse
 // @Router       /instruments/bonds/{uid} [get]
 func (h *Handler) getBond(c *gin.Context) {
-	h.handleTypedInstrument(c, func(ctx context.Context, uid uuid.UUID) (interface{}, error) {
-		return h.instruments.GetBond(ctx, uid)
+	h.handleTypedInstrument(c, func(ctx context.Context, uid uuid.UUID, includeDeleted bool) (interface{}, error) {
+		return h.instruments.GetBond(ctx, uid, includeDeleted)
 	})
 }
 
@@ -732,13 +1087,15 @@ func (h *Handler) getBond(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        uid   path      string  true  "Future UID"
+// @Param        include_deleted  query  bool    false  "Also return a soft-deleted instrument"
 // @Success      200   {object}  map[string]interface{}
-// @Failure      400   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Failure      400   {object}  errorResponse
+// @Failure      404   {object}  errorResponse
+// @Failure      500   {object}  errorResponse
 // @Router       /instruments/futures/{uid} [get]
 func (h *Handler) getFuture(c *gin.Context) {
-	h.handleTypedInstrument(c, func(ctx context.Context, uid uuid.UUID) (interface{}, error) {
-		return h.instruments.GetFuture(ctx, uid)
+	h.handleTypedInstrument(c, func(ctx context.Context, uid uuid.UUID, includeDeleted bool) (interface{}, error) {
+		return h.instruments.GetFuture(ctx, uid, includeDeleted)
 	})
 }
 
@@ -749,13 +1106,15 @@ func (h *Handler) getFuture(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        uid   path      string  true  "Currency UID"
+// @Param        include_deleted  query  bool    false  "Also return a soft-deleted instrument"
 // @Success      200   {object}  map[string]interface{}
-// @Failure      400   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Failure      400   {object}  errorResponse
+// @Failure      404   {object}  errorResponse
+// @Failure      500   {object}  errorResponse
 // @Router       /instruments/currencies/{uid} [get]
 func (h *Handler) getCurrency(c *gin.Context) {
-	h.handleTypedInstrument(c, func(ctx context.Context, uid uuid.UUID) (interface{}, error) {
-		return h.instruments.GetCurrency(ctx, uid)
+	h.handleTypedInstrument(c, func(ctx context.Context, uid uuid.UUID, includeDeleted bool) (interface{}, error) {
+		return h.instruments.GetCurrency(ctx, uid, includeDeleted)
 	})
 }
 
@@ -766,25 +1125,32 @@ func (h *Handler) getCurrency(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        uid   path      string  true  "ETF UID"
+// @Param        include_deleted  query  bool    false  "Also return a soft-deleted instrument"
 // @Success      200   {object}  map[string]interface{}
-// @Failure      400   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Failure      400   {object}  errorResponse
+// @Failure      404   {object}  errorResponse
+// @Failure      500   {object}  errorResponse
 // @Router       /instruments/etfs/{uid} [get]
 func (h *Handler) getEtf(c *gin.Context) {
-	h.handleTypedInstrument(c, func(ctx context.Context, uid uuid.UUID) (interface{}, error) {
-		return h.instruments.GetEtf(ctx, uid)
+	h.handleTypedInstrument(c, func(ctx context.Context, uid uuid.UUID, includeDeleted bool) (interface{}, error) {
+		return h.instruments.GetEtf(ctx, uid, includeDeleted)
 	})
 }
 
-func (h *Handler) handleTypedInstrument(c *gin.Context, fn func(ctx context.Context, uid uuid.UUID) (interface{}, error)) {
+func (h *Handler) handleTypedInstrument(c *gin.Context, fn func(ctx context.Context, uid uuid.UUID, includeDeleted bool) (interface{}, error)) {
 	uid, err := parseUIDParam(c)
 	if err != nil {
 		writeError(c, http.StatusBadRequest, err)
 		return
 	}
-	result, err := fn(c.Request.Context(), uid)
+	includeDeleted, err := parseOptionalBoolQuery(c, "include_deleted")
 	if err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	result, err := fn(c.Request.Context(), uid, includeDeleted)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
 	c.JSON(http.StatusOK, result)
@@ -799,9 +1165,10 @@ func (h *Handler) handleTypedInstrument(c *gin.Context, fn func(ctx context.Cont
 // @Accept       json
 // @Produce      json
 // @Param        trade  body      domainmarketdata.Trade  true  "Trade data"
-// @Success      201    "Created"
-// @Failure      400    {object}  map[string]string
-// @Failure      500    {object}  map[string]string
+// @Success      201    {object}  domainmarketdata.Trade
+// @Header       201    {string}  Location  "URL of the stored trade"
+// @Failure      400    {object}  errorResponse
+// @Failure      500    {object}  errorResponse
 // @Router       /marketdata/trades [post]
 func (h *Handler) addTrade(c *gin.Context) {
 	var trade domainmarketdata.Trade
@@ -810,48 +1177,70 @@ func (h *Handler) addTrade(c *gin.Context) {
 		return
 	}
 	if err := h.marketdata.AddTrade(c.Request.Context(), &trade); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
-	c.Status(http.StatusCreated)
+	c.Header("Location", recordLocation("trades", trade.ID))
+	c.JSON(http.StatusCreated, trade)
 }
 
 // addTradesBatch adds multiple trades in a batch
 // @Summary      Add trades batch
-// @Description  Add multiple trade records in a single request
+// @Description  Add multiple trade records in a single request. With validate_only=true the trades are only validated and a report of the failing ones is returned; nothing is stored.
 // @Tags         trades
 // @Accept       json
 // @Produce      json
-// @Param        trades  body      []domainmarketdata.Trade  true  "Array of trade data"
-// @Success      201     "Created"
-// @Failure      400     {object}  map[string]string
-// @Failure      500     {object}  map[string]string
+// @Param        trades         body      []domainmarketdata.Trade  true   "Array of trade data"
+// @Param        validate_only  query     bool                      false  "Validate without storing"
+// @Success      200            {object}  validationReport
+// @Success      201            {object}  batchResult
+// @Failure      400            {object}  errorResponse
+// @Failure      413            {object}  errorResponse
+// @Failure      500            {object}  errorResponse
 // @Router       /marketdata/trades/batch [post]
 func (h *Handler) addTradesBatch(c *gin.Context) {
+	validateOnly, err := parseOptionalBoolQuery(c, "validate_only")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
 	var trades []domainmarketdata.Trade
 	if err := c.ShouldBindJSON(&trades); err != nil {
 		writeError(c, http.StatusBadRequest, err)
 		return
 	}
-	if err := h.marketdata.AddTrades(c.Request.Context(), trades); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+	if validateOnly {
+		c.JSON(http.StatusOK, newValidationReport(len(trades), h.marketdata.ValidateTrades(trades)))
 		return
 	}
-	c.Status(http.StatusCreated)
+	inserted, err := h.marketdata.AddTrades(c.Request.Context(), trades)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusCreated, newBatchResult(len(trades), inserted))
 }
 
 // getTradesRange retrieves trades within a time range
 // @Summary      Get trades range
-// @Description  Get trades for an instrument within a time range
+// @Description  Get trades for an instrument within a time range. The window may span at most RANGE_QUERY_MAX_DAYS unless the admin token is sent as a bearer token.
 // @Tags         trades
 // @Accept       json
-// @Produce      json
+// @Produce      json,text/csv
 // @Param        instrument_uid  query     string  true  "Instrument UID"
-// @Param        from            query     string  true  "Start time (RFC3339)"
-// @Param        to              query     string  true  "End time (RFC3339)"
+// @Param        from            query     string  false "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given"
+// @Param        from_unix_ms    query     int     false "Start time in Unix milliseconds, instead of from"
+// @Param        to              query     string  false "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given"
+// @Param        to_unix_ms      query     int     false "End time in Unix milliseconds, instead of to"
+// @Param        venue           query     string  false "Only trades executed on this board (e.g. TQBR)"
+// @Param        format          query     string  false "Response format; csv streams the range as CSV, also selected by Accept: text/csv" Enums(json, csv) default(json)
+// @Param        limit           query     int     false "Page size; a CSV export without limit returns the whole range" default(1000) maximum(10000)
+// @Param        offset          query     int     false "Number of trades to skip" default(0)
+// @Param        meta            query     []string false "Metadata key=value the rows must contain; repeat to require several" collectionFormat(multi)
 // @Success      200             {array}   domainmarketdata.Trade
-// @Failure      400             {object}  map[string]string
-// @Failure      500             {object}  map[string]string
+// @Header       200             {integer} X-Next-Offset  "Offset of the next page; absent on the last page and on CSV exports"
+// @Failure      400             {object}  errorResponse
+// @Failure      500             {object}  errorResponse
 // @Router       /marketdata/trades [get]
 func (h *Handler) getTradesRange(c *gin.Context) {
 	instrumentUID, err := parseUUIDQuery(c, "instrument_uid")
@@ -864,11 +1253,31 @@ func (h *Handler) getTradesRange(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, errMissingRange)
 		return
 	}
-	trades, err := h.marketdata.GetTradesBetween(c.Request.Context(), instrumentUID, from, to)
+	meta, err := parseMetadataFilter(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	asCSV, err := wantsCSV(c)
 	if err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	if asCSV {
+		h.exportTrades(c, instrumentUID, c.Query("venue"), from, to, meta)
+		return
+	}
+	page, err := parsePage(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	trades, err := h.marketdata.GetTradesBetween(c.Request.Context(), instrumentUID, c.Query("venue"), from, to, meta, page)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
+	setNextOffset(c, page.Offset, page.Limit, len(trades))
 	c.JSON(http.StatusOK, trades)
 }
 
@@ -880,9 +1289,11 @@ func (h *Handler) getTradesRange(c *gin.Context) {
 // @Produce      json
 // @Param        instrument_uid  query     string  true  "Instrument UID"
 // @Param        limit           query     int     true  "Number of trades to retrieve"
+// @Param        venue           query     string  false "Only trades executed on this board (e.g. TQBR)"
+// @Param        order           query     string  false "Newest first, or the same trades oldest first" Enums(asc, desc) default(desc)
 // @Success      200             {array}   domainmarketdata.Trade
-// @Failure      400             {object}  map[string]string
-// @Failure      500             {object}  map[string]string
+// @Failure      400             {object}  errorResponse
+// @Failure      500             {object}  errorResponse
 // @Router       /marketdata/trades/last [get]
 func (h *Handler) getTradesLast(c *gin.Context) {
 	instrumentUID, limit, err := h.parseInstrumentAndLimit(c)
@@ -890,14 +1301,180 @@ func (h *Handler) getTradesLast(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, err)
 		return
 	}
-	trades, err := h.marketdata.GetLastTrades(c.Request.Context(), instrumentUID, limit)
+	ascending, err := parseLastOrder(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	trades, err := h.marketdata.GetLastTrades(c.Request.Context(), instrumentUID, c.Query("venue"), limit)
 	if err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
+	if ascending {
+		slices.Reverse(trades)
+	}
 	c.JSON(http.StatusOK, trades)
 }
 
+// getTradesActivity returns trade counts per time bucket
+// @Summary      Get trade activity
+// @Description  Get trade count and volume per time bucket for an instrument. Buckets are aligned to the Unix epoch, returned in ascending order, and empty buckets are included with zeros.
+// @Tags         trades
+// @Accept       json
+// @Produce      json
+// @Param        instrument_uid  query     string  true  "Instrument UID"
+// @Param        from            query     string  false "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given"
+// @Param        from_unix_ms    query     int     false "Start time in Unix milliseconds, instead of from"
+// @Param        to              query     string  false "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given"
+// @Param        to_unix_ms      query     int     false "End time in Unix milliseconds, instead of to"
+// @Param        bucket_seconds  query     int64   true  "Bucket width in seconds"
+// @Success      200             {array}   domainmarketdata.TradeActivityBucket
+// @Failure      400             {object}  errorResponse
+// @Failure      500             {object}  errorResponse
+// @Router       /marketdata/trades/activity [get]
+func (h *Handler) getTradesActivity(c *gin.Context) {
+	instrumentUID, err := parseUUIDQuery(c, "instrument_uid")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingInstrument)
+		return
+	}
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingRange)
+		return
+	}
+	bucketSeconds, err := parseInt64Query(c, "bucket_seconds")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingBucket)
+		return
+	}
+	buckets, err := h.marketdata.GetTradeActivity(c.Request.Context(), instrumentUID, from, to, bucketSeconds)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, buckets)
+}
+
+// getTradesVWAP returns the volume-weighted average price over a time range
+// @Summary      Get trade VWAP
+// @Description  Get the volume-weighted average price, SUM(price*quantity_lots)/SUM(quantity_lots), of an instrument's trades within a time range, with the total volume and trade count. A range without traded volume is answered with 404 NO_TRADES.
+// @Tags         trades
+// @Accept       json
+// @Produce      json
+// @Param        instrument_uid  query     string  true  "Instrument UID"
+// @Param        from            query     string  false "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given"
+// @Param        from_unix_ms    query     int     false "Start time in Unix milliseconds, instead of from"
+// @Param        to              query     string  false "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given"
+// @Param        to_unix_ms      query     int     false "End time in Unix milliseconds, instead of to"
+// @Success      200             {object}  domainmarketdata.VWAP
+// @Failure      400             {object}  errorResponse
+// @Failure      404             {object}  errorResponse
+// @Failure      500             {object}  errorResponse
+// @Router       /marketdata/trades/vwap [get]
+func (h *Handler) getTradesVWAP(c *gin.Context) {
+	instrumentUID, err := parseUUIDQuery(c, "instrument_uid")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingInstrument)
+		return
+	}
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingRange)
+		return
+	}
+	vwap, err := h.marketdata.GetVWAP(c.Request.Context(), instrumentUID, from, to)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, vwap)
+}
+
+// getTradesSummary describes the stored trades of an instrument
+// @Summary      Get trade summary
+// @Description  Get the number of stored trades of an instrument, the first and last trade time, the lowest and highest price and the total volume. An instrument without trades gets a zero count and null times and prices.
+// @Tags         trades
+// @Accept       json
+// @Produce      json
+// @Param        instrument_uid  query     string  true  "Instrument UID"
+// @Success      200             {object}  domainmarketdata.TradeSummary
+// @Failure      400             {object}  errorResponse
+// @Failure      500             {object}  errorResponse
+// @Router       /marketdata/trades/summary [get]
+func (h *Handler) getTradesSummary(c *gin.Context) {
+	instrumentUID, err := parseUUIDQuery(c, "instrument_uid")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingInstrument)
+		return
+	}
+	summary, err := h.marketdata.GetTradeSummary(c.Request.Context(), instrumentUID)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// getLastPrice returns the latest trade price of an instrument
+// @Summary      Get last price
+// @Description  Get the price of the newest trade of an instrument with the time it traded at, so a client can judge how stale it is. With REDIS_ADDR set the price is read from the Redis hash the broker consumer keeps up to date (source cache), falling back to the newest stored trade (source database). The response is never served from the response cache.
+// @Tags         trades
+// @Accept       json
+// @Produce      json
+// @Param        instrument_uid  query     string  true  "Instrument UID"
+// @Success      200             {object}  domainmarketdata.LastPrice
+// @Failure      400             {object}  errorResponse
+// @Failure      404             {object}  errorResponse
+// @Failure      500             {object}  errorResponse
+// @Router       /marketdata/price/last [get]
+func (h *Handler) getLastPrice(c *gin.Context) {
+	instrumentUID, err := parseUUIDQuery(c, "instrument_uid")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingInstrument)
+		return
+	}
+	price, err := h.marketdata.GetLastPrice(c.Request.Context(), instrumentUID)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, price)
+}
+
+// getTradesADV returns the average daily volume over the trailing days
+// @Summary      Get average daily volume
+// @Description  Get the average daily traded volume of an instrument over the last days complete calendar days in the market timezone (MARKET_TIMEZONE). The current day is excluded. The average is taken over the days with at least one trade, and the per-day volumes are returned alongside. A window without trades is answered with 404 NO_TRADES.
+// @Tags         trades
+// @Accept       json
+// @Produce      json
+// @Param        instrument_uid  query     string  true  "Instrument UID"
+// @Param        days            query     int     true  "Number of trailing calendar days" minimum(1) maximum(365)
+// @Success      200             {object}  domainmarketdata.ADV
+// @Failure      400             {object}  errorResponse
+// @Failure      404             {object}  errorResponse
+// @Failure      500             {object}  errorResponse
+// @Router       /marketdata/trades/adv [get]
+func (h *Handler) getTradesADV(c *gin.Context) {
+	instrumentUID, err := parseUUIDQuery(c, "instrument_uid")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingInstrument)
+		return
+	}
+	days, err := parseIntQuery(c, "days")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, appmarketdata.ErrInvalidDays)
+		return
+	}
+	adv, err := h.marketdata.GetADV(c.Request.Context(), instrumentUID, days)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, adv)
+}
+
 // addCandle adds a single candle
 // @Summary      Add candle
 // @Description  Add a single candle record
@@ -905,9 +1482,10 @@ func (h *Handler) getTradesLast(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        candle  body      domainmarketdata.Candle  true  "Candle data"
-// @Success      201     "Created"
-// @Failure      400     {object}  map[string]string
-// @Failure      500     {object}  map[string]string
+// @Success      201     {object}  domainmarketdata.Candle
+// @Header       201     {string}  Location  "URL of the stored candle"
+// @Failure      400     {object}  errorResponse
+// @Failure      500     {object}  errorResponse
 // @Router       /marketdata/candles [post]
 func (h *Handler) addCandle(c *gin.Context) {
 	var candle domainmarketdata.Candle
@@ -916,49 +1494,71 @@ func (h *Handler) addCandle(c *gin.Context) {
 		return
 	}
 	if err := h.marketdata.AddCandle(c.Request.Context(), &candle); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
-	c.Status(http.StatusCreated)
+	c.Header("Location", recordLocation("candles", candle.ID))
+	c.JSON(http.StatusCreated, candle)
 }
 
 // addCandlesBatch adds multiple candles in a batch
 // @Summary      Add candles batch
-// @Description  Add multiple candle records in a single request
+// @Description  Add multiple candle records in a single request. With validate_only=true the candles are only validated and a report of the failing ones is returned; nothing is stored.
 // @Tags         candles
 // @Accept       json
 // @Produce      json
-// @Param        candles  body      []domainmarketdata.Candle  true  "Array of candle data"
-// @Success      201      "Created"
-// @Failure      400      {object}  map[string]string
-// @Failure      500      {object}  map[string]string
+// @Param        candles        body      []domainmarketdata.Candle  true   "Array of candle data"
+// @Param        validate_only  query     bool                       false  "Validate without storing"
+// @Success      200            {object}  validationReport
+// @Success      201            {object}  batchResult
+// @Failure      400            {object}  errorResponse
+// @Failure      413            {object}  errorResponse
+// @Failure      500            {object}  errorResponse
 // @Router       /marketdata/candles/batch [post]
 func (h *Handler) addCandlesBatch(c *gin.Context) {
+	validateOnly, err := parseOptionalBoolQuery(c, "validate_only")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
 	var candles []domainmarketdata.Candle
 	if err := c.ShouldBindJSON(&candles); err != nil {
 		writeError(c, http.StatusBadRequest, err)
 		return
 	}
-	if err := h.marketdata.AddCandles(c.Request.Context(), candles); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+	if validateOnly {
+		c.JSON(http.StatusOK, newValidationReport(len(candles), h.marketdata.ValidateCandles(candles)))
+		return
+	}
+	inserted, err := h.marketdata.AddCandles(c.Request.Context(), candles)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
-	c.Status(http.StatusCreated)
+	c.JSON(http.StatusCreated, newBatchResult(len(candles), inserted))
 }
 
 // getCandlesRange retrieves candles within a time range
 // @Summary      Get candles range
-// @Description  Get candles for an instrument within a time range
+// @Description  Get candles for an instrument within a time range. With layout=columnar the response is a columnarCandles object of parallel arrays instead of an array of candles. The window may span at most RANGE_QUERY_MAX_DAYS unless the admin token is sent as a bearer token.
 // @Tags         candles
 // @Accept       json
-// @Produce      json
+// @Produce      json,text/csv
 // @Param        instrument_uid   query     string  true  "Instrument UID"
 // @Param        interval_seconds query     int64   true  "Candle interval in seconds"
-// @Param        from             query     string  true  "Start time (RFC3339)"
-// @Param        to               query     string  true  "End time (RFC3339)"
+// @Param        from             query     string  false "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given"
+// @Param        from_unix_ms     query     int     false "Start time in Unix milliseconds, instead of from"
+// @Param        to               query     string  false "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given"
+// @Param        to_unix_ms       query     int     false "End time in Unix milliseconds, instead of to"
+// @Param        layout           query     string  false "Response layout" Enums(objects, columnar) default(objects)
+// @Param        format           query     string  false "Response format; csv streams the range as CSV, also selected by Accept: text/csv" Enums(json, csv) default(json)
+// @Param        limit            query     int     false "Page size; a CSV export without limit returns the whole range" default(1000) maximum(10000)
+// @Param        offset           query     int     false "Number of candles to skip" default(0)
+// @Param        meta             query     []string false "Metadata key=value the rows must contain; repeat to require several" collectionFormat(multi)
 // @Success      200              {array}   domainmarketdata.Candle
-// @Failure      400              {object}  map[string]string
-// @Failure      500              {object}  map[string]string
+// @Header       200              {integer} X-Next-Offset  "Offset of the next page; absent on the last page and on CSV exports"
+// @Failure      400              {object}  errorResponse
+// @Failure      500              {object}  errorResponse
 // @Router       /marketdata/candles [get]
 func (h *Handler) getCandlesRange(c *gin.Context) {
 	instrumentUID, err := parseUUIDQuery(c, "instrument_uid")
@@ -976,9 +1576,38 @@ func (h *Handler) getCandlesRange(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, fmt.Errorf("interval_seconds query param required"))
 		return
 	}
-	candles, err := h.marketdata.GetCandlesBetween(c.Request.Context(), instrumentUID, intervalSeconds, from, to)
+	layout := c.DefaultQuery("layout", layoutObjects)
+	if layout != layoutObjects && layout != layoutColumnar {
+		writeError(c, http.StatusBadRequest, errInvalidLayout)
+		return
+	}
+	meta, err := parseMetadataFilter(c)
 	if err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	asCSV, err := wantsCSV(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	if asCSV {
+		h.exportCandles(c, instrumentUID, intervalSeconds, from, to, meta)
+		return
+	}
+	page, err := parsePage(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	candles, err := h.marketdata.GetCandlesBetween(c.Request.Context(), instrumentUID, intervalSeconds, from, to, meta, page)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	setNextOffset(c, page.Offset, page.Limit, len(candles))
+	if layout == layoutColumnar {
+		c.JSON(http.StatusOK, toColumnarCandles(candles))
 		return
 	}
 	c.JSON(http.StatusOK, candles)
@@ -993,9 +1622,10 @@ func (h *Handler) getCandlesRange(c *gin.Context) {
 // @Param        instrument_uid   query     string  true  "Instrument UID"
 // @Param        interval_seconds query     int64   true  "Candle interval in seconds"
 // @Param        limit            query     int     true  "Number of candles to retrieve"
+// @Param        order            query     string  false "Newest first, or the same candles oldest first" Enums(asc, desc) default(desc)
 // @Success      200              {array}   domainmarketdata.Candle
-// @Failure      400              {object}  map[string]string
-// @Failure      500              {object}  map[string]string
+// @Failure      400              {object}  errorResponse
+// @Failure      500              {object}  errorResponse
 // @Router       /marketdata/candles/last [get]
 func (h *Handler) getCandlesLast(c *gin.Context) {
 	instrumentUID, limit, interval, err := h.parseInstrumentLimitInterval(c)
@@ -1003,9 +1633,186 @@ func (h *Handler) getCandlesLast(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, err)
 		return
 	}
+	ascending, err := parseLastOrder(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
 	candles, err := h.marketdata.GetLastCandles(c.Request.Context(), instrumentUID, interval, limit)
 	if err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	if ascending {
+		slices.Reverse(candles)
+	}
+	c.JSON(http.StatusOK, candles)
+}
+
+// getCandlesSummary describes the stored candles of an instrument
+// @Summary      Get candle summary
+// @Description  Get the number of stored candles of an instrument and interval, the first and last period start, the lowest low, the highest high and the total volume. Without candles the count is zero and the periods and prices are null.
+// @Tags         candles
+// @Accept       json
+// @Produce      json
+// @Param        instrument_uid   query     string  true  "Instrument UID"
+// @Param        interval_seconds query     int64   true  "Candle interval in seconds"
+// @Success      200              {object}  domainmarketdata.CandleSummary
+// @Failure      400              {object}  errorResponse
+// @Failure      500              {object}  errorResponse
+// @Router       /marketdata/candles/summary [get]
+func (h *Handler) getCandlesSummary(c *gin.Context) {
+	instrumentUID, err := parseUUIDQuery(c, "instrument_uid")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingInstrument)
+		return
+	}
+	intervalSeconds, err := parseInt64Query(c, "interval_seconds")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, fmt.Errorf("interval_seconds query param required"))
+		return
+	}
+	summary, err := h.marketdata.GetCandleSummary(c.Request.Context(), instrumentUID, intervalSeconds)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// getCandleGaps finds missing candles within a time range
+// @Summary      Get candle gaps
+// @Description  Get runs of missing candles between stored candles of an instrument. Gaps before the first or after the last stored candle in the range are not reported.
+// @Tags         candles
+// @Accept       json
+// @Produce      json
+// @Param        instrument_uid   query     string  true  "Instrument UID"
+// @Param        interval_seconds query     int64   true  "Candle interval in seconds"
+// @Param        from             query     string  false "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given"
+// @Param        from_unix_ms     query     int     false "Start time in Unix milliseconds, instead of from"
+// @Param        to               query     string  false "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given"
+// @Param        to_unix_ms       query     int     false "End time in Unix milliseconds, instead of to"
+// @Success      200              {array}   domainmarketdata.CandleGap
+// @Failure      400              {object}  errorResponse
+// @Failure      500              {object}  errorResponse
+// @Router       /marketdata/candles/gaps [get]
+func (h *Handler) getCandleGaps(c *gin.Context) {
+	instrumentUID, err := parseUUIDQuery(c, "instrument_uid")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingInstrument)
+		return
+	}
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingRange)
+		return
+	}
+	intervalSeconds, err := parseInt64Query(c, "interval_seconds")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, fmt.Errorf("interval_seconds query param required"))
+		return
+	}
+	gaps, err := h.marketdata.GetCandleGaps(c.Request.Context(), intervalSeconds, from, to, instrumentUID)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, gaps)
+}
+
+// getDerivedCandles builds candles from stored trades
+// @Summary      Get candles derived from trades
+// @Description  Aggregate stored trades into candles of any interval. Periods start at multiples of interval_seconds since the Unix epoch, like stored candles, unless tz aligns them to a calendar, and only periods starting within [from, to] are returned. Periods without trades are skipped. Derived candles are not stored: their id is the nil UUID and metadata carries source=trades and the trade_count.
+// @Tags         candles
+// @Accept       json
+// @Produce      json
+// @Param        instrument_uid   query     string  true  "Instrument UID"
+// @Param        interval_seconds query     int64   true  "Candle interval in seconds"
+// @Param        from             query     string  false "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given"
+// @Param        from_unix_ms     query     int     false "Start time in Unix milliseconds, instead of from"
+// @Param        to               query     string  false "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given"
+// @Param        to_unix_ms       query     int     false "End time in Unix milliseconds, instead of to"
+// @Param        tz               query     string  false "IANA timezone (e.g. Europe/Moscow) whose midnight starts daily periods and whose Monday midnight starts weekly ones; only for interval_seconds 86400 or 604800"
+// @Success      200              {array}   domainmarketdata.Candle
+// @Failure      400              {object}  errorResponse
+// @Failure      500              {object}  errorResponse
+// @Router       /marketdata/candles/derived [get]
+func (h *Handler) getDerivedCandles(c *gin.Context) {
+	instrumentUID, err := parseUUIDQuery(c, "instrument_uid")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingInstrument)
+		return
+	}
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingRange)
+		return
+	}
+	intervalSeconds, err := parseInt64Query(c, "interval_seconds")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, fmt.Errorf("interval_seconds query param required"))
+		return
+	}
+	loc, err := parseTimezoneQuery(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	candles, err := h.marketdata.GetCandlesFromTrades(c.Request.Context(), instrumentUID, intervalSeconds, from, to, loc)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, candles)
+}
+
+// getResampledCandles merges stored candles into a coarser interval
+// @Summary      Get resampled candles
+// @Description  Merge stored candles of base_interval_seconds into candles of target_interval_seconds, which must be a multiple of it: open of the first, highest high, lowest low, close of the last and summed volume. Periods start at multiples of the target interval since the Unix epoch, unless tz aligns them to a calendar, and only periods starting within [from, to] are returned. Resampled candles are not stored: their id is the nil UUID and metadata carries source=candles, the base_interval_seconds and the candle_count merged.
+// @Tags         candles
+// @Accept       json
+// @Produce      json
+// @Param        instrument_uid          query     string  true  "Instrument UID"
+// @Param        base_interval_seconds   query     int64   true  "Interval of the stored candles in seconds"
+// @Param        target_interval_seconds query     int64   true  "Interval of the returned candles in seconds"
+// @Param        from                    query     string  false "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given"
+// @Param        from_unix_ms            query     int     false "Start time in Unix milliseconds, instead of from"
+// @Param        to                      query     string  false "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given"
+// @Param        to_unix_ms              query     int     false "End time in Unix milliseconds, instead of to"
+// @Param        tz                      query     string  false "IANA timezone (e.g. Europe/Moscow) whose midnight starts daily periods and whose Monday midnight starts weekly ones; only for target_interval_seconds 86400 or 604800"
+// @Success      200                     {array}   domainmarketdata.Candle
+// @Failure      400                     {object}  errorResponse
+// @Failure      500                     {object}  errorResponse
+// @Router       /marketdata/candles/resampled [get]
+func (h *Handler) getResampledCandles(c *gin.Context) {
+	instrumentUID, err := parseUUIDQuery(c, "instrument_uid")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingInstrument)
+		return
+	}
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingRange)
+		return
+	}
+	baseInterval, err := parseInt64Query(c, "base_interval_seconds")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, fmt.Errorf("base_interval_seconds query param required"))
+		return
+	}
+	targetInterval, err := parseInt64Query(c, "target_interval_seconds")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, fmt.Errorf("target_interval_seconds query param required"))
+		return
+	}
+	loc, err := parseTimezoneQuery(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	candles, err := h.marketdata.GetCandlesResampled(c.Request.Context(), instrumentUID, baseInterval, targetInterval, from, to, loc)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
 	c.JSON(http.StatusOK, candles)
@@ -1018,9 +1825,10 @@ func (h *Handler) getCandlesLast(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        orderbook  body      domainmarketdata.OrderBookSnapshot  true  "Order book snapshot data"
-// @Success      201        "Created"
-// @Failure      400        {object}  map[string]string
-// @Failure      500        {object}  map[string]string
+// @Success      201        {object}  domainmarketdata.OrderBookSnapshot
+// @Header       201        {string}  Location  "URL of the stored snapshot"
+// @Failure      400        {object}  errorResponse
+// @Failure      500        {object}  errorResponse
 // @Router       /marketdata/orderbooks [post]
 func (h *Handler) addOrderBook(c *gin.Context) {
 	var snapshot domainmarketdata.OrderBookSnapshot
@@ -1029,49 +1837,74 @@ func (h *Handler) addOrderBook(c *gin.Context) {
 		return
 	}
 	if err := h.marketdata.AddOrderBookSnapshot(c.Request.Context(), &snapshot); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
-	c.Status(http.StatusCreated)
+	c.Header("Location", recordLocation("orderbooks", snapshot.ID))
+	c.JSON(http.StatusCreated, snapshot)
 }
 
 // addOrderBooksBatch adds multiple order book snapshots in a batch
 // @Summary      Add order books batch
-// @Description  Add multiple order book snapshots in a single request
+// @Description  Add multiple order book snapshots in a single request. With validate_only=true the snapshots are only validated and a report of the failing ones is returned; nothing is stored.
 // @Tags         orderbooks
 // @Accept       json
 // @Produce      json
-// @Param        orderbooks  body      []domainmarketdata.OrderBookSnapshot  true  "Array of order book snapshot data"
-// @Success      201         "Created"
-// @Failure      400         {object}  map[string]string
-// @Failure      500         {object}  map[string]string
+// @Param        orderbooks     body      []domainmarketdata.OrderBookSnapshot  true   "Array of order book snapshot data"
+// @Param        validate_only  query     bool                                  false  "Validate without storing"
+// @Success      200            {object}  validationReport
+// @Success      201            {object}  batchResult
+// @Failure      400            {object}  errorResponse
+// @Failure      413            {object}  errorResponse
+// @Failure      500            {object}  errorResponse
 // @Router       /marketdata/orderbooks/batch [post]
 func (h *Handler) addOrderBooksBatch(c *gin.Context) {
+	validateOnly, err := parseOptionalBoolQuery(c, "validate_only")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
 	var snapshots []domainmarketdata.OrderBookSnapshot
 	if err := c.ShouldBindJSON(&snapshots); err != nil {
 		writeError(c, http.StatusBadRequest, err)
 		return
 	}
-	if err := h.marketdata.AddOrderBookSnapshots(c.Request.Context(), snapshots); err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+	if validateOnly {
+		c.JSON(http.StatusOK, newValidationReport(len(snapshots), h.marketdata.ValidateOrderBookSnapshots(snapshots)))
+		return
+	}
+	inserted, err := h.marketdata.AddOrderBookSnapshots(c.Request.Context(), snapshots)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
 		return
 	}
-	c.Status(http.StatusCreated)
+	c.JSON(http.StatusCreated, newBatchResult(len(snapshots), inserted))
 }
 
 // getOrderBooksRange retrieves order book snapshots within a time range
 // @Summary      Get order books range
-// @Description  Get order book snapshots for an instrument within a time range
+// @Description  Get order book snapshots for an instrument within a time range. The window may span at most RANGE_QUERY_MAX_DAYS unless the admin token is sent as a bearer token.
 // @Tags         orderbooks
 // @Accept       json
-// @Produce      json
+// @Produce      json,text/csv
 // @Param        instrument_uid  query     string  true  "Instrument UID"
 // @Param        depth           query     int     true  "Order book depth"
-// @Param        from            query     string  true  "Start time (RFC3339)"
-// @Param        to              query     string  true  "End time (RFC3339)"
+// @Param        depth_match     query     string  false "Match snapshots stored at this depth or deeper (truncated), or only at this depth" Enums(at_least, exact) default(at_least)
+// @Param        from            query     string  false "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given"
+// @Param        from_unix_ms    query     int     false "Start time in Unix milliseconds, instead of from"
+// @Param        to              query     string  false "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given"
+// @Param        to_unix_ms      query     int     false "End time in Unix milliseconds, instead of to"
+// @Param        flag_gaps       query     bool    false "Set sequence_gap on snapshots that do not follow the previous sequence"
+// @Param        format          query     string  false "Response format; csv streams the range as CSV with bids and asks as JSON columns, also selected by Accept: text/csv" Enums(json, csv) default(json)
+// @Param        limit           query     int     false "Page size; a CSV export without limit returns the whole range" default(1000) maximum(10000)
+// @Param        offset          query     int     false "Number of snapshots to skip" default(0)
+// @Param        meta            query     []string false "Metadata key=value the rows must contain; repeat to require several" collectionFormat(multi)
 // @Success      200             {array}   domainmarketdata.OrderBookSnapshot
-// @Failure      400             {object}  map[string]string
-// @Failure      500             {object}  map[string]string
+// @Header       200             {integer} X-Orderbook-Depth  "Depth actually served when it differs from the requested one"
+// @Header       200             {integer} X-Next-Offset  "Offset of the next page; absent on the last page and on CSV exports"
+// @Failure      400             {object}  errorResponse
+// @Failure      404             {object}  errorResponse
+// @Failure      500             {object}  errorResponse
 // @Router       /marketdata/orderbooks [get]
 func (h *Handler) getOrderBooksRange(c *gin.Context) {
 	instrumentUID, err := parseUUIDQuery(c, "instrument_uid")
@@ -1089,11 +1922,41 @@ func (h *Handler) getOrderBooksRange(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, fmt.Errorf("depth query param required"))
 		return
 	}
-	snapshots, err := h.marketdata.GetOrderBookSnapshotsBetween(c.Request.Context(), instrumentUID, int32(depth), from, to)
+	match := parseDepthMatch(c)
+	flagGaps, err := parseOptionalBoolQuery(c, "flag_gaps")
 	if err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, http.StatusBadRequest, err)
 		return
 	}
+	meta, err := parseMetadataFilter(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	asCSV, err := wantsCSV(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	if asCSV {
+		h.exportOrderBooks(c, instrumentUID, int32(depth), match, from, to, meta, flagGaps)
+		return
+	}
+	page, err := parsePage(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	snapshots, ok := h.fetchOrderBooks(c, instrumentUID, int32(depth), match, func(depth int32) ([]domainmarketdata.OrderBookSnapshot, error) {
+		return h.marketdata.GetOrderBookSnapshotsBetween(c.Request.Context(), instrumentUID, depth, match, from, to, meta, page)
+	})
+	if !ok {
+		return
+	}
+	setNextOffset(c, page.Offset, page.Limit, len(snapshots))
+	if flagGaps {
+		appmarketdata.FlagSequenceGaps(snapshots)
+	}
 	c.JSON(http.StatusOK, snapshots)
 }
 
@@ -1105,10 +1968,15 @@ func (h *Handler) getOrderBooksRange(c *gin.Context) {
 // @Produce      json
 // @Param        instrument_uid  query     string  true  "Instrument UID"
 // @Param        depth           query     int     true  "Order book depth"
+// @Param        depth_match     query     string  false "Match snapshots stored at this depth or deeper (truncated), or only at this depth" Enums(at_least, exact) default(at_least)
 // @Param        limit           query     int     true  "Number of snapshots to retrieve"
+// @Param        flag_gaps       query     bool    false "Set sequence_gap on snapshots that do not follow the previous sequence"
+// @Param        order           query     string  false "Newest first, or the same snapshots oldest first" Enums(asc, desc) default(desc)
 // @Success      200             {array}   domainmarketdata.OrderBookSnapshot
-// @Failure      400             {object}  map[string]string
-// @Failure      500             {object}  map[string]string
+// @Header       200             {integer} X-Orderbook-Depth  "Depth actually served when it differs from the requested one"
+// @Failure      400             {object}  errorResponse
+// @Failure      404             {object}  errorResponse
+// @Failure      500             {object}  errorResponse
 // @Router       /marketdata/orderbooks/last [get]
 func (h *Handler) getOrderBooksLast(c *gin.Context) {
 	instrumentUID, limit, err := h.parseInstrumentAndLimit(c)
@@ -1121,16 +1989,256 @@ func (h *Handler) getOrderBooksLast(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, fmt.Errorf("depth query param required"))
 		return
 	}
-	snapshots, err := h.marketdata.GetLastOrderBookSnapshots(c.Request.Context(), instrumentUID, int32(depth), limit)
+	match := parseDepthMatch(c)
+	flagGaps, err := parseOptionalBoolQuery(c, "flag_gaps")
 	if err != nil {
-		writeError(c, http.StatusInternalServerError, err)
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	ascending, err := parseLastOrder(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	snapshots, ok := h.fetchOrderBooks(c, instrumentUID, int32(depth), match, func(depth int32) ([]domainmarketdata.OrderBookSnapshot, error) {
+		return h.marketdata.GetLastOrderBookSnapshots(c.Request.Context(), instrumentUID, depth, match, limit)
+	})
+	if !ok {
 		return
 	}
+	if flagGaps {
+		appmarketdata.FlagSequenceGaps(snapshots)
+	}
+	if ascending {
+		slices.Reverse(snapshots)
+	}
 	c.JSON(http.StatusOK, snapshots)
 }
 
+// getOrderBooksSummary describes the stored order book snapshots of an instrument
+// @Summary      Get order book summary
+// @Description  Get the number of stored order book snapshots of an instrument across all depths, the first and last snapshot time and the depths stored. Without snapshots the count is zero, the times are null and depths is empty.
+// @Tags         orderbooks
+// @Accept       json
+// @Produce      json
+// @Param        instrument_uid  query     string  true  "Instrument UID"
+// @Success      200             {object}  domainmarketdata.OrderBookSummary
+// @Failure      400             {object}  errorResponse
+// @Failure      500             {object}  errorResponse
+// @Router       /marketdata/orderbooks/summary [get]
+func (h *Handler) getOrderBooksSummary(c *gin.Context) {
+	instrumentUID, err := parseUUIDQuery(c, "instrument_uid")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingInstrument)
+		return
+	}
+	summary, err := h.marketdata.GetOrderBookSummary(c.Request.Context(), instrumentUID)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// getOrderBooksSpread returns the top of book per snapshot
+// @Summary      Get order book spread
+// @Description  Get the best bid, best ask and spread of each order book snapshot for an instrument within a time range, in ascending time order, without the full books. A side without levels is null, and so is the spread.
+// @Tags         orderbooks
+// @Accept       json
+// @Produce      json
+// @Param        instrument_uid  query     string  true  "Instrument UID"
+// @Param        from            query     string  false "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given"
+// @Param        from_unix_ms    query     int     false "Start time in Unix milliseconds, instead of from"
+// @Param        to              query     string  false "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given"
+// @Param        to_unix_ms      query     int     false "End time in Unix milliseconds, instead of to"
+// @Param        limit           query     int     false "Page size" default(1000) maximum(10000)
+// @Param        offset          query     int     false "Number of snapshots to skip" default(0)
+// @Success      200             {array}   domainmarketdata.TopOfBook
+// @Header       200             {integer} X-Next-Offset  "Offset of the next page; absent on the last page"
+// @Failure      400             {object}  errorResponse
+// @Failure      500             {object}  errorResponse
+// @Router       /marketdata/orderbooks/spread [get]
+func (h *Handler) getOrderBooksSpread(c *gin.Context) {
+	instrumentUID, err := parseUUIDQuery(c, "instrument_uid")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingInstrument)
+		return
+	}
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingRange)
+		return
+	}
+	page, err := parsePage(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	tops, err := h.marketdata.GetTopOfBook(c.Request.Context(), instrumentUID, from, to, page)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	setNextOffset(c, page.Offset, page.Limit, len(tops))
+	c.JSON(http.StatusOK, tops)
+}
+
+// getOrderBooksSpreadSeries returns the average spread per time bucket
+// @Summary      Get order book spread series
+// @Description  Get the average best-ask minus best-bid spread and mid price per time bucket for an instrument. Buckets are aligned to the Unix epoch, returned in ascending order, and empty buckets are included with null averages. One-sided snapshots are skipped.
+// @Tags         orderbooks
+// @Accept       json
+// @Produce      json
+// @Param        instrument_uid  query     string  true  "Instrument UID"
+// @Param        depth           query     int     true  "Order book depth"
+// @Param        from            query     string  false "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given"
+// @Param        from_unix_ms    query     int     false "Start time in Unix milliseconds, instead of from"
+// @Param        to              query     string  false "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given"
+// @Param        to_unix_ms      query     int     false "End time in Unix milliseconds, instead of to"
+// @Param        bucket_seconds  query     int64   true  "Bucket width in seconds"
+// @Success      200             {array}   domainmarketdata.SpreadBucket
+// @Failure      400             {object}  errorResponse
+// @Failure      500             {object}  errorResponse
+// @Router       /marketdata/orderbooks/spread-series [get]
+func (h *Handler) getOrderBooksSpreadSeries(c *gin.Context) {
+	instrumentUID, err := parseUUIDQuery(c, "instrument_uid")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingInstrument)
+		return
+	}
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingRange)
+		return
+	}
+	depth, err := parseIntQuery(c, "depth")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, fmt.Errorf("depth query param required"))
+		return
+	}
+	bucketSeconds, err := parseInt64Query(c, "bucket_seconds")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingBucket)
+		return
+	}
+	buckets, err := h.marketdata.GetSpreadSeries(c.Request.Context(), instrumentUID, int32(depth), from, to, bucketSeconds)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, buckets)
+}
+
+// getOrderBooksImbalance returns the order book imbalance per snapshot
+// @Summary      Get order book imbalance
+// @Description  Get (bid quantity - ask quantity) / (bid quantity + ask quantity) of each order book snapshot for an instrument within a time range, in ascending time order. Quantities are summed over the first levels levels of each side, or all stored levels without levels. Of snapshots stored at several depths at the same instant the deepest is used. A snapshot with bids only has imbalance 1, one with asks only -1, and one with neither null.
+// @Tags         orderbooks
+// @Accept       json
+// @Produce      json
+// @Param        instrument_uid  query     string  true  "Instrument UID"
+// @Param        levels          query     int     false "Levels per side to count, from the best price; all stored levels if omitted"
+// @Param        from            query     string  false "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless from_unix_ms is given"
+// @Param        from_unix_ms    query     int     false "Start time in Unix milliseconds, instead of from"
+// @Param        to              query     string  false "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds); required unless to_unix_ms is given"
+// @Param        to_unix_ms      query     int     false "End time in Unix milliseconds, instead of to"
+// @Param        limit           query     int     false "Page size" default(1000) maximum(10000)
+// @Param        offset          query     int     false "Number of snapshots to skip" default(0)
+// @Success      200             {array}   domainmarketdata.OrderBookImbalance
+// @Header       200             {integer} X-Next-Offset  "Offset of the next page; absent on the last page"
+// @Failure      400             {object}  errorResponse
+// @Failure      500             {object}  errorResponse
+// @Router       /marketdata/orderbooks/imbalance [get]
+func (h *Handler) getOrderBooksImbalance(c *gin.Context) {
+	instrumentUID, err := parseUUIDQuery(c, "instrument_uid")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingInstrument)
+		return
+	}
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingRange)
+		return
+	}
+	var levels int
+	if c.Query("levels") != "" {
+		levels, err = parseIntQuery(c, "levels")
+		if err != nil || levels <= 0 {
+			writeError(c, http.StatusBadRequest, appmarketdata.ErrInvalidLevels)
+			return
+		}
+	}
+	page, err := parsePage(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	imbalances, err := h.marketdata.GetOrderBookImbalance(c.Request.Context(), instrumentUID, int32(levels), from, to, page)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	setNextOffset(c, page.Offset, page.Limit, len(imbalances))
+	c.JSON(http.StatusOK, imbalances)
+}
+
+// fetchOrderBooks runs fetch for the requested depth. An empty result for a depth
+// that was never stored is rejected or served from the nearest stored depth,
+// depending on the service's depth fallback; a substitution is reported in the
+// X-Orderbook-Depth header.
+func (h *Handler) fetchOrderBooks(c *gin.Context, instrumentUID uuid.UUID, depth int32, match domainmarketdata.DepthMatch, fetch func(depth int32) ([]domainmarketdata.OrderBookSnapshot, error)) ([]domainmarketdata.OrderBookSnapshot, bool) {
+	snapshots, err := fetch(depth)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return nil, false
+	}
+	if len(snapshots) > 0 {
+		return snapshots, true
+	}
+
+	served, err := h.marketdata.ResolveOrderBookDepth(c.Request.Context(), instrumentUID, depth, match)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return nil, false
+	}
+	if served == depth {
+		return snapshots, true
+	}
+	snapshots, err = fetch(served)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return nil, false
+	}
+	c.Header("X-Orderbook-Depth", strconv.Itoa(int(served)))
+	return snapshots, true
+}
+
 // Helpers
 
+// parseDepthMatch reads the depth_match query param; the service rejects unknown
+// values.
+func parseDepthMatch(c *gin.Context) domainmarketdata.DepthMatch {
+	return domainmarketdata.DepthMatch(c.DefaultQuery("depth_match", string(domainmarketdata.DepthMatchAtLeast)))
+}
+
+const (
+	orderAsc  = "asc"
+	orderDesc = "desc"
+)
+
+// parseLastOrder reads the order param of the last-N endpoints and reports
+// whether the rows go out oldest first. Either way they are the latest N rows;
+// asc only reverses them.
+func parseLastOrder(c *gin.Context) (bool, error) {
+	switch c.DefaultQuery("order", orderDesc) {
+	case orderDesc:
+		return false, nil
+	case orderAsc:
+		return true, nil
+	default:
+		return false, errInvalidOrder
+	}
+}
+
 type instrumentPayload struct {
 	UID       string `json:"uid,omitempty"`
 	Figi      string `json:"figi"`
@@ -1138,6 +2246,8 @@ type instrumentPayload struct {
 	Lot       int32  `json:"lot"`
 	ClassCode string `json:"class_code"`
 	LogoURL   string `json:"logo_url"`
+	// BrandUID links the instrument to a brand; empty leaves it unlinked.
+	BrandUID string `json:"brand_uid,omitempty"`
 }
 
 func (p instrumentPayload) toDomain() (*domaininstruments.Instrument, error) {
@@ -1155,6 +2265,13 @@ func (p instrumentPayload) toDomain() (*domaininstruments.Instrument, error) {
 		}
 		inst.UID = uid
 	}
+	if p.BrandUID != "" {
+		brandUID, err := uuid.Parse(p.BrandUID)
+		if err != nil {
+			return nil, fmt.Errorf("brand_uid: %w", err)
+		}
+		inst.BrandUID = brandUID
+	}
 	return inst, nil
 }
 
@@ -1267,6 +2384,104 @@ func (h *Handler) parseInstrumentLimitInterval(c *gin.Context) (uuid.UUID, int,
 	return instrumentUID, limit, intervalSeconds, nil
 }
 
+const (
+	layoutObjects  = "objects"
+	layoutColumnar = "columnar"
+)
+
+// columnarCandles is the layout=columnar candle response: parallel arrays where
+// index i across all of them describes one candle. T holds period starts as unix
+// seconds, V the volume in lots.
+type columnarCandles struct {
+	T []int64   `json:"t"`
+	O []float64 `json:"o"`
+	H []float64 `json:"h"`
+	L []float64 `json:"l"`
+	C []float64 `json:"c"`
+	V []int64   `json:"v"`
+}
+
+func toColumnarCandles(candles []domainmarketdata.Candle) columnarCandles {
+	out := columnarCandles{
+		T: make([]int64, len(candles)),
+		O: make([]float64, len(candles)),
+		H: make([]float64, len(candles)),
+		L: make([]float64, len(candles)),
+		C: make([]float64, len(candles)),
+		V: make([]int64, len(candles)),
+	}
+	for i, candle := range candles {
+		out.T[i] = candle.PeriodStart.Unix()
+		out.O[i] = candle.Open
+		out.H[i] = candle.High
+		out.L[i] = candle.Low
+		out.C[i] = candle.Close
+		out.V[i] = candle.VolumeLots
+	}
+	return out
+}
+
+// getTrade returns a single trade
+// @Summary      Get trade
+// @Description  Get a trade by its id, e.g. the one in the Location header of a created trade
+// @Tags         trades
+// @Produce      json
+// @Param        id   path      string  true  "Trade ID"
+// @Success      200  {object}  domainmarketdata.Trade
+// @Failure      400  {object}  errorResponse
+// @Failure      404  {object}  errorResponse
+// @Failure      500  {object}  errorResponse
+// @Router       /marketdata/trades/{id} [get]
+func (h *Handler) getTrade(c *gin.Context) {
+	getRecord(c, h.marketdata.GetTradeByID)
+}
+
+// getCandle returns a single candle
+// @Summary      Get candle
+// @Description  Get a candle by its id
+// @Tags         candles
+// @Produce      json
+// @Param        id   path      string  true  "Candle ID"
+// @Success      200  {object}  domainmarketdata.Candle
+// @Failure      400  {object}  errorResponse
+// @Failure      404  {object}  errorResponse
+// @Failure      500  {object}  errorResponse
+// @Router       /marketdata/candles/{id} [get]
+func (h *Handler) getCandle(c *gin.Context) {
+	getRecord(c, h.marketdata.GetCandleByID)
+}
+
+// getOrderBook returns a single order book snapshot
+// @Summary      Get order book
+// @Description  Get an order book snapshot by its id
+// @Tags         orderbooks
+// @Produce      json
+// @Param        id   path      string  true  "Snapshot ID"
+// @Success      200  {object}  domainmarketdata.OrderBookSnapshot
+// @Failure      400  {object}  errorResponse
+// @Failure      404  {object}  errorResponse
+// @Failure      500  {object}  errorResponse
+// @Router       /marketdata/orderbooks/{id} [get]
+func (h *Handler) getOrderBook(c *gin.Context) {
+	getRecord(c, h.marketdata.GetOrderBookByID)
+}
+
+// getRecord serves the by-id endpoints: 400 for an id that is not a UUID, 404
+// when no record has it.
+func getRecord[T any](c *gin.Context, get func(ctx context.Context, id uuid.UUID) (*T, error)) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errInvalidID)
+		return
+	}
+	record, err := get(c.Request.Context(), id)
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, record)
+}
+
 func parseUIDParam(c *gin.Context) (uuid.UUID, error) {
 	uid, err := uuid.Parse(c.Param("uid"))
 	if err != nil {
@@ -1280,25 +2495,61 @@ func writeError(c *gin.Context, status int, err error) {
 		status = http.StatusInternalServerError
 		err = errors.New("unknown error")
 	}
-	c.JSON(status, gin.H{"error": err.Error()})
+	if bp, ok := asBackpressure(err); ok {
+		writeBackpressure(c, bp)
+		return
+	}
+	if tooLarge := asBodyTooLarge(err); tooLarge != nil {
+		status, err = http.StatusRequestEntityTooLarge, tooLarge
+	}
+	if status >= http.StatusInternalServerError {
+		// Recorded for the access log, which ties it to the request ID.
+		_ = c.Error(err)
+	}
+	c.JSON(status, newErrorResponse(c, status, err))
 }
 
-// cacheMiddleware caches GET responses in Redis.
+// cacheMiddleware caches GET responses in Redis, for the TTL configured for
+// their route. Empty results are not cached, so a client polling through a
+// gap in the data sees new data as soon as it is stored.
 func (h *Handler) cacheMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if h.cache == nil || c.Request.Method != http.MethodGet {
 			c.Next()
 			return
 		}
+		ttl, cacheable := h.cacheTTLFor(c.FullPath())
+		if !cacheable {
+			c.Next()
+			return
+		}
+		// CSV exports stream straight to the client and are never cached.
+		if asCSV, _ := wantsCSV(c); asCSV {
+			c.Next()
+			return
+		}
 
 		key := h.cacheKey(c)
 		ctx := c.Request.Context()
 
-		if cached, err := h.cache.Get(ctx, key).Result(); err == nil {
-			c.Data(http.StatusOK, "application/json", []byte(cached))
+		if cached, ok := h.loadCachedResponse(ctx, key); ok {
+			h.metrics.cacheHit()
+			for name, value := range cached.Headers {
+				c.Header(name, value)
+			}
+			if cached.ContentEncoding != "" {
+				c.Header("Content-Encoding", cached.ContentEncoding)
+			}
+			contentType := cached.ContentType
+			if contentType == "" {
+				// Entries written before the content type was recorded.
+				contentType = "application/json"
+			}
+			c.Data(http.StatusOK, contentType, cached.Body)
 			c.Abort()
 			return
 		}
+		h.metrics.cacheMiss()
 
 		recorder := &responseRecorder{
 			ResponseWriter: c.Writer,
@@ -1309,16 +2560,72 @@ func (h *Handler) cacheMiddleware() gin.HandlerFunc {
 
 		c.Next()
 
-		if recorder.status >= 200 && recorder.status < 300 && recorder.body.Len() > 0 {
-			_ = h.cache.Set(ctx, key, recorder.body.Bytes(), h.cacheTTL).Err()
+		if recorder.status >= 200 && recorder.status < 300 && recorder.body.Len() > 0 && !isEmptyResult(recorder.contentType, recorder.body.Bytes()) {
+			entry := cachedResponse{
+				ContentType:     recorder.contentType,
+				ContentEncoding: recorder.contentEncoding,
+				Body:            recorder.body.Bytes(),
+			}
+			for _, name := range cachedHeaders {
+				if value := recorder.Header().Get(name); value != "" {
+					if entry.Headers == nil {
+						entry.Headers = make(map[string]string)
+					}
+					entry.Headers[name] = value
+				}
+			}
+			if payload, err := json.Marshal(entry); err == nil {
+				_ = h.cache.Set(ctx, key, payload, ttl).Err()
+			}
 		}
 	}
 }
 
+// isEmptyResult reports whether body is a JSON null or empty array.
+func isEmptyResult(contentType string, body []byte) bool {
+	if contentType != "" && !strings.HasPrefix(contentType, "application/json") {
+		return false
+	}
+	body = bytes.TrimSpace(body)
+	return bytes.Equal(body, []byte("[]")) || bytes.Equal(body, []byte("null"))
+}
+
+// cachedHeaders are the response headers stored with a cached body, so a cache
+// hit carries the same paging and substitution hints as the original response.
+var cachedHeaders = []string{"X-Next-Offset", "X-Orderbook-Depth"}
+
+// cachedResponse is the Redis value of a cached response. The body is stored as
+// sent, so ContentEncoding tells whether it is compressed.
+type cachedResponse struct {
+	ContentType     string            `json:"content_type,omitempty"`
+	ContentEncoding string            `json:"content_encoding,omitempty"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	Body            []byte            `json:"body"`
+}
+
+// loadCachedResponse loads a cached response; entries that fail to decode count as
+// misses.
+func (h *Handler) loadCachedResponse(ctx context.Context, key string) (cachedResponse, bool) {
+	var entry cachedResponse
+	raw, err := h.cache.Get(ctx, key).Bytes()
+	if err != nil {
+		return entry, false
+	}
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return entry, false
+	}
+	return entry, true
+}
+
+// responseRecorder copies a response body as the handler writes it. The content
+// headers are captured on the first write, before an outer writer such as the
+// gzip middleware can change them.
 type responseRecorder struct {
 	gin.ResponseWriter
-	body   *bytes.Buffer
-	status int
+	body            *bytes.Buffer
+	status          int
+	contentType     string
+	contentEncoding string
 }
 
 func (r *responseRecorder) WriteHeader(code int) {
@@ -1328,13 +2635,41 @@ func (r *responseRecorder) WriteHeader(code int) {
 
 func (r *responseRecorder) Write(data []byte) (int, error) {
 	if len(data) > 0 {
+		if r.body.Len() == 0 {
+			r.contentType = r.Header().Get("Content-Type")
+			r.contentEncoding = r.Header().Get("Content-Encoding")
+		}
 		r.body.Write(data)
 	}
 	return r.ResponseWriter.Write(data)
 }
 
+// cacheKey identifies a cached response by request path and query, by the Accept
+// and Accept-Encoding headers that may change the representation served, and by
+// whether the range limit was lifted, so admin results never reach other
+// callers.
 func (h *Handler) cacheKey(c *gin.Context) string {
-	return fmt.Sprintf("cache:%s:%s?%s", c.Request.Method, c.FullPath(), c.Request.URL.RawQuery)
+	return fmt.Sprintf("cache:%s:%s?%s|accept=%s|encoding=%s|unlimited=%t",
+		c.Request.Method, c.Request.URL.Path, c.Request.URL.RawQuery,
+		normalizeHeaderList(c.Request.Header.Values("Accept")),
+		normalizeHeaderList(c.Request.Header.Values("Accept-Encoding")),
+		appmarketdata.RangeUnlimited(c.Request.Context()))
+}
+
+// normalizeHeaderList lower-cases the comma-separated elements of a header,
+// strips whitespace and sorts them, so equivalent headers share a cache key.
+func normalizeHeaderList(values []string) string {
+	var elements []string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			element = strings.ToLower(strings.Join(strings.Fields(element), ""))
+			if element != "" {
+				elements = append(elements, element)
+			}
+		}
+	}
+	slices.Sort(elements)
+	return strings.Join(elements, ",")
 }
 
 func parseUUIDQuery(c *gin.Context, key string) (uuid.UUID, error) {
@@ -1345,6 +2680,21 @@ func parseUUIDQuery(c *gin.Context, key string) (uuid.UUID, error) {
 	return uuid.Parse(value)
 }
 
+// parseTimezoneQuery reads tz as an IANA timezone name; nil means no tz.
+func parseTimezoneQuery(c *gin.Context) (*time.Location, error) {
+	name := c.Query("tz")
+	if name == "" {
+		return nil, nil
+	}
+	// LoadLocation also takes "Local", the server's own zone, which Postgres
+	// does not know.
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		return nil, fmt.Errorf("%w: %q", errInvalidTimezone, name)
+	}
+	return loc, nil
+}
+
 func parseIntQuery(c *gin.Context, key string) (int, error) {
 	value := c.Query(key)
 	if value == "" {
@@ -1361,19 +2711,140 @@ func parseInt64Query(c *gin.Context, key string) (int64, error) {
 	return strconv.ParseInt(value, 10, 64)
 }
 
-func parseTimeRange(c *gin.Context) (time.Time, time.Time, error) {
-	fromStr := c.Query("from")
-	toStr := c.Query("to")
-	if fromStr == "" || toStr == "" {
-		return time.Time{}, time.Time{}, errMissingRange
+// parseOptionalBoolQuery returns false when the parameter is absent.
+func parseOptionalBoolQuery(c *gin.Context, key string) (bool, error) {
+	value := c.Query(key)
+	if value == "" {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s query param must be a boolean", key)
 	}
-	from, err := time.Parse(time.RFC3339, fromStr)
+	return parsed, nil
+}
+
+// parsePage reads the optional limit and offset query parameters of range
+// endpoints.
+func parsePage(c *gin.Context) (domainmarketdata.Page, error) {
+	limit, offset, err := parseLimitOffset(c, appmarketdata.DefaultPageLimit)
+	if err != nil {
+		return domainmarketdata.Page{}, err
+	}
+	return domainmarketdata.Page{Limit: limit, Offset: offset}, nil
+}
+
+// parseMetadataFilter reads the repeatable meta query param of range endpoints,
+// each a key=value pair the row's metadata must contain.
+func parseMetadataFilter(c *gin.Context) (domainmarketdata.MetadataFilter, error) {
+	pairs := c.QueryArray("meta")
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	filter := make(domainmarketdata.MetadataFilter, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%w, got %q", errInvalidMetaFilter, pair)
+		}
+		if _, dup := filter[key]; dup {
+			return nil, fmt.Errorf("%w: key %q given twice", errInvalidMetaFilter, key)
+		}
+		filter[key] = value
+	}
+	return filter, nil
+}
+
+// parseLimitOffset reads the optional limit and offset query parameters; an
+// absent limit is defaultLimit and an absent offset is zero.
+func parseLimitOffset(c *gin.Context, defaultLimit int) (int, int, error) {
+	limit, offset := defaultLimit, 0
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("limit query param must be an integer")
+		}
+		limit = parsed
+	}
+	if value := c.Query("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("offset query param must be an integer")
+		}
+		offset = parsed
+	}
+	return limit, offset, nil
+}
+
+// setNextOffset sets X-Next-Offset when a query filled its page, so there may
+// be more rows to fetch.
+func setNextOffset(c *gin.Context, offset, limit, returned int) {
+	if limit > 0 && returned >= limit {
+		c.Header("X-Next-Offset", strconv.Itoa(offset+returned))
+	}
+}
+
+// parseTimeRange reads the bounds of range endpoints. Each is a time in from /
+// to as parseTimeValue reads it, or a Unix time in milliseconds in
+// from_unix_ms / to_unix_ms.
+func parseTimeRange(c *gin.Context) (time.Time, time.Time, error) {
+	from, err := parseTimeBound(c, "from")
 	if err != nil {
 		return time.Time{}, time.Time{}, err
 	}
-	to, err := time.Parse(time.RFC3339, toStr)
+	to, err := parseTimeBound(c, "to")
 	if err != nil {
 		return time.Time{}, time.Time{}, err
 	}
 	return from, to, nil
 }
+
+// parseTimeBound reads key with parseTimeValue or key_unix_ms as Unix
+// milliseconds; giving both is an error. The bound is truncated to the
+// microsecond, the precision Postgres stores times at.
+func parseTimeBound(c *gin.Context, key string) (time.Time, error) {
+	value, millis := c.Query(key), c.Query(key+unixMillisSuffix)
+	switch {
+	case value != "" && millis != "":
+		return time.Time{}, fmt.Errorf("%s and %s%s query params are mutually exclusive", key, key, unixMillisSuffix)
+	case value != "":
+		bound, err := parseTimeValue(key, value)
+		return bound.Truncate(time.Microsecond), err
+	case millis != "":
+		ms, err := strconv.ParseInt(millis, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s%s query param must be an integer", key, unixMillisSuffix)
+		}
+		return time.UnixMilli(ms).UTC(), nil
+	default:
+		return time.Time{}, errMissingRange
+	}
+}
+
+// unixMillisDigits is the length of a Unix time in milliseconds from
+// September 2001 until the year 2286.
+const unixMillisDigits = 13
+
+// parseTimeValue reads an RFC3339 time, fractional seconds down to nanoseconds
+// included, or a Unix time given as digits only: milliseconds when it has 13
+// digits, seconds otherwise.
+func parseTimeValue(key, value string) (time.Time, error) {
+	if strings.Trim(value, "0123456789") != "" {
+		return time.Parse(time.RFC3339Nano, value)
+	}
+	unix, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s query param is out of range", key)
+	}
+	if len(value) == unixMillisDigits {
+		return time.UnixMilli(unix).UTC(), nil
+	}
+	return time.Unix(unix, 0).UTC(), nil
+}
+
+// hasTimeBound reports whether the request sets key in either form.
+func hasTimeBound(c *gin.Context, key string) bool {
+	_, ok := c.GetQuery(key)
+	_, okMillis := c.GetQuery(key + unixMillisSuffix)
+	return ok || okMillis
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCacheKeyVariesOnPathParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{}
+	var keys []string
+	router := gin.New()
+	router.GET(instrumentsBasePath+"/:uid/details", func(c *gin.Context) {
+		keys = append(keys, h.cacheKey(c))
+	})
+
+	for _, uid := range []string{
+		"9c7a8b4e-4f0e-4b7e-9f6a-1a2b3c4d5e6f",
+		"0d2f5c1a-7e3b-4c8d-a1b2-c3d4e5f6a7b8",
+	} {
+		req := httptest.NewRequest(http.MethodGet, instrumentsBasePath+"/"+uid+"/details", nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if len(keys) != 2 {
+		t.Fatalf("got %d keys, want 2", len(keys))
+	}
+	if keys[0] == keys[1] {
+		t.Fatalf("two uids share the cache key %q", keys[0])
+	}
+}
+
+func TestParseTimeBound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		query   string
+		key     string
+		want    time.Time
+		wantErr bool
+	}{
+		{"rfc3339 nano from", "from=2024-01-02T10:00:00.123456789Z", "from", time.Date(2024, 1, 2, 10, 0, 0, 123456000, time.UTC), false},
+		{"rfc3339 nano to with offset", "to=2024-01-02T13:00:00.000000999%2B03:00", "to", time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), false},
+		{"rfc3339 micro", "from=2024-01-02T10:00:00.000001Z", "from", time.Date(2024, 1, 2, 10, 0, 0, 1000, time.UTC), false},
+		{"unix millis from", "from_unix_ms=1704189600123", "from", time.Date(2024, 1, 2, 10, 0, 0, 123000000, time.UTC), false},
+		{"unix millis to", "to_unix_ms=1704189600999", "to", time.Date(2024, 1, 2, 10, 0, 0, 999000000, time.UTC), false},
+		{"each bound its own form", "from=2024-01-02T09:00:00Z&to_unix_ms=1704189600123", "to", time.Date(2024, 1, 2, 10, 0, 0, 123000000, time.UTC), false},
+		{"both forms", "from=2024-01-02T10:00:00.5Z&from_unix_ms=1704189600500", "from", time.Time{}, true},
+		{"fractional millis", "from_unix_ms=1704189600123.5", "from", time.Time{}, true},
+		{"non-numeric millis", "to_unix_ms=soon", "to", time.Time{}, true},
+		{"bad rfc3339", "from=2024-01-02T10:00:00.123", "from", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+			got, err := parseTimeBound(c, tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Fatalf("bound = %s, want %s", got.Format(time.RFC3339Nano), tt.want.Format(time.RFC3339Nano))
+			}
+		})
+	}
+}
+
+func TestParseTimeBoundMissing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	if _, err := parseTimeBound(c, "from"); !errors.Is(err, errMissingRange) {
+		t.Fatalf("err = %v, want errMissingRange", err)
+	}
+}
+
+func TestParseTimeValue(t *testing.T) {
+	instant := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"rfc3339", "2024-01-02T10:00:00Z", instant, false},
+		{"rfc3339 with offset", "2024-01-02T13:00:00+03:00", instant, false},
+		{"unix seconds", "1704189600", instant, false},
+		{"unix millis", "1704189600000", instant, false},
+		{"12 digits are seconds", "999999999999", time.Unix(999999999999, 0).UTC(), false},
+		{"13 digits are millis", "1000000000000", time.UnixMilli(1000000000000).UTC(), false},
+		{"largest millis", "9999999999999", time.UnixMilli(9999999999999).UTC(), false},
+		{"14 digits are seconds", "10000000000000", time.Unix(10000000000000, 0).UTC(), false},
+		{"overflow", "99999999999999999999", time.Time{}, true},
+		{"negative", "-1704189600", time.Time{}, true},
+		{"fractional seconds", "1704189600.5", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTimeValue("from", tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Fatalf("parseTimeValue(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
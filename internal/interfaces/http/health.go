@@ -0,0 +1,68 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout bounds all readiness checks of one /readyz request together.
+const readinessTimeout = 2 * time.Second
+
+type readinessCheck struct {
+	name  string
+	check func(context.Context) error
+}
+
+// healthResponse is the body of /healthz and /readyz. Checks maps each
+// dependency to "ok" or its error.
+type healthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+	Failed []string          `json:"failed,omitempty"`
+}
+
+// AddReadinessCheck registers a dependency checked by /readyz. Call it before the
+// handler starts serving. Redis is checked automatically when caching is enabled.
+func (h *Handler) AddReadinessCheck(name string, check func(context.Context) error) {
+	h.readiness = append(h.readiness, readinessCheck{name: name, check: check})
+}
+
+// healthz is the liveness probe: it answers 200 while the process serves HTTP.
+// Like /metrics it lives outside /api/v1 and is not part of the Swagger spec.
+func (h *Handler) healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, healthResponse{Status: "ok"})
+}
+
+// readyz is the readiness probe: it runs every registered check plus a Redis
+// ping when caching is enabled, and answers 503 listing the failed dependencies.
+func (h *Handler) readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	checks := h.readiness
+	if h.cache != nil {
+		checks = append(checks[:len(checks):len(checks)], readinessCheck{
+			name:  "redis",
+			check: func(ctx context.Context) error { return h.cache.Ping(ctx).Err() },
+		})
+	}
+
+	resp := healthResponse{Status: "ok", Checks: make(map[string]string, len(checks))}
+	for _, rc := range checks {
+		if err := rc.check(ctx); err != nil {
+			resp.Checks[rc.name] = err.Error()
+			resp.Failed = append(resp.Failed, rc.name)
+			continue
+		}
+		resp.Checks[rc.name] = "ok"
+	}
+	if len(resp.Failed) > 0 {
+		resp.Status = "unavailable"
+		c.JSON(http.StatusServiceUnavailable, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen bounds an incoming request ID, which is echoed and logged.
+const maxRequestIDLen = 128
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the ID of the request ctx belongs to, or "" when
+// it was not created by the handler.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestID returns the ID the logging middleware assigned to the request.
+func requestID(c *gin.Context) string {
+	return RequestIDFromContext(c.Request.Context())
+}
+
+// SetLogger replaces the logger used for the access log. Call it before
+// serving.
+func (h *Handler) SetLogger(logger *logrus.Logger) {
+	h.logger = logger
+}
+
+// requestLogger assigns every request an ID, taken from X-Request-ID when the
+// client sent a usable one, and returns it in the same header. Once the request
+// is served it writes an access log line, carrying the trace ID when the request
+// is traced. Health checks and metrics scrapes are
+// logged at debug level so they do not drown the rest.
+func (h *Handler) requestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDKey{}, id))
+		c.Header(requestIDHeader, id)
+		span := trace.SpanFromContext(c.Request.Context())
+		span.SetAttributes(attribute.String("http.request.id", id))
+		writer := c.Writer
+
+		c.Next()
+
+		status := writer.Status()
+		entry := h.logger.WithFields(logrus.Fields{
+			"request_id": id,
+			"method":     c.Request.Method,
+			"path":       c.Request.URL.Path,
+			"status":     status,
+			"latency_ms": time.Since(start).Milliseconds(),
+			"bytes":      max(writer.Size(), 0),
+			"client_ip":  c.ClientIP(),
+		})
+		if spanCtx := span.SpanContext(); spanCtx.IsValid() {
+			entry = entry.WithField("trace_id", spanCtx.TraceID().String())
+		}
+		if len(c.Errors) > 0 {
+			entry = entry.WithField("error", strings.Join(c.Errors.Errors(), "; "))
+		}
+		switch {
+		case quietPath(c.Request.URL.Path):
+			entry.Debug("request served")
+		case status >= 500:
+			entry.Error("request served")
+		case status >= 400:
+			entry.Warn("request served")
+		default:
+			entry.Info("request served")
+		}
+	}
+}
+
+// quietPath reports whether a path is polled by infrastructure rather than
+// called by clients.
+func quietPath(path string) bool {
+	return path == "/healthz" || path == "/readyz" || path == metricsPath
+}
+
+// validRequestID accepts printable ASCII IDs of reasonable length, so a client
+// cannot inject line breaks or huge values into headers and logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+package http
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsPath = "/metrics"
+
+// unmatchedRoute labels requests that hit no route, so arbitrary paths do not
+// create new series.
+const unmatchedRoute = "unmatched"
+
+// httpMetrics holds the collectors exposed on /metrics. Each handler gets its own
+// registry so building more than one handler never registers a collector twice.
+type httpMetrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+	cache    *prometheus.CounterVec
+	inFlight atomic.Int64
+}
+
+func newHTTPMetrics() *httpMetrics {
+	m := &httpMetrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP handler latency by method and route.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		cache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_cache_requests_total",
+			Help: "Response cache lookups by result (hit or miss).",
+		}, []string{"result"}),
+	}
+	m.registry.MustRegister(
+		m.requests,
+		m.latency,
+		m.cache,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "HTTP requests being served, including open live streams.",
+		}, func() float64 { return float64(m.inFlight.Load()) }),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// middleware records the request count and latency of every request, and
+// counts the requests in flight.
+func (m *httpMetrics) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		m.inFlight.Add(1)
+		defer m.inFlight.Add(-1)
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := c.Request.Method
+		m.requests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		m.latency.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+func (m *httpMetrics) cacheHit() {
+	m.cache.WithLabelValues("hit").Inc()
+}
+
+func (m *httpMetrics) cacheMiss() {
+	m.cache.WithLabelValues("miss").Inc()
+}
+
+// handler serves the registry in the Prometheus text format.
+func (m *httpMetrics) handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
+
+// InFlight returns how many requests are being served, open live streams
+// included.
+func (h *Handler) InFlight() int64 {
+	return h.metrics.inFlight.Load()
+}
+
+// RegisterMetrics adds collectors from other components to the registry served
+// on /metrics. Call it before the handler starts serving.
+func (h *Handler) RegisterMetrics(collectors ...prometheus.Collector) error {
+	for _, collector := range collectors {
+		if err := h.metrics.registry.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
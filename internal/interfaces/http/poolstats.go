@@ -0,0 +1,78 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type poolSource struct {
+	name string
+	stat func() *pgxpool.Stat
+}
+
+// poolStatsView is one Postgres pool's utilization. Counts and durations are
+// totals since the pool was created.
+type poolStatsView struct {
+	MaxConns                int32   `json:"max_conns"`
+	TotalConns              int32   `json:"total_conns"`
+	AcquiredConns           int32   `json:"acquired_conns"`
+	IdleConns               int32   `json:"idle_conns"`
+	ConstructingConns       int32   `json:"constructing_conns"`
+	NewConnsCount           int64   `json:"new_conns_count"`
+	AcquireCount            int64   `json:"acquire_count"`
+	AcquireDurationMS       float64 `json:"acquire_duration_ms"`
+	AvgAcquireDurationMS    float64 `json:"avg_acquire_duration_ms"`
+	EmptyAcquireCount       int64   `json:"empty_acquire_count"`
+	EmptyAcquireWaitMS      float64 `json:"empty_acquire_wait_ms"`
+	CanceledAcquireCount    int64   `json:"canceled_acquire_count"`
+	MaxLifetimeDestroyCount int64   `json:"max_lifetime_destroy_count"`
+	MaxIdleDestroyCount     int64   `json:"max_idle_destroy_count"`
+}
+
+func newPoolStatsView(stat *pgxpool.Stat) poolStatsView {
+	view := poolStatsView{
+		MaxConns:                stat.MaxConns(),
+		TotalConns:              stat.TotalConns(),
+		AcquiredConns:           stat.AcquiredConns(),
+		IdleConns:               stat.IdleConns(),
+		ConstructingConns:       stat.ConstructingConns(),
+		NewConnsCount:           stat.NewConnsCount(),
+		AcquireCount:            stat.AcquireCount(),
+		AcquireDurationMS:       float64(stat.AcquireDuration().Microseconds()) / 1000,
+		EmptyAcquireCount:       stat.EmptyAcquireCount(),
+		EmptyAcquireWaitMS:      float64(stat.EmptyAcquireWaitTime().Microseconds()) / 1000,
+		CanceledAcquireCount:    stat.CanceledAcquireCount(),
+		MaxLifetimeDestroyCount: stat.MaxLifetimeDestroyCount(),
+		MaxIdleDestroyCount:     stat.MaxIdleDestroyCount(),
+	}
+	if view.AcquireCount > 0 {
+		view.AvgAcquireDurationMS = view.AcquireDurationMS / float64(view.AcquireCount)
+	}
+	return view
+}
+
+// AddPoolStats registers a Postgres pool reported by the pool stats admin
+// endpoint under name. Call it before the handler starts serving.
+func (h *Handler) AddPoolStats(name string, stat func() *pgxpool.Stat) {
+	h.pools = append(h.pools, poolSource{name: name, stat: stat})
+}
+
+// getAdminPoolStats returns the connection statistics of the Postgres pools
+// @Summary      Get Postgres pool statistics
+// @Description  Get the utilization of each Postgres connection pool, keyed by pool name (instruments, marketdata): connections by state, how often a connection was acquired and how long that took, and how often a caller had to wait for one. Counts and durations are totals since startup. Requires ADMIN_TOKEN as a bearer token.
+// @Tags         admin
+// @Produce      json
+// @Param        Authorization  header    string  true  "Bearer admin token"
+// @Success      200            {object}  map[string]poolStatsView
+// @Failure      401            {object}  errorResponse
+// @Failure      404            {object}  errorResponse
+// @Router       /admin/pool-stats [get]
+func (h *Handler) getAdminPoolStats(c *gin.Context) {
+	out := make(map[string]poolStatsView, len(h.pools))
+	for _, pool := range h.pools {
+		out[pool.name] = newPoolStatsView(pool.stat())
+	}
+	c.JSON(http.StatusOK, out)
+}
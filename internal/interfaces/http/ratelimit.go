@@ -0,0 +1,129 @@
+package http
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// rateLimitSweepEvery is how often idle buckets are dropped.
+	rateLimitSweepEvery = time.Minute
+	// rateLimitMinIdle is the least time a bucket must sit unused before it is
+	// dropped. A dropped bucket comes back full, so buckets are also kept until
+	// they would have refilled anyway.
+	rateLimitMinIdle = 10 * time.Minute
+)
+
+var errRateLimited = errors.New("rate limit exceeded")
+
+// rateLimiter keeps a token bucket per client key. Buckets live in memory, so
+// each replica enforces the limit on its own.
+type rateLimiter struct {
+	rate    float64
+	burst   float64
+	idle    time.Duration
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	swept   time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(perSecond float64, burst int, now time.Time) *rateLimiter {
+	refill := time.Duration(float64(burst) / perSecond * float64(time.Second))
+	return &rateLimiter{
+		rate:    perSecond,
+		burst:   float64(burst),
+		idle:    max(rateLimitMinIdle, refill),
+		buckets: make(map[string]*tokenBucket),
+		swept:   now,
+	}
+}
+
+// take spends a token of key's bucket. When none is left it returns how long
+// until the next one.
+func (l *rateLimiter) take(key string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.swept) >= rateLimitSweepEvery {
+		l.sweep(now)
+	}
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = bucket
+	}
+	if elapsed := now.Sub(bucket.last); elapsed > 0 {
+		bucket.tokens = math.Min(l.burst, bucket.tokens+elapsed.Seconds()*l.rate)
+		bucket.last = now
+	}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return 0, true
+	}
+	return time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second)), false
+}
+
+func (l *rateLimiter) sweep(now time.Time) {
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.last) >= l.idle {
+			delete(l.buckets, key)
+		}
+	}
+	l.swept = now
+}
+
+// SetRateLimit throttles the market data write endpoints to perSecond requests
+// per client with bursts of up to burst. Clients are told apart by their API
+// key when it is one of API_KEYS, otherwise by IP. A zero perSecond turns the
+// limit off. It is safe to call while serving; unchanged settings keep the
+// current buckets.
+func (h *Handler) SetRateLimit(perSecond float64, burst int) {
+	if perSecond <= 0 || burst <= 0 {
+		h.limiter.Store(nil)
+		return
+	}
+	if current := h.limiter.Load(); current != nil && current.rate == perSecond && current.burst == float64(burst) {
+		return
+	}
+	h.limiter.Store(newRateLimiter(perSecond, burst, time.Now()))
+}
+
+// rateLimitMiddleware refuses writes over the client's rate with 429 and
+// Retry-After. Reads pass through untouched.
+func (h *Handler) rateLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limiter := h.limiter.Load()
+		if limiter == nil || c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+		if wait, ok := limiter.take(h.rateLimitKey(c), time.Now()); !ok {
+			writeError(c, http.StatusTooManyRequests, newBackpressureError(http.StatusTooManyRequests, reasonRateLimited, wait, errRateLimited))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitKey names the bucket of the request. Only a valid API key counts:
+// keying on any header value would give a client that sends a new value per
+// request a fresh bucket every time.
+func (h *Handler) rateLimitKey(c *gin.Context) string {
+	if cfg := h.config.Load(); cfg != nil {
+		if key := c.GetHeader(apiKeyHeader); validAPIKey(key, cfg.Auth.APIKeys) {
+			return "key:" + key
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
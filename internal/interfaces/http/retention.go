@@ -0,0 +1,121 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var errDeleteWindow = errors.New("either before or from/to query params required")
+
+// deleteResult is the response to a market data delete.
+type deleteResult struct {
+	Deleted int64 `json:"deleted"`
+}
+
+type (
+	deleteBeforeFunc  func(ctx context.Context, instrumentUID uuid.UUID, cutoff time.Time) (int64, error)
+	deleteBetweenFunc func(ctx context.Context, instrumentUID uuid.UUID, from, to time.Time) (int64, error)
+)
+
+// deleteTrades removes trades of an instrument
+// @Summary      Delete trades
+// @Description  Delete an instrument's trades older than before, or within from/to (bounds included). Rows are removed in chunks of RETENTION_DELETE_CHUNK_SIZE.
+// @Tags         trades
+// @Produce      json
+// @Param        instrument_uid  query     string  true   "Instrument UID"
+// @Param        before          query     string  false  "Delete trades executed before this time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds)"
+// @Param        before_unix_ms  query     int     false  "Unix milliseconds form of before"
+// @Param        from            query     string  false  "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds), with to instead of before"
+// @Param        from_unix_ms    query     int     false  "Unix milliseconds form of from"
+// @Param        to              query     string  false  "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds), with from instead of before"
+// @Param        to_unix_ms      query     int     false  "Unix milliseconds form of to"
+// @Success      200             {object}  deleteResult
+// @Failure      400             {object}  errorResponse
+// @Failure      500             {object}  errorResponse
+// @Router       /marketdata/trades [delete]
+func (h *Handler) deleteTrades(c *gin.Context) {
+	h.deleteMarketData(c, h.marketdata.DeleteTradesBefore, h.marketdata.DeleteTradesBetween)
+}
+
+// deleteCandles removes candles of an instrument
+// @Summary      Delete candles
+// @Description  Delete an instrument's candles of every interval starting before before, or within from/to (bounds included). Rows are removed in chunks of RETENTION_DELETE_CHUNK_SIZE.
+// @Tags         candles
+// @Produce      json
+// @Param        instrument_uid  query     string  true   "Instrument UID"
+// @Param        before          query     string  false  "Delete candles whose period started before this time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds)"
+// @Param        before_unix_ms  query     int     false  "Unix milliseconds form of before"
+// @Param        from            query     string  false  "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds), with to instead of before"
+// @Param        from_unix_ms    query     int     false  "Unix milliseconds form of from"
+// @Param        to              query     string  false  "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds), with from instead of before"
+// @Param        to_unix_ms      query     int     false  "Unix milliseconds form of to"
+// @Success      200             {object}  deleteResult
+// @Failure      400             {object}  errorResponse
+// @Failure      500             {object}  errorResponse
+// @Router       /marketdata/candles [delete]
+func (h *Handler) deleteCandles(c *gin.Context) {
+	h.deleteMarketData(c, h.marketdata.DeleteCandlesBefore, h.marketdata.DeleteCandlesBetween)
+}
+
+// deleteOrderBooks removes order book snapshots of an instrument
+// @Summary      Delete order books
+// @Description  Delete an instrument's order book snapshots of every depth taken before before, or within from/to (bounds included). Rows are removed in chunks of RETENTION_DELETE_CHUNK_SIZE.
+// @Tags         orderbooks
+// @Produce      json
+// @Param        instrument_uid  query     string  true   "Instrument UID"
+// @Param        before          query     string  false  "Delete snapshots taken before this time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds)"
+// @Param        before_unix_ms  query     int     false  "Unix milliseconds form of before"
+// @Param        from            query     string  false  "Start time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds), with to instead of before"
+// @Param        from_unix_ms    query     int     false  "Unix milliseconds form of from"
+// @Param        to              query     string  false  "End time (RFC3339, fractional seconds allowed, or Unix seconds or milliseconds), with from instead of before"
+// @Param        to_unix_ms      query     int     false  "Unix milliseconds form of to"
+// @Success      200             {object}  deleteResult
+// @Failure      400             {object}  errorResponse
+// @Failure      500             {object}  errorResponse
+// @Router       /marketdata/orderbooks [delete]
+func (h *Handler) deleteOrderBooks(c *gin.Context) {
+	h.deleteMarketData(c, h.marketdata.DeleteOrderBookSnapshotsBefore, h.marketdata.DeleteOrderBookSnapshotsBetween)
+}
+
+// deleteMarketData serves the delete endpoints, which take either before or a
+// from/to range but not both, each bound in either form parseTimeBound reads.
+func (h *Handler) deleteMarketData(c *gin.Context, before deleteBeforeFunc, between deleteBetweenFunc) {
+	instrumentUID, err := parseUUIDQuery(c, "instrument_uid")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, errMissingInstrument)
+		return
+	}
+
+	var deleted int64
+	ctx := c.Request.Context()
+	hasCutoff := hasTimeBound(c, "before")
+	switch {
+	case hasCutoff && !hasTimeBound(c, "from") && !hasTimeBound(c, "to"):
+		at, parseErr := parseTimeBound(c, "before")
+		if parseErr != nil {
+			writeError(c, http.StatusBadRequest, errDeleteWindow)
+			return
+		}
+		deleted, err = before(ctx, instrumentUID, at)
+	case !hasCutoff:
+		from, to, parseErr := parseTimeRange(c)
+		if parseErr != nil {
+			writeError(c, http.StatusBadRequest, errDeleteWindow)
+			return
+		}
+		deleted, err = between(ctx, instrumentUID, from, to)
+	default:
+		writeError(c, http.StatusBadRequest, errDeleteWindow)
+		return
+	}
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, deleteResult{Deleted: deleted})
+}
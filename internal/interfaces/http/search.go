@@ -0,0 +1,50 @@
+package http
+
+import (
+	"net/http"
+
+	appinstruments "main/internal/application/service/instruments"
+	domaininstruments "main/internal/domain/entity/instruments"
+
+	"github.com/gin-gonic/gin"
+)
+
+// searchInstruments finds instruments by ticker, brand name or company name
+// @Summary      Search instruments
+// @Description  Find instruments whose ticker, brand name or company name contains q, case-insensitively. Matches are ranked: an exact ticker first, then tickers starting with q, tickers containing q, brand or company names starting with q and the other name matches; within a rank shorter tickers come first. MatchedOn names the field that matched. Soft-deleted instruments are left out unless include_deleted is set.
+// @Tags         instruments
+// @Accept       json
+// @Produce      json
+// @Param        q                query     string  true  "Text to look for, 1 to 100 characters"
+// @Param        include_deleted  query     bool    false "Include soft-deleted instruments"
+// @Param        limit            query     int     false "Page size" default(20) maximum(100)
+// @Param        offset           query     int     false "Number of matches to skip" default(0)
+// @Success      200              {array}   domaininstruments.InstrumentMatch
+// @Header       200              {integer} X-Next-Offset  "Offset of the next page; absent on the last page"
+// @Failure      400              {object}  errorResponse
+// @Failure      500              {object}  errorResponse
+// @Router       /instruments/search [get]
+func (h *Handler) searchInstruments(c *gin.Context) {
+	includeDeleted, err := parseOptionalBoolQuery(c, "include_deleted")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	limit, offset, err := parseLimitOffset(c, appinstruments.DefaultSearchLimit)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	matches, err := h.instruments.SearchInstruments(c.Request.Context(), domaininstruments.InstrumentSearch{
+		Query:          c.Query("q"),
+		IncludeDeleted: includeDeleted,
+		Limit:          limit,
+		Offset:         offset,
+	})
+	if err != nil {
+		writeError(c, serviceErrorStatus(err), err)
+		return
+	}
+	setNextOffset(c, offset, limit, len(matches))
+	c.JSON(http.StatusOK, matches)
+}
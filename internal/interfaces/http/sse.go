@@ -0,0 +1,183 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	appmarketdata "main/internal/application/service/marketdata"
+	domainmarketdata "main/internal/domain/entity/marketdata"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// sseHeartbeat is how often an idle event stream sends a comment, which keeps
+// proxies from closing it.
+const sseHeartbeat = 15 * time.Second
+
+// Event names besides the default message event that carries candles.
+const (
+	sseEventGap   = "gap"
+	sseEventClose = "close"
+)
+
+var errInvalidLastEventID = errors.New("last event id must be an id sent by this stream")
+
+// sseGap tells a resumed client that more candles were missed than the stream
+// replays; fetch them from /marketdata/candles/ between After and Before.
+type sseGap struct {
+	After  time.Time `json:"after"`
+	Before time.Time `json:"before"`
+}
+
+// SetCandleStream enables the candle event stream, fed by hub. A client that
+// falls more than buffer candles behind is disconnected. Call it before serving.
+func (h *Handler) SetCandleStream(hub *appmarketdata.Hub[domainmarketdata.Candle], buffer int) {
+	h.candleHub = hub
+	h.candleBuf = buffer
+}
+
+// streamCandles streams stored candles as Server-Sent Events
+// @Summary      Stream candle updates
+// @Description  Emits every newly stored candle of the instrument and interval as an SSE data event whose id is the candle's period_start in unix milliseconds. A client resuming with Last-Event-ID is first sent the candles stored after that id; when more were missed than one page, a "gap" event names the range to fetch from /marketdata/candles/. A "close" event is sent before the server ends the stream because the client fell behind or the server is shutting down.
+// @Tags         candles
+// @Produce      text/event-stream
+// @Param        instrument_uid    query     string  true   "Instrument UID"
+// @Param        interval_seconds  query     int     true   "Candle interval in seconds"
+// @Param        Last-Event-ID     header    string  false  "Id of the last event received"
+// @Success      200               {object}  domainmarketdata.Candle
+// @Failure      400               {object}  errorResponse
+// @Failure      503               {object}  errorResponse
+// @Router       /marketdata/candles/sse [get]
+func (h *Handler) streamCandles(c *gin.Context) {
+	if h.candleHub == nil {
+		writeError(c, http.StatusServiceUnavailable, errStreamDisabled)
+		return
+	}
+	instrumentUID, err := parseUUIDQuery(c, "instrument_uid")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	intervalSeconds, err := parseInt64Query(c, "interval_seconds")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	if intervalSeconds <= 0 {
+		writeError(c, http.StatusBadRequest, appmarketdata.ErrInvalidInterval)
+		return
+	}
+	var resumeAfter time.Time
+	if lastID := strings.TrimSpace(c.GetHeader("Last-Event-ID")); lastID != "" {
+		ms, err := strconv.ParseInt(lastID, 10, 64)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, errInvalidLastEventID)
+			return
+		}
+		resumeAfter = time.UnixMilli(ms).UTC()
+	}
+
+	// Subscribe before replaying, so no candle stored meanwhile is lost.
+	sub, err := h.candleHub.Subscribe([]uuid.UUID{instrumentUID}, h.candleBuf)
+	if err != nil {
+		writeError(c, http.StatusServiceUnavailable, err)
+		return
+	}
+	defer sub.Close()
+
+	ctx := c.Request.Context()
+	var missed []domainmarketdata.Candle
+	if !resumeAfter.IsZero() {
+		// The replay is capped at one page, so a long disconnect need not
+		// fit in the range query window.
+		missed, err = h.marketdata.GetCandlesBetween(appmarketdata.WithoutRangeLimit(ctx), instrumentUID, intervalSeconds, resumeAfter.Add(time.Millisecond), time.Now(), nil, domainmarketdata.Page{Limit: appmarketdata.MaxPageLimit})
+		if err != nil {
+			writeError(c, serviceErrorStatus(err), err)
+			return
+		}
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	// replayedUpTo skips live candles the replay already sent.
+	var replayedUpTo time.Time
+	for i := range missed {
+		if !writeCandleEvent(c, &missed[i]) {
+			return
+		}
+		replayedUpTo = missed[i].PeriodStart
+	}
+	if len(missed) == appmarketdata.MaxPageLimit {
+		if !writeSSE(c, "", sseEventGap, sseGap{After: replayedUpTo, Before: time.Now().UTC()}) {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case candle, ok := <-sub.C:
+			if !ok {
+				reason := "stream closed"
+				if err := sub.Err(); err != nil {
+					reason = err.Error()
+				}
+				writeSSE(c, "", sseEventClose, gin.H{"reason": reason})
+				return
+			}
+			if candle.IntervalSeconds != intervalSeconds || !candle.PeriodStart.After(replayedUpTo) {
+				continue
+			}
+			if !writeCandleEvent(c, candle) {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func writeCandleEvent(c *gin.Context, candle *domainmarketdata.Candle) bool {
+	return writeSSE(c, strconv.FormatInt(candle.PeriodStart.UnixMilli(), 10), "", candle)
+}
+
+// writeSSE writes one event and flushes it. It reports false once the client is
+// gone.
+func writeSSE(c *gin.Context, id, event string, data any) bool {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		_ = c.Error(err)
+		return false
+	}
+	var b strings.Builder
+	if id != "" {
+		fmt.Fprintf(&b, "id: %s\n", id)
+	}
+	if event != "" {
+		fmt.Fprintf(&b, "event: %s\n", event)
+	}
+	fmt.Fprintf(&b, "data: %s\n\n", payload)
+	if _, err := c.Writer.WriteString(b.String()); err != nil {
+		return false
+	}
+	c.Writer.Flush()
+	return true
+}
@@ -0,0 +1,248 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	appmarketdata "main/internal/application/service/marketdata"
+	domainmarketdata "main/internal/domain/entity/marketdata"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// maxStreamInstruments caps the instruments one socket may subscribe to.
+	maxStreamInstruments = 100
+	// defaultStreamBuffer is the per-client buffer used until a stream is set.
+	defaultStreamBuffer = 256
+
+	streamWriteWait  = 10 * time.Second
+	streamPongWait   = 60 * time.Second
+	streamPingPeriod = streamPongWait * 9 / 10
+	streamMaxCommand = 64 << 10
+)
+
+var (
+	errStreamDisabled     = errors.New("live streaming is not enabled")
+	errTooManyInstruments = fmt.Errorf("at most %d instruments per stream", maxStreamInstruments)
+)
+
+// Messages exchanged over a stream socket.
+const (
+	streamActionSubscribe   = "subscribe"
+	streamActionUnsubscribe = "unsubscribe"
+
+	streamTypeTrade         = "trade"
+	streamTypeSubscriptions = "subscriptions"
+	streamTypeError         = "error"
+)
+
+// streamUpgrader accepts sockets from any origin: the API is not cookie
+// authenticated, so a cross-site page gains nothing a direct client lacks.
+var streamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(*http.Request) bool { return true },
+}
+
+// streamCommand is a client request to change the instruments of its socket.
+type streamCommand struct {
+	Action         string      `json:"action"`
+	InstrumentUIDs []uuid.UUID `json:"instrument_uids"`
+}
+
+// streamMessage is a server message: a trade, the current subscriptions after a
+// command, or an error for a rejected command.
+type streamMessage struct {
+	Type           string                  `json:"type"`
+	Trade          *domainmarketdata.Trade `json:"trade,omitempty"`
+	InstrumentUIDs []uuid.UUID             `json:"instrument_uids,omitempty"`
+	Error          string                  `json:"error,omitempty"`
+}
+
+// SetTradeStream enables the live trade stream, fed by hub. A client that falls
+// more than buffer trades behind is disconnected. Call it before serving.
+func (h *Handler) SetTradeStream(hub *appmarketdata.Hub[domainmarketdata.Trade], buffer int) {
+	h.tradeHub = hub
+	h.tradeBuf = buffer
+}
+
+// streamTrades streams live trades over a WebSocket
+// @Summary      Stream live trades
+// @Description  Upgrades to a WebSocket and pushes every new trade of the subscribed instruments as {"type":"trade","trade":{...}}. Send {"action":"subscribe"|"unsubscribe","instrument_uids":[...]} to change the subscription; the server answers with the current instrument_uids. A client that falls too far behind is closed with status 1013, and server shutdown closes sockets with 1001.
+// @Tags         trades
+// @Param        instrument_uid  query     []string  true  "Instrument UIDs, repeated or comma-separated"  collectionFormat(multi)
+// @Success      101             {object}  streamMessage
+// @Failure      400             {object}  errorResponse
+// @Failure      503             {object}  errorResponse
+// @Router       /marketdata/trades/stream [get]
+func (h *Handler) streamTrades(c *gin.Context) {
+	if h.tradeHub == nil {
+		writeError(c, http.StatusServiceUnavailable, errStreamDisabled)
+		return
+	}
+	instrumentUIDs, err := parseUUIDListQuery(c, "instrument_uid")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	if len(instrumentUIDs) == 0 {
+		writeError(c, http.StatusBadRequest, errMissingInstrument)
+		return
+	}
+	if len(instrumentUIDs) > maxStreamInstruments {
+		writeError(c, http.StatusBadRequest, errTooManyInstruments)
+		return
+	}
+
+	sub, err := h.tradeHub.Subscribe(instrumentUIDs, h.tradeBuf)
+	if err != nil {
+		writeError(c, http.StatusServiceUnavailable, err)
+		return
+	}
+	defer sub.Close()
+
+	// Upgrade answers a failed handshake itself.
+	conn, err := streamUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	replies := make(chan streamMessage)
+	readDone := make(chan struct{})
+	go readStreamCommands(conn, sub, replies, readDone, stop)
+
+	ping := time.NewTicker(streamPingPeriod)
+	defer ping.Stop()
+	for {
+		var msg streamMessage
+		select {
+		case trade, ok := <-sub.C:
+			if !ok {
+				closeStream(conn, sub.Err())
+				return
+			}
+			msg = streamMessage{Type: streamTypeTrade, Trade: trade}
+		case msg = <-replies:
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteWait)); err != nil {
+				return
+			}
+			continue
+		case <-readDone:
+			return
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+		if err := conn.WriteJSON(msg); err != nil {
+			return
+		}
+	}
+}
+
+// readStreamCommands applies subscribe and unsubscribe commands until the socket
+// fails or closes, handing replies to the writer. It closes done when it returns.
+func readStreamCommands(conn *websocket.Conn, sub *appmarketdata.Subscription[domainmarketdata.Trade], replies chan<- streamMessage, done, stop chan struct{}) {
+	defer close(done)
+	conn.SetReadLimit(streamMaxCommand)
+	_ = conn.SetReadDeadline(time.Now().Add(streamPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(streamPongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var cmd streamCommand
+		if err := json.Unmarshal(data, &cmd); err != nil {
+			if !reply(replies, stop, streamMessage{Type: streamTypeError, Error: "invalid command: " + err.Error()}) {
+				return
+			}
+			continue
+		}
+		if err := applyStreamCommand(sub, cmd); err != nil {
+			if !reply(replies, stop, streamMessage{Type: streamTypeError, Error: err.Error()}) {
+				return
+			}
+			continue
+		}
+		if !reply(replies, stop, streamMessage{Type: streamTypeSubscriptions, InstrumentUIDs: sub.Instruments()}) {
+			return
+		}
+	}
+}
+
+func applyStreamCommand(sub *appmarketdata.Subscription[domainmarketdata.Trade], cmd streamCommand) error {
+	if len(cmd.InstrumentUIDs) == 0 {
+		return errMissingInstrument
+	}
+	switch cmd.Action {
+	case streamActionSubscribe:
+		if len(sub.Instruments())+len(cmd.InstrumentUIDs) > maxStreamInstruments {
+			return errTooManyInstruments
+		}
+		sub.Add(cmd.InstrumentUIDs...)
+	case streamActionUnsubscribe:
+		sub.Remove(cmd.InstrumentUIDs...)
+	default:
+		return fmt.Errorf("action must be %s or %s", streamActionSubscribe, streamActionUnsubscribe)
+	}
+	return nil
+}
+
+func reply(replies chan<- streamMessage, stop <-chan struct{}, msg streamMessage) bool {
+	select {
+	case replies <- msg:
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+// closeStream tells the client why its subscription ended before the socket is
+// closed: 1013 for a slow client, 1001 for server shutdown.
+func closeStream(conn *websocket.Conn, reason error) {
+	code, text := websocket.CloseNormalClosure, ""
+	switch {
+	case errors.Is(reason, appmarketdata.ErrSlowConsumer):
+		code, text = websocket.CloseTryAgainLater, reason.Error()
+	case errors.Is(reason, appmarketdata.ErrHubClosed):
+		code, text = websocket.CloseGoingAway, "server shutting down"
+	}
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(streamWriteWait))
+}
+
+// parseUUIDListQuery reads a query param given repeatedly, comma-separated, or
+// both, dropping duplicates.
+func parseUUIDListQuery(c *gin.Context, key string) ([]uuid.UUID, error) {
+	var out []uuid.UUID
+	seen := make(map[uuid.UUID]struct{})
+	for _, value := range c.QueryArray(key) {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			uid, err := uuid.Parse(part)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			if _, ok := seen[uid]; ok {
+				continue
+			}
+			seen[uid] = struct{}{}
+			out = append(out, uid)
+		}
+	}
+	return out, nil
+}
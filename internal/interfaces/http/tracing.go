@@ -0,0 +1,29 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// SetTracing opens a server span for every request, named after its route and
+// continuing the trace of an incoming traceparent header. Spans go to the
+// global tracer provider under serviceName. Health checks and metrics scrapes
+// are not traced. Call it before serving.
+func (h *Handler) SetTracing(serviceName string) {
+	h.tracing = otelgin.Middleware(serviceName, otelgin.WithFilter(func(r *http.Request) bool {
+		return !quietPath(r.URL.Path)
+	}))
+}
+
+// tracingMiddleware runs the tracing middleware once SetTracing installed it.
+func (h *Handler) tracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.tracing == nil {
+			c.Next()
+			return
+		}
+		h.tracing(c)
+	}
+}
@@ -32,12 +32,12 @@ CREATE TABLE brands (
 
 CREATE TABLE instruments (
     uid UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-    figi VARCHAR(255) UNIQUE NOT NULL,
+    figi VARCHAR(255) NOT NULL,
     ticker VARCHAR(50) NOT NULL,
     lot INTEGER NOT NULL,
     class_code VARCHAR(50),
     logo_url VARCHAR,
-    brand_uid UUID NOT NULL REFERENCES brands(uid) ON DELETE RESTRICT,
+    brand_uid UUID REFERENCES brands(uid) ON DELETE RESTRICT,
     created_at TIMESTAMPTZ DEFAULT NOW(),
     updated_at TIMESTAMPTZ DEFAULT NOW(),
     deleted_at TIMESTAMPTZ
@@ -45,6 +45,19 @@ CREATE TABLE instruments (
 
 CREATE INDEX IF NOT EXISTS idx_instruments_ticker ON instruments(ticker);
 CREATE INDEX IF NOT EXISTS idx_instruments_figi ON instruments(figi);
+-- figi уникален только среди неудалённых инструментов, поэтому мягко удалённый figi можно создать заново
+CREATE UNIQUE INDEX IF NOT EXISTS ux_instruments_figi_live ON instruments(figi) WHERE deleted_at IS NULL;
+
+-- Optional: lets GET /instruments/search (ILIKE '%q%' over the ticker and the
+-- brand and company names) use an index instead of scanning the tables. Needs
+-- the pg_trgm extension.
+-- CREATE EXTENSION IF NOT EXISTS pg_trgm;
+-- CREATE INDEX IF NOT EXISTS idx_instruments_ticker_trgm
+-- ON instruments USING GIN (ticker gin_trgm_ops);
+-- CREATE INDEX IF NOT EXISTS idx_brands_name_trgm
+-- ON brands USING GIN (name gin_trgm_ops);
+-- CREATE INDEX IF NOT EXISTS idx_companies_name_trgm
+-- ON companies USING GIN (name gin_trgm_ops);
 
 -- Акции
 CREATE TABLE shares (
@@ -77,15 +90,32 @@ CREATE TABLE currencies (
     uid UUID PRIMARY KEY REFERENCES instruments(uid) ON DELETE CASCADE
 );
 
+-- Состояние инкрементальной синхронизации справочников (cmd/data)
+CREATE TABLE reference_sync_state (
+    kind VARCHAR(20) PRIMARY KEY,
+    last_synced_at TIMESTAMPTZ NOT NULL
+);
+
+-- хеш содержимого каждой строки, записанной последней синхронизацией
+CREATE TABLE reference_sync_hashes (
+    kind VARCHAR(20) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    content_hash CHAR(64) NOT NULL,
+    synced_at TIMESTAMPTZ NOT NULL,
+
+    PRIMARY KEY (kind, key)
+);
+
 -- Trades
 
 CREATE TABLE trades (
     trade_id UUID DEFAULT gen_random_uuid(),
     instrument_uid UUID NOT NULL,
-    side VARCHAR(4) NOT NULL CHECK (side IN ('BUY','SELL')), -- 0/1 = BUY/SELL
+    side VARCHAR(7) NOT NULL CHECK (side IN ('BUY','SELL','UNKNOWN')), -- 0/1 = BUY/SELL, UNKNOWN when the venue gives no direction
     price NUMERIC(20, 8) NOT NULL,
     quantity_lots BIGINT NOT NULL,
     traded_at TIMESTAMPTZ NOT NULL,
+    venue VARCHAR(50), -- board code, e.g. TQBR; falls back to instruments.class_code
     metadata JSONB,
 
     PRIMARY KEY (trade_id, traded_at)
@@ -105,6 +135,15 @@ ON trades(instrument_uid, traded_at);
 CREATE INDEX IF NOT EXISTS idx_trades_time
 ON trades(traded_at);
 
+CREATE INDEX IF NOT EXISTS idx_trades_instrument_venue_time
+ON trades(instrument_uid, venue, traded_at);
+
+-- Optional: speeds up the meta filter of range queries (metadata @> ...) over
+-- long ranges, at the cost of write throughput. The same applies to candles and
+-- order_book_snapshots.
+-- CREATE INDEX IF NOT EXISTS idx_trades_metadata
+-- ON trades USING GIN (metadata jsonb_path_ops);
+
 -- Candles
 
 CREATE TABLE candles (
@@ -163,6 +202,11 @@ CREATE TABLE order_book_snapshots (
     bids JSONB NOT NULL,
     asks JSONB NOT NULL,
 
+    -- монотонный номер снимка по инструменту, назначается продюсером при получении
+    sequence BIGINT,
+    -- номер первого снимка, отброшенного троттлингом консьюмера перед этим
+    sequence_from BIGINT,
+
     metadata JSONB,
 
     PRIMARY KEY (snapshot_id, snapshot_at)
@@ -181,4 +225,4 @@ ON order_book_snapshots(instrument_uid, snapshot_at);
 
 -- предотвращает дубли одинакового времени/глубины по инструменту
 CREATE UNIQUE INDEX IF NOT EXISTS ux_obs_natural
-ON order_book_snapshots(instrument_uid, snapshot_at, depth);
\ No newline at end of file
+ON order_book_snapshots(instrument_uid, snapshot_at, depth);